@@ -53,17 +53,6 @@ func (api *WorkspaceAPI) GetDashboard(c *gin.Context) {
 		return
 	}
 
-	// 获取重要文档
-	importantDocuments, err := api.workspaceService.GetImportantDocuments(userID, 5)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Code:    http.StatusInternalServerError,
-			Message: "获取重要文档失败",
-			Error:   err.Error(),
-		})
-		return
-	}
-
 	// 获取活动记录
 	activities, err := api.workspaceService.GetRecentActivities(userID, 10)
 	if err != nil {
@@ -77,9 +66,8 @@ func (api *WorkspaceAPI) GetDashboard(c *gin.Context) {
 
 	// 构建工作台响应
 	dashboard := model.DashboardResponse{
-		RecentDocuments:    recentDocuments,
-		ImportantDocuments: importantDocuments,
-		Activities:         activities,
+		RecentDocuments: recentDocuments,
+		Activities:      activities,
 	}
 
 	c.JSON(http.StatusOK, model.Response{
@@ -3,9 +3,11 @@ package main
 import (
 	"log"
 	"path/filepath"
+	"time"
 	"wz-wenzhan-backend/internal/config"
 	"wz-wenzhan-backend/internal/handler"
 	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/repository"
 	"wz-wenzhan-backend/internal/service"
 
@@ -39,30 +41,152 @@ func main() {
 	workspaceRepo := repository.NewWorkspaceRepository(db)
 	activityRepo := repository.NewActivityRepository(db)
 	recycleRepo := repository.NewRecycleRepository(db)
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	adminRepo := repository.NewAdminRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	templateRepo := repository.NewTemplateRepository(db)
+	aiDraftRepo := repository.NewAIDraftRepository(db)
+	documentVersionRepo := repository.NewDocumentVersionRepository(db)
+	aiUsageRepo := repository.NewAIUsageRepository(db)
+	aiSuggestionRepo := repository.NewAISuggestionRepository(db)
+	embeddingRepo := repository.NewEmbeddingRepository(db)
+	mindMapNodeRepo := repository.NewMindMapNodeRepository(db)
+	documentLinkRepo := repository.NewDocumentLinkRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	siteRepo := repository.NewSiteRepository(db)
+	favoriteRepo := repository.NewFavoriteRepository(db)
+	accessRepo := repository.NewAccessRepository(db)
+	var outboxRepo repository.OutboxRepository
+	if cfg.Event.Outbox {
+		outboxRepo = repository.NewOutboxRepository(db)
+	}
 
 	// 初始化服务层
-	userService := service.NewUserService(userRepo, logger)
-	documentService := service.NewDocumentService(documentRepo, logger)
-	folderService := service.NewFolderService(folderRepo, documentRepo, activityRepo, logger)
+	eventBus := service.NewEventBus(outboxRepo, logger)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, logger)
+	webhookService := service.NewWebhookService(webhookRepo, cfg.Webhook.AllowPrivateNetwork, logger)
+	templateService := service.NewTemplateService(templateRepo, documentRepo, userRepo, logger)
 	fileService := service.NewFileService("./uploads", "http://localhost:8080", logger)
+	attachmentService := service.NewAttachmentService(attachmentRepo, documentRepo, fileService, logger)
+	favoriteService := service.NewFavoriteService(favoriteRepo, documentRepo, folderRepo, logger)
+	accessService := service.NewAccessService(accessRepo, documentRepo, favoriteService, logger)
+	documentService := service.NewDocumentService(documentRepo, userRepo, templateService, attachmentService, favoriteService, accessService, eventBus, logger)
+	folderService := service.NewFolderService(folderRepo, documentRepo, favoriteService, eventBus, logger)
+	userService := service.NewUserService(userRepo, fileService, logger)
 	searchService := service.NewSearchService(documentRepo, folderRepo, logger)
-	workspaceService := service.NewWorkspaceService(workspaceRepo, logger)
-	activityService := service.NewActivityService(activityRepo, logger)
-	recycleService := service.NewRecycleService(recycleRepo, logger)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, favoriteService, accessService, logger)
+	activityService := service.NewActivityService(activityRepo, documentRepo, logger)
+	recycleService := service.NewRecycleService(recycleRepo, documentRepo, folderRepo, attachmentService, notificationService, eventBus, logger)
+	apiTokenService := service.NewAPITokenService(apiTokenRepo, userRepo, logger)
+	adminService := service.NewAdminService(userRepo, apiTokenRepo, adminRepo, logger)
+	auditService := service.NewAuditService(auditLogRepo, logger)
+	accountService := service.NewAccountService(accountRepo, userRepo, fileService, auditService, "./exports", logger)
+	commentService := service.NewCommentService(commentRepo, documentRepo, userRepo, reviewRepo, eventBus, logger)
+	documentVersionService := service.NewDocumentVersionService(documentVersionRepo, documentRepo, documentService, logger)
+	sheetService := service.NewSheetService(documentRepo, documentService, logger)
+	slideService := service.NewSlideService(documentRepo, documentService, logger)
+	mindMapService := service.NewMindMapService(documentRepo, mindMapNodeRepo, documentService, logger)
+
+	// 未启用AI写作时不创建模型服务商，相关接口返回503
+	var llmProvider service.LLMProvider
+	if cfg.AI.Enabled {
+		llmProvider, err = service.NewLLMProvider(cfg.AI.Provider, cfg.AI.BaseURL, cfg.AI.APIKey, cfg.AI.Model, cfg.AI.Timeout)
+		if err != nil {
+			log.Fatal("Failed to initialize LLM provider:", err)
+		}
+	}
+	aiUsageService := service.NewAIUsageService(aiUsageRepo, cfg.AI.RequestsPerMinute, cfg.AI.DailyTokenLimit, logger)
+	aiDraftService := service.NewAIDraftService(aiDraftRepo, documentRepo, documentService, aiUsageService, llmProvider, cfg.AI.MaxTokens, cfg.AI.Temperature, logger)
+	aiAssistService := service.NewAIAssistService(aiSuggestionRepo, documentRepo, documentService, documentVersionService, aiUsageService, llmProvider, cfg.AI.MaxTokens, cfg.AI.Temperature, logger)
+
+	// 未启用语义搜索时不创建向量服务商，语义搜索接口返回503
+	var embeddingProvider service.EmbeddingProvider
+	if cfg.Embedding.Enabled {
+		embeddingProvider, err = service.NewEmbeddingProvider(cfg.Embedding.Provider, cfg.Embedding.BaseURL, cfg.Embedding.APIKey, cfg.Embedding.Model, cfg.Embedding.Dimensions, cfg.Embedding.Timeout)
+		if err != nil {
+			log.Fatal("Failed to initialize embedding provider:", err)
+		}
+	}
+	semanticSearchService := service.NewSemanticSearchService(embeddingRepo, documentRepo, embeddingProvider, logger)
+	linkService := service.NewLinkService(documentRepo, documentLinkRepo, logger)
+	reviewService := service.NewReviewService(reviewRepo, documentRepo, userRepo, eventBus, logger)
+	siteService := service.NewSiteService(siteRepo, folderRepo, documentRepo, userRepo, logger)
+
+	// 注册事件订阅者
+	eventBus.Subscribe("activity", activityService.HandleEvent)
+	eventBus.Subscribe("search", searchService.HandleEvent)
+	eventBus.Subscribe("embedding", semanticSearchService.HandleEvent)
+	eventBus.Subscribe("mindmap", mindMapService.HandleEvent)
+	eventBus.Subscribe("link", linkService.HandleEvent)
+	eventBus.Subscribe("site", siteService.HandleEvent)
+	eventBus.Subscribe("favorite", favoriteService.HandleEvent)
+	eventBus.Subscribe("notification", notificationService.HandleEvent)
+	eventBus.Subscribe("webhook", webhookService.HandleEvent)
 
 	// 初始化处理器层
-	userHandler := handler.NewUserHandler(userService)
-	documentHandler := handler.NewDocumentHandler(documentService)
-	folderHandler := handler.NewFolderHandler(folderService)
+	userHandler := handler.NewUserHandler(userService, auditService)
+	documentHandler := handler.NewDocumentHandler(documentService, auditService, semanticSearchService, linkService)
+	folderHandler := handler.NewFolderHandler(folderService, auditService)
 	fileHandler := handler.NewFileHandler(fileService)
-	searchHandler := handler.NewSearchHandler(searchService)
+	searchHandler := handler.NewSearchHandler(searchService, semanticSearchService)
 	workspaceHandler := handler.NewWorkspaceHandler(workspaceService)
+	favoriteHandler := handler.NewFavoriteHandler(favoriteService)
 	activityHandler := handler.NewActivityHandler(activityService)
-	recycleHandler := handler.NewRecycleHandler(recycleService)
+	recycleHandler := handler.NewRecycleHandler(recycleService, auditService)
+	apiTokenHandler := handler.NewAPITokenHandler(apiTokenService, auditService)
+	adminHandler := handler.NewAdminHandler(adminService, auditService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	accountHandler := handler.NewAccountHandler(accountService, auditService)
+	commentHandler := handler.NewCommentHandler(commentService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	webhookHandler := handler.NewWebhookHandler(webhookService, auditService)
+	templateHandler := handler.NewTemplateHandler(templateService)
+	aiHandler := handler.NewAIHandler(aiDraftService, aiAssistService, aiUsageService)
+	versionHandler := handler.NewVersionHandler(documentVersionService)
+	sheetHandler := handler.NewSheetHandler(sheetService)
+	slideHandler := handler.NewSlideHandler(slideService)
+	mindMapHandler := handler.NewMindMapHandler(mindMapService)
+	linkHandler := handler.NewLinkHandler(linkService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	siteHandler := handler.NewSiteHandler(siteService, cfg.Site.BaseURL)
 	swaggerHandler := handler.NewSwaggerHandler(basePath)
 
+	// 启动事件补偿任务
+	eventBus.StartRelay(time.Minute)
+
+	// 启动账号相关的后台任务
+	accountService.StartScheduler(time.Hour)
+
+	// 启动回收站到期提醒和清理任务
+	recycleService.StartScheduler(time.Hour)
+
+	// 启动Webhook重试任务
+	webhookService.StartScheduler(30 * time.Second)
+
+	// 启动文档向量补充计算任务
+	semanticSearchService.StartScheduler(10 * time.Minute)
+
+	// 启动定时发布任务
+	reviewService.StartScheduler(time.Minute)
+
+	// 启动文档打开记录的批量写入任务
+	accessService.StartWriter(5 * time.Second)
+
+	// 为已有的思维导图建立节点索引
+	go mindMapService.Backfill()
+
+	// 首次部署时为已有文档解析链接
+	go linkService.Backfill()
+
 	// 初始化Gin引擎
-	r := gin.Default()
+	// 使用自定义的日志和恢复中间件，不使用gin默认的日志，避免查询参数中的票据被原样输出
+	r := gin.New()
 
 	// 加载HTML模板
 	r.LoadHTMLGlob(filepath.Join(basePath, "internal/templates/*.html"))
@@ -70,7 +194,7 @@ func main() {
 	// 设置CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"*"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
@@ -83,9 +207,12 @@ func main() {
 	// 静态文件服务（用于文件上传）
 	r.Static("/uploads", "./uploads")
 
+	// 认证中间件，同时支持登录JWT和个人访问令牌
+	authRequired := middleware.AuthRequired(userService, apiTokenService)
+
 	// 注册路由
-	setupRoutes(r, userHandler, documentHandler, folderHandler, fileHandler, 
-		searchHandler, workspaceHandler, activityHandler, recycleHandler, swaggerHandler)
+	setupRoutes(r, authRequired, userHandler, documentHandler, folderHandler, fileHandler, 
+		searchHandler, workspaceHandler, favoriteHandler, activityHandler, recycleHandler, apiTokenHandler, adminHandler, auditHandler, accountHandler, commentHandler, notificationHandler, webhookHandler, templateHandler, aiHandler, versionHandler, sheetHandler, slideHandler, mindMapHandler, linkHandler, attachmentHandler, reviewHandler, siteHandler, swaggerHandler)
 
 	// 启动服务器
 	log.Printf("Server starting on port %s", cfg.Server.Port)
@@ -95,14 +222,33 @@ func main() {
 }
 
 func setupRoutes(r *gin.Engine,
+	authRequired gin.HandlerFunc,
 	userHandler *handler.UserHandler,
 	documentHandler *handler.DocumentHandler,
 	folderHandler *handler.FolderHandler,
 	fileHandler *handler.FileHandler,
 	searchHandler *handler.SearchHandler,
 	workspaceHandler *handler.WorkspaceHandler,
+	favoriteHandler *handler.FavoriteHandler,
 	activityHandler *handler.ActivityHandler,
 	recycleHandler *handler.RecycleHandler,
+	apiTokenHandler *handler.APITokenHandler,
+	adminHandler *handler.AdminHandler,
+	auditHandler *handler.AuditHandler,
+	accountHandler *handler.AccountHandler,
+	commentHandler *handler.CommentHandler,
+	notificationHandler *handler.NotificationHandler,
+	webhookHandler *handler.WebhookHandler,
+	templateHandler *handler.TemplateHandler,
+	aiHandler *handler.AIHandler,
+	versionHandler *handler.VersionHandler,
+	sheetHandler *handler.SheetHandler,
+	slideHandler *handler.SlideHandler,
+	mindMapHandler *handler.MindMapHandler,
+	linkHandler *handler.LinkHandler,
+	attachmentHandler *handler.AttachmentHandler,
+	reviewHandler *handler.ReviewHandler,
+	siteHandler *handler.SiteHandler,
 	swaggerHandler *handler.SwaggerHandler) {
 
 	api := r.Group("/api/v1")
@@ -112,21 +258,57 @@ func setupRoutes(r *gin.Engine,
 	{
 		users.POST("/register", userHandler.Register)
 		users.POST("/login", userHandler.Login)
-		users.GET("/profile", middleware.AuthRequired(), userHandler.GetProfile)
-		users.PUT("/profile", middleware.AuthRequired(), userHandler.UpdateProfile)
+		users.GET("/profile", authRequired, middleware.RequireScope(model.ScopeResourceProfile), userHandler.GetProfile)
+		users.PUT("/profile", authRequired, middleware.RequireScope(model.ScopeResourceProfile), userHandler.UpdateProfile)
+		users.POST("/avatar", authRequired, middleware.RequireScope(model.ScopeResourceProfile), userHandler.UploadAvatar)
+	}
+
+	// 默认头像，无需登录
+	api.GET("/identicons/:key", userHandler.GetIdenticon)
+
+	// 个人访问令牌相关路由
+	tokens := users.Group("/tokens")
+	tokens.Use(authRequired, middleware.SessionRequired())
+	{
+		tokens.GET("", apiTokenHandler.List)
+		tokens.POST("", apiTokenHandler.Create)
+		tokens.DELETE("/:id", apiTokenHandler.Revoke)
+	}
+
+	// 个人数据导出与账号注销
+	account := users.Group("/account")
+	account.Use(authRequired, middleware.SessionRequired())
+	{
+		account.GET("/exports", accountHandler.ListExports)
+		account.POST("/exports", accountHandler.CreateExport)
+		account.GET("/exports/:id/download", accountHandler.DownloadExport)
+		account.GET("/deletion", accountHandler.GetDeletion)
+		account.POST("/deletion", accountHandler.RequestDeletion)
+		account.DELETE("/deletion", accountHandler.CancelDeletion)
 	}
 
 	// 工作台相关路由
 	workspace := api.Group("/workspace")
-	workspace.Use(middleware.AuthRequired())
+	workspace.Use(authRequired, middleware.RequireScope(model.ScopeResourceWorkspace))
 	{
 		workspace.GET("/dashboard", workspaceHandler.GetDashboard)
 		workspace.GET("/stats", workspaceHandler.GetStats)
+		workspace.GET("/recently-opened", workspaceHandler.GetRecentlyOpened)
+		workspace.GET("/frequent", workspaceHandler.GetFrequentDocuments)
+
+		// 收藏和置顶的文档、文件夹
+		workspace.GET("/favorites", favoriteHandler.ListFavorites)
+		workspace.POST("/favorites", favoriteHandler.AddFavorite)
+		workspace.DELETE("/favorites/:type/:id", favoriteHandler.RemoveFavorite)
+		workspace.GET("/pins", favoriteHandler.ListPins)
+		workspace.POST("/pins", favoriteHandler.Pin)
+		workspace.PUT("/pins/order", favoriteHandler.ReorderPins)
+		workspace.DELETE("/pins/:type/:id", favoriteHandler.Unpin)
 	}
 
 	// 文档相关路由
 	documents := api.Group("/documents")
-	documents.Use(middleware.AuthRequired())
+	documents.Use(authRequired, middleware.RequireScope(model.ScopeResourceDocuments))
 	{
 		documents.GET("", documentHandler.List)
 		documents.POST("", documentHandler.Create)
@@ -135,11 +317,109 @@ func setupRoutes(r *gin.Engine,
 		documents.DELETE("/:id", documentHandler.Delete)
 		documents.POST("/:id/share", documentHandler.Share)
 		documents.POST("/:id/copy", documentHandler.Copy)
+
+		// 文档评论
+		documents.GET("/:id/comments", commentHandler.List)
+		documents.POST("/:id/comments", commentHandler.Create)
+		documents.PUT("/:id/comments/:commentId", commentHandler.Update)
+		documents.DELETE("/:id/comments/:commentId", commentHandler.Delete)
+		documents.POST("/:id/comments/:commentId/resolve", commentHandler.Resolve)
+		documents.POST("/:id/comments/:commentId/reopen", commentHandler.Reopen)
+
+		// 文档操作历史
+		documents.GET("/:id/activities", activityHandler.ListDocumentHistory)
+		documents.GET("/:id/activities/export", activityHandler.ExportDocumentHistory)
+
+		// 文档版本
+		documents.GET("/:id/versions", versionHandler.List)
+		documents.GET("/:id/versions/:versionId", versionHandler.GetByID)
+		documents.POST("/:id/versions/:versionId/restore", versionHandler.Restore)
+
+		// 表格
+		documents.POST("/import/xlsx", sheetHandler.ImportXLSX)
+		documents.GET("/:id/sheets", sheetHandler.Get)
+		documents.PATCH("/:id/sheets/cells", sheetHandler.PatchCells)
+		documents.GET("/:id/export/xlsx", sheetHandler.ExportXLSX)
+		documents.POST("/import/pptx", slideHandler.ImportPPTX)
+		documents.GET("/:id/slides", slideHandler.Get)
+		documents.POST("/:id/slides", slideHandler.AddSlide)
+		documents.PUT("/:id/slides/order", slideHandler.Reorder)
+		documents.PUT("/:id/slides/:slideId", slideHandler.UpdateSlide)
+		documents.DELETE("/:id/slides/:slideId", slideHandler.DeleteSlide)
+		documents.PUT("/:id/slides/:slideId/notes", slideHandler.UpdateNotes)
+		documents.POST("/:id/slides/:slideId/duplicate", slideHandler.DuplicateSlide)
+		documents.GET("/:id/export/pptx", slideHandler.ExportPPTX)
+
+		// 思维导图
+		documents.POST("/import/mindmap", mindMapHandler.Import)
+		documents.GET("/:id/mindmap", mindMapHandler.Get)
+		documents.POST("/:id/mindmap/operations", mindMapHandler.ApplyOperations)
+		documents.POST("/:id/mindmap/nodes", mindMapHandler.AddNode)
+		documents.PUT("/:id/mindmap/nodes/:nodeId", mindMapHandler.UpdateNode)
+		documents.DELETE("/:id/mindmap/nodes/:nodeId", mindMapHandler.DeleteNode)
+		documents.POST("/:id/mindmap/nodes/:nodeId/move", mindMapHandler.MoveNode)
+		documents.POST("/:id/mindmap/nodes/:nodeId/collapse", mindMapHandler.SetCollapsed(model.MindMapOperationCollapse))
+		documents.POST("/:id/mindmap/nodes/:nodeId/expand", mindMapHandler.SetCollapsed(model.MindMapOperationExpand))
+		documents.GET("/:id/export/opml", mindMapHandler.Export(model.MindMapFormatOPML))
+		documents.GET("/:id/export/markdown", mindMapHandler.Export(model.MindMapFormatMarkdown))
+		documents.GET("/:id/export/freemind", mindMapHandler.Export(model.MindMapFormatFreeMind))
+		documents.GET("/:id/links", linkHandler.GetLinks)
+		documents.GET("/links/broken", linkHandler.ListBroken)
+		documents.GET("/graph", linkHandler.Graph)
+
+		// 文档附件
+		documents.GET("/:id/attachments", attachmentHandler.List)
+		documents.POST("/:id/attachments", attachmentHandler.Upload)
+		documents.GET("/:id/attachments/:attachmentId", attachmentHandler.Download)
+		documents.DELETE("/:id/attachments/:attachmentId", attachmentHandler.Delete)
+
+		// 审核与发布
+		documents.POST("/:id/review", reviewHandler.Submit)
+		documents.POST("/:id/review/withdraw", reviewHandler.Withdraw)
+		documents.GET("/:id/reviews", reviewHandler.ListByDocument)
+		documents.POST("/:id/publish", reviewHandler.Transition(model.DocumentActionPublish))
+		documents.POST("/:id/unpublish", reviewHandler.Transition(model.DocumentActionUnpublish))
+		documents.POST("/:id/archive", reviewHandler.Transition(model.DocumentActionArchive))
+		documents.POST("/:id/unarchive", reviewHandler.Transition(model.DocumentActionUnarchive))
+		documents.POST("/:id/schedule", reviewHandler.Schedule)
+		documents.DELETE("/:id/schedule", reviewHandler.CancelSchedule)
+
+		// AI辅助，调用模型的操作同时需要ai权限
+		aiScope := middleware.RequireScope(model.ScopeResourceAI)
+		documents.POST("/:id/ai/summarize", aiScope, aiHandler.Assist(model.AIFeatureSummarize))
+		documents.POST("/:id/ai/rewrite", aiScope, aiHandler.Assist(model.AIFeatureRewrite))
+		documents.POST("/:id/ai/translate", aiScope, aiHandler.Assist(model.AIFeatureTranslate))
+		documents.POST("/:id/ai/continue", aiScope, aiHandler.Assist(model.AIFeatureContinue))
+		documents.POST("/:id/ai/outline", aiScope, aiHandler.Assist(model.AIFeatureOutline))
+		documents.GET("/:id/ai/suggestions", aiHandler.ListSuggestions)
+		documents.POST("/:id/ai/suggestions/:suggestionId/apply", aiHandler.ApplySuggestion)
+		documents.POST("/:id/ai/suggestions/:suggestionId/discard", aiHandler.DiscardSuggestion)
+	}
+
+	// 分配给我的审核
+	reviews := api.Group("/reviews")
+	reviews.Use(authRequired, middleware.RequireScope(model.ScopeResourceDocuments))
+	{
+		reviews.GET("", reviewHandler.ListAssigned)
+		reviews.GET("/:id", reviewHandler.GetByID)
+		reviews.POST("/:id/approve", reviewHandler.Approve)
+		reviews.POST("/:id/reject", reviewHandler.Reject)
+	}
+
+	// 公开站点管理，站点由文件夹发布
+	sites := api.Group("/sites")
+	sites.Use(authRequired, middleware.RequireScope(model.ScopeResourceFolders))
+	{
+		sites.GET("", siteHandler.List)
+		sites.POST("", siteHandler.Create)
+		sites.PUT("/:id", siteHandler.Update)
+		sites.DELETE("/:id", siteHandler.Delete)
+		sites.GET("/:id/pages", siteHandler.ListPages)
 	}
 
 	// 文件夹相关路由
 	folders := api.Group("/folders")
-	folders.Use(middleware.AuthRequired())
+	folders.Use(authRequired, middleware.RequireScope(model.ScopeResourceFolders))
 	{
 		folders.GET("/tree", folderHandler.GetTree)
 		folders.POST("", folderHandler.Create)
@@ -152,7 +432,7 @@ func setupRoutes(r *gin.Engine,
 
 	// 文件上传相关路由
 	files := api.Group("/files")
-	files.Use(middleware.AuthRequired())
+	files.Use(authRequired, middleware.RequireScope(model.ScopeResourceFiles))
 	{
 		files.POST("/upload", fileHandler.Upload)
 		files.DELETE("/:fileId", fileHandler.Delete)
@@ -161,30 +441,111 @@ func setupRoutes(r *gin.Engine,
 
 	// 搜索相关路由
 	search := api.Group("/search")
-	search.Use(middleware.AuthRequired())
+	search.Use(authRequired, middleware.RequireScope(model.ScopeResourceSearch))
 	{
 		search.GET("/documents", searchHandler.SearchDocuments)
 		search.GET("/all", searchHandler.SearchAll)
+		search.GET("/semantic", searchHandler.SemanticSearch)
+		search.GET("/mindmap", mindMapHandler.SearchNodes)
 	}
 
 	// 足迹记录相关路由
 	activities := api.Group("/activities")
-	activities.Use(middleware.AuthRequired())
+	activities.Use(authRequired, middleware.RequireScope(model.ScopeResourceActivities))
 	{
 		activities.GET("", activityHandler.List)
+		activities.GET("/export", activityHandler.Export)
 		activities.POST("", activityHandler.Create)
 	}
 
 	// 回收站相关路由
 	recycle := api.Group("/recycle")
-	recycle.Use(middleware.AuthRequired())
+	recycle.Use(authRequired, middleware.RequireScope(model.ScopeResourceRecycle))
 	{
 		recycle.GET("", recycleHandler.List)
 		recycle.POST("/:id/restore", recycleHandler.Restore)
 		recycle.DELETE("/:id", recycleHandler.DeletePermanently)
 		recycle.DELETE("/batch", recycleHandler.DeleteBatch)
 	}
-	
+
+	// 通知中心路由
+	notifications := api.Group("/notifications")
+	{
+		notificationScope := middleware.RequireScope(model.ScopeResourceNotifications)
+		notifications.GET("", authRequired, notificationScope, notificationHandler.List)
+		notifications.GET("/unread-count", authRequired, notificationScope, notificationHandler.UnreadCount)
+		notifications.PUT("/read-all", authRequired, notificationScope, notificationHandler.MarkAllRead)
+		notifications.PUT("/:id/read", authRequired, notificationScope, notificationHandler.MarkRead)
+		notifications.GET("/preferences", authRequired, notificationScope, notificationHandler.GetPreferences)
+		notifications.PUT("/preferences", authRequired, notificationScope, notificationHandler.UpdatePreferences)
+		// EventSource无法设置请求头，先签发一次性票据，再通过查询参数传递票据建立连接
+		notifications.POST("/stream-ticket", authRequired, notificationScope, notificationHandler.CreateStreamTicket)
+		notifications.GET("/stream", notificationHandler.Stream)
+	}
+
+	// Webhook相关路由
+	webhooks := api.Group("/webhooks")
+	webhooks.Use(authRequired, middleware.RequireScope(model.ScopeResourceWebhooks))
+	{
+		webhooks.GET("", webhookHandler.List)
+		webhooks.POST("", webhookHandler.Create)
+		webhooks.GET("/:id", webhookHandler.GetByID)
+		webhooks.PUT("/:id", webhookHandler.Update)
+		webhooks.DELETE("/:id", webhookHandler.Delete)
+		webhooks.POST("/:id/rotate-secret", webhookHandler.RotateSecret)
+		webhooks.POST("/:id/test", webhookHandler.Test)
+		webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+	}
+
+	// 文档模板路由
+	templates := api.Group("/templates")
+	templates.Use(authRequired, middleware.RequireScope(model.ScopeResourceTemplates))
+	{
+		templates.GET("", templateHandler.List)
+		templates.POST("", templateHandler.Create)
+		templates.GET("/:id", templateHandler.GetByID)
+		templates.PUT("/:id", templateHandler.Update)
+		templates.DELETE("/:id", templateHandler.Delete)
+	}
+
+	// AI写作路由
+	ai := api.Group("/ai")
+	ai.Use(authRequired, middleware.RequireScope(model.ScopeResourceAI))
+	{
+		ai.POST("/drafts", aiHandler.CreateDraft)
+		ai.GET("/drafts", aiHandler.ListDrafts)
+		ai.GET("/drafts/:id", aiHandler.GetDraft)
+		ai.GET("/usage", aiHandler.Usage)
+	}
+
+	// 管理后台路由
+	admin := api.Group("/admin")
+	admin.Use(authRequired, middleware.SessionRequired())
+	{
+		admin.GET("/users", middleware.RequirePermission(model.PermissionUserRead), adminHandler.ListUsers)
+		admin.GET("/users/:id", middleware.RequirePermission(model.PermissionUserRead), adminHandler.GetUser)
+		admin.PUT("/users/:id/status", middleware.RequirePermission(model.PermissionUserManage), adminHandler.UpdateUserStatus)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(model.PermissionUserManage), adminHandler.UpdateUserRole)
+		admin.POST("/users/:id/reset-password", middleware.RequirePermission(model.PermissionUserManage), adminHandler.ResetPassword)
+		admin.GET("/stats", middleware.RequirePermission(model.PermissionStatsRead), adminHandler.GetStats)
+		admin.GET("/audit-logs", middleware.RequirePermission(model.PermissionAuditRead), auditHandler.List)
+		admin.GET("/audit-logs/export", middleware.RequirePermission(model.PermissionAuditRead), auditHandler.Export)
+		admin.GET("/audit-logs/verify", middleware.RequirePermission(model.PermissionAuditRead), auditHandler.Verify)
+	}
+
+	// 分享链接查看文档，无需登录
+	api.GET("/share/:token", documentHandler.GetShared)
+
+	// 公开站点，无需登录
+	publicSites := r.Group("/sites/:slug")
+	{
+		publicSites.GET("", siteHandler.Home)
+		publicSites.GET("/pages/:page", siteHandler.Page)
+		publicSites.GET("/feed.xml", siteHandler.RSS)
+		publicSites.GET("/atom.xml", siteHandler.Atom)
+		publicSites.GET("/sitemap.xml", siteHandler.Sitemap)
+	}
+
 	// API文档路由
 	docs := r.Group("/api/docs")
 	{
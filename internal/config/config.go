@@ -13,11 +13,16 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Log       LogConfig       `mapstructure:"log"`
+	Webhook   WebhookConfig   `mapstructure:"webhook"`
+	Event     EventConfig     `mapstructure:"event"`
+	AI        AIConfig        `mapstructure:"ai"`
+	Embedding EmbeddingConfig `mapstructure:"embedding"`
+	Site      SiteConfig      `mapstructure:"site"`
 }
 
 type ServerConfig struct {
@@ -54,6 +59,50 @@ type LogConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 }
 
+type WebhookConfig struct {
+	// 是否允许投递到内网地址，仅在内网部署且回调目标可信时开启
+	AllowPrivateNetwork bool `mapstructure:"allow_private_network"`
+}
+
+type EventConfig struct {
+	// 是否启用事件发件箱，启用后事件先落库再分发，进程崩溃后可补偿
+	Outbox bool `mapstructure:"outbox"`
+}
+
+type AIConfig struct {
+	// 是否启用AI写作，未启用时相关接口返回503
+	Enabled bool `mapstructure:"enabled"`
+	// 模型服务商，目前支持openai(兼容OpenAI Chat Completions接口的服务均可使用)
+	Provider    string        `mapstructure:"provider"`
+	BaseURL     string        `mapstructure:"base_url"`
+	APIKey      string        `mapstructure:"api_key"`
+	Model       string        `mapstructure:"model"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	MaxTokens   int           `mapstructure:"max_tokens"`
+	Temperature float64       `mapstructure:"temperature"`
+	// 每个用户每分钟的请求数，0表示不限制
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	// 每个用户每天可消耗的token数，0表示不限制
+	DailyTokenLimit int64 `mapstructure:"daily_token_limit"`
+}
+
+type EmbeddingConfig struct {
+	// 是否启用语义搜索，未启用时语义搜索接口返回503，文档详情不返回相关文档
+	Enabled bool `mapstructure:"enabled"`
+	// 向量服务商：local为本地哈希向量，无需外部服务，只适合开发和测试；openai为兼容OpenAI Embeddings接口的服务
+	Provider   string        `mapstructure:"provider"`
+	BaseURL    string        `mapstructure:"base_url"`
+	APIKey     string        `mapstructure:"api_key"`
+	Model      string        `mapstructure:"model"`
+	Dimensions int           `mapstructure:"dimensions"`
+	Timeout    time.Duration `mapstructure:"timeout"`
+}
+
+type SiteConfig struct {
+	// 公开站点的外部访问地址，用于订阅源和站点地图中的绝对链接，为空时使用请求的地址
+	BaseURL string `mapstructure:"base_url"`
+}
+
 func Load() *Config {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -99,6 +148,31 @@ func setDefaults() {
 	viper.SetDefault("log.max_size", 100)
 	viper.SetDefault("log.max_age", 30)
 	viper.SetDefault("log.max_backups", 10)
+	
+	viper.SetDefault("webhook.allow_private_network", false)
+	
+	viper.SetDefault("event.outbox", true)
+	
+	viper.SetDefault("ai.enabled", false)
+	viper.SetDefault("ai.provider", "openai")
+	viper.SetDefault("ai.base_url", "https://api.openai.com/v1")
+	viper.SetDefault("ai.api_key", "")
+	viper.SetDefault("ai.model", "gpt-4o-mini")
+	viper.SetDefault("ai.timeout", "120s")
+	viper.SetDefault("ai.max_tokens", 2048)
+	viper.SetDefault("ai.temperature", 0.7)
+	viper.SetDefault("ai.requests_per_minute", 10)
+	viper.SetDefault("ai.daily_token_limit", 200000)
+
+	viper.SetDefault("embedding.enabled", false)
+	viper.SetDefault("embedding.provider", "local")
+	viper.SetDefault("embedding.base_url", "https://api.openai.com/v1")
+	viper.SetDefault("embedding.api_key", "")
+	viper.SetDefault("embedding.model", "text-embedding-3-small")
+	viper.SetDefault("embedding.dimensions", 256)
+	viper.SetDefault("embedding.timeout", "30s")
+
+	viper.SetDefault("site.base_url", "")
 }
 
 func InitDB(cfg *Config) *gorm.DB {
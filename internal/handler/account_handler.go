@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService service.AccountService
+	auditService   service.AuditService
+}
+
+func NewAccountHandler(accountService service.AccountService, auditService service.AuditService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		auditService:   auditService,
+	}
+}
+
+func (h *AccountHandler) CreateExport(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	export, err := h.accountService.CreateExport(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditService, model.AuditActionAccountExport, model.AuditResourceUser, userID, nil, gin.H{"export_id": export.ID})
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    202,
+		"message": "导出任务已创建，完成后可在导出列表中下载",
+		"data":    export,
+	})
+}
+
+func (h *AccountHandler) ListExports(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	exports, err := h.accountService.ListExports(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取导出列表失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    exports,
+	})
+}
+
+func (h *AccountHandler) DownloadExport(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的导出ID",
+		})
+		return
+	}
+
+	export, err := h.accountService.GetExportFile(uint(id), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.FileAttachment(export.FilePath, fmt.Sprintf("wz-export-%d.zip", export.ID))
+}
+
+func (h *AccountHandler) GetDeletion(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	deletion, err := h.accountService.GetDeletion(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取注销申请失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    deletion,
+	})
+}
+
+func (h *AccountHandler) RequestDeletion(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.RequestAccountDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	deletion, err := h.accountService.RequestDeletion(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditService, model.AuditActionDeletionRequest, model.AuditResourceUser, userID, nil, gin.H{
+		"content_action":      deletion.ContentAction,
+		"transfer_to_user_id": deletion.TransferToUserID,
+		"scheduled_at":        deletion.ScheduledAt,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "注销申请已提交，冷静期结束前可以撤销",
+		"data":    deletion,
+	})
+}
+
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	err := h.accountService.CancelDeletion(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditService, model.AuditActionDeletionCancel, model.AuditResourceUser, userID, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已撤销注销申请",
+	})
+}
@@ -1,7 +1,13 @@
 package handler
 
 import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 	"wz-wenzhan-backend/internal/middleware"
 	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/service"
@@ -86,7 +92,7 @@ func (h *ActivityHandler) List(c *gin.Context) {
 		req.PageSize = 20
 	}
 
-	activities, total, err := h.activityService.List(userID, &req)
+	result, err := h.activityService.List(userID, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -99,11 +105,245 @@ func (h *ActivityHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "获取成功",
-		"data": gin.H{
-			"items": activities,
-			"total": total,
-			"page":  req.Page,
-			"size":  req.PageSize,
-		},
+		"data":    result,
 	})
 }
+
+// ListDocumentHistory 文档的操作历史，包含协作者的评论等操作
+func (h *ActivityHandler) ListDocumentHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return
+	}
+
+	var req model.ActivityListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	result, err := h.activityService.ListDocumentHistory(uint(documentID), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    result,
+	})
+}
+
+// Export 导出个人足迹时间线
+func (h *ActivityHandler) Export(c *gin.Context) {
+	h.exportTimeline(c, 0)
+}
+
+// ExportDocumentHistory 导出文档的操作历史
+func (h *ActivityHandler) ExportDocumentHistory(c *gin.Context) {
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return
+	}
+	h.exportTimeline(c, uint(documentID))
+}
+
+// exportTimeline 以CSV或ICS格式流式导出时间线
+func (h *ActivityHandler) exportTimeline(c *gin.Context, documentID uint) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	// 分页参数对导出无效，先填充默认值以通过校验
+	req := model.ActivityExportRequest{
+		ActivityListRequest: model.ActivityListRequest{Page: 1, PageSize: 1},
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+
+	fileName := fmt.Sprintf("activities_%s.%s", time.Now().Format("20060102150405"), req.Format)
+	if documentID != 0 {
+		fileName = fmt.Sprintf("document_%d_activities_%s.%s", documentID, time.Now().Format("20060102150405"), req.Format)
+	}
+
+	// 输出先写入缓冲区，校验文档权限失败时尚未发送任何内容，仍可返回JSON错误
+	buffer := bufio.NewWriter(c.Writer)
+	var err error
+	if req.Format == "ics" {
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		err = writeICS(buffer, func(fn func(item *model.ActivityResponse) error) error {
+			return h.activityService.ExportTimeline(userID, documentID, &req.ActivityListRequest, fn)
+		})
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		writer := csv.NewWriter(buffer)
+		writer.Write([]string{
+			"id", "created_at", "user_id", "username", "type", "resource_type", "resource_id",
+			"resource_name", "description", "session_count", "session_started_at", "session_ended_at",
+		})
+		err = h.activityService.ExportTimeline(userID, documentID, &req.ActivityListRequest, func(item *model.ActivityResponse) error {
+			record := []string{
+				strconv.FormatUint(uint64(item.ID), 10),
+				item.CreatedAt.Format(time.RFC3339),
+				strconv.FormatUint(uint64(item.UserID), 10),
+				item.Username,
+				string(item.Type),
+				string(item.ResourceType),
+				strconv.FormatUint(uint64(item.ResourceID), 10),
+				item.ResourceName,
+				item.Description,
+				"1", "", "",
+			}
+			if item.Session != nil {
+				record[9] = strconv.Itoa(item.Session.Count)
+				record[10] = item.Session.StartedAt.Format(time.RFC3339)
+				record[11] = item.Session.EndedAt.Format(time.RFC3339)
+			}
+			return writer.Write(record)
+		})
+		writer.Flush()
+	}
+
+	if err != nil && !c.Writer.Written() {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	buffer.Flush()
+
+	// 响应头已发送，出错时只能中断输出
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// writeICS 将时间线写为iCalendar，每条记录或编辑会话对应一个VEVENT
+func writeICS(w *bufio.Writer, export func(fn func(item *model.ActivityResponse) error) error) error {
+	line := func(name, value string) {
+		w.WriteString(foldICSLine(name + ":" + value))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//wz-wenzhan//Activity Timeline//ZH")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", "足迹时间线")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	err := export(func(item *model.ActivityResponse) error {
+		uid := fmt.Sprintf("activity-%d@wz-wenzhan", item.ID)
+		start, end := item.CreatedAt, item.CreatedAt
+		if item.Session != nil {
+			uid = fmt.Sprintf("activity-%d-%d@wz-wenzhan", item.Session.FirstID, item.ID)
+			start, end = item.Session.StartedAt, item.Session.EndedAt
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", uid)
+		line("DTSTAMP", stamp)
+		line("DTSTART", start.UTC().Format("20060102T150405Z"))
+		if end.After(start) {
+			line("DTEND", end.UTC().Format("20060102T150405Z"))
+		}
+		line("SUMMARY", escapeICSText(item.Description+"："+item.ResourceName))
+		description := fmt.Sprintf("类型：%s\n资源：%s #%d", item.Type, item.ResourceType, item.ResourceID)
+		if item.Username != "" {
+			description = "操作者：" + item.Username + "\n" + description
+		}
+		line("DESCRIPTION", escapeICSText(description))
+		line("END", "VEVENT")
+		return nil
+	})
+
+	line("END", "VCALENDAR")
+	return err
+}
+
+// escapeICSText 转义iCalendar文本值中的特殊字符
+func escapeICSText(text string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+		"\r", "",
+	).Replace(text)
+}
+
+// foldICSLine iCalendar每行不超过75字节，超出部分以空格开头续行，按字符边界折行
+func foldICSLine(content string) string {
+	var builder strings.Builder
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > 75 {
+			builder.WriteString("\r\n ")
+			width = 1
+		}
+		builder.WriteRune(r)
+		width += size
+	}
+	builder.WriteString("\r\n")
+	return builder.String()
+}
+
+// clientInfo 获取请求的IP和UA，随事件传给足迹记录
+func clientInfo(c *gin.Context) *model.ClientInfo {
+	return &model.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService service.AdminService
+	auditService service.AuditService
+}
+
+func NewAdminHandler(adminService service.AdminService, auditService service.AuditService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		auditService: auditService,
+	}
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var req model.AdminUserListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	users, total, err := h.adminService.ListUsers(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取用户列表失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": users,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	id, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.GetUser(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    user,
+	})
+}
+
+func (h *AdminHandler) UpdateUserStatus(c *gin.Context) {
+	adminID, _ := middleware.GetUserID(c)
+	id, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 记录变更前的值用于审计
+	before, _ := h.adminService.GetUser(id)
+
+	err := h.adminService.UpdateUserStatus(adminID, id, *req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var beforeValue interface{}
+	if before != nil {
+		beforeValue = gin.H{"status": before.Status}
+	}
+	recordAudit(c, h.auditService, model.AuditActionUserStatus, model.AuditResourceUser, id, beforeValue, gin.H{"status": *req.Status})
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+	})
+}
+
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	adminID, _ := middleware.GetUserID(c)
+	id, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 记录变更前的值用于审计
+	before, _ := h.adminService.GetUser(id)
+
+	err := h.adminService.UpdateUserRole(adminID, id, req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var beforeValue interface{}
+	if before != nil {
+		beforeValue = gin.H{"role": before.Role}
+	}
+	recordAudit(c, h.auditService, model.AuditActionUserRole, model.AuditResourceUser, id, beforeValue, gin.H{"role": req.Role})
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+	})
+}
+
+func (h *AdminHandler) ResetPassword(c *gin.Context) {
+	adminID, _ := middleware.GetUserID(c)
+	id, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := h.adminService.ResetPassword(adminID, id, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 临时密码不写入审计日志
+	recordAudit(c, h.auditService, model.AuditActionPasswordReset, model.AuditResourceUser, id, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "重置成功",
+		"data":    result,
+	})
+}
+
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.adminService.GetSystemStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取统计数据失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    stats,
+	})
+}
+
+func parseUserIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的用户ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,435 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AIHandler struct {
+	aiDraftService  service.AIDraftService
+	aiAssistService service.AIAssistService
+	aiUsageService  service.AIUsageService
+}
+
+func NewAIHandler(aiDraftService service.AIDraftService, aiAssistService service.AIAssistService, aiUsageService service.AIUsageService) *AIHandler {
+	return &AIHandler{
+		aiDraftService:  aiDraftService,
+		aiAssistService: aiAssistService,
+		aiUsageService:  aiUsageService,
+	}
+}
+
+// CreateDraft 生成草稿并通过SSE推送：delta事件为增量内容，done事件为保存后的起草记录，error事件为失败原因
+func (h *AIHandler) CreateDraft(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	if !h.aiDraftService.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    503,
+			"message": "AI写作功能未启用",
+		})
+		return
+	}
+
+	var req model.CreateAIDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	draft, err := h.aiDraftService.Generate(c.Request.Context(), userID, &req, clientInfo(c), func(delta string) error {
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return nil
+	})
+
+	// 参考文档校验等在生成前失败时还未推送任何内容，按普通请求返回错误
+	if err != nil && !c.Writer.Written() {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		if abortRateLimited(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+			"data":    draft,
+		})
+		return
+	}
+
+	if err != nil {
+		c.SSEvent("error", gin.H{"message": err.Error(), "draft": draft})
+	} else {
+		c.SSEvent("done", draft)
+	}
+	c.Writer.Flush()
+}
+
+func (h *AIHandler) ListDrafts(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.AIDraftListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	drafts, total, err := h.aiDraftService.List(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取起草记录失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": drafts,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+func (h *AIHandler) GetDraft(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的起草记录ID",
+		})
+		return
+	}
+
+	draft, err := h.aiDraftService.GetByID(uint(id), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    draft,
+	})
+}
+
+// Assist 对文档执行指定的AI操作，返回待确认的建议
+func (h *AIHandler) Assist(feature model.AIFeature) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := middleware.GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "用户未认证",
+			})
+			return
+		}
+
+		if !h.aiAssistService.Enabled() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"code":    503,
+				"message": "AI写作功能未启用",
+			})
+			return
+		}
+
+		documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "无效的文档ID",
+			})
+			return
+		}
+
+		var req model.AIAssistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		suggestion, err := h.aiAssistService.Run(c.Request.Context(), uint(documentID), userID, feature, &req)
+		if err != nil {
+			if abortRateLimited(c, err) {
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "生成成功",
+			"data":    suggestion,
+		})
+	}
+}
+
+func (h *AIHandler) ListSuggestions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return
+	}
+
+	var req model.AISuggestionListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	suggestions, total, err := h.aiAssistService.ListSuggestions(uint(documentID), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取建议列表失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": suggestions,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+// ApplySuggestion 将建议应用到文档，生成新版本
+func (h *AIHandler) ApplySuggestion(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return
+	}
+
+	suggestionID, err := strconv.ParseUint(c.Param("suggestionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的建议ID",
+		})
+		return
+	}
+
+	var req model.ApplyAISuggestionRequest
+	// 请求体可省略，省略时按操作类型选择默认的应用方式
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	version, err := h.aiAssistService.Apply(uint(documentID), uint(suggestionID), userID, &req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "应用成功",
+		"data":    version,
+	})
+}
+
+func (h *AIHandler) DiscardSuggestion(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return
+	}
+
+	suggestionID, err := strconv.ParseUint(c.Param("suggestionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的建议ID",
+		})
+		return
+	}
+
+	if err := h.aiAssistService.Discard(uint(documentID), uint(suggestionID), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已放弃该建议",
+	})
+}
+
+// Usage 获取当前用户的AI用量和额度
+func (h *AIHandler) Usage(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	summary, err := h.aiUsageService.Summary(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取AI用量失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    summary,
+	})
+}
+
+// abortRateLimited 超出频率或额度时返回429并设置Retry-After
+func abortRateLimited(c *gin.Context, err error) bool {
+	var limitErr *service.RateLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"code":    429,
+		"message": limitErr.Message,
+	})
+	return true
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenHandler struct {
+	apiTokenService service.APITokenService
+	auditService    service.AuditService
+}
+
+func NewAPITokenHandler(apiTokenService service.APITokenService, auditService service.AuditService) *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: apiTokenService,
+		auditService:    auditService,
+	}
+}
+
+func (h *APITokenHandler) Create(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	token, err := h.apiTokenService.Create(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 只记录令牌元数据，不记录明文
+	recordAudit(c, h.auditService, model.AuditActionTokenCreate, model.AuditResourceAPIToken, token.ID, nil, token.APITokenResponse)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "创建成功，请妥善保存令牌，关闭后将无法再次查看",
+		"data":    token,
+	})
+}
+
+func (h *APITokenHandler) List(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	tokens, err := h.apiTokenService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取访问令牌失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    tokens,
+	})
+}
+
+func (h *APITokenHandler) Revoke(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的令牌ID",
+		})
+		return
+	}
+
+	err = h.apiTokenService.Revoke(uint(id), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "撤销失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditService, model.AuditActionTokenRevoke, model.AuditResourceAPIToken, uint(id), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "撤销成功",
+	})
+}
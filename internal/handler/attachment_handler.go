@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// List 获取文档的附件
+func (h *AttachmentHandler) List(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentService.List(documentID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    attachments,
+	})
+}
+
+// Upload 上传文档附件，附件大小计入文档大小
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请选择要上传的文件",
+		})
+		return
+	}
+
+	attachment, err := h.attachmentService.Upload(documentID, userID, fileHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "上传成功",
+		"data":    attachment,
+	})
+}
+
+// Download 以上传时的文件名下载附件
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	attachmentID, ok := h.parseAttachmentID(c)
+	if !ok {
+		return
+	}
+
+	attachment, file, err := h.attachmentService.Open(documentID, attachmentID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.MimeType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(attachment.FileName)),
+	})
+}
+
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	attachmentID, ok := h.parseAttachmentID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.Delete(documentID, attachmentID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+	})
+}
+
+// parseRequest 读取当前用户和路径中的文档ID，失败时已写入响应
+func (h *AttachmentHandler) parseRequest(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, 0, false
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return 0, 0, false
+	}
+	return userID, uint(documentID), true
+}
+
+func (h *AttachmentHandler) parseAttachmentID(c *gin.Context) (uint, bool) {
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的附件ID",
+		})
+		return 0, false
+	}
+	return uint(attachmentID), true
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAuditEntry 根据请求上下文填充操作人、IP和UA
+func newAuditEntry(c *gin.Context, action model.AuditAction, resourceType string, resourceID uint) *model.AuditEntry {
+	actorID, _ := middleware.GetUserID(c)
+	actorRole, _ := middleware.GetUserRole(c)
+
+	return &model.AuditEntry{
+		ActorID:      actorID,
+		ActorRole:    actorRole,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	}
+}
+
+// recordAudit 记录审计日志。写入失败已由服务记录错误日志，不影响业务请求的结果。
+func recordAudit(c *gin.Context, auditService service.AuditService, action model.AuditAction, resourceType string, resourceID uint, before, after interface{}) {
+	entry := newAuditEntry(c, action, resourceType, resourceID)
+	entry.Before = before
+	entry.After = after
+	_ = auditService.Record(entry)
+}
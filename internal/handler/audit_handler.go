@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+func (h *AuditHandler) List(c *gin.Context) {
+	var req model.AuditLogListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	logs, total, err := h.auditService.List(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取审计日志失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": logs,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+// Export 以CSV或JSONL格式流式导出审计日志
+func (h *AuditHandler) Export(c *gin.Context) {
+	// 分页参数对导出无效，先填充默认值以通过校验
+	req := model.AuditLogExportRequest{
+		AuditLogListRequest: model.AuditLogListRequest{Page: 1, PageSize: 1},
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+
+	// 导出行为本身也需要审计
+	recordAudit(c, h.auditService, model.AuditActionAuditExport, model.AuditResourceAuditLog, 0, nil, req)
+
+	fileName := fmt.Sprintf("audit_logs_%s.%s", time.Now().Format("20060102150405"), req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+
+	var err error
+	if req.Format == "jsonl" {
+		c.Header("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(c.Writer)
+		err = h.auditService.Export(&req.AuditLogListRequest, func(log *model.AuditLog) error {
+			return encoder.Encode(log)
+		})
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		writer := csv.NewWriter(c.Writer)
+		writer.Write([]string{
+			"id", "created_at", "actor_id", "actor_role", "action", "resource_type", "resource_id",
+			"ip_address", "user_agent", "before", "after", "prev_hash", "hash",
+		})
+		err = h.auditService.Export(&req.AuditLogListRequest, func(log *model.AuditLog) error {
+			return writer.Write([]string{
+				strconv.FormatUint(uint64(log.ID), 10),
+				log.CreatedAt.Format(time.RFC3339),
+				strconv.FormatUint(uint64(log.ActorID), 10),
+				log.ActorRole,
+				string(log.Action),
+				log.ResourceType,
+				strconv.FormatUint(uint64(log.ResourceID), 10),
+				log.IPAddress,
+				log.UserAgent,
+				log.Before,
+				log.After,
+				log.PrevHash,
+				log.Hash,
+			})
+		})
+		writer.Flush()
+	}
+
+	// 响应头已发送，出错时只能中断输出
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// Verify 校验审计日志Hash链是否完整
+func (h *AuditHandler) Verify(c *gin.Context) {
+	result, err := h.auditService.Verify()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "校验失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "校验完成",
+		"data":    result,
+	})
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+func (h *CommentHandler) List(c *gin.Context) {
+	userID, documentID, ok := parseCommentDocument(c)
+	if !ok {
+		return
+	}
+
+	var req model.CommentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	comments, total, err := h.commentService.List(documentID, userID, c.Query("share_token"), &req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": comments,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+func (h *CommentHandler) Create(c *gin.Context) {
+	userID, documentID, ok := parseCommentDocument(c)
+	if !ok {
+		return
+	}
+
+	var req model.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentService.Create(documentID, userID, c.Query("share_token"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "评论成功",
+		"data":    comment,
+	})
+}
+
+func (h *CommentHandler) Update(c *gin.Context) {
+	userID, documentID, ok := parseCommentDocument(c)
+	if !ok {
+		return
+	}
+	commentID, ok := parseCommentIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentService.Update(documentID, commentID, userID, c.Query("share_token"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    comment,
+	})
+}
+
+func (h *CommentHandler) Delete(c *gin.Context) {
+	userID, documentID, ok := parseCommentDocument(c)
+	if !ok {
+		return
+	}
+	commentID, ok := parseCommentIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.commentService.Delete(documentID, commentID, userID, c.Query("share_token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+	})
+}
+
+func (h *CommentHandler) Resolve(c *gin.Context) {
+	h.setResolved(c, true, "已标记为解决")
+}
+
+func (h *CommentHandler) Reopen(c *gin.Context) {
+	h.setResolved(c, false, "已重新打开")
+}
+
+func (h *CommentHandler) setResolved(c *gin.Context, resolved bool, message string) {
+	userID, documentID, ok := parseCommentDocument(c)
+	if !ok {
+		return
+	}
+	commentID, ok := parseCommentIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.commentService.SetResolved(documentID, commentID, userID, c.Query("share_token"), resolved)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": message,
+	})
+}
+
+// parseCommentDocument 获取当前用户和路径中的文档ID
+func parseCommentDocument(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, 0, false
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return 0, 0, false
+	}
+
+	return userID, uint(documentID), true
+}
+
+func parseCommentIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的评论ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
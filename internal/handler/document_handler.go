@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"wz-wenzhan-backend/internal/middleware"
@@ -10,13 +11,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// relatedDocumentLimit 文档详情中返回的相关文档数
+const relatedDocumentLimit = 5
+
 type DocumentHandler struct {
-	documentService service.DocumentService
+	documentService       service.DocumentService
+	auditService          service.AuditService
+	semanticSearchService service.SemanticSearchService
+	linkService           service.LinkService
 }
 
-func NewDocumentHandler(documentService service.DocumentService) *DocumentHandler {
+func NewDocumentHandler(documentService service.DocumentService, auditService service.AuditService, semanticSearchService service.SemanticSearchService, linkService service.LinkService) *DocumentHandler {
 	return &DocumentHandler{
-		documentService: documentService,
+		documentService:       documentService,
+		auditService:          auditService,
+		semanticSearchService: semanticSearchService,
+		linkService:           linkService,
 	}
 }
 
@@ -40,7 +50,14 @@ func (h *DocumentHandler) Create(c *gin.Context) {
 		return
 	}
 
-	document, err := h.documentService.Create(userID, &req)
+	document, err := h.documentService.Create(userID, &req, clientInfo(c))
+	if err != nil && req.TemplateID != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -77,7 +94,7 @@ func (h *DocumentHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	document, err := h.documentService.GetByID(uint(id), userID)
+	document, err := h.documentService.GetByID(uint(id), userID, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    404,
@@ -86,6 +103,8 @@ func (h *DocumentHandler) GetByID(c *gin.Context) {
 		})
 		return
 	}
+	document.Related = h.semanticSearchService.Related(document.ID, userID, relatedDocumentLimit)
+	document.Backlinks = h.linkService.Backlinks(document.ID, userID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -124,10 +143,11 @@ func (h *DocumentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err = h.documentService.Update(uint(id), userID, &req)
+	err = h.documentService.Update(uint(id), userID, &req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
+		status := conflictStatus(err, http.StatusInternalServerError)
+		c.JSON(status, gin.H{
+			"code":    status,
 			"message": "更新失败",
 			"error":   err.Error(),
 		})
@@ -160,7 +180,7 @@ func (h *DocumentHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.documentService.Delete(uint(id), userID)
+	err = h.documentService.Delete(uint(id), userID, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -170,6 +190,8 @@ func (h *DocumentHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, model.AuditActionDocumentDelete, model.AuditResourceDocument, uint(id), nil, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "删除成功",
@@ -256,7 +278,7 @@ func (h *DocumentHandler) Share(c *gin.Context) {
 		return
 	}
 
-	token, err := h.documentService.Share(uint(id), userID, &req)
+	token, err := h.documentService.Share(uint(id), userID, &req, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -266,6 +288,8 @@ func (h *DocumentHandler) Share(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, model.AuditActionDocumentShare, model.AuditResourceDocument, uint(id), nil, req)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "分享成功",
@@ -276,6 +300,25 @@ func (h *DocumentHandler) Share(c *gin.Context) {
 	})
 }
 
+// GetShared 通过分享链接查看文档，无需登录
+func (h *DocumentHandler) GetShared(c *gin.Context) {
+	document, err := h.documentService.GetByShareToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "文档不存在",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    document,
+	})
+}
+
 func (h *DocumentHandler) Copy(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
 	if !exists {
@@ -296,7 +339,7 @@ func (h *DocumentHandler) Copy(c *gin.Context) {
 		return
 	}
 
-	document, err := h.documentService.Copy(uint(id), userID)
+	document, err := h.documentService.Copy(uint(id), userID, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -312,3 +355,11 @@ func (h *DocumentHandler) Copy(c *gin.Context) {
 		"data":    document,
 	})
 }
+
+// conflictStatus 文档在读取后被其他请求修改时返回409，否则返回status
+func conflictStatus(err error, status int) int {
+	if errors.Is(err, service.ErrDocumentConflict) {
+		return http.StatusConflict
+	}
+	return status
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FavoriteHandler struct {
+	favoriteService service.FavoriteService
+}
+
+func NewFavoriteHandler(favoriteService service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{
+		favoriteService: favoriteService,
+	}
+}
+
+// ListFavorites 获取收藏的文档和文件夹
+func (h *FavoriteHandler) ListFavorites(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.FavoriteListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	items, err := h.favoriteService.ListFavorites(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取收藏失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    items,
+	})
+}
+
+// AddFavorite 收藏文档或文件夹
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	userID, req, ok := h.parseMarkRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.AddFavorite(userID, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "收藏成功",
+	})
+}
+
+// RemoveFavorite 取消收藏
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	userID, resourceType, resourceID, ok := h.parseResource(c)
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.RemoveFavorite(userID, resourceType, resourceID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已取消收藏",
+	})
+}
+
+// ListPins 按置顶顺序获取置顶的文档和文件夹
+func (h *FavoriteHandler) ListPins(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	items, err := h.favoriteService.ListPins(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取置顶项失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    items,
+	})
+}
+
+// Pin 置顶文档或文件夹
+func (h *FavoriteHandler) Pin(c *gin.Context) {
+	userID, req, ok := h.parseMarkRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.Pin(userID, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "置顶成功",
+	})
+}
+
+// Unpin 取消置顶
+func (h *FavoriteHandler) Unpin(c *gin.Context) {
+	userID, resourceType, resourceID, ok := h.parseResource(c)
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteService.Unpin(userID, resourceType, resourceID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已取消置顶",
+	})
+}
+
+// ReorderPins 调整置顶项的顺序
+func (h *FavoriteHandler) ReorderPins(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.ReorderPinsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	items, err := h.favoriteService.ReorderPins(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "排序成功",
+		"data":    items,
+	})
+}
+
+// parseMarkRequest 解析当前用户和收藏、置顶请求，失败时已写入响应
+func (h *FavoriteHandler) parseMarkRequest(c *gin.Context) (uint, *model.MarkRequest, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, nil, false
+	}
+
+	var req model.MarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return 0, nil, false
+	}
+
+	return userID, &req, true
+}
+
+// parseResource 解析当前用户和路径中的资源类型、资源ID，失败时已写入响应
+func (h *FavoriteHandler) parseResource(c *gin.Context) (uint, model.ResourceType, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, "", 0, false
+	}
+
+	resourceType := model.ResourceType(c.Param("type"))
+	if resourceType != model.ResourceTypeDocument && resourceType != model.ResourceTypeFolder {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的资源类型",
+		})
+		return 0, "", 0, false
+	}
+
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的资源ID",
+		})
+		return 0, "", 0, false
+	}
+
+	return userID, resourceType, uint(resourceID), true
+}
@@ -12,11 +12,13 @@ import (
 
 type FolderHandler struct {
 	folderService service.FolderService
+	auditService  service.AuditService
 }
 
-func NewFolderHandler(folderService service.FolderService) *FolderHandler {
+func NewFolderHandler(folderService service.FolderService, auditService service.AuditService) *FolderHandler {
 	return &FolderHandler{
 		folderService: folderService,
+		auditService:  auditService,
 	}
 }
 
@@ -108,12 +110,20 @@ func (h *FolderHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// 记录删除前的文件夹信息用于审计
+	var before interface{}
+	if folder, err := h.folderService.GetByID(uint(id), userID); err == nil {
+		before = gin.H{"name": folder.Name, "parent_id": folder.ParentID}
+	}
+
 	err = h.folderService.Delete(uint(id), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(500, err.Error()))
 		return
 	}
 
+	recordAudit(c, h.auditService, model.AuditActionFolderDelete, model.AuditResourceFolder, uint(id), before, nil)
+
 	c.JSON(http.StatusOK, model.NewSuccessResponse(nil))
 }
 
@@ -0,0 +1,333 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var mindMapContentTypes = map[model.MindMapFormat]string{
+	model.MindMapFormatOPML:     "text/x-opml; charset=utf-8",
+	model.MindMapFormatMarkdown: "text/markdown; charset=utf-8",
+	model.MindMapFormatFreeMind: "application/x-freemind; charset=utf-8",
+}
+
+type MindMapHandler struct {
+	mindMapService service.MindMapService
+}
+
+func NewMindMapHandler(mindMapService service.MindMapService) *MindMapHandler {
+	return &MindMapHandler{
+		mindMapService: mindMapService,
+	}
+}
+
+// Get 获取思维导图的节点树
+func (h *MindMapHandler) Get(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return
+	}
+
+	mindMap, err := h.mindMapService.Get(uint(documentID), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    mindMap,
+	})
+}
+
+// ApplyOperations 批量执行节点操作，全部成功后一次保存
+func (h *MindMapHandler) ApplyOperations(c *gin.Context) {
+	var req model.MindMapOperationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.apply(c, req.Operations)
+}
+
+func (h *MindMapHandler) AddNode(c *gin.Context) {
+	var req model.AddMindMapNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.apply(c, []model.MindMapOperation{{
+		Op:       model.MindMapOperationAdd,
+		ParentID: req.ParentID,
+		Index:    req.Index,
+		Text:     &req.Text,
+		Note:     &req.Note,
+	}})
+}
+
+func (h *MindMapHandler) UpdateNode(c *gin.Context) {
+	var req model.UpdateMindMapNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.apply(c, []model.MindMapOperation{{
+		Op:     model.MindMapOperationUpdate,
+		NodeID: c.Param("nodeId"),
+		Text:   req.Text,
+		Note:   req.Note,
+	}})
+}
+
+func (h *MindMapHandler) MoveNode(c *gin.Context) {
+	var req model.MoveMindMapNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.apply(c, []model.MindMapOperation{{
+		Op:       model.MindMapOperationMove,
+		NodeID:   c.Param("nodeId"),
+		ParentID: req.ParentID,
+		Index:    req.Index,
+	}})
+}
+
+// DeleteNode 删除节点及其所有子节点
+func (h *MindMapHandler) DeleteNode(c *gin.Context) {
+	h.apply(c, []model.MindMapOperation{{
+		Op:     model.MindMapOperationDelete,
+		NodeID: c.Param("nodeId"),
+	}})
+}
+
+// SetCollapsed 返回折叠或展开节点的处理函数，op为collapse或expand
+func (h *MindMapHandler) SetCollapsed(op model.MindMapOperationType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.apply(c, []model.MindMapOperation{{
+			Op:     op,
+			NodeID: c.Param("nodeId"),
+		}})
+	}
+}
+
+func (h *MindMapHandler) apply(c *gin.Context, operations []model.MindMapOperation) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return
+	}
+
+	response, err := h.mindMapService.ApplyOperations(uint(documentID), userID, operations, clientInfo(c))
+	if err != nil {
+		status := conflictStatus(err, http.StatusBadRequest)
+		c.JSON(status, gin.H{
+			"code":    status,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    response,
+	})
+}
+
+// Export 返回导出为指定格式的处理函数
+func (h *MindMapHandler) Export(format model.MindMapFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := middleware.GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "用户未认证",
+			})
+			return
+		}
+
+		documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "无效的文档ID",
+			})
+			return
+		}
+
+		fileName, data, err := h.mindMapService.Export(uint(documentID), userID, format)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    404,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(fileName)))
+		c.Data(http.StatusOK, mindMapContentTypes[format], data)
+	}
+}
+
+// Import 上传OPML、Markdown或FreeMind文件创建思维导图
+func (h *MindMapHandler) Import(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.ImportMindMapRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请选择要导入的文件",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "读取文件失败",
+		})
+		return
+	}
+	defer file.Close()
+
+	document, err := h.mindMapService.Import(userID, fileHeader.Filename, file, &req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "导入成功",
+		"data":    document,
+	})
+}
+
+// SearchNodes 按节点搜索思维导图
+func (h *MindMapHandler) SearchNodes(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.MindMapNodeSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	results, total, err := h.mindMapService.SearchNodes(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "搜索成功",
+		"data": gin.H{
+			"items": results,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
@@ -12,11 +12,13 @@ import (
 
 type RecycleHandler struct {
 	recycleService service.RecycleService
+	auditService   service.AuditService
 }
 
-func NewRecycleHandler(recycleService service.RecycleService) *RecycleHandler {
+func NewRecycleHandler(recycleService service.RecycleService, auditService service.AuditService) *RecycleHandler {
 	return &RecycleHandler{
 		recycleService: recycleService,
+		auditService:   auditService,
 	}
 }
 
@@ -146,6 +148,8 @@ func (h *RecycleHandler) DeletePermanently(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, model.AuditActionRecycleDelete, model.AuditResourceRecycle, uint(id), nil, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "删除成功",
@@ -182,6 +186,8 @@ func (h *RecycleHandler) DeleteBatch(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, model.AuditActionRecycleDelete, model.AuditResourceRecycle, 0, nil, gin.H{"ids": req.IDs})
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "批量删除成功",
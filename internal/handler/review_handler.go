@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewHandler struct {
+	reviewService service.ReviewService
+}
+
+func NewReviewHandler(reviewService service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+	}
+}
+
+// Submit 提交文档审核
+func (h *ReviewHandler) Submit(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c, "无效的文档ID")
+	if !ok {
+		return
+	}
+
+	var req model.SubmitReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	review, err := h.reviewService.Submit(documentID, userID, &req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "提交成功",
+		"data":    review,
+	})
+}
+
+// Withdraw 撤回文档等待中的审核
+func (h *ReviewHandler) Withdraw(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c, "无效的文档ID")
+	if !ok {
+		return
+	}
+
+	if err := h.reviewService.Withdraw(documentID, userID, clientInfo(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "撤回成功",
+	})
+}
+
+// ListByDocument 获取文档的审核记录
+func (h *ReviewHandler) ListByDocument(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c, "无效的文档ID")
+	if !ok {
+		return
+	}
+
+	reviews, err := h.reviewService.ListByDocument(documentID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    reviews,
+	})
+}
+
+// Transition 返回直接修改文档状态的处理函数，action为publish、unpublish、archive或unarchive
+func (h *ReviewHandler) Transition(action model.DocumentAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, documentID, ok := h.parseRequest(c, "无效的文档ID")
+		if !ok {
+			return
+		}
+
+		if err := h.reviewService.Transition(documentID, userID, action, clientInfo(c)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "操作成功",
+		})
+	}
+}
+
+// Schedule 设置审核通过的文档的定时发布时间
+func (h *ReviewHandler) Schedule(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c, "无效的文档ID")
+	if !ok {
+		return
+	}
+
+	var req model.SchedulePublishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := h.reviewService.Schedule(documentID, userID, &req.PublishAt, clientInfo(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "设置成功",
+	})
+}
+
+// CancelSchedule 取消定时发布，文档保持审核通过状态
+func (h *ReviewHandler) CancelSchedule(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c, "无效的文档ID")
+	if !ok {
+		return
+	}
+
+	if err := h.reviewService.Schedule(documentID, userID, nil, clientInfo(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已取消定时发布",
+	})
+}
+
+// ListAssigned 获取分配给我的审核
+func (h *ReviewHandler) ListAssigned(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.ReviewListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	reviews, total, err := h.reviewService.ListAssigned(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取审核列表失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": reviews,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+// GetByID 获取审核详情，审核人通过该接口阅读待审核的文档
+func (h *ReviewHandler) GetByID(c *gin.Context) {
+	userID, reviewID, ok := h.parseRequest(c, "无效的审核ID")
+	if !ok {
+		return
+	}
+
+	review, err := h.reviewService.GetByID(reviewID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    review,
+	})
+}
+
+func (h *ReviewHandler) Approve(c *gin.Context) {
+	h.decide(c, h.reviewService.Approve)
+}
+
+// Reject 驳回审核，必须填写审核意见
+func (h *ReviewHandler) Reject(c *gin.Context) {
+	h.decide(c, h.reviewService.Reject)
+}
+
+func (h *ReviewHandler) decide(c *gin.Context, decide func(id, userID uint, req *model.ReviewDecisionRequest, client *model.ClientInfo) (*model.ReviewResponse, error)) {
+	userID, reviewID, ok := h.parseRequest(c, "无效的审核ID")
+	if !ok {
+		return
+	}
+
+	// 通过时可以不提交请求体
+	var req model.ReviewDecisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "请求参数错误",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	review, err := decide(reviewID, userID, &req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "审核成功",
+		"data":    review,
+	})
+}
+
+// parseRequest 读取当前用户和路径中的ID，失败时已写入响应
+func (h *ReviewHandler) parseRequest(c *gin.Context, invalidMessage string) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": invalidMessage,
+		})
+		return 0, 0, false
+	}
+	return userID, uint(id), true
+}
@@ -10,12 +10,14 @@ import (
 )
 
 type SearchHandler struct {
-	searchService service.SearchService
+	searchService         service.SearchService
+	semanticSearchService service.SemanticSearchService
 }
 
-func NewSearchHandler(searchService service.SearchService) *SearchHandler {
+func NewSearchHandler(searchService service.SearchService, semanticSearchService service.SemanticSearchService) *SearchHandler {
 	return &SearchHandler{
-		searchService: searchService,
+		searchService:         searchService,
+		semanticSearchService: semanticSearchService,
 	}
 }
 
@@ -62,3 +64,31 @@ func (h *SearchHandler) SearchAll(c *gin.Context) {
 
 	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
 }
+
+// SemanticSearch 按语义搜索文档，结果按相似度从高到低排列
+func (h *SearchHandler) SemanticSearch(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.NewErrorResponse(401, "用户未认证"))
+		return
+	}
+
+	if !h.semanticSearchService.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, model.NewErrorResponse(503, "语义搜索未启用"))
+		return
+	}
+
+	var req model.SemanticSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewErrorResponse(400, "请求参数错误"))
+		return
+	}
+
+	results, err := h.semanticSearchService.Search(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(500, "搜索失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(results))
+}
@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SiteHandler struct {
+	siteService service.SiteService
+	baseURL     string
+}
+
+// NewSiteHandler baseURL为站点的外部访问地址，为空时按请求的地址生成订阅源和站点地图中的链接
+func NewSiteHandler(siteService service.SiteService, baseURL string) *SiteHandler {
+	return &SiteHandler{
+		siteService: siteService,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Create 将文件夹发布为公开站点
+func (h *SiteHandler) Create(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.CreateSiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	site, err := h.siteService.Create(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "发布成功",
+		"data":    site,
+	})
+}
+
+func (h *SiteHandler) List(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	sites, err := h.siteService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取站点列表失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    sites,
+	})
+}
+
+func (h *SiteHandler) Update(c *gin.Context) {
+	userID, siteID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateSiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	site, err := h.siteService.Update(siteID, userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    site,
+	})
+}
+
+// Delete 取消发布站点，文档和文件夹不受影响
+func (h *SiteHandler) Delete(c *gin.Context) {
+	userID, siteID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.siteService.Delete(siteID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已取消发布",
+	})
+}
+
+// ListPages 获取站点中当前公开的文档及地址
+func (h *SiteHandler) ListPages(c *gin.Context) {
+	userID, siteID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	pages, err := h.siteService.ListPages(siteID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    pages,
+	})
+}
+
+// Home 站点首页，展示导航和最近更新的文档
+func (h *SiteHandler) Home(c *gin.Context) {
+	view, err := h.siteService.GetSite(c.Param("slug"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	recent := view.Pages
+	if len(recent) > model.SiteRecentLimit {
+		recent = recent[:model.SiteRecentLimit]
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", model.SiteCacheMaxAge))
+	c.HTML(http.StatusOK, "site_index.html", gin.H{
+		"title":  view.Site.Title,
+		"view":   view,
+		"recent": recent,
+	})
+}
+
+// Page 站点中的文档页面
+func (h *SiteHandler) Page(c *gin.Context) {
+	view, err := h.siteService.GetPage(c.Param("slug"), c.Param("page"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", model.SiteCacheMaxAge))
+	c.HTML(http.StatusOK, "site_page.html", gin.H{
+		"title":   view.Page.Title + " - " + view.Site.Title,
+		"view":    view,
+		"content": template.HTML(view.Content),
+	})
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+// RSS 站点最近更新文档的RSS 2.0订阅源
+func (h *SiteHandler) RSS(c *gin.Context) {
+	view, entries, err := h.siteService.Feed(c.Param("slug"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	channel := rssChannel{
+		Title:       view.Site.Title,
+		Link:        h.absoluteURL(c, view.URL),
+		Description: view.Site.Description,
+	}
+	if len(entries) > 0 {
+		channel.LastBuildDate = entries[0].UpdatedAt.Format(time.RFC1123Z)
+	}
+	for _, entry := range entries {
+		link := h.absoluteURL(c, entry.URL)
+		channel.Items = append(channel.Items, rssItem{
+			Title:       entry.Title,
+			Link:        link,
+			GUID:        link,
+			PubDate:     entry.UpdatedAt.Format(time.RFC1123Z),
+			Description: entry.Summary,
+		})
+	}
+
+	h.renderXML(c, "application/rss+xml; charset=utf-8", rssFeed{Version: "2.0", Channel: channel})
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string   `xml:"title"`
+	ID        string   `xml:"id"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+	Summary   string   `xml:"summary"`
+}
+
+// Atom 站点最近更新文档的Atom订阅源
+func (h *SiteHandler) Atom(c *gin.Context) {
+	view, entries, err := h.siteService.Feed(c.Param("slug"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	siteURL := h.absoluteURL(c, view.URL)
+	feed := atomFeed{
+		Title: view.Site.Title,
+		ID:    siteURL,
+		Links: []atomLink{
+			{Href: siteURL},
+			{Href: h.absoluteURL(c, c.Request.URL.Path), Rel: "self"},
+		},
+		Updated: view.Site.UpdatedAt.Format(time.RFC3339),
+		Author:  atomAuthor{Name: view.Author},
+	}
+	if len(entries) > 0 {
+		feed.Updated = entries[0].UpdatedAt.Format(time.RFC3339)
+	}
+	for _, entry := range entries {
+		link := h.absoluteURL(c, entry.URL)
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:     entry.Title,
+			ID:        link,
+			Link:      atomLink{Href: link},
+			Published: entry.CreatedAt.Format(time.RFC3339),
+			Updated:   entry.UpdatedAt.Format(time.RFC3339),
+			Summary:   entry.Summary,
+		})
+	}
+
+	h.renderXML(c, "application/atom+xml; charset=utf-8", feed)
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap 站点首页和全部公开文档的站点地图
+func (h *SiteHandler) Sitemap(c *gin.Context) {
+	view, err := h.siteService.GetSite(c.Param("slug"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	urlSet := sitemapURLSet{URLs: []sitemapURL{{Loc: h.absoluteURL(c, view.URL)}}}
+	for _, page := range view.Pages {
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:     h.absoluteURL(c, page.URL),
+			LastMod: page.UpdatedAt.Format("2006-01-02"),
+		})
+	}
+
+	h.renderXML(c, "application/xml; charset=utf-8", urlSet)
+}
+
+func (h *SiteHandler) renderXML(c *gin.Context, contentType string, v interface{}) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "生成失败")
+		return
+	}
+	c.Header("Cache-Control", h.feedCacheControl())
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), data...))
+}
+
+// renderError 公开页面的错误以HTML页面返回
+func (h *SiteHandler) renderError(c *gin.Context, err error) {
+	c.HTML(http.StatusNotFound, "site_error.html", gin.H{
+		"title":   err.Error(),
+		"message": err.Error(),
+	})
+}
+
+// feedCacheControl 订阅源和站点地图中的绝对地址在未配置站点地址时取自请求头，
+// 此时不允许共享缓存，避免伪造Host的请求生成的地址被缓存后返回给其他访问者
+func (h *SiteHandler) feedCacheControl() string {
+	if h.baseURL == "" {
+		return fmt.Sprintf("private, max-age=%d", model.SiteCacheMaxAge)
+	}
+	return fmt.Sprintf("public, max-age=%d", model.SiteCacheMaxAge)
+}
+
+// absoluteURL 生成绝对地址，未配置站点地址时使用请求的协议和域名
+func (h *SiteHandler) absoluteURL(c *gin.Context, path string) string {
+	if h.baseURL != "" {
+		return h.baseURL + path
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
+
+// parseRequest 读取当前用户和路径中的站点ID，失败时已写入响应
+func (h *SiteHandler) parseRequest(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, 0, false
+	}
+
+	siteID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的站点ID",
+		})
+		return 0, 0, false
+	}
+	return userID, uint(siteID), true
+}
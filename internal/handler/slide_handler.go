@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const pptxContentType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+type SlideHandler struct {
+	slideService service.SlideService
+}
+
+func NewSlideHandler(slideService service.SlideService) *SlideHandler {
+	return &SlideHandler{
+		slideService: slideService,
+	}
+}
+
+// Get 获取演示文稿的全部幻灯片
+func (h *SlideHandler) Get(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	presentation, err := h.slideService.Get(documentID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    presentation,
+	})
+}
+
+func (h *SlideHandler) AddSlide(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req model.AddSlideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.slideService.AddSlide(documentID, userID, &req, clientInfo(c))
+	h.respond(c, response, err)
+}
+
+func (h *SlideHandler) UpdateSlide(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var slide model.Slide
+	if err := c.ShouldBindJSON(&slide); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.slideService.UpdateSlide(documentID, userID, c.Param("slideId"), &slide, clientInfo(c))
+	h.respond(c, response, err)
+}
+
+// UpdateNotes 修改演讲者备注，备注为空时清除
+func (h *SlideHandler) UpdateNotes(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateSlideNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.slideService.UpdateNotes(documentID, userID, c.Param("slideId"), req.Notes, clientInfo(c))
+	h.respond(c, response, err)
+}
+
+func (h *SlideHandler) DeleteSlide(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.slideService.DeleteSlide(documentID, userID, c.Param("slideId"), clientInfo(c))
+	h.respond(c, response, err)
+}
+
+// DuplicateSlide 复制幻灯片，副本位于原幻灯片之后
+func (h *SlideHandler) DuplicateSlide(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.slideService.DuplicateSlide(documentID, userID, c.Param("slideId"), clientInfo(c))
+	h.respond(c, response, err)
+}
+
+// Reorder 调整幻灯片顺序
+func (h *SlideHandler) Reorder(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req model.ReorderSlidesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.slideService.Reorder(documentID, userID, req.SlideIDs, clientInfo(c))
+	h.respond(c, response, err)
+}
+
+// ExportPPTX 将演示文稿导出为pptx文件
+func (h *SlideHandler) ExportPPTX(c *gin.Context) {
+	userID, documentID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	// 先写入缓冲区，出错时仍可以返回JSON
+	var buffer bytes.Buffer
+	fileName, err := h.slideService.ExportPPTX(documentID, userID, &buffer)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(fileName)))
+	c.Data(http.StatusOK, pptxContentType, buffer.Bytes())
+}
+
+// ImportPPTX 上传pptx文件创建演示文稿
+func (h *SlideHandler) ImportPPTX(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.ImportPPTXRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请选择要导入的文件",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "读取文件失败",
+		})
+		return
+	}
+	defer file.Close()
+
+	document, err := h.slideService.ImportPPTX(userID, fileHeader.Filename, file, &req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "导入成功",
+		"data":    document,
+	})
+}
+
+// parseRequest 读取当前用户和路径中的文档ID，失败时已写入响应
+func (h *SlideHandler) parseRequest(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, 0, false
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的文档ID",
+		})
+		return 0, 0, false
+	}
+	return userID, uint(documentID), true
+}
+
+func (h *SlideHandler) respond(c *gin.Context, response *model.PresentationResponse, err error) {
+	if err != nil {
+		status := conflictStatus(err, http.StatusBadRequest)
+		c.JSON(status, gin.H{
+			"code":    status,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    response,
+	})
+}
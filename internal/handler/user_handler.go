@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 	"wz-wenzhan-backend/internal/middleware"
 	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/service"
@@ -10,12 +11,14 @@ import (
 )
 
 type UserHandler struct {
-	userService service.UserService
+	userService  service.UserService
+	auditService service.AuditService
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, auditService service.AuditService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:  userService,
+		auditService: auditService,
 	}
 }
 
@@ -64,6 +67,10 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 	token, profile, err := h.userService.Login(&req)
 	if err != nil {
+		entry := newAuditEntry(c, model.AuditActionLoginFailed, model.AuditResourceUser, 0)
+		entry.After = gin.H{"username": req.Username, "reason": err.Error()}
+		_ = h.auditService.Record(entry)
+
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    401,
 			"message": err.Error(),
@@ -71,6 +78,11 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	entry := newAuditEntry(c, model.AuditActionLogin, model.AuditResourceUser, profile.ID)
+	entry.ActorID = profile.ID
+	entry.ActorRole = profile.Role
+	_ = h.auditService.Record(entry)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "登录成功",
@@ -143,3 +155,62 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		"message": "更新成功",
 	})
 }
+
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请选择要上传的头像",
+		})
+		return
+	}
+
+	avatar, err := h.userService.UploadAvatar(userID, fileHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "上传成功",
+		"data":    avatar,
+	})
+}
+
+// GetIdenticon 返回按默认头像标识生成的头像，无需认证
+func (h *UserHandler) GetIdenticon(c *gin.Context) {
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "128"))
+	if size < 16 {
+		size = 16
+	}
+	if size > 512 {
+		size = 512
+	}
+
+	data, err := h.userService.GetIdenticon(c.Param("key"), size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 相同标识和尺寸生成的图片不变
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Data(http.StatusOK, "image/png", data)
+}
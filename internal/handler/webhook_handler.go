@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+	auditService   service.AuditService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService, auditService service.AuditService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		auditService:   auditService,
+	}
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	var req model.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	webhook, err := h.webhookService.Create(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 只记录Webhook配置，不记录签名密钥
+	recordAudit(c, h.auditService, model.AuditActionWebhookCreate, model.AuditResourceWebhook, webhook.ID, nil, webhook.WebhookResponse)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "创建成功，请妥善保存签名密钥，关闭后将无法再次查看",
+		"data":    webhook,
+	})
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
+	webhooks, err := h.webhookService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取Webhook失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    webhooks,
+	})
+}
+
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	userID, id, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    webhook,
+	})
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	userID, id, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	before, err := h.webhookService.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	webhook, err := h.webhookService.Update(id, userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditService, model.AuditActionWebhookUpdate, model.AuditResourceWebhook, id, before, webhook)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    webhook,
+	})
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	userID, id, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	err := h.webhookService.Delete(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditService, model.AuditActionWebhookDelete, model.AuditResourceWebhook, id, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+	})
+}
+
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	userID, id, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.RotateSecret(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditService, model.AuditActionWebhookRotate, model.AuditResourceWebhook, id, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "密钥已更新，请妥善保存，关闭后将无法再次查看",
+		"data":    webhook,
+	})
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, id, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	var req model.WebhookDeliveryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(id, userID, &req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": deliveries,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+// Test 立即发送一次ping事件，返回本次投递结果
+func (h *WebhookHandler) Test(c *gin.Context) {
+	userID, id, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.TestDelivery(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	message := "测试投递成功"
+	if delivery.Status != model.WebhookDeliverySuccess {
+		message = "测试投递失败"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": message,
+		"data":    delivery,
+	})
+}
+
+// parseWebhookParams 获取当前用户和路径中的Webhook ID
+func parseWebhookParams(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的Webhook ID",
+		})
+		return 0, 0, false
+	}
+
+	return userID, uint(id), true
+}
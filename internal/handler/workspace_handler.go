@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"wz-wenzhan-backend/internal/middleware"
+	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -71,3 +72,78 @@ func (h *WorkspaceHandler) GetStats(c *gin.Context) {
 		"data":    stats,
 	})
 }
+
+// GetRecentlyOpened 获取最近打开的文档
+func (h *WorkspaceHandler) GetRecentlyOpened(c *gin.Context) {
+	userID, limit, ok := h.parseAccessRequest(c)
+	if !ok {
+		return
+	}
+
+	documents, err := h.workspaceService.GetRecentlyOpened(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取最近打开的文档失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    documents,
+	})
+}
+
+// GetFrequentDocuments 获取常用文档
+func (h *WorkspaceHandler) GetFrequentDocuments(c *gin.Context) {
+	userID, limit, ok := h.parseAccessRequest(c)
+	if !ok {
+		return
+	}
+
+	documents, err := h.workspaceService.GetFrequentDocuments(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取常用文档失败",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    documents,
+	})
+}
+
+// parseAccessRequest 解析当前用户和返回条数，默认返回20条，失败时已写入响应
+func (h *WorkspaceHandler) parseAccessRequest(c *gin.Context) (uint, int, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return 0, 0, false
+	}
+
+	var req model.AccessListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误",
+			"error":   err.Error(),
+		})
+		return 0, 0, false
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	return userID, req.Limit, true
+}
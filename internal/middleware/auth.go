@@ -3,12 +3,23 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRequired() gin.HandlerFunc {
+// SessionValidator 校验登录JWT对应用户的当前状态（是否禁用、令牌是否已失效）
+type SessionValidator interface {
+	ValidateSession(userID uint, tokenVersion int) (*model.User, error)
+}
+
+// APITokenAuthenticator 个人访问令牌校验
+type APITokenAuthenticator interface {
+	Authenticate(rawToken, ipAddress string) (*model.APIToken, error)
+}
+
+func AuthRequired(sessions SessionValidator, tokenAuth APITokenAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -31,6 +42,24 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		// 个人访问令牌
+		if strings.HasPrefix(parts[1], model.APITokenPrefix) {
+			token, err := tokenAuth.Authenticate(parts[1], c.ClientIP())
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"code":    401,
+					"message": "Token无效",
+				})
+				c.Abort()
+				return
+			}
+
+			c.Set("user_id", token.UserID)
+			c.Set("api_token", token)
+			c.Next()
+			return
+		}
+
 		claims, err := utils.ParseJWT(parts[1])
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -41,8 +70,78 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
-		// 将用户ID存储到上下文中
-		c.Set("user_id", claims.UserID)
+		user, err := sessions.ValidateSession(claims.UserID, claims.TokenVersion)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		// 将用户ID和角色存储到上下文中
+		c.Set("user_id", user.ID)
+		c.Set("user_role", user.Role)
+		c.Next()
+	}
+}
+
+// RequirePermission 校验当前用户角色是否拥有指定权限，需在AuthRequired之后使用
+func RequirePermission(permission model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := GetUserRole(c)
+		if !model.RoleHasPermission(role, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "权限不足",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope 校验个人访问令牌的权限范围，GET/HEAD请求需要读权限，其余需要写权限。
+// 使用登录JWT认证的请求不受限制。
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := GetAPIToken(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		action := model.ScopeActionWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			action = model.ScopeActionRead
+		}
+
+		if !token.HasScope(resource, action) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "访问令牌缺少权限: " + resource + ":" + action,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SessionRequired 仅允许登录JWT访问，用于令牌管理等敏感操作
+func SessionRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIToken(c); ok {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "该操作不支持使用访问令牌",
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -55,3 +154,23 @@ func GetUserID(c *gin.Context) (uint, bool) {
 	id, ok := userID.(uint)
 	return id, ok
 }
+
+// GetUserRole 获取当前用户角色，使用访问令牌认证的请求没有角色
+func GetUserRole(c *gin.Context) (string, bool) {
+	role, exists := c.Get("user_role")
+	if !exists {
+		return "", false
+	}
+	r, ok := role.(string)
+	return r, ok
+}
+
+// GetAPIToken 获取当前请求使用的个人访问令牌
+func GetAPIToken(c *gin.Context) (*model.APIToken, bool) {
+	token, exists := c.Get("api_token")
+	if !exists {
+		return nil, false
+	}
+	t, ok := token.(*model.APIToken)
+	return t, ok
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,7 +26,7 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 		bodySize := c.Writer.Size()
 
 		if raw != "" {
-			path = path + "?" + raw
+			path = path + "?" + redactQuery(raw)
 		}
 
 		logger.Info("HTTP Request",
@@ -39,6 +40,28 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 	})
 }
 
+// redactedQueryParams 写入日志前隐藏的查询参数
+var redactedQueryParams = []string{"access_token", "ticket"}
+
+// redactQuery 隐藏查询参数中的令牌和票据，避免写入日志
+func redactQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	redacted := false
+	for _, param := range redactedQueryParams {
+		if values.Has(param) {
+			values.Set(param, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return raw
+	}
+	return values.Encode()
+}
+
 func Recovery(logger *zap.Logger) gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
 		logger.Error("Panic recovered",
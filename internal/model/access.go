@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const (
+	// AccessFlushBatchSize 待写入的访问记录达到该数量时立即写入，不等待定时任务
+	AccessFlushBatchSize = 500
+	// DashboardAccessLimit 工作台展示的最近打开和常用文档数
+	DashboardAccessLimit = 5
+	// FrecencyHalfLife 常用文档按打开次数和最近打开时间综合排序，每次打开的权重每隔半衰期减半
+	FrecencyHalfLife = 14 * 24 * time.Hour
+)
+
+// DocumentAccess 用户打开文档的记录，每个用户每篇文档一条，记录最近打开时间和累计打开次数
+type DocumentAccess struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_document;index:idx_user_last_opened"`
+	DocumentID   uint      `json:"document_id" gorm:"not null;uniqueIndex:idx_user_document;index:idx_document_id"`
+	OpenCount    int       `json:"open_count" gorm:"not null;default:0"`
+	Score        float64   `json:"-" gorm:"not null;default:0"` // 按半衰期衰减到LastOpenedAt时的打开次数，写入时更新
+	LastOpenedAt time.Time `json:"last_opened_at" gorm:"not null;index:idx_user_last_opened"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// 请求和响应结构
+
+type AccessListRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// AccessedDocumentResponse 最近打开或常用的文档
+type AccessedDocumentResponse struct {
+	DocumentResponse
+	OpenCount    int       `json:"open_count"`
+	LastOpenedAt time.Time `json:"last_opened_at"`
+}
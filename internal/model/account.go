@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+// DataExportStatus 数据导出任务状态
+type DataExportStatus string
+
+const (
+	DataExportStatusPending    DataExportStatus = "pending"    // 等待处理
+	DataExportStatusProcessing DataExportStatus = "processing" // 处理中
+	DataExportStatusCompleted  DataExportStatus = "completed"  // 已完成
+	DataExportStatusFailed     DataExportStatus = "failed"     // 失败
+	DataExportStatusExpired    DataExportStatus = "expired"    // 已过期，文件已清理
+)
+
+// AccountDeletionStatus 注销申请状态
+type AccountDeletionStatus string
+
+const (
+	AccountDeletionStatusPending   AccountDeletionStatus = "pending"   // 冷静期中
+	AccountDeletionStatusCancelled AccountDeletionStatus = "cancelled" // 已撤销
+	AccountDeletionStatusCompleted AccountDeletionStatus = "completed" // 已执行
+)
+
+const (
+	// DeletedUserName 注销后用户的昵称，也用于替换其他记录中出现的用户名和昵称
+	DeletedUserName = "已注销用户"
+	// DeletedCommentContent 注销用户发表的评论的内容
+	DeletedCommentContent = "该评论已随账号注销删除"
+)
+
+// 注销后对用户内容的处理方式
+const (
+	ContentActionPurge    = "purge"    // 彻底删除
+	ContentActionTransfer = "transfer" // 转移给其他用户
+)
+
+const (
+	// AccountDeletionGracePeriod 注销冷静期，期间可以撤销
+	AccountDeletionGracePeriod = 14 * 24 * time.Hour
+	// DataExportRetention 导出文件保留时间
+	DataExportRetention = 7 * 24 * time.Hour
+)
+
+// DataExport 个人数据导出任务
+type DataExport struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	UserID      uint             `json:"user_id" gorm:"not null;index"`
+	Status      DataExportStatus `json:"status" gorm:"size:20;not null;index"`
+	FilePath    string           `json:"-" gorm:"size:500"`
+	FileSize    int64            `json:"file_size" gorm:"default:0"`
+	Error       string           `json:"error,omitempty" gorm:"size:500"`
+	CompletedAt *time.Time       `json:"completed_at"`
+	ExpiresAt   *time.Time       `json:"expires_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// AccountDeletion 账号注销申请，冷静期结束后由定时任务执行
+type AccountDeletion struct {
+	ID               uint                  `json:"id" gorm:"primaryKey"`
+	UserID           uint                  `json:"user_id" gorm:"not null;index"`
+	Status           AccountDeletionStatus `json:"status" gorm:"size:20;not null;index"`
+	ContentAction    string                `json:"content_action" gorm:"size:20;not null"`
+	TransferToUserID *uint                 `json:"transfer_to_user_id"`
+	Reason           string                `json:"reason" gorm:"size:500"`
+	ScheduledAt      time.Time             `json:"scheduled_at" gorm:"index"`
+	CompletedAt      *time.Time            `json:"completed_at"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+}
+
+// 请求和响应结构
+type RequestAccountDeletionRequest struct {
+	Password           string `json:"password" binding:"required"`
+	ContentAction      string `json:"content_action" binding:"required,oneof=purge transfer"`
+	TransferToUsername string `json:"transfer_to_username"` // content_action为transfer时必填
+	Reason             string `json:"reason" binding:"max=500"`
+}
+
+// ExportDocument 导出文件中的文档元数据，正文单独保存为Markdown
+type ExportDocument struct {
+	ID        uint           `json:"id"`
+	Title     string         `json:"title"`
+	Type      DocumentType   `json:"type"`
+	Status    DocumentStatus `json:"status"`
+	FolderID  *uint          `json:"folder_id"`
+	Tags      string         `json:"tags"`
+	Path      string         `json:"path"` // 压缩包内的正文文件路径
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+
+	Attachments []string `json:"attachments,omitempty"` // 压缩包内的附件文件路径
+}
@@ -9,23 +9,39 @@ import (
 type ActivityType string
 
 const (
-	ActivityTypeCreate ActivityType = "create" // 创建
-	ActivityTypeUpdate ActivityType = "update" // 更新
-	ActivityTypeDelete ActivityType = "delete" // 删除
-	ActivityTypeView   ActivityType = "view"   // 查看
-	ActivityTypeShare  ActivityType = "share"  // 分享
-	ActivityTypeCopy   ActivityType = "copy"   // 复制
-	ActivityTypeMove   ActivityType = "move"   // 移动
+	ActivityTypeCreate  ActivityType = "create"  // 创建
+	ActivityTypeUpdate  ActivityType = "update"  // 更新
+	ActivityTypeDelete  ActivityType = "delete"  // 删除
+	ActivityTypeView    ActivityType = "view"    // 查看
+	ActivityTypeShare   ActivityType = "share"   // 分享
+	ActivityTypeCopy    ActivityType = "copy"    // 复制
+	ActivityTypeMove    ActivityType = "move"    // 移动
+	ActivityTypeComment ActivityType = "comment" // 评论
+	ActivityTypeRestore ActivityType = "restore" // 恢复
+	ActivityTypeReview  ActivityType = "review"  // 审核
 )
 
 // ResourceType 资源类型
 type ResourceType string
 
 const (
-	ResourceTypeDocument ResourceType = "document" // 文档
-	ResourceTypeFolder   ResourceType = "folder"   // 文件夹
+	ResourceTypeDocument    ResourceType = "document"     // 文档
+	ResourceTypeFolder      ResourceType = "folder"       // 文件夹
+	ResourceTypeRecycleItem ResourceType = "recycle_item" // 回收站项目
 )
 
+// ViewActivityDedupWindow 同一用户在该时间内重复查看同一资源只记录一次
+const ViewActivityDedupWindow = 30 * time.Minute
+
+// ActivitySessionGap 同一用户对同一资源的相邻两次编辑间隔不超过该时间时合并为一次编辑会话
+const ActivitySessionGap = 30 * time.Minute
+
+// ClientInfo 发起请求的客户端信息，随事件传递给足迹记录
+type ClientInfo struct {
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
 type Activity struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
 	UserID       uint           `json:"user_id" gorm:"not null;index"`
@@ -60,14 +76,42 @@ type ActivityListRequest struct {
 	ResourceType ResourceType `form:"resource_type"`
 	StartDate    string       `form:"start_date"` // YYYY-MM-DD
 	EndDate      string       `form:"end_date"`   // YYYY-MM-DD
+	ActorID      uint         `form:"actor_id"`   // 按操作者过滤，用于查看文档历史中协作者的操作
+	Group        bool         `form:"group"`      // 将连续编辑合并为编辑会话
+}
+
+type ActivityExportRequest struct {
+	ActivityListRequest
+	Format string `form:"format" binding:"omitempty,oneof=csv ics"` // 默认csv
 }
 
 type ActivityResponse struct {
-	ID           uint         `json:"id"`
-	Type         ActivityType `json:"type"`
-	ResourceType ResourceType `json:"resource_type"`
-	ResourceID   uint         `json:"resource_id"`
-	ResourceName string       `json:"resource_name"`
-	Description  string       `json:"description"`
-	CreatedAt    time.Time    `json:"created_at"`
+	ID           uint             `json:"id"`
+	Type         ActivityType     `json:"type"`
+	ResourceType ResourceType     `json:"resource_type"`
+	ResourceID   uint             `json:"resource_id"`
+	ResourceName string           `json:"resource_name"`
+	Description  string           `json:"description"`
+	UserID       uint             `json:"user_id"`
+	Username     string           `json:"username,omitempty"` // 仅文档历史返回
+	CreatedAt    time.Time        `json:"created_at"`
+	Session      *ActivitySession `json:"session,omitempty"` // 合并后的编辑会话，ID和CreatedAt取会话中最后一次编辑
+}
+
+// ActivityListResponse 足迹分页结果。合并编辑会话时只合并到当前页为止，不统计总数，
+// Total为空，通过HasMore判断是否有下一页
+type ActivityListResponse struct {
+	Items   []ActivityResponse `json:"items"`
+	Total   *int64             `json:"total"`
+	HasMore bool               `json:"has_more"`
+	Page    int                `json:"page"`
+	Size    int                `json:"size"`
+}
+
+// ActivitySession 连续编辑合并成的会话
+type ActivitySession struct {
+	Count     int       `json:"count"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	FirstID   uint      `json:"first_id"`
 } 
\ No newline at end of file
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// 管理后台请求和响应结构
+type AdminUserListRequest struct {
+	Page     int    `form:"page" binding:"min=1"`
+	PageSize int    `form:"page_size" binding:"min=1,max=100"`
+	Keyword  string `form:"keyword"` // 匹配用户名、邮箱、昵称
+	Role     string `form:"role"`
+	Status   *int   `form:"status"`
+}
+
+type AdminUserResponse struct {
+	ID        uint       `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Nickname  string     `json:"nickname"`
+	Avatar    string     `json:"avatar"`
+	Status    int        `json:"status"`
+	Role      string     `json:"role"`
+	LastLogin *time.Time `json:"last_login"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type UpdateUserStatusRequest struct {
+	Status *int `json:"status" binding:"required,oneof=0 1"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin auditor"`
+}
+
+type ResetPasswordRequest struct {
+	NewPassword string `json:"new_password" binding:"omitempty,min=6"` // 为空时自动生成临时密码
+}
+
+type ResetPasswordResponse struct {
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+}
+
+// SystemStats 系统统计数据
+type SystemStats struct {
+	TotalUsers      int64            `json:"total_users"`
+	ActiveUsers     int64            `json:"active_users"`
+	DisabledUsers   int64            `json:"disabled_users"`
+	UsersByRole     map[string]int64 `json:"users_by_role"`
+	NewUsersToday   int64            `json:"new_users_today"`
+	NewUsersWeek    int64            `json:"new_users_week"`
+	ActiveUsersWeek int64            `json:"active_users_week"` // 近7天有活动的用户
+	TotalDocuments  int64            `json:"total_documents"`
+	TotalFolders    int64            `json:"total_folders"`
+	TotalActivities int64            `json:"total_activities"`
+	StorageUsed     int64            `json:"storage_used"` // 字节
+	DocumentsByType map[string]int64 `json:"documents_by_type"`
+}
@@ -0,0 +1,240 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 对话消息角色
+const (
+	LLMRoleSystem    = "system"
+	LLMRoleUser      = "user"
+	LLMRoleAssistant = "assistant"
+)
+
+// LLMMessage 发送给模型的对话消息
+type LLMMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// LLMRequest 一次生成请求
+type LLMRequest struct {
+	Messages    []LLMMessage
+	MaxTokens   int
+	Temperature float64
+}
+
+// LLMUsage 模型返回的token用量，服务商未返回时为0
+type LLMUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// AIFeature AI功能，用于用量统计
+type AIFeature string
+
+const (
+	AIFeatureDraft     AIFeature = "draft"     // 起草
+	AIFeatureSummarize AIFeature = "summarize" // 摘要
+	AIFeatureRewrite   AIFeature = "rewrite"   // 改写润色
+	AIFeatureTranslate AIFeature = "translate" // 翻译
+	AIFeatureContinue  AIFeature = "continue"  // 续写
+	AIFeatureOutline   AIFeature = "outline"   // 提取大纲
+)
+
+// AIUsageStatus 调用结果
+type AIUsageStatus string
+
+const (
+	AIUsageSuccess AIUsageStatus = "success"
+	AIUsageFailed  AIUsageStatus = "failed"
+)
+
+// AIUsage 每次调用模型的用量记录，用于统计和每日额度
+type AIUsage struct {
+	ID               uint          `json:"id" gorm:"primaryKey"`
+	UserID           uint          `json:"user_id" gorm:"not null;index:idx_user_created"`
+	Feature          AIFeature     `json:"feature" gorm:"size:20;not null"`
+	Provider         string        `json:"provider" gorm:"size:50"`
+	Model            string        `json:"model" gorm:"size:100"`
+	Status           AIUsageStatus `json:"status" gorm:"size:20;not null"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	DurationMs       int64         `json:"duration_ms"`
+	CreatedAt        time.Time     `json:"created_at" gorm:"index:idx_user_created"`
+}
+
+// AIDraftStatus 起草状态
+type AIDraftStatus string
+
+const (
+	AIDraftStatusGenerating AIDraftStatus = "generating" // 生成中
+	AIDraftStatusCompleted  AIDraftStatus = "completed"  // 已生成并保存为文档
+	AIDraftStatusFailed     AIDraftStatus = "failed"     // 生成失败
+	AIDraftStatusCancelled  AIDraftStatus = "cancelled"  // 客户端断开，未保存
+)
+
+// AIDraft AI起草记录，保存生成文档时使用的提示词等元数据
+type AIDraft struct {
+	ID                uint          `json:"id" gorm:"primaryKey"`
+	UserID            uint          `json:"user_id" gorm:"not null;index"`
+	DocumentID        *uint         `json:"document_id" gorm:"index"` // 生成成功后保存的文档
+	Prompt            string        `json:"prompt" gorm:"type:text;not null"`
+	Outline           string        `json:"outline" gorm:"type:text"`            // 大纲，JSON数组
+	SourceDocumentIDs string        `json:"source_document_ids" gorm:"size:255"` // 参考文档，逗号分隔
+	Provider          string        `json:"provider" gorm:"size:50"`
+	Model             string        `json:"model" gorm:"size:100"`
+	Status            AIDraftStatus `json:"status" gorm:"size:20;not null"`
+	Error             string        `json:"error" gorm:"size:1000"`
+	PromptTokens      int           `json:"prompt_tokens"`
+	CompletionTokens  int           `json:"completion_tokens"`
+	DurationMs        int64         `json:"duration_ms"`
+	CreatedAt         time.Time     `json:"created_at" gorm:"index"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// OutlineList 返回大纲条目
+func (d *AIDraft) OutlineList() []string {
+	var outline []string
+	if d.Outline != "" {
+		json.Unmarshal([]byte(d.Outline), &outline)
+	}
+	return outline
+}
+
+// SourceDocumentIDList 返回参考文档ID
+func (d *AIDraft) SourceDocumentIDList() []uint {
+	var ids []uint
+	for _, part := range strings.Split(d.SourceDocumentIDs, ",") {
+		if id, err := strconv.ParseUint(part, 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// 请求和响应结构
+type CreateAIDraftRequest struct {
+	Prompt            string   `json:"prompt" binding:"required,max=2000"`
+	Outline           []string `json:"outline" binding:"max=30,dive,max=200"`
+	SourceDocumentIDs []uint   `json:"source_document_ids" binding:"max=5"` // 作为参考资料的文档，需为本人文档
+	Title             string   `json:"title" binding:"max=255"`             // 默认使用生成内容的一级标题
+	FolderID          *uint    `json:"folder_id"`
+}
+
+type AIDraftListRequest struct {
+	Page       int           `form:"page" binding:"min=1"`
+	PageSize   int           `form:"page_size" binding:"min=1,max=100"`
+	Status     AIDraftStatus `form:"status"`
+	DocumentID uint          `form:"document_id"` // 查询文档的起草记录
+}
+
+type AIDraftResponse struct {
+	ID                uint          `json:"id"`
+	DocumentID        *uint         `json:"document_id"`
+	Prompt            string        `json:"prompt"`
+	Outline           []string      `json:"outline"`
+	SourceDocumentIDs []uint        `json:"source_document_ids"`
+	Model             string        `json:"model"`
+	Status            AIDraftStatus `json:"status"`
+	Error             string        `json:"error,omitempty"`
+	Usage             LLMUsage      `json:"usage"`
+	DurationMs        int64         `json:"duration_ms"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+// AISuggestionStatus 建议状态
+type AISuggestionStatus string
+
+const (
+	AISuggestionPending   AISuggestionStatus = "pending"   // 待处理
+	AISuggestionApplied   AISuggestionStatus = "applied"   // 已应用为新版本
+	AISuggestionDiscarded AISuggestionStatus = "discarded" // 已放弃
+)
+
+// AIApplyMode 建议的应用方式
+type AIApplyMode string
+
+const (
+	AIApplyReplace      AIApplyMode = "replace"       // 替换选中内容，改写和翻译的默认方式
+	AIApplyInsertBefore AIApplyMode = "insert_before" // 插入到选中内容之前，摘要和大纲的默认方式
+	AIApplyInsertAfter  AIApplyMode = "insert_after"  // 插入到选中内容之后，续写的默认方式
+)
+
+// AISuggestion 对已有文档执行AI辅助操作的结果，确认后才会写入文档
+type AISuggestion struct {
+	ID               uint               `json:"id" gorm:"primaryKey"`
+	UserID           uint               `json:"user_id" gorm:"not null;index"`
+	DocumentID       uint               `json:"document_id" gorm:"not null;index"`
+	Feature          AIFeature          `json:"feature" gorm:"size:20;not null"`
+	RangeStart       int                `json:"range_start"` // 按字符计算的偏移，处理全文时为0到全文长度
+	RangeEnd         int                `json:"range_end"`
+	Instruction      string             `json:"instruction" gorm:"size:500"`
+	TargetLanguage   string             `json:"target_language" gorm:"size:50"`
+	Output           string             `json:"output" gorm:"type:longtext"`
+	BaseHash         string             `json:"-" gorm:"size:64"` // 生成时文档内容的SHA-256，应用时用于检测文档是否已被修改
+	Status           AISuggestionStatus `json:"status" gorm:"size:20;not null"`
+	VersionID        *uint              `json:"version_id"` // 应用后生成的版本
+	Model            string             `json:"model" gorm:"size:100"`
+	PromptTokens     int                `json:"prompt_tokens"`
+	CompletionTokens int                `json:"completion_tokens"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// AITextRange 选中范围，按字符计算的偏移，包含Start不包含End
+type AITextRange struct {
+	Start int `json:"start" binding:"min=0"`
+	End   int `json:"end" binding:"min=0"`
+}
+
+type AIAssistRequest struct {
+	Range          *AITextRange `json:"range"`                            // 为空时处理全文
+	Instruction    string       `json:"instruction" binding:"max=500"`    // 改写风格、续写方向等补充要求
+	TargetLanguage string       `json:"target_language" binding:"max=50"` // 翻译的目标语言，默认英文
+}
+
+type ApplyAISuggestionRequest struct {
+	Mode AIApplyMode `json:"mode" binding:"omitempty,oneof=replace insert_before insert_after"` // 默认按操作类型决定
+}
+
+type AISuggestionListRequest struct {
+	Page     int                `form:"page" binding:"min=1"`
+	PageSize int                `form:"page_size" binding:"min=1,max=100"`
+	Status   AISuggestionStatus `form:"status"`
+}
+
+type AISuggestionResponse struct {
+	ID             uint               `json:"id"`
+	DocumentID     uint               `json:"document_id"`
+	Feature        AIFeature          `json:"feature"`
+	Range          AITextRange        `json:"range"`
+	Instruction    string             `json:"instruction,omitempty"`
+	TargetLanguage string             `json:"target_language,omitempty"`
+	Output         string             `json:"output"`
+	Status         AISuggestionStatus `json:"status"`
+	VersionID      *uint              `json:"version_id"`
+	Model          string             `json:"model"`
+	Usage          LLMUsage           `json:"usage"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// AIFeatureUsage 按功能汇总的用量
+type AIFeatureUsage struct {
+	Feature          AIFeature `json:"feature"`
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+}
+
+// AIUsageSummary 用户的AI用量和限额
+type AIUsageSummary struct {
+	TokensToday       int64            `json:"tokens_today"`
+	DailyTokenLimit   int64            `json:"daily_token_limit"` // 0表示不限制
+	RequestsPerMinute int              `json:"requests_per_minute"`
+	Last30Days        []AIFeatureUsage `json:"last_30_days"`
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APITokenPrefix 个人访问令牌前缀，用于与登录JWT区分
+const APITokenPrefix = "wzp_"
+
+// 令牌权限操作
+const (
+	ScopeActionRead  = "read"  // 只读
+	ScopeActionWrite = "write" // 读写
+)
+
+// 令牌可授权的资源
+const (
+	ScopeResourceDocuments     = "documents"     // 文档
+	ScopeResourceFolders       = "folders"       // 文件夹
+	ScopeResourceFiles         = "files"         // 文件
+	ScopeResourceSearch        = "search"        // 搜索
+	ScopeResourceActivities    = "activities"    // 足迹记录
+	ScopeResourceRecycle       = "recycle"       // 回收站
+	ScopeResourceWorkspace     = "workspace"     // 工作台
+	ScopeResourceProfile       = "profile"       // 个人信息
+	ScopeResourceNotifications = "notifications" // 通知
+	ScopeResourceWebhooks      = "webhooks"      // Webhook
+	ScopeResourceTemplates     = "templates"     // 文档模板
+	ScopeResourceAI            = "ai"            // AI写作
+)
+
+// TokenScopeResources 所有可授权的资源
+var TokenScopeResources = []string{
+	ScopeResourceDocuments,
+	ScopeResourceFolders,
+	ScopeResourceFiles,
+	ScopeResourceSearch,
+	ScopeResourceActivities,
+	ScopeResourceRecycle,
+	ScopeResourceWorkspace,
+	ScopeResourceProfile,
+	ScopeResourceNotifications,
+	ScopeResourceWebhooks,
+	ScopeResourceTemplates,
+	ScopeResourceAI,
+}
+
+// IsValidTokenScope 校验权限范围格式，格式为 资源:操作，如 documents:read
+func IsValidTokenScope(scope string) bool {
+	parts := strings.SplitN(scope, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	if parts[1] != ScopeActionRead && parts[1] != ScopeActionWrite {
+		return false
+	}
+	for _, resource := range TokenScopeResources {
+		if parts[0] == resource {
+			return true
+		}
+	}
+	return false
+}
+
+type APIToken struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	Name       string         `json:"name" gorm:"size:100;not null"`
+	Prefix     string         `json:"prefix" gorm:"size:16;not null"`        // 令牌前几位，用于识别
+	TokenHash  string         `json:"-" gorm:"size:64;not null;uniqueIndex"` // SHA-256摘要
+	Scopes     string         `json:"scopes" gorm:"size:500;not null"`       // 权限范围，逗号分隔
+	ExpiresAt  time.Time      `json:"expires_at" gorm:"not null"`            // 过期时间
+	LastUsedAt *time.Time     `json:"last_used_at"`                          // 最后使用时间
+	LastUsedIP string         `json:"last_used_ip" gorm:"size:45"`           // 最后使用IP
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"` // 撤销即软删除
+
+	// 关联
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// ScopeList 返回权限范围列表
+func (t *APIToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope 判断令牌是否拥有资源的指定操作权限，写权限包含读权限
+func (t *APIToken) HasScope(resource, action string) bool {
+	for _, scope := range t.ScopeList() {
+		if scope == resource+":"+action {
+			return true
+		}
+		if action == ScopeActionRead && scope == resource+":"+ScopeActionWrite {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired 判断令牌是否已过期
+func (t *APIToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// 请求和响应结构
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=365"` // 默认30天
+}
+
+type APITokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"` // 明文令牌，仅在创建时返回一次
+}
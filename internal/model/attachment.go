@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	// AttachmentMaxSize 单个附件的大小上限
+	AttachmentMaxSize = 20 << 20
+	// AttachmentMaxPerDocument 每个文档最多上传的附件数
+	AttachmentMaxPerDocument = 50
+)
+
+// Attachment 文档附件，FilePath为相对上传目录的路径，文档转移给其他用户后文件仍保留在原路径
+type Attachment struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	DocumentID uint      `json:"document_id" gorm:"not null;index"`
+	FileName   string    `json:"file_name" gorm:"size:255;not null"` // 上传时的文件名
+	FilePath   string    `json:"-" gorm:"size:500;not null"`
+	MimeType   string    `json:"mime_type" gorm:"size:100"`
+	Size       int64     `json:"size"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// 请求和响应结构
+
+type AttachmentResponse struct {
+	ID         uint      `json:"id"`
+	DocumentID uint      `json:"document_id"`
+	FileName   string    `json:"file_name"`
+	MimeType   string    `json:"mime_type"`
+	Size       int64     `json:"size"`
+	URL        string    `json:"url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
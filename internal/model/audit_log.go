@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+// AuditAction 审计动作
+type AuditAction string
+
+const (
+	AuditActionLogin           AuditAction = "login"                    // 登录成功
+	AuditActionLoginFailed     AuditAction = "login_failed"             // 登录失败
+	AuditActionTokenCreate     AuditAction = "api_token.create"         // 创建访问令牌
+	AuditActionTokenRevoke     AuditAction = "api_token.revoke"         // 撤销访问令牌
+	AuditActionUserStatus      AuditAction = "user.status"              // 管理员修改账号状态
+	AuditActionUserRole        AuditAction = "user.role"                // 管理员修改角色
+	AuditActionPasswordReset   AuditAction = "user.password_reset"      // 管理员重置密码
+	AuditActionDocumentShare   AuditAction = "document.share"           // 分享文档
+	AuditActionDocumentDelete  AuditAction = "document.delete"          // 删除文档
+	AuditActionFolderDelete    AuditAction = "folder.delete"            // 删除文件夹
+	AuditActionRecycleDelete   AuditAction = "recycle.delete"           // 从回收站永久删除
+	AuditActionAuditExport     AuditAction = "audit.export"             // 导出审计日志
+	AuditActionAccountExport   AuditAction = "account.export"           // 导出个人数据
+	AuditActionDeletionRequest AuditAction = "account.deletion_request" // 申请注销账号
+	AuditActionDeletionCancel  AuditAction = "account.deletion_cancel"  // 撤销注销申请
+	AuditActionAccountDelete   AuditAction = "account.delete"           // 注销账号
+	AuditActionWebhookCreate   AuditAction = "webhook.create"           // 创建Webhook
+	AuditActionWebhookUpdate   AuditAction = "webhook.update"           // 修改Webhook
+	AuditActionWebhookDelete   AuditAction = "webhook.delete"           // 删除Webhook
+	AuditActionWebhookRotate   AuditAction = "webhook.rotate_secret"    // 轮换Webhook签名密钥
+)
+
+// 审计对象类型
+const (
+	AuditResourceUser     = "user"
+	AuditResourceAPIToken = "api_token"
+	AuditResourceDocument = "document"
+	AuditResourceFolder   = "folder"
+	AuditResourceRecycle  = "recycle_item"
+	AuditResourceAuditLog = "audit_log"
+	AuditResourceWebhook  = "webhook"
+)
+
+// AuditLog 审计日志，只追加不修改。每条记录的Hash包含上一条记录的Hash，
+// 任意记录被篡改或删除都会导致后续链条校验失败。
+type AuditLog struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	ActorID      uint        `json:"actor_id" gorm:"index"` // 操作人，登录失败等匿名操作为0
+	ActorRole    string      `json:"actor_role" gorm:"size:20"`
+	Action       AuditAction `json:"action" gorm:"size:50;not null;index"`
+	ResourceType string      `json:"resource_type" gorm:"size:30"`
+	ResourceID   uint        `json:"resource_id"`
+	IPAddress    string      `json:"ip_address" gorm:"size:45"`
+	UserAgent    string      `json:"user_agent" gorm:"size:500"`
+	Before       string      `json:"before" gorm:"type:text"` // 变更前的值(JSON)
+	After        string      `json:"after" gorm:"type:text"`  // 变更后的值(JSON)
+	PrevHash     string      `json:"prev_hash" gorm:"size:64"`
+	Hash         string      `json:"hash" gorm:"size:64;not null;uniqueIndex"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"index"`
+}
+
+// AuditEntry 待记录的审计事件
+type AuditEntry struct {
+	ActorID      uint
+	ActorRole    string
+	Action       AuditAction
+	ResourceType string
+	ResourceID   uint
+	IPAddress    string
+	UserAgent    string
+	Before       interface{}
+	After        interface{}
+}
+
+// 请求和响应结构
+type AuditLogListRequest struct {
+	Page         int         `form:"page" binding:"min=1"`
+	PageSize     int         `form:"page_size" binding:"min=1,max=100"`
+	ActorID      uint        `form:"actor_id"`
+	Action       AuditAction `form:"action"`
+	ResourceType string      `form:"resource_type"`
+	ResourceID   uint        `form:"resource_id"`
+	StartDate    string      `form:"start_date"` // YYYY-MM-DD
+	EndDate      string      `form:"end_date"`   // YYYY-MM-DD
+}
+
+type AuditLogExportRequest struct {
+	AuditLogListRequest
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl"` // 默认csv
+}
+
+type AuditVerifyResponse struct {
+	Valid        bool   `json:"valid"`
+	CheckedCount int64  `json:"checked_count"`
+	BrokenID     uint   `json:"broken_id,omitempty"` // 第一条校验失败的记录
+	Message      string `json:"message"`
+}
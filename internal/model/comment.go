@@ -0,0 +1,126 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CommentAnchorType 评论锚点类型
+type CommentAnchorType string
+
+const (
+	CommentAnchorNone  CommentAnchorType = ""      // 针对整篇文档
+	CommentAnchorRange CommentAnchorType = "range" // 针对一段文本
+	CommentAnchorBlock CommentAnchorType = "block" // 针对一个内容块
+)
+
+// MentionPattern 匹配评论中的@用户名，用户名末尾的"."和"-"不属于用户名
+var MentionPattern = regexp.MustCompile(`@([\p{L}\p{N}_.\-]{3,50})`)
+
+// MentionedUsername 返回MentionPattern匹配到的用户名
+func MentionedUsername(match string) string {
+	return strings.TrimRight(match, ".-")
+}
+
+// ReplaceMention 将内容中对username的@替换为@replacement，以username为前缀的其他用户名不受影响
+func ReplaceMention(content, username, replacement string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range MentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		name := MentionedUsername(content[loc[2]:loc[3]])
+		if !strings.EqualFold(name, username) {
+			continue
+		}
+		b.WriteString(content[last:loc[2]])
+		b.WriteString(replacement)
+		last = loc[2] + len(name)
+	}
+	if last == 0 {
+		return content
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// Comment 文档评论。ParentID为空的是讨论串的首条评论，回复统一挂在首条评论下，
+// 锚点和解决状态只记录在首条评论上。
+type Comment struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	DocumentID    uint              `json:"document_id" gorm:"not null;index"`
+	UserID        uint              `json:"user_id" gorm:"not null;index"`
+	ParentID      *uint             `json:"parent_id" gorm:"index"`
+	Content       string            `json:"content" gorm:"type:text;not null"`
+	AnchorType    CommentAnchorType `json:"anchor_type" gorm:"size:10"`
+	AnchorStart   int               `json:"anchor_start"`                   // 文本起始偏移（字符）
+	AnchorEnd     int               `json:"anchor_end"`                     // 文本结束偏移（字符）
+	AnchorBlockID string            `json:"anchor_block_id" gorm:"size:64"` // 内容块ID
+	Quote         string            `json:"quote" gorm:"size:1000"`         // 评论时选中的文本
+	Mentions      string            `json:"mentions" gorm:"size:500"`       // 被@的用户ID，逗号分隔
+	Resolved      bool              `json:"resolved" gorm:"default:false"`
+	ResolvedBy    *uint             `json:"resolved_by"`
+	ResolvedAt    *time.Time        `json:"resolved_at"`
+	EditedAt      *time.Time        `json:"edited_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `json:"-" gorm:"index"`
+
+	// 关联
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// IsThread 是否为讨论串的首条评论
+func (c *Comment) IsThread() bool {
+	return c.ParentID == nil
+}
+
+// 请求和响应结构
+type CreateCommentRequest struct {
+	Content       string            `json:"content" binding:"required,max=5000"`
+	ParentID      *uint             `json:"parent_id"` // 回复的评论，为空时创建新讨论串
+	AnchorType    CommentAnchorType `json:"anchor_type" binding:"omitempty,oneof=range block"`
+	AnchorStart   int               `json:"anchor_start" binding:"min=0"`
+	AnchorEnd     int               `json:"anchor_end" binding:"min=0"`
+	AnchorBlockID string            `json:"anchor_block_id" binding:"max=64"`
+	Quote         string            `json:"quote" binding:"max=1000"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
+
+type CommentListRequest struct {
+	Page            int  `form:"page" binding:"min=1"`
+	PageSize        int  `form:"page_size" binding:"min=1,max=100"`
+	IncludeResolved bool `form:"include_resolved"`
+}
+
+// CommentUser 评论中展示的用户信息
+type CommentUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+type CommentResponse struct {
+	ID            uint              `json:"id"`
+	DocumentID    uint              `json:"document_id"`
+	ParentID      *uint             `json:"parent_id"`
+	Author        CommentUser       `json:"author"`
+	Content       string            `json:"content"`
+	AnchorType    CommentAnchorType `json:"anchor_type,omitempty"`
+	AnchorStart   int               `json:"anchor_start,omitempty"`
+	AnchorEnd     int               `json:"anchor_end,omitempty"`
+	AnchorBlockID string            `json:"anchor_block_id,omitempty"`
+	Quote         string            `json:"quote,omitempty"`
+	Mentions      []CommentUser     `json:"mentions"`
+	Resolved      bool              `json:"resolved"`
+	ResolvedBy    *uint             `json:"resolved_by,omitempty"`
+	ResolvedAt    *time.Time        `json:"resolved_at,omitempty"`
+	EditedAt      *time.Time        `json:"edited_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Replies       []CommentResponse `json:"replies,omitempty"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "testing"
+
+func TestReplaceMention(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		username string
+		want     string
+	}{
+		{"替换@用户名", "@alice 请看", "alice", "@已注销用户 请看"},
+		{"替换多处@用户名", "@alice 和 @alice", "alice", "@已注销用户 和 @已注销用户"},
+		{"紧跟文字的@与解析@时一致，不是该用户", "@alice和", "alice", "@alice和"},
+		{"不替换以用户名为前缀的其他用户名", "@alice2 @alicebob @alice_x @alice", "alice", "@alice2 @alicebob @alice_x @已注销用户"},
+		{"不替换包含点号的其他用户名", "@alice.bob", "alice", "@alice.bob"},
+		{"保留用户名末尾的标点", "谢谢@alice.", "alice", "谢谢@已注销用户."},
+		{"保留用户名末尾的连字符", "@alice-", "alice", "@已注销用户-"},
+		{"用户名不区分大小写", "@Alice", "alice", "@已注销用户"},
+		{"不替换没有@的用户名", "alice写的", "alice", "alice写的"},
+		{"不替换邮箱中的用户名", "bob@alice.com", "alice", "bob@alice.com"},
+		{"中文用户名", "@张三丰 @张三丰年", "张三丰", "@已注销用户 @张三丰年"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReplaceMention(tt.content, tt.username, DeletedUserName); got != tt.want {
+				t.Errorf("ReplaceMention(%q, %q) = %q, want %q", tt.content, tt.username, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,12 +11,24 @@ type DocumentType string
 const (
 	DocumentTypeWord      DocumentType = "word"      // Word文档
 	DocumentTypeExcel     DocumentType = "excel"     // Excel表格
+	DocumentTypePPT       DocumentType = "ppt"       // PPT演示文稿
 	DocumentTypeMindMap   DocumentType = "mindmap"   // 思维导图
 	DocumentTypeNote      DocumentType = "note"      // 个人随笔
 	DocumentTypeAIDraft   DocumentType = "ai_draft"  // AI快速起草
 	DocumentTypeImported  DocumentType = "imported"  // 导入文件
 )
 
+// DocumentTypes 全部文档类型，类型统计中没有文档的类型计为0
+var DocumentTypes = []DocumentType{
+	DocumentTypeWord,
+	DocumentTypeExcel,
+	DocumentTypePPT,
+	DocumentTypeMindMap,
+	DocumentTypeNote,
+	DocumentTypeAIDraft,
+	DocumentTypeImported,
+}
+
 // DocumentStatus 文档状态
 type DocumentStatus int
 
@@ -24,6 +36,8 @@ const (
 	DocumentStatusDraft     DocumentStatus = 1 // 草稿
 	DocumentStatusPublished DocumentStatus = 2 // 已发布
 	DocumentStatusArchived  DocumentStatus = 3 // 已归档
+	DocumentStatusInReview  DocumentStatus = 4 // 审核中
+	DocumentStatusApproved  DocumentStatus = 5 // 审核通过，等待发布
 )
 
 type Document struct {
@@ -40,6 +54,8 @@ type Document struct {
 	IsShared    bool             `json:"is_shared" gorm:"default:false"`
 	ShareToken  string           `json:"share_token" gorm:"size:32;index"`
 	ShareExpiry *time.Time       `json:"share_expiry"`
+	PublishAt   *time.Time       `json:"publish_at" gorm:"index"` // 定时发布时间，仅审核通过的文档
+	Version     int64            `json:"version" gorm:"default:0"` // 内容版本，每次更新加1，用于检测并发修改
 	CreatedAt   time.Time        `json:"created_at"`
 	UpdatedAt   time.Time        `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt   `json:"-" gorm:"index"`
@@ -67,11 +83,13 @@ type Folder struct {
 
 // 请求和响应结构
 type CreateDocumentRequest struct {
-	Title    string       `json:"title" binding:"required,max=255"`
-	Content  string       `json:"content"`
-	Type     DocumentType `json:"type" binding:"required"`
-	FolderID *uint        `json:"folder_id"`
-	Tags     string       `json:"tags"`
+	Title      string            `json:"title" binding:"required_without=TemplateID,max=255"` // 使用模板时可为空，默认使用模板标题
+	Content    string            `json:"content"`
+	Type       DocumentType      `json:"type" binding:"required_without=TemplateID"`
+	FolderID   *uint             `json:"folder_id"`
+	Tags       string            `json:"tags"`
+	TemplateID uint              `json:"template_id"` // 从模板创建，内容为空时使用模板内容
+	Variables  map[string]string `json:"variables"`   // 模板占位符的值
 }
 
 type UpdateDocumentRequest struct {
@@ -80,6 +98,7 @@ type UpdateDocumentRequest struct {
 	Status   *DocumentStatus   `json:"status"`
 	FolderID *uint             `json:"folder_id"`
 	Tags     string            `json:"tags"`
+	Version  *int64            `json:"version"` // 修改所基于的文档版本，与当前版本不一致时返回冲突，为空时不检查
 }
 
 type DocumentListRequest struct {
@@ -101,17 +120,35 @@ type DocumentResponse struct {
 	Size        int64          `json:"size"`
 	ViewCount   int            `json:"view_count"`
 	IsShared    bool           `json:"is_shared"`
+	PublishAt   *time.Time     `json:"publish_at,omitempty"` // 定时发布时间
+	Favorited   bool           `json:"favorited"`            // 当前用户是否已收藏
+	Pinned      bool           `json:"pinned"`               // 当前用户是否已置顶
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
 type DocumentDetailResponse struct {
 	DocumentResponse
-	Content string `json:"content"`
+	Content     string               `json:"content"`
+	Version     int64                `json:"version"`               // 内容版本，更新时传回以检测并发修改
+	Related     []RelatedDocument    `json:"related,omitempty"`     // 语义相近的文档，未启用语义搜索时为空
+	TOC         []TOCEntry           `json:"toc,omitempty"`         // Word文档的目录
+	Backlinks   []LinkedDocument     `json:"backlinks,omitempty"`   // 链接到该文档的其他文档
+	Attachments []AttachmentResponse `json:"attachments,omitempty"` // 文档附件
+}
+
+// ShareRecipient 分享时指定的用户，分享链接有效期间可以访问文档并在评论中被@，重新分享后失效
+type ShareRecipient struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DocumentID uint      `json:"document_id" gorm:"not null;index:idx_document_token"`
+	ShareToken string    `json:"-" gorm:"size:32;not null;index:idx_document_token"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type ShareDocumentRequest struct {
-	ExpiryHours int `json:"expiry_hours" binding:"min=1,max=8760"` // 最长1年
+	ExpiryHours int      `json:"expiry_hours" binding:"min=1,max=8760"` // 最长1年
+	Recipients  []string `json:"recipients" binding:"max=20"`           // 分享给的用户名，可选，分享对象会收到通知并可在评论中被@
 }
 
 // 文件夹请求和响应结构
@@ -128,6 +165,8 @@ type FolderResponse struct {
 	ID        uint      `json:"id"`
 	Name      string    `json:"name"`
 	ParentID  *uint     `json:"parent_id"`
+	Favorited bool      `json:"favorited"`
+	Pinned    bool      `json:"pinned"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// DocumentVersionSource 版本来源
+type DocumentVersionSource string
+
+const (
+	VersionSourceSnapshot DocumentVersionSource = "snapshot"  // 内容被替换前自动保存
+	VersionSourceAIAssist DocumentVersionSource = "ai_assist" // 应用AI建议
+	VersionSourceRestore  DocumentVersionSource = "restore"   // 恢复历史版本
+)
+
+// DocumentVersion 文档内容的历史版本，版本号在同一文档内递增
+type DocumentVersion struct {
+	ID         uint                  `json:"id" gorm:"primaryKey"`
+	DocumentID uint                  `json:"document_id" gorm:"not null;uniqueIndex:uk_document_version"`
+	Version    int                   `json:"version" gorm:"not null;uniqueIndex:uk_document_version"`
+	UserID     uint                  `json:"user_id" gorm:"not null"` // 产生该版本的用户
+	Title      string                `json:"title" gorm:"size:255;not null"`
+	Content    string                `json:"content" gorm:"type:longtext"`
+	Size       int64                 `json:"size"`
+	Source     DocumentVersionSource `json:"source" gorm:"size:20;not null"`
+	Note       string                `json:"note" gorm:"size:255"`
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+// 请求和响应结构
+type DocumentVersionListRequest struct {
+	Page     int `form:"page" binding:"min=1"`
+	PageSize int `form:"page_size" binding:"min=1,max=100"`
+}
+
+type DocumentVersionResponse struct {
+	ID         uint                  `json:"id"`
+	DocumentID uint                  `json:"document_id"`
+	Version    int                   `json:"version"`
+	UserID     uint                  `json:"user_id"`
+	Title      string                `json:"title"`
+	Size       int64                 `json:"size"`
+	Source     DocumentVersionSource `json:"source"`
+	Note       string                `json:"note"`
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+type DocumentVersionDetailResponse struct {
+	DocumentVersionResponse
+	Content string `json:"content"`
+}
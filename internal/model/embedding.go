@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// DocumentEmbedding 文档分块的向量，每个文档按段落切分为多个分块
+type DocumentEmbedding struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DocumentID  uint      `json:"document_id" gorm:"not null;index"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	ChunkIndex  int       `json:"chunk_index" gorm:"not null"`
+	Content     string    `json:"content" gorm:"type:text"`             // 分块原文，用于展示匹配片段
+	ContentHash string    `json:"content_hash" gorm:"size:64;not null"` // 整篇文档标题和内容的SHA-256，内容未变化时跳过重新计算
+	Model       string    `json:"model" gorm:"size:100;not null"`       // 生成向量的模型，更换模型后需要重新计算
+	Dimensions  int       `json:"dimensions" gorm:"not null"`
+	Vector      []byte    `json:"-" gorm:"type:mediumblob;not null"` // float32小端序
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// 请求和响应结构
+type SemanticSearchRequest struct {
+	Query    string `form:"q" binding:"required,max=500"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=50"`
+	FolderID *uint  `form:"folder_id"`
+}
+
+type SemanticSearchResult struct {
+	Document DocumentResponse `json:"document"`
+	Score    float64          `json:"score"`   // 余弦相似度
+	Snippet  string           `json:"snippet"` // 最匹配的分块
+}
+
+// RelatedDocument 文档详情中的相关文档
+type RelatedDocument struct {
+	ID    uint         `json:"id"`
+	Title string       `json:"title"`
+	Type  DocumentType `json:"type"`
+	Score float64      `json:"score"`
+}
@@ -0,0 +1,129 @@
+package model
+
+import "time"
+
+// EventType 领域事件类型
+type EventType string
+
+const (
+	EventDocumentCreated   EventType = "document.created"   // 创建文档
+	EventDocumentCopied    EventType = "document.copied"    // 复制文档
+	EventDocumentUpdated   EventType = "document.updated"   // 更新文档
+	EventDocumentPublished EventType = "document.published" // 发布文档，与document.updated同时发出
+	EventDocumentDeleted   EventType = "document.deleted"   // 删除文档
+	EventDocumentRestored  EventType = "document.restored"  // 从回收站恢复文档
+	EventDocumentSubmitted EventType = "document.submitted" // 提交审核
+	EventDocumentWithdrawn EventType = "document.withdrawn" // 撤回审核
+	EventDocumentReviewed  EventType = "document.reviewed"  // 审核人提交审核意见
+	EventDocumentScheduled EventType = "document.scheduled" // 设置或取消定时发布
+	EventDocumentMoved     EventType = "document.moved"     // 移动文档到其他文件夹
+	EventDocumentViewed    EventType = "document.viewed"    // 查看文档
+	EventDocumentShared    EventType = "document.shared"    // 分享文档
+	EventFolderCreated     EventType = "folder.created"     // 创建文件夹
+	EventFolderUpdated     EventType = "folder.updated"     // 重命名文件夹
+	EventFolderMoved       EventType = "folder.moved"       // 移动文件夹
+	EventFolderDeleted     EventType = "folder.deleted"     // 删除文件夹
+	EventCommentCreated    EventType = "comment.created"    // 发表评论
+	EventCommentUpdated    EventType = "comment.updated"    // 编辑评论
+	EventCommentDeleted    EventType = "comment.deleted"    // 删除评论
+	EventCommentResolved   EventType = "comment.resolved"   // 解决讨论
+	EventCommentReopened   EventType = "comment.reopened"   // 重新打开讨论
+	EventRecyclePurged     EventType = "recycle.purged"     // 从回收站永久删除
+)
+
+// Event 服务层在数据变更后发布的领域事件，根据类型填充对应的数据字段
+type Event struct {
+	Type       EventType          `json:"type"`
+	ActorID    uint               `json:"actor_id"` // 触发事件的用户
+	OccurredAt time.Time          `json:"occurred_at"`
+	Client     *ClientInfo        `json:"client,omitempty"` // 请求来源，后台任务触发的事件为空
+	Document   *DocumentEventData `json:"document,omitempty"`
+	Folder     *FolderEventData   `json:"folder,omitempty"`
+	Share      *ShareEventData    `json:"share,omitempty"`
+	Comment    *CommentEventData  `json:"comment,omitempty"`
+	Recycle    *RecycleEventData  `json:"recycle,omitempty"`
+	Review     *ReviewEventData   `json:"review,omitempty"`
+}
+
+// DocumentEventData 文档事件的数据
+type DocumentEventData struct {
+	ID               uint           `json:"id"`
+	Title            string         `json:"title"`
+	Type             DocumentType   `json:"type"`
+	Status           DocumentStatus `json:"status"`
+	PreviousStatus   DocumentStatus `json:"previous_status,omitempty"` // 仅更新事件
+	FolderID         *uint          `json:"folder_id"`
+	PreviousFolderID *uint          `json:"previous_folder_id,omitempty"` // 仅移动事件
+	Tags             string         `json:"tags"`
+	SourceID         uint           `json:"source_id,omitempty"` // 仅复制事件，被复制的文档
+	PublishAt        *time.Time     `json:"publish_at,omitempty"` // 定时发布时间
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// FolderEventData 文件夹事件的数据
+type FolderEventData struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	PreviousName string `json:"previous_name,omitempty"` // 仅更新事件
+	ParentID     *uint  `json:"parent_id"`
+}
+
+// ShareEventData 分享事件的数据
+type ShareEventData struct {
+	DocumentID  uint       `json:"document_id"`
+	Title       string     `json:"title"`
+	ShareURL    string     `json:"share_url"`
+	ShareExpiry *time.Time `json:"share_expiry"`
+	Recipients  []uint     `json:"recipients,omitempty"` // 需要通知的分享对象用户ID
+}
+
+// CommentEventData 评论事件的数据
+type CommentEventData struct {
+	ID              uint   `json:"id"`
+	DocumentID      uint   `json:"document_id"`
+	DocumentTitle   string `json:"document_title"`
+	DocumentOwnerID uint   `json:"document_owner_id"`
+	ParentID        *uint  `json:"parent_id"`
+	ThreadAuthorID  uint   `json:"thread_author_id,omitempty"` // 回复时为讨论串首条评论的作者
+	AuthorName      string `json:"author_name"`
+	Content         string `json:"content"`
+	Mentions        []uint `json:"mentions,omitempty"` // 本次新增@的用户
+}
+
+// ReviewEventData 审核事件的数据，随文档事件数据一起发布
+type ReviewEventData struct {
+	ID          uint           `json:"id"`
+	Status      ReviewStatus   `json:"status"`
+	SubmitterID uint           `json:"submitter_id"`
+	Reviewers   []uint         `json:"reviewers,omitempty"` // 仅提交事件
+	Decision    ReviewDecision `json:"decision,omitempty"`  // 仅审核事件
+	Comment     string         `json:"comment,omitempty"`   // 仅审核事件
+}
+
+// RecycleEventData 回收站事件的数据
+type RecycleEventData struct {
+	IDs []uint `json:"ids"`
+}
+
+// OutboxStatus 事件发件箱状态
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending" // 等待处理或重试
+	OutboxStatusDone    OutboxStatus = "done"    // 所有订阅者均已处理
+	OutboxStatusFailed  OutboxStatus = "failed"  // 重试次数用尽
+)
+
+// OutboxEvent 事件发件箱，事件在分发前先落库，进程崩溃后由补偿任务重新分发
+type OutboxEvent struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	Type          EventType    `json:"type" gorm:"size:50;not null"`
+	Payload       string       `json:"payload" gorm:"type:mediumtext;not null"`
+	Handled       string       `json:"handled" gorm:"size:500"` // 已处理成功的订阅者，逗号分隔，重试时跳过
+	Status        OutboxStatus `json:"status" gorm:"size:20;not null;index:idx_status_next"`
+	Attempts      int          `json:"attempts"`
+	LastError     string       `json:"last_error" gorm:"size:1000"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"index:idx_status_next"`
+	CreatedAt     time.Time    `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
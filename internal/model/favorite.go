@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+const (
+	// FavoriteMaxPerUser 每个用户最多收藏的文档和文件夹数
+	FavoriteMaxPerUser = 500
+	// PinMaxPerUser 每个用户最多置顶的文档和文件夹数
+	PinMaxPerUser = 50
+	// DashboardFavoriteLimit 工作台展示的最近收藏数
+	DashboardFavoriteLimit = 10
+)
+
+// Favorite 用户收藏的文档或文件夹
+type Favorite struct {
+	ID           uint         `json:"id" gorm:"primaryKey"`
+	UserID       uint         `json:"user_id" gorm:"not null;uniqueIndex:idx_user_resource"`
+	ResourceType ResourceType `json:"resource_type" gorm:"size:20;not null;uniqueIndex:idx_user_resource"`
+	ResourceID   uint         `json:"resource_id" gorm:"not null;uniqueIndex:idx_user_resource"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// Pin 置顶的文档或文件夹，在所在文件夹和工作台中排在最前，按Position排序
+type Pin struct {
+	ID           uint         `json:"id" gorm:"primaryKey"`
+	UserID       uint         `json:"user_id" gorm:"not null;uniqueIndex:idx_user_resource"`
+	ResourceType ResourceType `json:"resource_type" gorm:"size:20;not null;uniqueIndex:idx_user_resource"`
+	ResourceID   uint         `json:"resource_id" gorm:"not null;uniqueIndex:idx_user_resource"`
+	Position     int          `json:"position" gorm:"not null;default:0"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// 请求和响应结构
+
+// MarkRequest 收藏或置顶文档、文件夹
+type MarkRequest struct {
+	ResourceType ResourceType `json:"resource_type" binding:"required,oneof=document folder"`
+	ResourceID   uint         `json:"resource_id" binding:"required"`
+}
+
+type FavoriteListRequest struct {
+	ResourceType ResourceType `form:"resource_type" binding:"omitempty,oneof=document folder"`
+}
+
+// ReorderPinsRequest 按给定顺序排列置顶项，未列出的置顶项保持原有顺序排在后面
+type ReorderPinsRequest struct {
+	Items []MarkRequest `json:"items" binding:"required,min=1,max=50,dive"`
+}
+
+// MarkedItemResponse 收藏或置顶的文档、文件夹
+type MarkedItemResponse struct {
+	ResourceType ResourceType `json:"resource_type"`
+	ResourceID   uint         `json:"resource_id"`
+	Title        string       `json:"title"`                   // 文档标题或文件夹名称
+	DocumentType DocumentType `json:"document_type,omitempty"` // 仅文档
+	FolderID     *uint        `json:"folder_id"`               // 文档所在文件夹或上级文件夹
+	Position     int          `json:"position"`                // 置顶顺序，仅置顶项
+	UpdatedAt    time.Time    `json:"updated_at"`
+	MarkedAt     time.Time    `json:"marked_at"` // 收藏或置顶时间
+}
@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// DocumentLinkType 文档内部链接的写法
+type DocumentLinkType string
+
+const (
+	DocumentLinkByID    DocumentLinkType = "id"    // [[#123]]或/documents/123
+	DocumentLinkByTitle DocumentLinkType = "title" // [[标题]]
+)
+
+// DocumentLink 文档之间的引用，文档保存后从内容中解析。按标题引用且没有同名文档时TargetID为空，
+// 目标文档被删除后保留TargetID，查询时视为失效链接，恢复文档后自动恢复
+type DocumentLink struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	UserID      uint             `json:"user_id" gorm:"not null;index:idx_user_title"`
+	SourceID    uint             `json:"source_id" gorm:"not null;index"`
+	TargetID    *uint            `json:"target_id" gorm:"index"`
+	TargetTitle string           `json:"target_title" gorm:"size:255;index:idx_user_title"` // 按标题引用时的标题
+	LinkType    DocumentLinkType `json:"link_type" gorm:"size:10;not null"`
+	Context     string           `json:"context" gorm:"size:500"` // 链接所在位置前后的文本
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// 请求和响应结构
+
+// LinkedDocument 出链或反向链接中的文档，Broken为true表示目标不存在或已删除
+type LinkedDocument struct {
+	DocumentID *uint            `json:"document_id"`
+	Title      string           `json:"title"`
+	Type       DocumentType     `json:"type,omitempty"`
+	LinkType   DocumentLinkType `json:"link_type"`
+	Context    string           `json:"context,omitempty"`
+	Broken     bool             `json:"broken"`
+}
+
+type DocumentLinksResponse struct {
+	Outgoing  []LinkedDocument `json:"outgoing"`
+	Backlinks []LinkedDocument `json:"backlinks"`
+}
+
+// BrokenLink 失效的链接，TargetID为空表示按标题找不到文档
+type BrokenLink struct {
+	SourceID    uint             `json:"source_id"`
+	SourceTitle string           `json:"source_title"`
+	TargetID    *uint            `json:"target_id"`
+	TargetTitle string           `json:"target_title"`
+	LinkType    DocumentLinkType `json:"link_type"`
+	Context     string           `json:"context"`
+}
+
+type BrokenLinkListRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// DocumentGraphRequest 知识图谱的范围，按文件夹或标签筛选，都为空时包含全部文档
+type DocumentGraphRequest struct {
+	FolderID *uint  `form:"folder_id"`
+	Tag      string `form:"tag" binding:"max=50"`
+}
+
+// GraphNode 图谱节点。InScope为false的节点不在筛选范围内，因与范围内的文档有链接而包含
+type GraphNode struct {
+	ID      uint         `json:"id"`
+	Title   string       `json:"title"`
+	Type    DocumentType `json:"type"`
+	InScope bool         `json:"in_scope"`
+	Degree  int          `json:"degree"` // 出链和反向链接的数量
+}
+
+// GraphEdge 图谱的边，同一对文档之间的多个链接合并，Weight为链接数
+type GraphEdge struct {
+	Source uint `json:"source"`
+	Target uint `json:"target"`
+	Weight int  `json:"weight"`
+}
+
+type DocumentGraph struct {
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Truncated bool        `json:"truncated"` // 范围内的文档超过上限时只包含最近更新的部分
+}
@@ -0,0 +1,119 @@
+package model
+
+import "time"
+
+// MindMap 思维导图的内容结构，以JSON保存在Document.Content中
+type MindMap struct {
+	Root *MindMapNode `json:"root"`
+}
+
+// MindMapNode 思维导图节点，ID在导图内唯一，保存时由服务端为缺少ID的节点补充
+type MindMapNode struct {
+	ID        string         `json:"id"`
+	Text      string         `json:"text"`
+	Note      string         `json:"note,omitempty"`
+	Collapsed bool           `json:"collapsed,omitempty"`
+	Children  []*MindMapNode `json:"children"`
+}
+
+// MindMapNodeIndex 思维导图节点的搜索索引，文档保存后按节点重建
+type MindMapNodeIndex struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DocumentID uint      `json:"document_id" gorm:"not null;index"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	NodeID     string    `json:"node_id" gorm:"size:32;not null"`
+	ParentID   string    `json:"parent_id" gorm:"size:32"`
+	Depth      int       `json:"depth" gorm:"not null"`
+	Text       string    `json:"text" gorm:"size:1000"`
+	Note       string    `json:"note" gorm:"type:text"`
+	Path       string    `json:"path" gorm:"size:2000"` // 祖先节点的文本，以" > "连接
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (MindMapNodeIndex) TableName() string {
+	return "mindmap_nodes"
+}
+
+// MindMapFormat 思维导图的导入导出格式
+type MindMapFormat string
+
+const (
+	MindMapFormatOPML     MindMapFormat = "opml"     // OPML大纲
+	MindMapFormatMarkdown MindMapFormat = "markdown" // Markdown标题和列表
+	MindMapFormatFreeMind MindMapFormat = "freemind" // FreeMind的.mm文件
+)
+
+// MindMapOperationType 节点操作类型
+type MindMapOperationType string
+
+const (
+	MindMapOperationAdd      MindMapOperationType = "add"
+	MindMapOperationUpdate   MindMapOperationType = "update"
+	MindMapOperationMove     MindMapOperationType = "move"
+	MindMapOperationDelete   MindMapOperationType = "delete"
+	MindMapOperationCollapse MindMapOperationType = "collapse"
+	MindMapOperationExpand   MindMapOperationType = "expand"
+)
+
+// 请求和响应结构
+
+// MindMapOperation 单个节点操作。add时NodeID可以为空，由服务端生成；
+// Index为插入到父节点子节点中的位置，为空时追加到末尾
+type MindMapOperation struct {
+	Op       MindMapOperationType `json:"op" binding:"required,oneof=add update move delete collapse expand"`
+	NodeID   string               `json:"node_id" binding:"max=32"`
+	ParentID string               `json:"parent_id" binding:"max=32"`
+	Index    *int                 `json:"index" binding:"omitempty,min=0"`
+	Text     *string              `json:"text"`
+	Note     *string              `json:"note"`
+}
+
+type MindMapOperationsRequest struct {
+	Operations []MindMapOperation `json:"operations" binding:"required,min=1,max=200,dive"`
+}
+
+type AddMindMapNodeRequest struct {
+	ParentID string `json:"parent_id" binding:"required,max=32"`
+	Index    *int   `json:"index" binding:"omitempty,min=0"`
+	Text     string `json:"text" binding:"required"`
+	Note     string `json:"note"`
+}
+
+type UpdateMindMapNodeRequest struct {
+	Text *string `json:"text"`
+	Note *string `json:"note"`
+}
+
+type MoveMindMapNodeRequest struct {
+	ParentID string `json:"parent_id" binding:"required,max=32"`
+	Index    *int   `json:"index" binding:"omitempty,min=0"`
+}
+
+// MindMapOperationsResponse 操作后的完整导图，NodeIDs为各操作涉及的节点ID，与请求中的操作一一对应
+type MindMapOperationsResponse struct {
+	MindMap   *MindMap  `json:"mindmap"`
+	NodeIDs   []string  `json:"node_ids"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ImportMindMapRequest struct {
+	Title    string `form:"title" binding:"max=255"` // 为空时使用根节点文本
+	FolderID *uint  `form:"folder_id"`
+}
+
+type MindMapNodeSearchRequest struct {
+	Query    string `form:"q" binding:"required,max=200"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// MindMapNodeSearchResult 节点搜索结果
+type MindMapNodeSearchResult struct {
+	DocumentID    uint   `json:"document_id"`
+	DocumentTitle string `json:"document_title"`
+	NodeID        string `json:"node_id"`
+	Text          string `json:"text"`
+	Note          string `json:"note,omitempty"`
+	Path          string `json:"path"`
+	Depth         int    `json:"depth"`
+}
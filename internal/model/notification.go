@@ -0,0 +1,87 @@
+package model
+
+import "time"
+
+// NotificationType 通知类型
+type NotificationType string
+
+const (
+	NotificationTypeDocumentShared  NotificationType = "document_shared"  // 文档被分享给我
+	NotificationTypeCommentCreated  NotificationType = "comment_created"  // 我的文档收到评论
+	NotificationTypeCommentReply    NotificationType = "comment_reply"    // 我参与的讨论有新回复
+	NotificationTypeCommentMention  NotificationType = "comment_mention"  // 评论中@了我
+	NotificationTypeRecycleExpiring NotificationType = "recycle_expiring" // 回收站内容即将被清理
+	NotificationTypeReviewRequested NotificationType = "review_requested" // 有文档需要我审核
+	NotificationTypeReviewCompleted NotificationType = "review_completed" // 我提交的审核已完成
+)
+
+// NotificationTypes 所有通知类型，用于偏好设置
+var NotificationTypes = []NotificationType{
+	NotificationTypeDocumentShared,
+	NotificationTypeCommentCreated,
+	NotificationTypeCommentReply,
+	NotificationTypeCommentMention,
+	NotificationTypeRecycleExpiring,
+	NotificationTypeReviewRequested,
+	NotificationTypeReviewCompleted,
+}
+
+// IsValidNotificationType 校验通知类型是否合法
+func IsValidNotificationType(t NotificationType) bool {
+	for _, notificationType := range NotificationTypes {
+		if notificationType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// RecycleExpiryNoticeWindow 回收站内容在清理前多久发送提醒
+const RecycleExpiryNoticeWindow = 3 * 24 * time.Hour
+
+type Notification struct {
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	UserID       uint             `json:"user_id" gorm:"not null;index:idx_user_read"`
+	Type         NotificationType `json:"type" gorm:"size:30;not null"`
+	Title        string           `json:"title" gorm:"size:255;not null"`
+	Content      string           `json:"content" gorm:"size:1000"`
+	ActorID      *uint            `json:"actor_id"` // 触发通知的用户，系统通知为空
+	ResourceType ResourceType     `json:"resource_type" gorm:"size:20"`
+	ResourceID   uint             `json:"resource_id"`
+	Link         string           `json:"link" gorm:"size:500"`
+	ReadAt       *time.Time       `json:"read_at" gorm:"index:idx_user_read"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// NotificationPreference 用户对某类通知的开关，没有记录时默认开启
+type NotificationPreference struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	UserID    uint             `json:"user_id" gorm:"not null;uniqueIndex:idx_user_type"`
+	Type      NotificationType `json:"type" gorm:"size:30;not null;uniqueIndex:idx_user_type"`
+	Enabled   bool             `json:"enabled"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// 请求和响应结构
+type NotificationListRequest struct {
+	Page       int              `form:"page" binding:"min=1"`
+	PageSize   int              `form:"page_size" binding:"min=1,max=100"`
+	UnreadOnly bool             `form:"unread_only"`
+	Type       NotificationType `form:"type"`
+}
+
+type NotificationPreferenceItem struct {
+	Type    NotificationType `json:"type"`
+	Enabled bool             `json:"enabled"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceItem `json:"preferences" binding:"required,min=1,dive"`
+}
+
+// StreamTicketResponse 建立实时通知连接用的一次性票据
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
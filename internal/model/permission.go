@@ -0,0 +1,42 @@
+package model
+
+// Permission 系统权限
+type Permission string
+
+const (
+	PermissionUserRead   Permission = "user:read"   // 查看用户
+	PermissionUserManage Permission = "user:manage" // 管理用户（禁用、重置密码、分配角色）
+	PermissionStatsRead  Permission = "stats:read"  // 查看系统统计
+	PermissionAuditRead  Permission = "audit:read"  // 查看和导出审计日志
+)
+
+// rolePermissions 角色与权限的对应关系
+var rolePermissions = map[string][]Permission{
+	UserRoleAdmin: {
+		PermissionUserRead,
+		PermissionUserManage,
+		PermissionStatsRead,
+		PermissionAuditRead,
+	},
+	UserRoleAuditor: {
+		PermissionUserRead,
+		PermissionStatsRead,
+		PermissionAuditRead,
+	},
+	UserRoleUser: {},
+}
+
+// RoleHasPermission 判断角色是否拥有指定权限
+func RoleHasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions 返回角色拥有的权限列表
+func RolePermissions(role string) []Permission {
+	return rolePermissions[role]
+}
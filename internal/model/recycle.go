@@ -5,6 +5,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// RecycleRetention 删除的内容在回收站中保留的时间，过期后自动清理
+const RecycleRetention = 30 * 24 * time.Hour
+
 type RecycleItem struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
 	UserID       uint           `json:"user_id" gorm:"not null;index"`
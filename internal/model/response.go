@@ -154,9 +154,8 @@ type SearchAllResponse struct {
 
 // DashboardResponse 工作台首页响应
 type DashboardResponse struct {
-	RecentDocuments    []*DocumentResponse `json:"recent_documents"`
-	ImportantDocuments []*DocumentResponse `json:"important_documents"`
-	Activities         []*ActivityResponse `json:"activities"`
+	RecentDocuments []*DocumentResponse `json:"recent_documents"`
+	Activities      []*ActivityResponse `json:"activities"`
 }
 
 // StatsResponse 统计信息响应
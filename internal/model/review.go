@@ -0,0 +1,149 @@
+package model
+
+import "time"
+
+// DocumentAction 文档发布流程中的操作
+type DocumentAction string
+
+const (
+	DocumentActionSubmit    DocumentAction = "submit"    // 提交审核
+	DocumentActionWithdraw  DocumentAction = "withdraw"  // 撤回审核
+	DocumentActionApprove   DocumentAction = "approve"   // 审核通过
+	DocumentActionReject    DocumentAction = "reject"    // 驳回
+	DocumentActionPublish   DocumentAction = "publish"   // 发布
+	DocumentActionSchedule  DocumentAction = "schedule"  // 设置或取消定时发布
+	DocumentActionUnpublish DocumentAction = "unpublish" // 取消发布
+	DocumentActionArchive   DocumentAction = "archive"   // 归档
+	DocumentActionUnarchive DocumentAction = "unarchive" // 取消归档
+)
+
+// documentTransitions 文档状态机，列出每个状态下允许的操作及操作后的状态
+var documentTransitions = map[DocumentStatus]map[DocumentAction]DocumentStatus{
+	DocumentStatusDraft: {
+		DocumentActionSubmit:  DocumentStatusInReview,
+		DocumentActionArchive: DocumentStatusArchived,
+	},
+	DocumentStatusInReview: {
+		DocumentActionWithdraw: DocumentStatusDraft,
+		DocumentActionApprove:  DocumentStatusApproved,
+		DocumentActionReject:   DocumentStatusDraft,
+	},
+	DocumentStatusApproved: {
+		DocumentActionPublish:  DocumentStatusPublished,
+		DocumentActionSchedule: DocumentStatusApproved,
+		DocumentActionArchive:  DocumentStatusArchived,
+	},
+	DocumentStatusPublished: {
+		DocumentActionUnpublish: DocumentStatusDraft,
+		DocumentActionArchive:   DocumentStatusArchived,
+	},
+	DocumentStatusArchived: {
+		DocumentActionUnarchive: DocumentStatusDraft,
+	},
+}
+
+// NextDocumentStatus 返回在当前状态下执行操作后的状态，不允许的操作返回false
+func NextDocumentStatus(status DocumentStatus, action DocumentAction) (DocumentStatus, bool) {
+	next, ok := documentTransitions[status][action]
+	return next, ok
+}
+
+// ReviewStatus 审核请求状态
+type ReviewStatus string
+
+const (
+	ReviewStatusPending   ReviewStatus = "pending"   // 等待审核
+	ReviewStatusApproved  ReviewStatus = "approved"  // 所有审核人均已通过
+	ReviewStatusRejected  ReviewStatus = "rejected"  // 被审核人驳回
+	ReviewStatusWithdrawn ReviewStatus = "withdrawn" // 提交人已撤回
+)
+
+// ReviewDecision 单个审核人的审核意见
+type ReviewDecision string
+
+const (
+	ReviewDecisionPending  ReviewDecision = "pending"  // 未处理
+	ReviewDecisionApproved ReviewDecision = "approved" // 通过
+	ReviewDecisionRejected ReviewDecision = "rejected" // 驳回
+)
+
+// SchedulePublishMinDelay 定时发布时间至少在当前时间之后多久
+const SchedulePublishMinDelay = time.Minute
+
+// DocumentReview 一次提交审核，所有审核人通过后文档进入审核通过状态，任一审核人驳回后退回草稿
+type DocumentReview struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	DocumentID  uint         `json:"document_id" gorm:"not null;index"`
+	SubmitterID uint         `json:"submitter_id" gorm:"not null;index"`
+	Status      ReviewStatus `json:"status" gorm:"size:20;not null;index"`
+	Message     string       `json:"message" gorm:"size:500"` // 提交说明
+	CompletedAt *time.Time   `json:"completed_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+
+	// 关联
+	Reviewers []DocumentReviewer `json:"reviewers" gorm:"foreignKey:ReviewID"`
+	Document  *Document          `json:"-" gorm:"foreignKey:DocumentID"`
+}
+
+type DocumentReviewer struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	ReviewID   uint           `json:"review_id" gorm:"not null;index"`
+	ReviewerID uint           `json:"reviewer_id" gorm:"not null;index:idx_reviewer_decision"`
+	Decision   ReviewDecision `json:"decision" gorm:"size:20;not null;index:idx_reviewer_decision"`
+	Comment    string         `json:"comment" gorm:"size:1000"`
+	DecidedAt  *time.Time     `json:"decided_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// 请求和响应结构
+
+type SubmitReviewRequest struct {
+	Reviewers []string `json:"reviewers" binding:"required,min=1,max=10,dive,required,max=50"` // 审核人用户名
+	Message   string   `json:"message" binding:"max=500"`
+}
+
+// ReviewDecisionRequest 审核意见，驳回时必须填写
+type ReviewDecisionRequest struct {
+	Comment string `json:"comment" binding:"max=1000"`
+}
+
+type SchedulePublishRequest struct {
+	PublishAt time.Time `json:"publish_at" binding:"required"`
+}
+
+// ReviewListRequest 查询分配给我的审核，Decision为空时返回全部
+type ReviewListRequest struct {
+	Page     int            `form:"page" binding:"omitempty,min=1"`
+	PageSize int            `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Decision ReviewDecision `form:"decision" binding:"omitempty,oneof=pending approved rejected"`
+}
+
+type ReviewerResponse struct {
+	UserID    uint           `json:"user_id"`
+	Username  string         `json:"username"`
+	Nickname  string         `json:"nickname"`
+	Decision  ReviewDecision `json:"decision"`
+	Comment   string         `json:"comment,omitempty"`
+	DecidedAt *time.Time     `json:"decided_at"`
+}
+
+type ReviewResponse struct {
+	ID            uint               `json:"id"`
+	DocumentID    uint               `json:"document_id"`
+	DocumentTitle string             `json:"document_title"`
+	SubmitterID   uint               `json:"submitter_id"`
+	SubmitterName string             `json:"submitter_name"`
+	Status        ReviewStatus       `json:"status"`
+	Message       string             `json:"message"`
+	Reviewers     []ReviewerResponse `json:"reviewers"`
+	CreatedAt     time.Time          `json:"created_at"`
+	CompletedAt   *time.Time         `json:"completed_at"`
+}
+
+// ReviewDetailResponse 审核详情，审核人无权访问文档，通过审核详情阅读文档内容
+type ReviewDetailResponse struct {
+	ReviewResponse
+	DocumentType DocumentType `json:"document_type"`
+	Content      string       `json:"content"`
+}
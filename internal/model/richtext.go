@@ -0,0 +1,53 @@
+package model
+
+// RichTextDocument Word文档的内容结构，以JSON保存在Document.Content中
+type RichTextDocument struct {
+	Version int              `json:"version"`
+	Blocks  []*RichTextBlock `json:"blocks"`
+}
+
+// RichTextBlockType 内容块类型
+type RichTextBlockType string
+
+const (
+	RichTextBlockHeading   RichTextBlockType = "heading"   // 标题
+	RichTextBlockParagraph RichTextBlockType = "paragraph" // 段落
+	RichTextBlockList      RichTextBlockType = "list"      // 列表
+	RichTextBlockTable     RichTextBlockType = "table"     // 表格
+	RichTextBlockImage     RichTextBlockType = "image"     // 图片
+	RichTextBlockCode      RichTextBlockType = "code"      // 代码
+	RichTextBlockQuote     RichTextBlockType = "quote"     // 引用
+	RichTextBlockDivider   RichTextBlockType = "divider"   // 分隔线
+)
+
+// RichTextBlock 内容块，按类型使用对应的字段。
+// Text、列表项和表格单元格为行内HTML，保存时只保留白名单中的标签和属性；Code、Alt、Caption为纯文本
+type RichTextBlock struct {
+	ID       string              `json:"id"` // 评论可以通过ID锚定到内容块，缺少时由服务端生成
+	Type     RichTextBlockType   `json:"type"`
+	Level    int                 `json:"level,omitempty"`    // 标题级别1-6
+	Text     string              `json:"text,omitempty"`     // 标题、段落和引用
+	Ordered  bool                `json:"ordered,omitempty"`  // 有序列表
+	Items    []*RichTextListItem `json:"items,omitempty"`    // 列表项
+	Header   bool                `json:"header,omitempty"`   // 表格第一行是否为表头
+	Rows     [][]string          `json:"rows,omitempty"`     // 表格
+	URL      string              `json:"url,omitempty"`      // 图片地址，只允许http(s)或站内路径
+	Alt      string              `json:"alt,omitempty"`      // 图片替代文本
+	Caption  string              `json:"caption,omitempty"`  // 图片说明
+	Language string              `json:"language,omitempty"` // 代码语言
+	Code     string              `json:"code,omitempty"`     // 代码
+}
+
+// RichTextListItem 列表项，可以嵌套子列表
+type RichTextListItem struct {
+	Text    string              `json:"text"`
+	Checked *bool               `json:"checked,omitempty"` // 任务列表的完成状态，普通列表为空
+	Items   []*RichTextListItem `json:"items,omitempty"`
+}
+
+// TOCEntry 目录项，由Word文档的标题生成
+type TOCEntry struct {
+	BlockID string `json:"block_id"`
+	Level   int    `json:"level"`
+	Text    string `json:"text"` // 纯文本
+}
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// Workbook 表格文档的内容结构，以JSON保存在Document.Content中
+type Workbook struct {
+	Sheets []Sheet `json:"sheets"`
+}
+
+// Sheet 工作表，单元格按A1形式的引用稀疏保存
+type Sheet struct {
+	Name         string             `json:"name"`
+	Cells        map[string]*Cell   `json:"cells"`
+	ColumnWidths map[string]float64 `json:"column_widths,omitempty"` // 列宽，键为列名如"A"
+	Merges       []string           `json:"merges,omitempty"`        // 合并区域，如"A1:C1"
+	FrozenRows   int                `json:"frozen_rows,omitempty"`
+	FrozenCols   int                `json:"frozen_cols,omitempty"`
+}
+
+// Cell 单元格。Value为输入的值，支持字符串、数字和布尔值；
+// 设置Formula时Value被忽略，Result为服务端计算的结果，客户端提交的Result会被覆盖
+type Cell struct {
+	Value   interface{} `json:"v,omitempty"`
+	Formula string      `json:"f,omitempty"` // 不含开头的"="
+	Result  interface{} `json:"r,omitempty"` // 公式的计算结果，出错时为"#DIV/0!"等错误值
+	Style   *CellStyle  `json:"s,omitempty"`
+}
+
+// CellStyle 单元格样式
+type CellStyle struct {
+	Bold         bool    `json:"bold,omitempty"`
+	Italic       bool    `json:"italic,omitempty"`
+	Underline    bool    `json:"underline,omitempty"`
+	FontSize     float64 `json:"font_size,omitempty"`
+	Color        string  `json:"color,omitempty"`      // 文字颜色，#RRGGBB
+	Background   string  `json:"background,omitempty"` // 背景色，#RRGGBB
+	Align        string  `json:"align,omitempty"`      // left, center, right
+	NumberFormat string  `json:"number_format,omitempty"`
+}
+
+// 请求和响应结构
+type SheetCellUpdate struct {
+	Sheet string `json:"sheet" binding:"required,max=31"`
+	// Range 如"B2:D4"，单个单元格可写作"B2"
+	Range string `json:"range" binding:"required,max=32"`
+	// Cells 按行排列，行列数需与Range一致，null表示清空该单元格
+	Cells [][]*Cell `json:"cells" binding:"required"`
+}
+
+type PatchSheetCellsRequest struct {
+	Updates []SheetCellUpdate `json:"updates" binding:"required,min=1,max=100,dive"`
+}
+
+// SheetChangedCell 修改后值或计算结果发生变化的单元格，包括受影响的公式单元格
+type SheetChangedCell struct {
+	Sheet string `json:"sheet"`
+	Ref   string `json:"ref"`
+	Cell  *Cell  `json:"cell"` // 为null表示单元格已被清空
+}
+
+type PatchSheetCellsResponse struct {
+	Changed   []SheetChangedCell `json:"changed"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+type ImportXLSXRequest struct {
+	Title    string `form:"title" binding:"max=255"` // 为空时使用文件名
+	FolderID *uint  `form:"folder_id"`
+}
@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+const (
+	// SiteFeedLimit 订阅源中的最近更新文档数
+	SiteFeedLimit = 20
+	// SiteRecentLimit 站点首页展示的最近更新文档数
+	SiteRecentLimit = 10
+	// SiteCacheMaxAge 公开站点页面的缓存时间（秒）
+	SiteCacheMaxAge = 300
+)
+
+// PublicSite 将文件夹发布为公开的只读站点，站点包含该文件夹及其子文件夹中已发布的文档
+type PublicSite struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	FolderID    uint      `json:"folder_id" gorm:"not null;uniqueIndex"`
+	Slug        string    `json:"slug" gorm:"size:100;not null;uniqueIndex"`
+	Title       string    `json:"title" gorm:"size:200;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PublicPage 文档在站点中的固定地址，文档首次出现在站点中时生成，之后修改标题或移动文档不改变地址
+type PublicPage struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	SiteID     uint      `json:"site_id" gorm:"not null;uniqueIndex:idx_site_document;uniqueIndex:idx_site_slug"`
+	DocumentID uint      `json:"document_id" gorm:"not null;uniqueIndex:idx_site_document"`
+	Slug       string    `json:"slug" gorm:"size:200;not null;uniqueIndex:idx_site_slug"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// 请求和响应结构
+
+// CreateSiteRequest 发布站点，Slug为空时按文件夹名称生成，Title为空时使用文件夹名称
+type CreateSiteRequest struct {
+	FolderID    uint   `json:"folder_id" binding:"required"`
+	Slug        string `json:"slug" binding:"max=100"`
+	Title       string `json:"title" binding:"max=200"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+type UpdateSiteRequest struct {
+	Slug        string  `json:"slug" binding:"max=100"`
+	Title       string  `json:"title" binding:"max=200"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+}
+
+type SiteResponse struct {
+	ID          uint      `json:"id"`
+	FolderID    uint      `json:"folder_id"`
+	FolderName  string    `json:"folder_name"`
+	Slug        string    `json:"slug"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SitePageResponse 站点中当前公开的文档及其地址
+type SitePageResponse struct {
+	DocumentID uint      `json:"document_id"`
+	Title      string    `json:"title"`
+	Slug       string    `json:"slug"`
+	URL        string    `json:"url"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SiteNavNode 站点导航中的文件夹或文档，文件夹的URL为空
+type SiteNavNode struct {
+	Title    string
+	URL      string
+	Current  bool
+	Children []SiteNavNode
+}
+
+// SiteView 渲染站点页面所需的数据
+type SiteView struct {
+	Site   *PublicSite
+	URL    string // 站点首页地址
+	Nav    []SiteNavNode
+	Pages  []SitePageResponse // 按更新时间倒序
+	Author string
+}
+
+// SitePageView 渲染文档页面所需的数据，Content为过滤后的HTML
+type SitePageView struct {
+	SiteView
+	Page    SitePageResponse
+	Content string
+	TOC     []TOCEntry
+}
+
+// SiteFeedEntry 订阅源中的文档，Summary为纯文本摘要
+type SiteFeedEntry struct {
+	SitePageResponse
+	Summary   string
+	CreatedAt time.Time
+}
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+// Presentation 演示文稿的内容结构，以JSON保存在Document.Content中
+type Presentation struct {
+	Aspect SlideAspect `json:"aspect"`
+	Slides []*Slide    `json:"slides"`
+}
+
+// SlideAspect 幻灯片的宽高比
+type SlideAspect string
+
+const (
+	SlideAspectWide     SlideAspect = "16:9"
+	SlideAspectStandard SlideAspect = "4:3"
+)
+
+// SlideLayout 幻灯片版式，决定使用哪些内容字段及其位置
+type SlideLayout string
+
+const (
+	SlideLayoutTitle     SlideLayout = "title"      // 标题页：标题和副标题
+	SlideLayoutSection   SlideLayout = "section"    // 节标题：标题和副标题
+	SlideLayoutContent   SlideLayout = "content"    // 标题和正文
+	SlideLayoutTwoColumn SlideLayout = "two_column" // 标题和左右两栏正文
+	SlideLayoutImage     SlideLayout = "image"      // 标题和图片
+	SlideLayoutBlank     SlideLayout = "blank"      // 空白
+)
+
+// Slide 幻灯片，ID在演示文稿内唯一，保存时由服务端为缺少ID的幻灯片补充
+type Slide struct {
+	ID         string           `json:"id"`
+	Layout     SlideLayout      `json:"layout"`
+	Title      string           `json:"title,omitempty"`
+	Subtitle   string           `json:"subtitle,omitempty"`   // 标题页和节标题使用
+	Body       []SlideParagraph `json:"body,omitempty"`       // 正文，两栏版式中为左栏
+	Body2      []SlideParagraph `json:"body2,omitempty"`      // 两栏版式的右栏
+	Image      *SlideImage      `json:"image,omitempty"`      // 图片版式使用
+	Notes      string           `json:"notes,omitempty"`      // 演讲者备注
+	Background string           `json:"background,omitempty"` // 背景色，#RRGGBB
+	Hidden     bool             `json:"hidden,omitempty"`     // 放映时跳过
+}
+
+// SlideParagraph 正文段落，Level为项目符号的缩进级别，从0开始
+type SlideParagraph struct {
+	Text  string `json:"text"`
+	Level int    `json:"level,omitempty"`
+}
+
+type SlideImage struct {
+	URL string `json:"url"`
+	Alt string `json:"alt,omitempty"`
+}
+
+// 请求和响应结构
+
+// AddSlideRequest 新增幻灯片，Index为插入的位置，为空时追加到末尾
+type AddSlideRequest struct {
+	Index *int `json:"index" binding:"omitempty,min=0"`
+	Slide
+}
+
+type UpdateSlideNotesRequest struct {
+	Notes string `json:"notes"`
+}
+
+// ReorderSlidesRequest 按给定顺序排列幻灯片，需要包含全部幻灯片的ID
+type ReorderSlidesRequest struct {
+	SlideIDs []string `json:"slide_ids" binding:"required,min=1"`
+}
+
+// PresentationResponse 修改后的完整演示文稿，SlideID为新增、复制或修改的幻灯片ID
+type PresentationResponse struct {
+	Presentation *Presentation `json:"presentation"`
+	SlideID      string        `json:"slide_id,omitempty"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+type ImportPPTXRequest struct {
+	Title    string `form:"title" binding:"max=255"` // 为空时使用文件名
+	FolderID *uint  `form:"folder_id"`
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TemplateScope 模板范围
+type TemplateScope string
+
+const (
+	TemplateScopeSystem   TemplateScope = "system"   // 系统内置，所有用户可用，不可修改
+	TemplateScopePersonal TemplateScope = "personal" // 个人模板，仅创建者可用
+	TemplateScopeTeam     TemplateScope = "team"     // 团队模板，站内所有用户可用，仅创建者可修改
+)
+
+// TemplateVariable 模板中的占位符，标题和内容中写作{{name}}
+type TemplateVariable struct {
+	Name     string `json:"name" binding:"required,max=50"`
+	Label    string `json:"label" binding:"max=100"`
+	Default  string `json:"default" binding:"max=500"`
+	Required bool   `json:"required"`
+}
+
+// BuiltinTemplateVariables 无需声明即可使用的占位符，创建文档时自动填充
+var BuiltinTemplateVariables = []TemplateVariable{
+	{Name: "date", Label: "当前日期"},
+	{Name: "time", Label: "当前时间"},
+	{Name: "year", Label: "当前年份"},
+	{Name: "month", Label: "当前月份"},
+	{Name: "week", Label: "当前周数"},
+	{Name: "user", Label: "当前用户"},
+}
+
+type DocumentTemplate struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	UserID           uint           `json:"user_id" gorm:"index"` // 系统模板为0
+	Scope            TemplateScope  `json:"scope" gorm:"size:20;not null;index"`
+	Name             string         `json:"name" gorm:"size:100;not null"`
+	Description      string         `json:"description" gorm:"size:500"`
+	Category         string         `json:"category" gorm:"size:50"`
+	Type             DocumentType   `json:"type" gorm:"size:20;not null"`
+	Title            string         `json:"title" gorm:"size:255;not null"` // 新文档的标题，可包含占位符
+	Content          string         `json:"content" gorm:"type:longtext"`
+	Tags             string         `json:"tags" gorm:"size:500"`
+	Variables        string         `json:"variables" gorm:"type:text"` // 声明的占位符，JSON数组
+	SourceDocumentID *uint          `json:"source_document_id"`
+	UsageCount       int            `json:"usage_count" gorm:"default:0"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// VariableList 返回声明的占位符
+func (t *DocumentTemplate) VariableList() []TemplateVariable {
+	var variables []TemplateVariable
+	if t.Variables != "" {
+		json.Unmarshal([]byte(t.Variables), &variables)
+	}
+	return variables
+}
+
+// 请求和响应结构
+type TemplateListRequest struct {
+	Page     int           `form:"page" binding:"min=1"`
+	PageSize int           `form:"page_size" binding:"min=1,max=100"`
+	Scope    TemplateScope `form:"scope"`
+	Type     DocumentType  `form:"type"`
+	Category string        `form:"category"`
+	Keyword  string        `form:"keyword"`
+}
+
+// CreateTemplateRequest 将已有文档保存为模板
+type CreateTemplateRequest struct {
+	DocumentID  uint               `json:"document_id" binding:"required"`
+	Name        string             `json:"name" binding:"required,max=100"`
+	Description string             `json:"description" binding:"max=500"`
+	Category    string             `json:"category" binding:"max=50"`
+	Scope       TemplateScope      `json:"scope" binding:"omitempty,oneof=personal team"` // 默认personal
+	Title       string             `json:"title" binding:"max=255"`                       // 默认使用文档标题
+	Variables   []TemplateVariable `json:"variables" binding:"max=50,dive"`
+}
+
+type UpdateTemplateRequest struct {
+	Name        string              `json:"name" binding:"max=100"`
+	Description *string             `json:"description" binding:"omitempty,max=500"`
+	Category    *string             `json:"category" binding:"omitempty,max=50"`
+	Scope       TemplateScope       `json:"scope" binding:"omitempty,oneof=personal team"`
+	Title       string              `json:"title" binding:"max=255"`
+	Variables   *[]TemplateVariable `json:"variables" binding:"omitempty,max=50,dive"`
+}
+
+type TemplateResponse struct {
+	ID          uint               `json:"id"`
+	Scope       TemplateScope      `json:"scope"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Category    string             `json:"category"`
+	Type        DocumentType       `json:"type"`
+	Title       string             `json:"title"`
+	Tags        string             `json:"tags"`
+	Variables   []TemplateVariable `json:"variables"`
+	UsageCount  int                `json:"usage_count"`
+	Editable    bool               `json:"editable"` // 当前用户是否可修改
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type TemplateDetailResponse struct {
+	TemplateResponse
+	Content string `json:"content"`
+}
+
+// RenderedTemplate 填充占位符后的模板内容
+type RenderedTemplate struct {
+	Type    DocumentType
+	Title   string
+	Content string
+	Tags    string
+}
@@ -1,22 +1,70 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"time"
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	UserRoleUser    = "user"    // 普通用户
+	UserRoleAdmin   = "admin"   // 管理员
+	UserRoleAuditor = "auditor" // 审计员
+)
+
+// 用户状态
+const (
+	UserStatusDisabled = 0 // 禁用
+	UserStatusActive   = 1 // 激活
+)
+
+// 头像尺寸（像素）
+const (
+	AvatarSizeLarge  = 256
+	AvatarSizeMedium = 128
+	AvatarSizeSmall  = 48
+)
+
+// AvatarSizes 上传头像时生成的标准尺寸
+var AvatarSizes = map[string]int{
+	"large":  AvatarSizeLarge,
+	"medium": AvatarSizeMedium,
+	"small":  AvatarSizeSmall,
+}
+
+// DefaultAvatarURL 未上传头像时使用的默认头像地址，按用户名的Hash生成，不暴露用户ID
+func DefaultAvatarURL(username string) string {
+	return fmt.Sprintf("/api/v1/identicons/%s", IdenticonKey(username))
+}
+
+// IdenticonKey 默认头像地址中的标识，为用户名的SHA-256
+func IdenticonKey(username string) string {
+	sum := sha256.Sum256([]byte(username))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsValidUserRole 校验角色是否合法
+func IsValidUserRole(role string) bool {
+	return role == UserRoleUser || role == UserRoleAdmin || role == UserRoleAuditor
+}
+
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"uniqueIndex;size:50;not null"`
-	Email     string         `json:"email" gorm:"uniqueIndex;size:100;not null"`
-	Password  string         `json:"-" gorm:"size:255;not null"`
-	Avatar    string         `json:"avatar" gorm:"size:255"`
-	Nickname  string         `json:"nickname" gorm:"size:50"`
-	Status    int            `json:"status" gorm:"default:1"` // 1:激活 0:禁用
-	LastLogin *time.Time     `json:"last_login"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Username     string         `json:"username" gorm:"uniqueIndex;size:50;not null"`
+	Email        string         `json:"email" gorm:"uniqueIndex;size:100;not null"`
+	Password     string         `json:"-" gorm:"size:255;not null"`
+	Avatar       string         `json:"avatar" gorm:"size:255"`
+	Nickname     string         `json:"nickname" gorm:"size:50"`
+	Status       int            `json:"status" gorm:"default:1"`                // 1:激活 0:禁用
+	Role         string         `json:"role" gorm:"size:20;default:user;index"` // user/admin/auditor
+	TokenVersion int            `json:"-" gorm:"default:0"`                     // 令牌版本，递增后已签发的JWT失效
+	LastLogin    *time.Time     `json:"last_login"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 type UserProfile struct {
@@ -26,6 +74,7 @@ type UserProfile struct {
 	Avatar   string `json:"avatar"`
 	Nickname string `json:"nickname"`
 	Status   int    `json:"status"`
+	Role     string `json:"role"`
 }
 
 type LoginRequest struct {
@@ -43,4 +92,9 @@ type RegisterRequest struct {
 type UpdateProfileRequest struct {
 	Nickname string `json:"nickname"`
 	Avatar   string `json:"avatar"`
-} 
\ No newline at end of file
+}
+
+type AvatarResponse struct {
+	Avatar string            `json:"avatar"`
+	Sizes  map[string]string `json:"sizes"` // large/medium/small
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSecretPrefix Webhook签名密钥前缀
+const WebhookSecretPrefix = "whsec_"
+
+// WebhookEvent Webhook事件类型
+type WebhookEvent string
+
+const (
+	WebhookEventDocumentCreated   WebhookEvent = "document.created"   // 创建文档
+	WebhookEventDocumentUpdated   WebhookEvent = "document.updated"   // 更新文档
+	WebhookEventDocumentPublished WebhookEvent = "document.published" // 发布文档
+	WebhookEventDocumentDeleted   WebhookEvent = "document.deleted"   // 删除文档
+	WebhookEventFolderCreated     WebhookEvent = "folder.created"     // 创建文件夹
+	WebhookEventFolderUpdated     WebhookEvent = "folder.updated"     // 重命名文件夹
+	WebhookEventFolderMoved       WebhookEvent = "folder.moved"       // 移动文件夹
+	WebhookEventFolderDeleted     WebhookEvent = "folder.deleted"     // 删除文件夹
+	WebhookEventShareCreated      WebhookEvent = "share.created"      // 创建分享链接
+	WebhookEventPing              WebhookEvent = "ping"               // 测试投递，不可订阅
+)
+
+// WebhookEvents 所有可订阅的事件
+var WebhookEvents = []WebhookEvent{
+	WebhookEventDocumentCreated,
+	WebhookEventDocumentUpdated,
+	WebhookEventDocumentPublished,
+	WebhookEventDocumentDeleted,
+	WebhookEventFolderCreated,
+	WebhookEventFolderUpdated,
+	WebhookEventFolderMoved,
+	WebhookEventFolderDeleted,
+	WebhookEventShareCreated,
+}
+
+// IsValidWebhookEvent 校验事件是否可订阅
+func IsValidWebhookEvent(event WebhookEvent) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type Webhook struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	UserID         uint           `json:"user_id" gorm:"not null;index"`
+	Name           string         `json:"name" gorm:"size:100;not null"`
+	URL            string         `json:"url" gorm:"size:500;not null"`
+	Secret         string         `json:"-" gorm:"size:80;not null"`       // HMAC签名密钥
+	Events         string         `json:"events" gorm:"size:500;not null"` // 订阅的事件，逗号分隔
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+	LastDeliveryAt *time.Time     `json:"last_delivery_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// EventList 返回订阅的事件列表
+func (w *Webhook) EventList() []WebhookEvent {
+	if w.Events == "" {
+		return nil
+	}
+	parts := strings.Split(w.Events, ",")
+	events := make([]WebhookEvent, 0, len(parts))
+	for _, part := range parts {
+		events = append(events, WebhookEvent(part))
+	}
+	return events
+}
+
+// Subscribes 判断是否订阅了指定事件
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.EventList() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus 投递状态
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending  WebhookDeliveryStatus = "pending"  // 等待投递
+	WebhookDeliveryRetrying WebhookDeliveryStatus = "retrying" // 投递失败，等待重试
+	WebhookDeliverySuccess  WebhookDeliveryStatus = "success"  // 投递成功
+	WebhookDeliveryFailed   WebhookDeliveryStatus = "failed"   // 重试次数用尽
+)
+
+// WebhookDelivery 投递记录，每个事件对每个Webhook生成一条，重试时更新同一条记录
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	WebhookID      uint                  `json:"webhook_id" gorm:"not null;index"`
+	Event          WebhookEvent          `json:"event" gorm:"size:50;not null"`
+	Payload        string                `json:"payload" gorm:"type:mediumtext"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"size:20;not null;index:idx_status_next"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"index:idx_status_next"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body" gorm:"type:text"`
+	Error          string                `json:"error" gorm:"size:1000"`
+	DurationMs     int64                 `json:"duration_ms"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// WebhookPayload 投递给接收方的请求体
+type WebhookPayload struct {
+	Event     WebhookEvent `json:"event"`
+	UserID    uint         `json:"user_id"`
+	Timestamp time.Time    `json:"timestamp"`
+	Data      interface{}  `json:"data"`
+}
+
+// 请求和响应结构
+type CreateWebhookRequest struct {
+	Name   string         `json:"name" binding:"required,max=100"`
+	URL    string         `json:"url" binding:"required,url,max=500"`
+	Events []WebhookEvent `json:"events" binding:"required,min=1"`
+}
+
+type UpdateWebhookRequest struct {
+	Name     string         `json:"name" binding:"max=100"`
+	URL      string         `json:"url" binding:"omitempty,url,max=500"`
+	Events   []WebhookEvent `json:"events"`
+	IsActive *bool          `json:"is_active"`
+}
+
+type WebhookResponse struct {
+	ID             uint           `json:"id"`
+	Name           string         `json:"name"`
+	URL            string         `json:"url"`
+	Events         []WebhookEvent `json:"events"`
+	IsActive       bool           `json:"is_active"`
+	LastDeliveryAt *time.Time     `json:"last_delivery_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// WebhookSecretResponse 创建或轮换密钥时返回，密钥只展示这一次
+type WebhookSecretResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryListRequest struct {
+	Page     int                   `form:"page" binding:"min=1"`
+	PageSize int                   `form:"page_size" binding:"min=1,max=100"`
+	Status   WebhookDeliveryStatus `form:"status"`
+	Event    WebhookEvent          `form:"event"`
+}
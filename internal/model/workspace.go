@@ -15,11 +15,15 @@ type WorkspaceStats struct {
 
 // DashboardData 仪表板数据
 type DashboardData struct {
-	Stats             WorkspaceStats      `json:"stats"`
-	RecentDocuments   []DocumentResponse  `json:"recent_documents"`
-	RecentActivities  []ActivityResponse  `json:"recent_activities"`
-	DocumentsByType   map[string]int64    `json:"documents_by_type"`
-	ActivitiesByDay   []ActivityByDay     `json:"activities_by_day"`
+	Stats             WorkspaceStats             `json:"stats"`
+	RecentDocuments   []DocumentResponse         `json:"recent_documents"`
+	RecentActivities  []ActivityResponse         `json:"recent_activities"`
+	DocumentsByType   map[string]int64           `json:"documents_by_type"`
+	ActivitiesByDay   []ActivityByDay            `json:"activities_by_day"`
+	Pinned            []MarkedItemResponse       `json:"pinned"`             // 置顶的文档和文件夹，按置顶顺序
+	Favorites         []MarkedItemResponse       `json:"favorites"`          // 最近收藏的文档和文件夹
+	RecentlyOpened    []AccessedDocumentResponse `json:"recently_opened"`    // 最近打开的文档
+	FrequentDocuments []AccessedDocumentResponse `json:"frequent_documents"` // 常用文档
 }
 
 // ActivityByDay 按天统计的活动数据
@@ -41,9 +45,11 @@ func GetQuickActions() []QuickAction {
 	return []QuickAction{
 		{Type: "word", Title: "Word文档", Icon: "file-word", URL: "/documents/create?type=word"},
 		{Type: "excel", Title: "Excel表格", Icon: "file-excel", URL: "/documents/create?type=excel"},
+		{Type: "ppt", Title: "PPT演示文稿", Icon: "file-ppt", URL: "/documents/create?type=ppt"},
 		{Type: "mindmap", Title: "思维导图", Icon: "share-alt", URL: "/documents/create?type=mindmap"},
 		{Type: "note", Title: "个人随笔", Icon: "edit", URL: "/documents/create?type=note"},
 		{Type: "ai_draft", Title: "AI快速起草", Icon: "robot", URL: "/documents/create?type=ai_draft"},
+		{Type: "template", Title: "从模板新建", Icon: "appstore", URL: "/templates"},
 		{Type: "import", Title: "文件导入", Icon: "upload", URL: "/documents/import"},
 	}
 } 
\ No newline at end of file
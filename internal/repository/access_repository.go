@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AccessRepository interface {
+	// Record 批量写入打开记录，已有记录时累加打开次数并保留较晚的打开时间，
+	// 原有得分按两次打开的间隔衰减后加上本次的打开次数
+	Record(accesses []model.DocumentAccess) error
+	// ListRecent 按最近打开时间倒序获取用户的打开记录，已删除的文档不返回
+	ListRecent(userID uint, limit int) ([]model.DocumentAccess, error)
+	// ListFrequent 获取用户在since之后打开过的文档记录，按衰减到now的得分倒序
+	ListFrequent(userID uint, since, now time.Time, limit int) ([]model.DocumentAccess, error)
+}
+
+type accessRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessRepository(db *gorm.DB) AccessRepository {
+	return &accessRepository{db: db}
+}
+
+func (r *accessRepository) Record(accesses []model.DocumentAccess) error {
+	if len(accesses) == 0 {
+		return nil
+	}
+	// 赋值按顺序执行，得分必须在更新最近打开时间之前计算；较早的一方按两次打开的间隔衰减
+	halfLife := model.FrecencyHalfLife.Seconds()
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "document_id"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "score"}, Value: gorm.Expr(
+				"IF(VALUES(last_opened_at) >= last_opened_at, "+
+					"score * POW(2, -TIMESTAMPDIFF(SECOND, last_opened_at, VALUES(last_opened_at)) / ?) + VALUES(score), "+
+					"score + VALUES(score) * POW(2, -TIMESTAMPDIFF(SECOND, VALUES(last_opened_at), last_opened_at) / ?))",
+				halfLife, halfLife)},
+			{Column: clause.Column{Name: "open_count"}, Value: gorm.Expr("open_count + VALUES(open_count)")},
+			{Column: clause.Column{Name: "last_opened_at"}, Value: gorm.Expr("GREATEST(last_opened_at, VALUES(last_opened_at))")},
+		},
+	}).Create(&accesses).Error
+}
+
+func (r *accessRepository) ListRecent(userID uint, limit int) ([]model.DocumentAccess, error) {
+	var accesses []model.DocumentAccess
+	err := r.ownedDocuments(userID).
+		Order("document_accesses.last_opened_at DESC").
+		Limit(limit).Find(&accesses).Error
+	return accesses, err
+}
+
+func (r *accessRepository) ListFrequent(userID uint, since, now time.Time, limit int) ([]model.DocumentAccess, error) {
+	var accesses []model.DocumentAccess
+	err := r.ownedDocuments(userID).
+		Where("document_accesses.last_opened_at >= ?", since).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "document_accesses.score * POW(2, TIMESTAMPDIFF(SECOND, ?, document_accesses.last_opened_at) / ?) DESC, document_accesses.last_opened_at DESC",
+			Vars: []interface{}{now, model.FrecencyHalfLife.Seconds()},
+		}}).
+		Limit(limit).Find(&accesses).Error
+	return accesses, err
+}
+
+// ownedDocuments 用户对自己未删除文档的打开记录，文档转移或删除后不再返回
+func (r *accessRepository) ownedDocuments(userID uint) *gorm.DB {
+	return r.db.Model(&model.DocumentAccess{}).
+		Select("document_accesses.*").
+		Joins("JOIN documents ON documents.id = document_accesses.document_id AND documents.user_id = document_accesses.user_id AND documents.deleted_at IS NULL").
+		Where("document_accesses.user_id = ?", userID)
+}
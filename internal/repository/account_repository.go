@@ -0,0 +1,497 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AccountRepository interface {
+	// 数据导出任务
+	CreateExport(export *model.DataExport) error
+	UpdateExport(export *model.DataExport) error
+	GetExportByIDAndUserID(id, userID uint) (*model.DataExport, error)
+	ListExportsByUserID(userID uint) ([]model.DataExport, error)
+	ListExportsByStatus(status model.DataExportStatus) ([]model.DataExport, error)
+	ListExpiredExports(now time.Time) ([]model.DataExport, error)
+	DeleteExportsByUserID(userID uint) error
+
+	// 注销申请
+	CreateDeletion(deletion *model.AccountDeletion) error
+	UpdateDeletion(deletion *model.AccountDeletion) error
+	GetPendingDeletion(userID uint) (*model.AccountDeletion, error)
+	ListDueDeletions(now time.Time) ([]model.AccountDeletion, error)
+
+	// 导出用户数据
+	GetUserFolders(userID uint) ([]model.Folder, error)
+	FindUserDocuments(userID uint, fn func(documents []model.Document) error) error
+	// GetUserAttachments 获取用户未删除的文档的附件
+	GetUserAttachments(userID uint) ([]model.Attachment, error)
+	FindUserActivities(userID uint, fn func(activities []model.Activity) error) error
+
+	// 注销时处理用户数据
+	TransferContent(fromUserID, toUserID uint, rootFolderName string) error
+	PurgeContent(userID uint) error
+	AnonymizeUser(userID uint) error
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) CreateExport(export *model.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+func (r *accountRepository) UpdateExport(export *model.DataExport) error {
+	return r.db.Save(export).Error
+}
+
+func (r *accountRepository) GetExportByIDAndUserID(id, userID uint) (*model.DataExport, error) {
+	var export model.DataExport
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *accountRepository) ListExportsByUserID(userID uint) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&exports).Error
+	return exports, err
+}
+
+func (r *accountRepository) ListExportsByStatus(status model.DataExportStatus) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.db.Where("status = ?", status).Order("id ASC").Find(&exports).Error
+	return exports, err
+}
+
+func (r *accountRepository) ListExpiredExports(now time.Time) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.db.Where("status = ? AND expires_at < ?", model.DataExportStatusCompleted, now).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *accountRepository) DeleteExportsByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.DataExport{}).Error
+}
+
+func (r *accountRepository) CreateDeletion(deletion *model.AccountDeletion) error {
+	return r.db.Create(deletion).Error
+}
+
+func (r *accountRepository) UpdateDeletion(deletion *model.AccountDeletion) error {
+	return r.db.Save(deletion).Error
+}
+
+func (r *accountRepository) GetPendingDeletion(userID uint) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+	err := r.db.Where("user_id = ? AND status = ?", userID, model.AccountDeletionStatusPending).
+		First(&deletion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (r *accountRepository) ListDueDeletions(now time.Time) ([]model.AccountDeletion, error) {
+	var deletions []model.AccountDeletion
+	err := r.db.Where("status = ? AND scheduled_at <= ?", model.AccountDeletionStatusPending, now).
+		Order("scheduled_at ASC").Find(&deletions).Error
+	return deletions, err
+}
+
+func (r *accountRepository) GetUserFolders(userID uint) ([]model.Folder, error) {
+	var folders []model.Folder
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&folders).Error
+	return folders, err
+}
+
+func (r *accountRepository) FindUserDocuments(userID uint, fn func(documents []model.Document) error) error {
+	var documents []model.Document
+	return r.db.Where("user_id = ?", userID).Order("id ASC").
+		FindInBatches(&documents, 100, func(tx *gorm.DB, batch int) error {
+			return fn(documents)
+		}).Error
+}
+
+func (r *accountRepository) GetUserAttachments(userID uint) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	err := r.db.Where("user_id = ? AND document_id IN (?)", userID,
+		r.db.Model(&model.Document{}).Select("id").Where("user_id = ?", userID)).
+		Order("id ASC").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *accountRepository) FindUserActivities(userID uint, fn func(activities []model.Activity) error) error {
+	var activities []model.Activity
+	return r.db.Where("user_id = ?", userID).Order("id ASC").
+		FindInBatches(&activities, 1000, func(tx *gorm.DB, batch int) error {
+			return fn(activities)
+		}).Error
+}
+
+// TransferContent 将用户的文件夹和文档转移给另一个用户，统一放在新建的根文件夹下。
+// 已在回收站中的内容直接清理，转移后的文档取消分享。
+func (r *accountRepository) TransferContent(fromUserID, toUserID uint, rootFolderName string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := purgeDeleted(tx, fromUserID); err != nil {
+			return err
+		}
+
+		root := &model.Folder{Name: rootFolderName, UserID: toUserID}
+		if err := tx.Create(root).Error; err != nil {
+			return err
+		}
+
+		// 原根目录下的内容移动到新建的根文件夹
+		err := tx.Model(&model.Folder{}).
+			Where("user_id = ? AND parent_id IS NULL", fromUserID).
+			Update("parent_id", root.ID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.Folder{}).
+			Where("user_id = ?", fromUserID).
+			Update("user_id", toUserID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.Document{}).
+			Where("user_id = ? AND folder_id IS NULL", fromUserID).
+			Update("folder_id", root.ID).Error
+		if err != nil {
+			return err
+		}
+		// 文档向量、思维导图节点索引和文档链接按所有者建立，随文档一并转移
+		err = tx.Model(&model.DocumentEmbedding{}).
+			Where("user_id = ?", fromUserID).
+			Update("user_id", toUserID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.MindMapNodeIndex{}).
+			Where("user_id = ?", fromUserID).
+			Update("user_id", toUserID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.DocumentLink{}).
+			Where("user_id = ?", fromUserID).
+			Update("user_id", toUserID).Error
+		if err != nil {
+			return err
+		}
+		// 附件文件仍保留在原用户的目录下
+		err = tx.Model(&model.Attachment{}).
+			Where("user_id = ?", fromUserID).
+			Update("user_id", toUserID).Error
+		if err != nil {
+			return err
+		}
+		// 与分享链接一样，转移后公开站点取消发布
+		if err := deleteUserSites(tx, fromUserID); err != nil {
+			return err
+		}
+		// 收藏、置顶和打开记录属于个人偏好，不随内容转移
+		if err := deleteUserMarks(tx, fromUserID); err != nil {
+			return err
+		}
+		// 团队模板仍供其他用户使用，随内容一并转移
+		err = tx.Model(&model.DocumentTemplate{}).
+			Where("user_id = ? AND scope = ?", fromUserID, model.TemplateScopeTeam).
+			Update("user_id", toUserID).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.Document{}).
+			Where("user_id = ?", fromUserID).
+			Updates(map[string]interface{}{
+				"user_id":      toUserID,
+				"is_shared":    false,
+				"share_token":  "",
+				"share_expiry": nil,
+			}).Error
+	})
+}
+
+// PurgeContent 彻底删除用户的文档及其评论、历史版本、分享对象、向量、思维导图节点索引、文档链接、附件记录、审核记录、公开站点、收藏和置顶、打开记录、文件夹、模板和回收站记录
+func (r *accountRepository) PurgeContent(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Where("document_id IN (?)", tx.Unscoped().Model(&model.Document{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&model.Comment{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("document_id IN (?)", tx.Unscoped().Model(&model.Document{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&model.DocumentVersion{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("document_id IN (?)", tx.Unscoped().Model(&model.Document{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&model.ShareRecipient{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ?", userID).Delete(&model.DocumentEmbedding{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ?", userID).Delete(&model.MindMapNodeIndex{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ?", userID).Delete(&model.DocumentLink{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ?", userID).Delete(&model.Attachment{}).Error
+		if err != nil {
+			return err
+		}
+		if err := deleteUserSites(tx, userID); err != nil {
+			return err
+		}
+		if err := deleteUserMarks(tx, userID); err != nil {
+			return err
+		}
+		reviews := tx.Model(&model.DocumentReview{}).Select("id").
+			Where("document_id IN (?)", tx.Unscoped().Model(&model.Document{}).Select("id").Where("user_id = ?", userID))
+		err = tx.Where("review_id IN (?)", reviews).Delete(&model.DocumentReviewer{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("document_id IN (?)", tx.Unscoped().Model(&model.Document{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&model.DocumentReview{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("user_id = ?", userID).Delete(&model.Document{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("user_id = ?", userID).Delete(&model.Folder{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("user_id = ?", userID).Delete(&model.DocumentTemplate{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecycleItem{}).Error
+	})
+}
+
+// AnonymizeUser 清除用户的个人信息并注销账号：
+//   - 足迹保留操作类型和资源，去除IP、设备信息和驳回意见，描述中的用户名和昵称替换为占位名称。
+//     足迹的操作人按UserID关联显示，其他用户的足迹不做修改
+//   - 发表在其他用户文档中的评论保留位置，内容和引用文本清空；记录了@该用户的评论中的@用户名替换为占位名称
+//   - 删除收到的通知和通知设置，其他用户收到的由该用户触发的通知标题中的名称替换为占位名称，内容清空
+//
+// 审计日志按Hash链防篡改，记录的IP、设备信息和Before/After快照原样保留，只能通过审计日志的保留期限清理
+func (r *accountRepository) AnonymizeUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		names := anonymizedNames(&user)
+
+		err := tx.Model(&model.Activity{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"ip_address": "",
+				"user_agent": "",
+			}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.Activity{}).
+			Where("user_id = ? AND type = ? AND description LIKE ?", userID, model.ActivityTypeReview, "驳回审核：%").
+			Update("description", "驳回审核").Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Model(&model.Comment{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"content": model.DeletedCommentContent,
+				"quote":   "",
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", userID).Delete(&model.Notification{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ?", userID).Delete(&model.NotificationPreference{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.Notification{}).Where("actor_id = ?", userID).Update("content", "").Error
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			pattern := "%" + escapeLike(name) + "%"
+			err = tx.Model(&model.Activity{}).Where("user_id = ? AND description LIKE ?", userID, pattern).
+				Update("description", gorm.Expr("REPLACE(description, ?, ?)", name, model.DeletedUserName)).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&model.Notification{}).Where("actor_id = ? AND title LIKE ?", userID, pattern).
+				Update("title", gorm.Expr("REPLACE(title, ?, ?)", name, model.DeletedUserName)).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := anonymizeMentions(tx, &user); err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Where("user_id = ?", userID).Delete(&model.APIToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("webhook_id IN (?)", tx.Unscoped().Model(&model.Webhook{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&model.WebhookDelivery{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Where("user_id = ?", userID).Delete(&model.Webhook{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Where("user_id = ? AND scope = ?", userID, model.TemplateScopePersonal).
+			Delete(&model.DocumentTemplate{}).Error
+		if err != nil {
+			return err
+		}
+
+		// 起草记录中的提示词属于个人数据
+		err = tx.Where("user_id = ?", userID).Delete(&model.AIDraft{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ?", userID).Delete(&model.AISuggestion{}).Error
+		if err != nil {
+			return err
+		}
+
+		// 用户名和邮箱有唯一索引，使用ID生成占位值
+		err = tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"username":      fmt.Sprintf("deleted_%d", userID),
+				"email":         fmt.Sprintf("deleted_%d@deleted.invalid", userID),
+				"nickname":      model.DeletedUserName,
+				"avatar":        "",
+				"password":      "",
+				"status":        model.UserStatusDisabled,
+				"token_version": gorm.Expr("token_version + 1"),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.User{}, userID).Error
+	})
+}
+
+// purgeDeleted 清理已在回收站中的文档及其附件记录和文件夹
+func purgeDeleted(tx *gorm.DB, userID uint) error {
+	err := tx.Where("document_id IN (?)", tx.Unscoped().Model(&model.Document{}).Select("id").Where("user_id = ? AND deleted_at IS NOT NULL", userID)).
+		Delete(&model.Attachment{}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Delete(&model.Document{}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Delete(&model.Folder{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecycleItem{}).Error
+}
+
+// deleteUserSites 删除用户的公开站点及其页面地址
+func deleteUserSites(tx *gorm.DB, userID uint) error {
+	err := tx.Where("site_id IN (?)", tx.Model(&model.PublicSite{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&model.PublicPage{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&model.PublicSite{}).Error
+}
+
+// anonymizedNames 注销时需要从其他记录中替换的用户名和昵称，过短的昵称容易误伤其他内容，不做替换
+func anonymizedNames(user *model.User) []string {
+	names := []string{user.Username}
+	if user.Nickname != "" && user.Nickname != user.Username && utf8.RuneCountInString(user.Nickname) >= 2 {
+		names = append(names, user.Nickname)
+	}
+	return names
+}
+
+// anonymizeMentions 将@了该用户的评论中的@用户名替换为占位名称，按评论记录的被@用户ID查找，
+// 只替换完整的用户名，以该用户名为前缀的其他用户名不受影响
+func anonymizeMentions(tx *gorm.DB, user *model.User) error {
+	var comments []model.Comment
+	err := tx.Unscoped().Select("id", "content").
+		Where("CONCAT(',', mentions, ',') LIKE ?", "%,"+strconv.FormatUint(uint64(user.ID), 10)+",%").
+		Find(&comments).Error
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		content := model.ReplaceMention(comment.Content, user.Username, model.DeletedUserName)
+		if content == comment.Content {
+			continue
+		}
+		err = tx.Unscoped().Model(&model.Comment{}).Where("id = ?", comment.ID).
+			UpdateColumn("content", content).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLike 转义LIKE模式中的通配符，使用默认的转义字符"\"
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// deleteUserMarks 删除用户的收藏、置顶和文档打开记录
+func deleteUserMarks(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.Favorite{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&model.Pin{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&model.DocumentAccess{}).Error
+}
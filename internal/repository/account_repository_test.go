@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testStatement 执行的SQL语句和参数
+type testStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// testDB 记录执行的语句，查询时按表名返回预设的结果，不连接数据库
+type testDB struct {
+	execs []testStatement
+	rows  map[string]testRows // 表名对应的查询结果
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (d *testDB) Connect(ctx context.Context) (driver.Conn, error) { return &testConn{db: d}, nil }
+func (d *testDB) Driver() driver.Driver                            { return nil }
+
+type testConn struct {
+	db *testDB
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *testConn) Commit() error             { return nil }
+func (c *testConn) Rollback() error           { return nil }
+
+func (c *testConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.execs = append(c.db.execs, testStatement{query: query, args: namedValues(args)})
+	return driver.RowsAffected(1), nil
+}
+
+func (c *testConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	for table, rows := range c.db.rows {
+		if strings.Contains(query, "FROM `"+table+"`") {
+			return &testResult{rows: rows}, nil
+		}
+	}
+	return &testResult{}, nil
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+type testResult struct {
+	rows testRows
+	next int
+}
+
+func (r *testResult) Columns() []string { return r.rows.columns }
+func (r *testResult) Close() error      { return nil }
+
+func (r *testResult) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows.values) {
+		return io.EOF
+	}
+	copy(dest, r.rows.values[r.next])
+	r.next++
+	return nil
+}
+
+func openTestDB(t *testing.T, d *testDB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sql.OpenDB(d), SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// statements 返回修改指定表的语句
+func (d *testDB) statements(table string) []testStatement {
+	var statements []testStatement
+	for _, s := range d.execs {
+		if strings.Contains(s.query, "`"+table+"`") {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
+
+func hasArg(s testStatement, want driver.Value) bool {
+	for _, arg := range s.args {
+		if arg == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnonymizeUserScope(t *testing.T) {
+	const userID = 7
+	d := &testDB{rows: map[string]testRows{
+		"users": {
+			columns: []string{"id", "username", "nickname", "email"},
+			values:  [][]driver.Value{{int64(userID), "al_ice", "100%用户", "alice@example.com"}},
+		},
+		"comments": {
+			columns: []string{"id", "content"},
+			values: [][]driver.Value{
+				{int64(1), "@al_ice 看一下"},
+				{int64(2), "@al_ice2 和 @al_ice"},
+				{int64(3), "@al_ice2 无关"},
+			},
+		},
+	}}
+	repo := NewAccountRepository(openTestDB(t, d))
+
+	if err := repo.AnonymizeUser(userID); err != nil {
+		t.Fatalf("AnonymizeUser() error = %v", err)
+	}
+
+	t.Run("每条语句都限定范围", func(t *testing.T) {
+		for _, s := range d.execs {
+			if !strings.Contains(s.query, "WHERE") {
+				t.Errorf("statement without WHERE: %s", s.query)
+			}
+		}
+	})
+
+	t.Run("只替换该用户的足迹和发出的通知", func(t *testing.T) {
+		tests := []struct {
+			table string
+			scope string
+		}{
+			{"activities", "user_id = ?"},
+			{"notifications", "actor_id = ?"},
+		}
+		for _, tt := range tests {
+			replaced := 0
+			for _, s := range d.statements(tt.table) {
+				if !strings.Contains(s.query, "REPLACE(") {
+					continue
+				}
+				replaced++
+				if !strings.Contains(s.query, tt.scope) || !hasArg(s, int64(userID)) {
+					t.Errorf("%s rename not scoped by %s: %s %v", tt.table, tt.scope, s.query, s.args)
+				}
+			}
+			if replaced != 2 {
+				t.Errorf("%s renamed %d names, want 2", tt.table, replaced)
+			}
+		}
+	})
+
+	t.Run("转义LIKE通配符", func(t *testing.T) {
+		escaped := map[driver.Value]bool{`%al\_ice%`: false, `%100\%用户%`: false}
+		for _, s := range d.execs {
+			for _, arg := range s.args {
+				if arg == "%al_ice%" || arg == "%100%用户%" {
+					t.Errorf("unescaped pattern %q: %s", arg, s.query)
+				}
+				if _, ok := escaped[arg]; ok {
+					escaped[arg] = true
+				}
+			}
+		}
+		for pattern, found := range escaped {
+			if !found {
+				t.Errorf("pattern %q not used", pattern)
+			}
+		}
+	})
+
+	t.Run("只替换完整的@用户名", func(t *testing.T) {
+		want := map[int64]string{
+			1: "@" + model.DeletedUserName + " 看一下",
+			2: "@al_ice2 和 @" + model.DeletedUserName,
+		}
+		got := make(map[int64]string)
+		for _, s := range d.statements("comments") {
+			if strings.Contains(s.query, "user_id = ?") {
+				continue
+			}
+			if len(s.args) != 2 {
+				t.Fatalf("unexpected comment update: %s %v", s.query, s.args)
+			}
+			content, _ := s.args[0].(string)
+			id, _ := s.args[1].(int64)
+			got[id] = content
+		}
+		if len(got) != len(want) {
+			t.Fatalf("updated comments %v, want %v", got, want)
+		}
+		for id, content := range want {
+			if got[id] != content {
+				t.Errorf("comment %d = %q, want %q", id, got[id], content)
+			}
+		}
+	})
+
+	t.Run("删除的数据属于该用户", func(t *testing.T) {
+		for _, s := range d.execs {
+			if !strings.HasPrefix(s.query, "DELETE") {
+				continue
+			}
+			if !hasArg(s, int64(userID)) {
+				t.Errorf("delete not scoped to user: %s %v", s.query, s.args)
+			}
+		}
+	})
+}
@@ -14,6 +14,14 @@ type ActivityRepository interface {
 	CountByUserIDAndDateRange(userID uint, start, end time.Time) (int64, error)
 	GetActivitiesByDay(userID uint, days int) ([]model.ActivityByDay, error)
 	DeleteOldActivities(days int) error
+	// ExistsSince 判断用户在since之后是否已有同类型、同资源的记录
+	ExistsSince(userID uint, activityType model.ActivityType, resourceType model.ResourceType, resourceID uint, since time.Time) (bool, error)
+	// ListByResource 查询资源上所有用户的操作记录，预加载操作者
+	ListByResource(resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest) ([]model.Activity, int64, error)
+	// FindInBatches 按时间正序分批遍历记录，resourceID为0时遍历userID的个人足迹，否则遍历该资源的历史
+	FindInBatches(userID uint, resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest, batchSize int, fn func(activities []model.Activity) error) error
+	// FindInBatchesDesc 按时间倒序分批遍历记录，fn返回false时停止遍历
+	FindInBatchesDesc(userID uint, resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest, batchSize int, fn func(activities []model.Activity) (bool, error)) error
 }
 
 type activityRepository struct {
@@ -28,32 +36,20 @@ func (r *activityRepository) Create(activity *model.Activity) error {
 	return r.db.Create(activity).Error
 }
 
+func (r *activityRepository) ExistsSince(userID uint, activityType model.ActivityType, resourceType model.ResourceType, resourceID uint, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Activity{}).
+		Where("user_id = ? AND type = ? AND resource_type = ? AND resource_id = ? AND created_at >= ?",
+			userID, activityType, resourceType, resourceID, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *activityRepository) List(userID uint, req *model.ActivityListRequest) ([]model.Activity, int64, error) {
 	var activities []model.Activity
 	var total int64
 	
-	query := r.db.Model(&model.Activity{}).Where("user_id = ?", userID)
-	
-	// 添加过滤条件
-	if req.Type != "" {
-		query = query.Where("type = ?", req.Type)
-	}
-	if req.ResourceType != "" {
-		query = query.Where("resource_type = ?", req.ResourceType)
-	}
-	if req.StartDate != "" {
-		startTime, err := time.Parse("2006-01-02", req.StartDate)
-		if err == nil {
-			query = query.Where("created_at >= ?", startTime)
-		}
-	}
-	if req.EndDate != "" {
-		endTime, err := time.Parse("2006-01-02", req.EndDate)
-		if err == nil {
-			endTime = endTime.Add(24 * time.Hour - time.Second) // 包含整天
-			query = query.Where("created_at <= ?", endTime)
-		}
-	}
+	query := r.filter(req).Where("user_id = ?", userID)
 	
 	// 计算总数
 	err := query.Count(&total).Error
@@ -107,3 +103,96 @@ func (r *activityRepository) DeleteOldActivities(days int) error {
 	cutoffTime := time.Now().AddDate(0, 0, -days)
 	return r.db.Where("created_at < ?", cutoffTime).Delete(&model.Activity{}).Error
 }
+
+func (r *activityRepository) ListByResource(resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest) ([]model.Activity, int64, error) {
+	var activities []model.Activity
+	var total int64
+
+	query := r.filter(req).Where("resource_type = ? AND resource_id = ?", resourceType, resourceID)
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Preload("User").Offset(offset).Limit(req.PageSize).
+		Order("created_at DESC, id DESC").Find(&activities).Error
+
+	return activities, total, err
+}
+
+func (r *activityRepository) FindInBatches(userID uint, resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest, batchSize int, fn func(activities []model.Activity) error) error {
+	// 记录按时间顺序写入，主键顺序即时间顺序
+	var activities []model.Activity
+	return r.timeline(userID, resourceType, resourceID, req).Order("id ASC").
+		FindInBatches(&activities, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(activities)
+		}).Error
+}
+
+func (r *activityRepository) FindInBatchesDesc(userID uint, resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest, batchSize int, fn func(activities []model.Activity) (bool, error)) error {
+	query := r.timeline(userID, resourceType, resourceID, req).Session(&gorm.Session{})
+
+	var lastID uint
+	for {
+		batch := query
+		if lastID != 0 {
+			batch = batch.Where("id < ?", lastID)
+		}
+		var activities []model.Activity
+		if err := batch.Order("id DESC").Limit(batchSize).Find(&activities).Error; err != nil {
+			return err
+		}
+		if len(activities) == 0 {
+			return nil
+		}
+
+		next, err := fn(activities)
+		if err != nil || !next || len(activities) < batchSize {
+			return err
+		}
+		lastID = activities[len(activities)-1].ID
+	}
+}
+
+// timeline resourceID为0时查询userID的个人足迹，否则查询该资源的历史并预加载操作者
+func (r *activityRepository) timeline(userID uint, resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest) *gorm.DB {
+	query := r.filter(req)
+	if resourceID != 0 {
+		return query.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).Preload("User")
+	}
+	return query.Where("user_id = ?", userID)
+}
+
+func (r *activityRepository) filter(req *model.ActivityListRequest) *gorm.DB {
+	query := r.db.Model(&model.Activity{})
+
+	// 添加过滤条件
+	if req.ActorID != 0 {
+		query = query.Where("user_id = ?", req.ActorID)
+	}
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.ResourceType != "" {
+		query = query.Where("resource_type = ?", req.ResourceType)
+	}
+	if req.StartDate != "" {
+		startTime, err := time.Parse("2006-01-02", req.StartDate)
+		if err == nil {
+			query = query.Where("created_at >= ?", startTime)
+		}
+	}
+	if req.EndDate != "" {
+		endTime, err := time.Parse("2006-01-02", req.EndDate)
+		if err == nil {
+			endTime = endTime.Add(24*time.Hour - time.Second) // 包含整天
+			query = query.Where("created_at <= ?", endTime)
+		}
+	}
+
+	return query
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AdminRepository interface {
+	GetSystemStats() (*model.SystemStats, error)
+}
+
+type adminRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+func (r *adminRepository) GetSystemStats() (*model.SystemStats, error) {
+	stats := &model.SystemStats{
+		UsersByRole:     make(map[string]int64),
+		DocumentsByType: make(map[string]int64),
+	}
+
+	// 用户统计
+	if err := r.db.Model(&model.User{}).Count(&stats.TotalUsers).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.User{}).
+		Where("status = ?", model.UserStatusActive).Count(&stats.ActiveUsers).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.User{}).
+		Where("status = ?", model.UserStatusDisabled).Count(&stats.DisabledUsers).Error; err != nil {
+		return nil, err
+	}
+
+	var roleCounts []struct {
+		Role  string
+		Count int64
+	}
+	if err := r.db.Model(&model.User{}).
+		Select("role, COUNT(*) as count").
+		Group("role").Scan(&roleCounts).Error; err != nil {
+		return nil, err
+	}
+	for _, rc := range roleCounts {
+		stats.UsersByRole[rc.Role] = rc.Count
+	}
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekAgo := now.AddDate(0, 0, -7)
+
+	if err := r.db.Model(&model.User{}).
+		Where("created_at >= ?", startOfDay).Count(&stats.NewUsersToday).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.User{}).
+		Where("created_at >= ?", weekAgo).Count(&stats.NewUsersWeek).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.Activity{}).
+		Where("created_at >= ?", weekAgo).
+		Distinct("user_id").Count(&stats.ActiveUsersWeek).Error; err != nil {
+		return nil, err
+	}
+
+	// 内容统计
+	if err := r.db.Model(&model.Document{}).Count(&stats.TotalDocuments).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.Folder{}).Count(&stats.TotalFolders).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.Activity{}).Count(&stats.TotalActivities).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.Document{}).
+		Select("COALESCE(SUM(size), 0)").Scan(&stats.StorageUsed).Error; err != nil {
+		return nil, err
+	}
+
+	var typeCounts []struct {
+		Type  string
+		Count int64
+	}
+	if err := r.db.Model(&model.Document{}).
+		Select("type, COUNT(*) as count").
+		Group("type").Scan(&typeCounts).Error; err != nil {
+		return nil, err
+	}
+	for _, documentType := range model.DocumentTypes {
+		stats.DocumentsByType[string(documentType)] = 0
+	}
+	for _, tc := range typeCounts {
+		stats.DocumentsByType[tc.Type] = tc.Count
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AIDraftRepository interface {
+	Create(draft *model.AIDraft) error
+	Update(draft *model.AIDraft) error
+	GetByIDAndUserID(id, userID uint) (*model.AIDraft, error)
+	List(userID uint, req *model.AIDraftListRequest) ([]model.AIDraft, int64, error)
+}
+
+type aiDraftRepository struct {
+	db *gorm.DB
+}
+
+func NewAIDraftRepository(db *gorm.DB) AIDraftRepository {
+	return &aiDraftRepository{db: db}
+}
+
+func (r *aiDraftRepository) Create(draft *model.AIDraft) error {
+	return r.db.Create(draft).Error
+}
+
+func (r *aiDraftRepository) Update(draft *model.AIDraft) error {
+	return r.db.Save(draft).Error
+}
+
+func (r *aiDraftRepository) GetByIDAndUserID(id, userID uint) (*model.AIDraft, error) {
+	var draft model.AIDraft
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&draft).Error
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+func (r *aiDraftRepository) List(userID uint, req *model.AIDraftListRequest) ([]model.AIDraft, int64, error) {
+	var drafts []model.AIDraft
+	var total int64
+
+	query := r.db.Model(&model.AIDraft{}).Where("user_id = ?", userID)
+
+	// 添加过滤条件
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.DocumentID != 0 {
+		query = query.Where("document_id = ?", req.DocumentID)
+	}
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Offset(offset).Limit(req.PageSize).
+		Order("id DESC").Find(&drafts).Error
+
+	return drafts, total, err
+}
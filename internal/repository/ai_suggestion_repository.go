@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AISuggestionRepository interface {
+	Create(suggestion *model.AISuggestion) error
+	Update(suggestion *model.AISuggestion) error
+	GetByID(documentID, id, userID uint) (*model.AISuggestion, error)
+	List(documentID, userID uint, req *model.AISuggestionListRequest) ([]model.AISuggestion, int64, error)
+}
+
+type aiSuggestionRepository struct {
+	db *gorm.DB
+}
+
+func NewAISuggestionRepository(db *gorm.DB) AISuggestionRepository {
+	return &aiSuggestionRepository{db: db}
+}
+
+func (r *aiSuggestionRepository) Create(suggestion *model.AISuggestion) error {
+	return r.db.Create(suggestion).Error
+}
+
+func (r *aiSuggestionRepository) Update(suggestion *model.AISuggestion) error {
+	return r.db.Save(suggestion).Error
+}
+
+func (r *aiSuggestionRepository) GetByID(documentID, id, userID uint) (*model.AISuggestion, error) {
+	var suggestion model.AISuggestion
+	err := r.db.Where("id = ? AND document_id = ? AND user_id = ?", id, documentID, userID).First(&suggestion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &suggestion, nil
+}
+
+func (r *aiSuggestionRepository) List(documentID, userID uint, req *model.AISuggestionListRequest) ([]model.AISuggestion, int64, error) {
+	var suggestions []model.AISuggestion
+	var total int64
+
+	query := r.db.Model(&model.AISuggestion{}).Where("document_id = ? AND user_id = ?", documentID, userID)
+
+	// 添加过滤条件
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Offset(offset).Limit(req.PageSize).
+		Order("id DESC").Find(&suggestions).Error
+
+	return suggestions, total, err
+}
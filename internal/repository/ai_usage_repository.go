@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AIUsageRepository interface {
+	Create(usage *model.AIUsage) error
+	// SumTokensSince 统计用户在since之后消耗的token总数
+	SumTokensSince(userID uint, since time.Time) (int64, error)
+	SummaryByFeature(userID uint, since time.Time) ([]model.AIFeatureUsage, error)
+}
+
+type aiUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewAIUsageRepository(db *gorm.DB) AIUsageRepository {
+	return &aiUsageRepository{db: db}
+}
+
+func (r *aiUsageRepository) Create(usage *model.AIUsage) error {
+	return r.db.Create(usage).Error
+}
+
+func (r *aiUsageRepository) SumTokensSince(userID uint, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&model.AIUsage{}).
+		Select("COALESCE(SUM(prompt_tokens + completion_tokens), 0)").
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *aiUsageRepository) SummaryByFeature(userID uint, since time.Time) ([]model.AIFeatureUsage, error) {
+	var results []model.AIFeatureUsage
+	err := r.db.Model(&model.AIUsage{}).
+		Select("feature, COUNT(*) as requests, COALESCE(SUM(prompt_tokens), 0) as prompt_tokens, COALESCE(SUM(completion_tokens), 0) as completion_tokens").
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Group("feature").
+		Order("requests DESC").
+		Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type APITokenRepository interface {
+	Create(token *model.APIToken) error
+	GetByHash(tokenHash string) (*model.APIToken, error)
+	GetByIDAndUserID(id, userID uint) (*model.APIToken, error)
+	ListByUserID(userID uint) ([]model.APIToken, error)
+	Delete(id, userID uint) error
+	DeleteByUserID(userID uint) error
+	UpdateLastUsed(id uint, usedAt time.Time, ipAddress string) error
+}
+
+type apiTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository(db *gorm.DB) APITokenRepository {
+	return &apiTokenRepository{db: db}
+}
+
+func (r *apiTokenRepository) Create(token *model.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *apiTokenRepository) GetByHash(tokenHash string) (*model.APIToken, error) {
+	var token model.APIToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepository) GetByIDAndUserID(id, userID uint) (*model.APIToken, error) {
+	var token model.APIToken
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepository) ListByUserID(userID uint) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *apiTokenRepository) Delete(id, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.APIToken{}).Error
+}
+
+func (r *apiTokenRepository) DeleteByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.APIToken{}).Error
+}
+
+func (r *apiTokenRepository) UpdateLastUsed(id uint, usedAt time.Time, ipAddress string) error {
+	return r.db.Model(&model.APIToken{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_used_at": usedAt,
+			"last_used_ip": ipAddress,
+		}).Error
+}
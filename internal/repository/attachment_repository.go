@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository interface {
+	Create(attachment *model.Attachment) error
+	GetByIDAndDocumentID(id, documentID uint) (*model.Attachment, error)
+	ListByDocumentID(documentID uint) ([]model.Attachment, error)
+	CountByDocumentID(documentID uint) (int64, error)
+	SumSizeByDocumentID(documentID uint) (int64, error)
+	Delete(id uint) error
+	DeleteByDocumentID(documentID uint) error
+	// RefreshDocumentSize 将文档大小更新为正文长度加上附件大小，不修改文档的更新时间
+	RefreshDocumentSize(documentID uint) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) Create(attachment *model.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r *attachmentRepository) GetByIDAndDocumentID(id, documentID uint) (*model.Attachment, error) {
+	var attachment model.Attachment
+	err := r.db.Where("id = ? AND document_id = ?", id, documentID).First(&attachment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepository) ListByDocumentID(documentID uint) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	err := r.db.Where("document_id = ?", documentID).Order("id ASC").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *attachmentRepository) CountByDocumentID(documentID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Attachment{}).Where("document_id = ?", documentID).Count(&count).Error
+	return count, err
+}
+
+func (r *attachmentRepository) SumSizeByDocumentID(documentID uint) (int64, error) {
+	var size int64
+	err := r.db.Model(&model.Attachment{}).
+		Where("document_id = ?", documentID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&size).Error
+	return size, err
+}
+
+func (r *attachmentRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Attachment{}, id).Error
+}
+
+func (r *attachmentRepository) DeleteByDocumentID(documentID uint) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&model.Attachment{}).Error
+}
+
+func (r *attachmentRepository) RefreshDocumentSize(documentID uint) error {
+	return r.db.Exec("UPDATE documents SET size = COALESCE(LENGTH(content), 0) + "+
+		"(SELECT COALESCE(SUM(size), 0) FROM attachments WHERE document_id = ?) WHERE id = ?",
+		documentID, documentID).Error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuditLogRepository interface {
+	// Append 在事务中锁定最新一条记录，由computeHash根据其Hash计算新记录的Hash后写入
+	Append(log *model.AuditLog, computeHash func(prevHash string) string) error
+	List(req *model.AuditLogListRequest) ([]model.AuditLog, int64, error)
+	FindInBatches(req *model.AuditLogListRequest, batchSize int, fn func(logs []model.AuditLog) error) error
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Append(log *model.AuditLog, computeHash func(prevHash string) string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var last model.AuditLog
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Order("id DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			return err
+		}
+
+		log.PrevHash = last.Hash
+		log.Hash = computeHash(last.Hash)
+		return tx.Create(log).Error
+	})
+}
+
+func (r *auditLogRepository) List(req *model.AuditLogListRequest) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := r.filter(req)
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Offset(offset).Limit(req.PageSize).
+		Order("id DESC").Find(&logs).Error
+
+	return logs, total, err
+}
+
+func (r *auditLogRepository) FindInBatches(req *model.AuditLogListRequest, batchSize int, fn func(logs []model.AuditLog) error) error {
+	var logs []model.AuditLog
+	return r.filter(req).Order("id ASC").
+		FindInBatches(&logs, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(logs)
+		}).Error
+}
+
+func (r *auditLogRepository) filter(req *model.AuditLogListRequest) *gorm.DB {
+	query := r.db.Model(&model.AuditLog{})
+
+	// 添加过滤条件
+	if req.ActorID != 0 {
+		query = query.Where("actor_id = ?", req.ActorID)
+	}
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+	if req.ResourceType != "" {
+		query = query.Where("resource_type = ?", req.ResourceType)
+	}
+	if req.ResourceID != 0 {
+		query = query.Where("resource_id = ?", req.ResourceID)
+	}
+	if req.StartDate != "" {
+		startTime, err := time.Parse("2006-01-02", req.StartDate)
+		if err == nil {
+			query = query.Where("created_at >= ?", startTime)
+		}
+	}
+	if req.EndDate != "" {
+		endTime, err := time.Parse("2006-01-02", req.EndDate)
+		if err == nil {
+			endTime = endTime.Add(24*time.Hour - time.Second) // 包含整天
+			query = query.Where("created_at <= ?", endTime)
+		}
+	}
+
+	return query
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type CommentRepository interface {
+	Create(comment *model.Comment) error
+	GetByID(id uint) (*model.Comment, error)
+	Update(comment *model.Comment) error
+	// Delete 删除评论，删除讨论串首条评论时一并删除其回复
+	Delete(id uint) error
+	ListThreads(documentID uint, req *model.CommentListRequest) ([]model.Comment, int64, error)
+	ListReplies(threadIDs []uint) ([]model.Comment, error)
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+func (r *commentRepository) Create(comment *model.Comment) error {
+	return r.db.Create(comment).Error
+}
+
+func (r *commentRepository) GetByID(id uint) (*model.Comment, error) {
+	var comment model.Comment
+	err := r.db.Preload("User").First(&comment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) Update(comment *model.Comment) error {
+	return r.db.Omit("User").Save(comment).Error
+}
+
+func (r *commentRepository) Delete(id uint) error {
+	return r.db.Where("id = ? OR parent_id = ?", id, id).Delete(&model.Comment{}).Error
+}
+
+func (r *commentRepository) ListThreads(documentID uint, req *model.CommentListRequest) ([]model.Comment, int64, error) {
+	var comments []model.Comment
+	var total int64
+
+	query := r.db.Model(&model.Comment{}).
+		Where("document_id = ? AND parent_id IS NULL", documentID)
+
+	// 添加过滤条件
+	if !req.IncludeResolved {
+		query = query.Where("resolved = ?", false)
+	}
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Preload("User").Offset(offset).Limit(req.PageSize).
+		Order("created_at ASC").Find(&comments).Error
+
+	return comments, total, err
+}
+
+func (r *commentRepository) ListReplies(threadIDs []uint) ([]model.Comment, error) {
+	var comments []model.Comment
+	if len(threadIDs) == 0 {
+		return comments, nil
+	}
+	err := r.db.Preload("User").Where("parent_id IN ?", threadIDs).
+		Order("created_at ASC").Find(&comments).Error
+	return comments, err
+}
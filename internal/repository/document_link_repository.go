@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"strings"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type DocumentLinkRepository interface {
+	// ReplaceForDocument 删除文档原有的出链并写入新的出链
+	ReplaceForDocument(sourceID uint, links []model.DocumentLink) error
+	DeleteBySourceID(sourceID uint) error
+	// ResolveTitle 将尚未找到目标的同名标题链接指向targetID
+	ResolveTitle(userID uint, title string, targetID uint) error
+	// UnresolveRenamed 文档改名后，按旧标题指向它的链接改为未找到目标
+	UnresolveRenamed(targetID uint, title string) error
+	// FindIDsByTitles 按标题查找用户的文档，同名时取最近更新的，返回小写标题到文档ID的映射
+	FindIDsByTitles(userID uint, titles []string) (map[string]uint, error)
+	ListOutgoing(sourceID uint) ([]model.LinkedDocument, error)
+	// ListBacklinks 列出未删除的文档中指向targetID的链接
+	ListBacklinks(targetID, userID uint) ([]model.LinkedDocument, error)
+	ListBroken(userID uint, page, pageSize int) ([]model.BrokenLink, int64, error)
+	// ListGraphDocuments 按文件夹和标签筛选图谱范围内的文档，按更新时间倒序最多返回limit个
+	ListGraphDocuments(userID uint, req *model.DocumentGraphRequest, limit int) ([]model.Document, error)
+	// ListValidLinks 列出起点或终点在ids中、两端文档都未删除的链接
+	ListValidLinks(userID uint, ids []uint) ([]model.DocumentLink, error)
+	Count() (int64, error)
+	// ListDocumentsAfter 按ID顺序列出afterID之后的文档，用于首次建立链接
+	ListDocumentsAfter(afterID uint, limit int) ([]model.Document, error)
+}
+
+type documentLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentLinkRepository(db *gorm.DB) DocumentLinkRepository {
+	return &documentLinkRepository{db: db}
+}
+
+func (r *documentLinkRepository) ReplaceForDocument(sourceID uint, links []model.DocumentLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_id = ?", sourceID).Delete(&model.DocumentLink{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(links, 200).Error
+	})
+}
+
+func (r *documentLinkRepository) DeleteBySourceID(sourceID uint) error {
+	return r.db.Where("source_id = ?", sourceID).Delete(&model.DocumentLink{}).Error
+}
+
+func (r *documentLinkRepository) ResolveTitle(userID uint, title string, targetID uint) error {
+	return r.db.Model(&model.DocumentLink{}).
+		Where("user_id = ? AND link_type = ? AND target_id IS NULL AND target_title = ?", userID, model.DocumentLinkByTitle, title).
+		Update("target_id", targetID).Error
+}
+
+func (r *documentLinkRepository) UnresolveRenamed(targetID uint, title string) error {
+	return r.db.Model(&model.DocumentLink{}).
+		Where("target_id = ? AND link_type = ? AND target_title <> ?", targetID, model.DocumentLinkByTitle, title).
+		Update("target_id", nil).Error
+}
+
+func (r *documentLinkRepository) FindIDsByTitles(userID uint, titles []string) (map[string]uint, error) {
+	ids := make(map[string]uint)
+	if len(titles) == 0 {
+		return ids, nil
+	}
+
+	var documents []model.Document
+	err := r.db.Select("id, title").
+		Where("user_id = ? AND title IN ?", userID, titles).
+		Order("updated_at ASC").
+		Find(&documents).Error
+	if err != nil {
+		return nil, err
+	}
+	// 按更新时间升序覆盖，同名时保留最近更新的
+	for _, document := range documents {
+		ids[strings.ToLower(document.Title)] = document.ID
+	}
+	return ids, nil
+}
+
+func (r *documentLinkRepository) ListOutgoing(sourceID uint) ([]model.LinkedDocument, error) {
+	var links []model.LinkedDocument
+	err := r.db.Table("document_links").
+		Joins("LEFT JOIN documents ON documents.id = document_links.target_id AND documents.user_id = document_links.user_id AND documents.deleted_at IS NULL").
+		Where("document_links.source_id = ?", sourceID).
+		Select("document_links.target_id AS document_id, COALESCE(documents.title, document_links.target_title) AS title, " +
+			"documents.type, document_links.link_type, document_links.context, documents.id IS NULL AS broken").
+		Order("document_links.id ASC").
+		Scan(&links).Error
+	return links, err
+}
+
+func (r *documentLinkRepository) ListBacklinks(targetID, userID uint) ([]model.LinkedDocument, error) {
+	var links []model.LinkedDocument
+	err := r.db.Table("document_links").
+		Joins("JOIN documents ON documents.id = document_links.source_id AND documents.deleted_at IS NULL").
+		Where("document_links.target_id = ? AND document_links.user_id = ?", targetID, userID).
+		Select("document_links.source_id AS document_id, documents.title, documents.type, " +
+			"document_links.link_type, document_links.context, FALSE AS broken").
+		Order("documents.updated_at DESC").
+		Scan(&links).Error
+	return links, err
+}
+
+func (r *documentLinkRepository) ListBroken(userID uint, page, pageSize int) ([]model.BrokenLink, int64, error) {
+	var links []model.BrokenLink
+	var total int64
+
+	query := r.db.Table("document_links").
+		Joins("JOIN documents AS sources ON sources.id = document_links.source_id AND sources.deleted_at IS NULL").
+		Joins("LEFT JOIN documents AS targets ON targets.id = document_links.target_id AND targets.user_id = document_links.user_id AND targets.deleted_at IS NULL").
+		Where("document_links.user_id = ? AND targets.id IS NULL", userID)
+
+	// 计算总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	err := query.Select("document_links.source_id, sources.title AS source_title, document_links.target_id, " +
+		"document_links.target_title, document_links.link_type, document_links.context").
+		Order("sources.updated_at DESC, document_links.id ASC").
+		Offset(offset).Limit(pageSize).
+		Scan(&links).Error
+
+	return links, total, err
+}
+
+func (r *documentLinkRepository) ListGraphDocuments(userID uint, req *model.DocumentGraphRequest, limit int) ([]model.Document, error) {
+	var documents []model.Document
+	query := r.db.Select("id, title, type, updated_at").Where("user_id = ?", userID)
+
+	// 添加过滤条件
+	if req.FolderID != nil {
+		query = query.Where("folder_id = ?", *req.FolderID)
+	}
+	if req.Tag != "" {
+		// 标签以逗号分隔保存
+		tag := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.TrimSpace(req.Tag))
+		query = query.Where("CONCAT(',', REPLACE(tags, ' ', ''), ',') LIKE ?", "%,"+tag+",%")
+	}
+
+	err := query.Order("updated_at DESC").Limit(limit).Find(&documents).Error
+	return documents, err
+}
+
+func (r *documentLinkRepository) ListValidLinks(userID uint, ids []uint) ([]model.DocumentLink, error) {
+	var links []model.DocumentLink
+	if len(ids) == 0 {
+		return links, nil
+	}
+	err := r.db.Table("document_links").
+		Joins("JOIN documents AS sources ON sources.id = document_links.source_id AND sources.deleted_at IS NULL").
+		Joins("JOIN documents AS targets ON targets.id = document_links.target_id AND targets.user_id = document_links.user_id AND targets.deleted_at IS NULL").
+		Where("document_links.user_id = ? AND (document_links.source_id IN ? OR document_links.target_id IN ?)", userID, ids, ids).
+		Select("document_links.*").
+		Scan(&links).Error
+	return links, err
+}
+
+func (r *documentLinkRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&model.DocumentLink{}).Count(&count).Error
+	return count, err
+}
+
+func (r *documentLinkRepository) ListDocumentsAfter(afterID uint, limit int) ([]model.Document, error) {
+	var documents []model.Document
+	err := r.db.Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
@@ -10,11 +10,34 @@ type DocumentRepository interface {
 	Create(document *model.Document) error
 	GetByID(id uint) (*model.Document, error)
 	GetByIDAndUserID(id, userID uint) (*model.Document, error)
+	GetByIDs(userID uint, ids []uint) ([]model.Document, error)
 	Update(document *model.Document) error
+	// UpdateIfUnchanged 文档状态仍为status且版本仍为document.Version时更新标题、内容、大小、状态、文件夹和标签，
+	// 并将版本加1，返回是否更新成功
+	// 不覆盖查看次数、分享设置等其他列，避免用过期的数据覆盖并发修改
+	UpdateIfUnchanged(document *model.Document, status model.DocumentStatus) (bool, error)
 	Delete(id, userID uint) error
+	// DeleteToRecycle 在同一事务中软删除文档并放入回收站，inTx不为空时在提交前调用，用于写入发件箱等
+	DeleteToRecycle(id, userID uint, item *model.RecycleItem, inTx func(tx *gorm.DB) error) error
+	// GetDeletedByIDAndUserID 获取已删除的文档，用于从回收站恢复
+	GetDeletedByIDAndUserID(id, userID uint) (*model.Document, error)
+	// Restore 恢复已删除的文档到指定文件夹，folderID为空时恢复到根目录
+	Restore(id, userID uint, folderID *uint) error
+	// TransitionStatus 文档状态仍为from时更新为to并设置定时发布时间，返回是否更新成功
+	TransitionStatus(id uint, from, to model.DocumentStatus, publishAt *time.Time) (bool, error)
+	// ListDueScheduled 获取定时发布时间已到的审核通过文档
+	ListDueScheduled(now time.Time, limit int) ([]model.Document, error)
+	// ListPublishedByFolderIDs 获取文件夹中已发布的文档，不加载内容，用于生成公开站点的导航
+	ListPublishedByFolderIDs(userID uint, folderIDs []uint) ([]model.Document, error)
 	List(userID uint, req *model.DocumentListRequest) ([]model.Document, int64, error)
 	GetByShareToken(token string) (*model.Document, error)
-	UpdateViewCount(id uint) error
+	// UpdateShare 只更新分享链接和过期时间，不修改更新时间和其他列
+	UpdateShare(id uint, token string, expiry *time.Time) error
+	AddShareRecipients(recipients []model.ShareRecipient) error
+	// ListShareRecipientIDs 获取文档当前有效的分享链接分享给的用户ID，未分享或分享已过期时为空
+	ListShareRecipientIDs(documentID uint) ([]uint, error)
+	// AddViewCounts 批量累加文档的查看次数，键为文档ID
+	AddViewCounts(counts map[uint]int) error
 	GetRecentDocuments(userID uint, limit int) ([]model.Document, error)
 	CountByUserID(userID uint) (int64, error)
 	CountByUserIDAndStatus(userID uint, status model.DocumentStatus) (int64, error)
@@ -52,14 +75,117 @@ func (r *documentRepository) GetByIDAndUserID(id, userID uint) (*model.Document,
 	return &document, nil
 }
 
+func (r *documentRepository) GetByIDs(userID uint, ids []uint) ([]model.Document, error) {
+	var documents []model.Document
+	if len(ids) == 0 {
+		return documents, nil
+	}
+	err := r.db.Where("id IN ? AND user_id = ?", ids, userID).Find(&documents).Error
+	return documents, err
+}
+
 func (r *documentRepository) Update(document *model.Document) error {
 	return r.db.Save(document).Error
 }
 
+func (r *documentRepository) UpdateIfUnchanged(document *model.Document, status model.DocumentStatus) (bool, error) {
+	result := r.db.Model(&model.Document{}).
+		Where("id = ? AND status = ? AND version = ?", document.ID, status, document.Version).
+		Updates(map[string]interface{}{
+			"title":      document.Title,
+			"content":    document.Content,
+			"size":       document.Size,
+			"status":     document.Status,
+			"publish_at": document.PublishAt,
+			"folder_id":  document.FolderID,
+			"tags":       document.Tags,
+			"updated_at": document.UpdatedAt,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	document.Version++
+	return true, nil
+}
+
 func (r *documentRepository) Delete(id, userID uint) error {
 	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Document{}).Error
 }
 
+func (r *documentRepository) DeleteToRecycle(id, userID uint, item *model.RecycleItem, inTx func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Document{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		if inTx != nil {
+			return inTx(tx)
+		}
+		return nil
+	})
+}
+
+func (r *documentRepository) GetDeletedByIDAndUserID(id, userID uint) (*model.Document, error) {
+	var document model.Document
+	err := r.db.Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *documentRepository) Restore(id, userID uint, folderID *uint) error {
+	result := r.db.Unscoped().Model(&model.Document{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"folder_id":  folderID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *documentRepository) TransitionStatus(id uint, from, to model.DocumentStatus, publishAt *time.Time) (bool, error) {
+	result := r.db.Model(&model.Document{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{
+			"status":     to,
+			"publish_at": publishAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *documentRepository) ListDueScheduled(now time.Time, limit int) ([]model.Document, error) {
+	var documents []model.Document
+	err := r.db.Where("status = ? AND publish_at IS NOT NULL AND publish_at <= ?", model.DocumentStatusApproved, now).
+		Order("publish_at ASC").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
+
+func (r *documentRepository) ListPublishedByFolderIDs(userID uint, folderIDs []uint) ([]model.Document, error) {
+	var documents []model.Document
+	err := r.db.Select("id, user_id, folder_id, title, type, status, created_at, updated_at").
+		Where("user_id = ? AND status = ? AND folder_id IN ?", userID, model.DocumentStatusPublished, folderIDs).
+		Order("title ASC").
+		Find(&documents).Error
+	return documents, err
+}
+
 func (r *documentRepository) List(userID uint, req *model.DocumentListRequest) ([]model.Document, int64, error) {
 	var documents []model.Document
 	var total int64
@@ -86,15 +212,45 @@ func (r *documentRepository) List(userID uint, req *model.DocumentListRequest) (
 		return nil, 0, err
 	}
 	
-	// 分页查询
+	// 分页查询，浏览文件夹时置顶的文档排在最前
+	order := "updated_at DESC"
+	if req.FolderID != nil {
+		order = pinnedFirst("documents", model.ResourceTypeDocument) + ", " + order
+	}
 	offset := (req.Page - 1) * req.PageSize
 	err = query.Preload("Folder").
 		Offset(offset).Limit(req.PageSize).
-		Order("updated_at DESC").Find(&documents).Error
+		Order(order).Find(&documents).Error
 	
 	return documents, total, err
 }
 
+func (r *documentRepository) UpdateShare(id uint, token string, expiry *time.Time) error {
+	return r.db.Model(&model.Document{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"is_shared":    true,
+			"share_token":  token,
+			"share_expiry": expiry,
+		}).Error
+}
+
+func (r *documentRepository) AddShareRecipients(recipients []model.ShareRecipient) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	return r.db.Create(&recipients).Error
+}
+
+func (r *documentRepository) ListShareRecipientIDs(documentID uint) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.Model(&model.ShareRecipient{}).
+		Joins("JOIN documents ON documents.id = share_recipients.document_id AND documents.share_token = share_recipients.share_token").
+		Where("share_recipients.document_id = ? AND documents.is_shared = ? AND documents.deleted_at IS NULL", documentID, true).
+		Where("documents.share_expiry IS NULL OR documents.share_expiry > ?", time.Now()).
+		Pluck("share_recipients.user_id", &userIDs).Error
+	return userIDs, err
+}
+
 func (r *documentRepository) GetByShareToken(token string) (*model.Document, error) {
 	var document model.Document
 	err := r.db.Preload("User").
@@ -106,9 +262,17 @@ func (r *documentRepository) GetByShareToken(token string) (*model.Document, err
 	return &document, nil
 }
 
-func (r *documentRepository) UpdateViewCount(id uint) error {
-	return r.db.Model(&model.Document{}).Where("id = ?", id).
-		Update("view_count", gorm.Expr("view_count + 1")).Error
+func (r *documentRepository) AddViewCounts(counts map[uint]int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for id, count := range counts {
+			err := tx.Model(&model.Document{}).Where("id = ?", id).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", count)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *documentRepository) GetRecentDocuments(userID uint, limit int) ([]model.Document, error) {
@@ -147,6 +311,9 @@ func (r *documentRepository) CountByType(userID uint) (map[string]int64, error)
 	}
 	
 	countMap := make(map[string]int64)
+	for _, documentType := range model.DocumentTypes {
+		countMap[string(documentType)] = 0
+	}
 	for _, result := range results {
 		countMap[result.Type] = result.Count
 	}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DocumentVersionRepository interface {
+	// Create 在事务中分配下一个版本号后保存
+	Create(version *model.DocumentVersion) error
+	GetByID(documentID, id uint) (*model.DocumentVersion, error)
+	GetLatest(documentID uint) (*model.DocumentVersion, error)
+	List(documentID uint, req *model.DocumentVersionListRequest) ([]model.DocumentVersion, int64, error)
+}
+
+type documentVersionRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentVersionRepository(db *gorm.DB) DocumentVersionRepository {
+	return &documentVersionRepository{db: db}
+}
+
+func (r *documentVersionRepository) Create(version *model.DocumentVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定文档行，避免并发保存时分配到相同的版本号
+		var document model.Document
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&document, version.DocumentID).Error
+		if err != nil {
+			return err
+		}
+
+		var latest int
+		err = tx.Model(&model.DocumentVersion{}).Where("document_id = ?", version.DocumentID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+
+		version.Version = latest + 1
+		return tx.Create(version).Error
+	})
+}
+
+func (r *documentVersionRepository) GetByID(documentID, id uint) (*model.DocumentVersion, error) {
+	var version model.DocumentVersion
+	err := r.db.Where("id = ? AND document_id = ?", id, documentID).First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *documentVersionRepository) GetLatest(documentID uint) (*model.DocumentVersion, error) {
+	var version model.DocumentVersion
+	err := r.db.Where("document_id = ?", documentID).Order("version DESC").First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *documentVersionRepository) List(documentID uint, req *model.DocumentVersionListRequest) ([]model.DocumentVersion, int64, error) {
+	var versions []model.DocumentVersion
+	var total int64
+
+	query := r.db.Model(&model.DocumentVersion{}).Where("document_id = ?", documentID)
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Omit("content").Offset(offset).Limit(req.PageSize).
+		Order("version DESC").Find(&versions).Error
+
+	return versions, total, err
+}
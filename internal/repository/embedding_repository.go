@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type EmbeddingRepository interface {
+	// ReplaceForDocument 删除文档原有的向量并写入新的向量
+	ReplaceForDocument(documentID uint, embeddings []model.DocumentEmbedding) error
+	DeleteByDocumentID(documentID uint) error
+	// GetIndexState 返回文档已有向量对应的内容摘要和模型，没有向量时返回空字符串
+	GetIndexState(documentID uint) (contentHash, modelName string, err error)
+	ListByUserID(userID uint) ([]model.DocumentEmbedding, error)
+	// ListUnindexedDocuments 查找还没有向量或向量由其他模型生成的文档
+	ListUnindexedDocuments(modelName string, limit int) ([]model.Document, error)
+}
+
+type embeddingRepository struct {
+	db *gorm.DB
+}
+
+func NewEmbeddingRepository(db *gorm.DB) EmbeddingRepository {
+	return &embeddingRepository{db: db}
+}
+
+func (r *embeddingRepository) ReplaceForDocument(documentID uint, embeddings []model.DocumentEmbedding) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", documentID).Delete(&model.DocumentEmbedding{}).Error; err != nil {
+			return err
+		}
+		if len(embeddings) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(embeddings, 50).Error
+	})
+}
+
+func (r *embeddingRepository) DeleteByDocumentID(documentID uint) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&model.DocumentEmbedding{}).Error
+}
+
+func (r *embeddingRepository) GetIndexState(documentID uint) (string, string, error) {
+	var embedding model.DocumentEmbedding
+	err := r.db.Select("content_hash", "model").
+		Where("document_id = ?", documentID).
+		Limit(1).Find(&embedding).Error
+	return embedding.ContentHash, embedding.Model, err
+}
+
+func (r *embeddingRepository) ListByUserID(userID uint) ([]model.DocumentEmbedding, error) {
+	var embeddings []model.DocumentEmbedding
+	err := r.db.Where("user_id = ?", userID).
+		Order("document_id ASC, chunk_index ASC").
+		Find(&embeddings).Error
+	return embeddings, err
+}
+
+func (r *embeddingRepository) ListUnindexedDocuments(modelName string, limit int) ([]model.Document, error) {
+	var documents []model.Document
+	indexed := r.db.Model(&model.DocumentEmbedding{}).Select("document_id").Where("model = ?", modelName)
+	err := r.db.Where("id NOT IN (?)", indexed).
+		Order("id ASC").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"fmt"
+
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type FavoriteRepository interface {
+	CreateFavorite(favorite *model.Favorite) error
+	GetFavorite(userID uint, resourceType model.ResourceType, resourceID uint) (*model.Favorite, error)
+	DeleteFavorite(id uint) error
+	// ListFavorites 按收藏时间倒序获取收藏，resourceType为空时不筛选，limit为0时不限制
+	ListFavorites(userID uint, resourceType model.ResourceType, limit int) ([]model.Favorite, error)
+	CountFavorites(userID uint) (int64, error)
+	// FavoritedIDs 返回ids中已收藏的资源ID
+	FavoritedIDs(userID uint, resourceType model.ResourceType, ids []uint) ([]uint, error)
+
+	CreatePin(pin *model.Pin) error
+	GetPin(userID uint, resourceType model.ResourceType, resourceID uint) (*model.Pin, error)
+	DeletePin(id uint) error
+	ListPins(userID uint) ([]model.Pin, error)
+	// UpdatePinPositions 批量更新置顶项的顺序，键为置顶项ID
+	UpdatePinPositions(positions map[uint]int) error
+	// PinnedIDs 返回ids中已置顶的资源ID
+	PinnedIDs(userID uint, resourceType model.ResourceType, ids []uint) ([]uint, error)
+
+	// DeleteByResource 删除资源的所有收藏和置顶
+	DeleteByResource(resourceType model.ResourceType, resourceID uint) error
+}
+
+type favoriteRepository struct {
+	db *gorm.DB
+}
+
+func NewFavoriteRepository(db *gorm.DB) FavoriteRepository {
+	return &favoriteRepository{db: db}
+}
+
+func (r *favoriteRepository) CreateFavorite(favorite *model.Favorite) error {
+	return r.db.Create(favorite).Error
+}
+
+func (r *favoriteRepository) GetFavorite(userID uint, resourceType model.ResourceType, resourceID uint) (*model.Favorite, error) {
+	var favorite model.Favorite
+	err := r.db.Where("user_id = ? AND resource_type = ? AND resource_id = ?", userID, resourceType, resourceID).
+		First(&favorite).Error
+	if err != nil {
+		return nil, err
+	}
+	return &favorite, nil
+}
+
+func (r *favoriteRepository) DeleteFavorite(id uint) error {
+	return r.db.Delete(&model.Favorite{}, id).Error
+}
+
+func (r *favoriteRepository) ListFavorites(userID uint, resourceType model.ResourceType, limit int) ([]model.Favorite, error) {
+	var favorites []model.Favorite
+	query := r.db.Where("user_id = ?", userID)
+	// 添加过滤条件
+	if resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Order("id DESC").Find(&favorites).Error
+	return favorites, err
+}
+
+func (r *favoriteRepository) CountFavorites(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Favorite{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *favoriteRepository) FavoritedIDs(userID uint, resourceType model.ResourceType, ids []uint) ([]uint, error) {
+	var resourceIDs []uint
+	if len(ids) == 0 {
+		return resourceIDs, nil
+	}
+	err := r.db.Model(&model.Favorite{}).
+		Where("user_id = ? AND resource_type = ? AND resource_id IN ?", userID, resourceType, ids).
+		Pluck("resource_id", &resourceIDs).Error
+	return resourceIDs, err
+}
+
+func (r *favoriteRepository) CreatePin(pin *model.Pin) error {
+	return r.db.Create(pin).Error
+}
+
+func (r *favoriteRepository) GetPin(userID uint, resourceType model.ResourceType, resourceID uint) (*model.Pin, error) {
+	var pin model.Pin
+	err := r.db.Where("user_id = ? AND resource_type = ? AND resource_id = ?", userID, resourceType, resourceID).
+		First(&pin).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+func (r *favoriteRepository) DeletePin(id uint) error {
+	return r.db.Delete(&model.Pin{}, id).Error
+}
+
+func (r *favoriteRepository) ListPins(userID uint) ([]model.Pin, error) {
+	var pins []model.Pin
+	err := r.db.Where("user_id = ?", userID).Order("position ASC, id ASC").Find(&pins).Error
+	return pins, err
+}
+
+func (r *favoriteRepository) UpdatePinPositions(positions map[uint]int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for id, position := range positions {
+			if err := tx.Model(&model.Pin{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *favoriteRepository) PinnedIDs(userID uint, resourceType model.ResourceType, ids []uint) ([]uint, error) {
+	var resourceIDs []uint
+	if len(ids) == 0 {
+		return resourceIDs, nil
+	}
+	err := r.db.Model(&model.Pin{}).
+		Where("user_id = ? AND resource_type = ? AND resource_id IN ?", userID, resourceType, ids).
+		Pluck("resource_id", &resourceIDs).Error
+	return resourceIDs, err
+}
+
+func (r *favoriteRepository) DeleteByResource(resourceType model.ResourceType, resourceID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+			Delete(&model.Favorite{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+			Delete(&model.Pin{}).Error
+	})
+}
+
+// pinnedFirst 生成将置顶项排在最前的排序条件，置顶项之间按Position排序
+func pinnedFirst(table string, resourceType model.ResourceType) string {
+	position := fmt.Sprintf("(SELECT position FROM pins WHERE pins.user_id = %s.user_id AND pins.resource_type = '%s' AND pins.resource_id = %s.id)",
+		table, resourceType, table)
+	return position + " IS NULL, " + position + " ASC"
+}
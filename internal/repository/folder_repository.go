@@ -92,8 +92,9 @@ func (r *folderRepository) loadChildren(folder *model.Folder) {
 
 func (r *folderRepository) GetSubFolders(parentID uint, userID uint) ([]model.Folder, error) {
 	var folders []model.Folder
+	// 置顶的子文件夹排在最前
 	err := r.db.Where("parent_id = ? AND user_id = ?", parentID, userID).
-		Order("name ASC").Find(&folders).Error
+		Order(pinnedFirst("folders", model.ResourceTypeFolder) + ", name ASC").Find(&folders).Error
 	return folders, err
 }
 
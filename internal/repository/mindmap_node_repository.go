@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type MindMapNodeRepository interface {
+	// ReplaceForDocument 删除文档原有的节点索引并写入新的索引
+	ReplaceForDocument(documentID uint, nodes []model.MindMapNodeIndex) error
+	DeleteByDocumentID(documentID uint) error
+	Search(userID uint, keyword string, page, pageSize int) ([]model.MindMapNodeSearchResult, int64, error)
+	// ListUnindexedDocuments 按ID顺序查找afterID之后还没有节点索引的思维导图
+	ListUnindexedDocuments(afterID uint, limit int) ([]model.Document, error)
+}
+
+type mindMapNodeRepository struct {
+	db *gorm.DB
+}
+
+func NewMindMapNodeRepository(db *gorm.DB) MindMapNodeRepository {
+	return &mindMapNodeRepository{db: db}
+}
+
+func (r *mindMapNodeRepository) ReplaceForDocument(documentID uint, nodes []model.MindMapNodeIndex) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", documentID).Delete(&model.MindMapNodeIndex{}).Error; err != nil {
+			return err
+		}
+		if len(nodes) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(nodes, 200).Error
+	})
+}
+
+func (r *mindMapNodeRepository) DeleteByDocumentID(documentID uint) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&model.MindMapNodeIndex{}).Error
+}
+
+func (r *mindMapNodeRepository) Search(userID uint, keyword string, page, pageSize int) ([]model.MindMapNodeSearchResult, int64, error) {
+	var results []model.MindMapNodeSearchResult
+	var total int64
+
+	query := r.db.Table("mindmap_nodes").
+		Joins("JOIN documents ON documents.id = mindmap_nodes.document_id AND documents.deleted_at IS NULL").
+		Where("mindmap_nodes.user_id = ?", userID)
+
+	// 添加过滤条件
+	like := "%" + keyword + "%"
+	query = query.Where("mindmap_nodes.text LIKE ? OR mindmap_nodes.note LIKE ?", like, like)
+
+	// 计算总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	err := query.Select("mindmap_nodes.document_id, documents.title AS document_title, mindmap_nodes.node_id, " +
+		"mindmap_nodes.text, mindmap_nodes.note, mindmap_nodes.path, mindmap_nodes.depth").
+		Order("documents.updated_at DESC, mindmap_nodes.id ASC").
+		Offset(offset).Limit(pageSize).
+		Scan(&results).Error
+
+	return results, total, err
+}
+
+func (r *mindMapNodeRepository) ListUnindexedDocuments(afterID uint, limit int) ([]model.Document, error) {
+	var documents []model.Document
+	indexed := r.db.Model(&model.MindMapNodeIndex{}).Select("document_id")
+	err := r.db.Where("type = ? AND id > ? AND id NOT IN (?)", model.DocumentTypeMindMap, afterID, indexed).
+		Order("id ASC").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	Create(notification *model.Notification) error
+	List(userID uint, req *model.NotificationListRequest) ([]model.Notification, int64, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(id, userID uint) error
+	MarkAllRead(userID uint) error
+	// Exists 判断是否已为同一资源发送过同类通知，用于避免重复提醒
+	Exists(userID uint, notificationType model.NotificationType, resourceType model.ResourceType, resourceID uint) (bool, error)
+	GetPreferences(userID uint) ([]model.NotificationPreference, error)
+	SavePreferences(preferences []model.NotificationPreference) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(notification *model.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *notificationRepository) List(userID uint, req *model.NotificationListRequest) ([]model.Notification, int64, error) {
+	var notifications []model.Notification
+	var total int64
+
+	query := r.db.Model(&model.Notification{}).Where("user_id = ?", userID)
+
+	// 添加过滤条件
+	if req.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Offset(offset).Limit(req.PageSize).
+		Order("id DESC").Find(&notifications).Error
+
+	return notifications, total, err
+}
+
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkRead(id, userID uint) error {
+	var notification model.Notification
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error
+	if err != nil {
+		return err
+	}
+	if notification.ReadAt != nil {
+		return nil
+	}
+	return r.db.Model(&notification).Update("read_at", time.Now()).Error
+}
+
+func (r *notificationRepository) MarkAllRead(userID uint) error {
+	return r.db.Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+func (r *notificationRepository) Exists(userID uint, notificationType model.NotificationType, resourceType model.ResourceType, resourceID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Notification{}).
+		Where("user_id = ? AND type = ? AND resource_type = ? AND resource_id = ?",
+			userID, notificationType, resourceType, resourceID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *notificationRepository) GetPreferences(userID uint) ([]model.NotificationPreference, error) {
+	var preferences []model.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *notificationRepository) SavePreferences(preferences []model.NotificationPreference) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&preferences).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Create(event *model.OutboxEvent) error
+	// CreateTx 在调用方的事务中写入事件，与数据变更一同提交
+	CreateTx(tx *gorm.DB, event *model.OutboxEvent) error
+	Update(event *model.OutboxEvent) error
+	// ListDue 查询到达处理时间的待处理事件
+	ListDue(now time.Time, limit int) ([]model.OutboxEvent, error)
+	// Claim 将下次处理时间推迟到lockUntil以占用该事件，返回是否占用成功
+	Claim(id uint, now, lockUntil time.Time) (bool, error)
+	DeleteDoneBefore(before time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Create(event *model.OutboxEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *outboxRepository) CreateTx(tx *gorm.DB, event *model.OutboxEvent) error {
+	return tx.Create(event).Error
+}
+
+func (r *outboxRepository) Update(event *model.OutboxEvent) error {
+	return r.db.Save(event).Error
+}
+
+func (r *outboxRepository) ListDue(now time.Time, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", model.OutboxStatusPending, now).
+		Order("id ASC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *outboxRepository) Claim(id uint, now, lockUntil time.Time) (bool, error) {
+	result := r.db.Model(&model.OutboxEvent{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, model.OutboxStatusPending, now).
+		UpdateColumn("next_attempt_at", lockUntil)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *outboxRepository) DeleteDoneBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND created_at < ?", model.OutboxStatusDone, before).
+		Delete(&model.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"time"
 	"wz-wenzhan-backend/internal/model"
 	"gorm.io/gorm"
 )
@@ -10,10 +11,15 @@ type RecycleRepository interface {
 	List(userID uint, req *model.RecycleListRequest) ([]model.RecycleItem, int64, error)
 	GetByID(id uint) (*model.RecycleItem, error)
 	GetByIDAndUserID(id, userID uint) (*model.RecycleItem, error)
+	GetByIDs(ids []uint, userID uint) ([]model.RecycleItem, error)
 	Delete(id, userID uint) error
 	DeleteBatch(ids []uint, userID uint) error
 	CountByUserID(userID uint) (int64, error)
-	DeleteExpired() error
+	ListExpired() ([]model.RecycleItem, error)
+	ListExpiring(before time.Time) ([]model.RecycleItem, error)
+	// PurgeDocument 彻底删除已删除的文档及其评论、历史版本、向量、思维导图节点索引、文档链接、AI建议、
+	// 审核记录、站点页面地址、收藏、置顶和打开记录，附件由附件服务清理
+	PurgeDocument(documentID uint) error
 }
 
 type recycleRepository struct {
@@ -74,6 +80,12 @@ func (r *recycleRepository) GetByIDAndUserID(id, userID uint) (*model.RecycleIte
 	return &item, nil
 }
 
+func (r *recycleRepository) GetByIDs(ids []uint, userID uint) ([]model.RecycleItem, error) {
+	var items []model.RecycleItem
+	err := r.db.Where("id IN ? AND user_id = ?", ids, userID).Find(&items).Error
+	return items, err
+}
+
 func (r *recycleRepository) Delete(id, userID uint) error {
 	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.RecycleItem{}).Error
 }
@@ -88,6 +100,77 @@ func (r *recycleRepository) CountByUserID(userID uint) (int64, error) {
 	return count, err
 }
 
-func (r *recycleRepository) DeleteExpired() error {
-	return r.db.Where("auto_delete IS NOT NULL AND auto_delete <= NOW()").Delete(&model.RecycleItem{}).Error
+// ListExpired 获取已到自动清理时间的项目
+func (r *recycleRepository) ListExpired() ([]model.RecycleItem, error) {
+	var items []model.RecycleItem
+	err := r.db.Where("auto_delete IS NOT NULL AND auto_delete <= NOW()").
+		Order("auto_delete ASC").Find(&items).Error
+	return items, err
+}
+
+// ListExpiring 获取在指定时间前将被自动清理的项目
+func (r *recycleRepository) ListExpiring(before time.Time) ([]model.RecycleItem, error) {
+	var items []model.RecycleItem
+	err := r.db.Where("auto_delete IS NOT NULL AND auto_delete > NOW() AND auto_delete <= ?", before).
+		Order("auto_delete ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *recycleRepository) PurgeDocument(documentID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 只清理仍在回收站中的文档，已恢复的文档不受影响
+		var count int64
+		err := tx.Unscoped().Model(&model.Document{}).
+			Where("id = ? AND deleted_at IS NOT NULL", documentID).Count(&count).Error
+		if err != nil || count == 0 {
+			return err
+		}
+		err = tx.Unscoped().Where("document_id = ?", documentID).Delete(&model.Comment{}).Error
+		if err != nil {
+			return err
+		}
+		dependents := []interface{}{
+			&model.DocumentVersion{},
+			&model.DocumentEmbedding{},
+			&model.MindMapNodeIndex{},
+			&model.AISuggestion{},
+			&model.PublicPage{},
+			&model.DocumentAccess{},
+			&model.ShareRecipient{},
+		}
+		for _, dependent := range dependents {
+			if err := tx.Where("document_id = ?", documentID).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+		reviews := tx.Model(&model.DocumentReview{}).Select("id").Where("document_id = ?", documentID)
+		err = tx.Where("review_id IN (?)", reviews).Delete(&model.DocumentReviewer{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("document_id = ?", documentID).Delete(&model.DocumentReview{}).Error
+		if err != nil {
+			return err
+		}
+		// 出链随文档删除，指向该文档的链接改为未找到目标
+		err = tx.Where("source_id = ?", documentID).Delete(&model.DocumentLink{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.DocumentLink{}).Where("target_id = ?", documentID).Update("target_id", nil).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.AIDraft{}).Where("document_id = ?", documentID).Update("document_id", nil).Error
+		if err != nil {
+			return err
+		}
+		for _, mark := range []interface{}{&model.Favorite{}, &model.Pin{}} {
+			err = tx.Where("resource_type = ? AND resource_id = ?", model.ResourceTypeDocument, documentID).Delete(mark).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", documentID).Delete(&model.Document{}).Error
+	})
 }
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ReviewRepository interface {
+	// Create 创建审核请求及审核人
+	Create(review *model.DocumentReview) error
+	GetByID(id uint) (*model.DocumentReview, error)
+	// GetPendingByDocumentID 获取文档等待中的审核请求
+	GetPendingByDocumentID(documentID uint) (*model.DocumentReview, error)
+	ListByDocumentID(documentID uint) ([]model.DocumentReview, error)
+	// ListByReviewer 分页查询分配给审核人的审核请求，decision为空时不筛选
+	ListByReviewer(reviewerID uint, decision model.ReviewDecision, page, pageSize int) ([]model.DocumentReview, int64, error)
+	Update(review *model.DocumentReview) error
+	UpdateReviewer(reviewer *model.DocumentReviewer) error
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) Create(review *model.DocumentReview) error {
+	return r.db.Create(review).Error
+}
+
+func (r *reviewRepository) GetByID(id uint) (*model.DocumentReview, error) {
+	var review model.DocumentReview
+	err := r.db.Preload("Reviewers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&review, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) GetPendingByDocumentID(documentID uint) (*model.DocumentReview, error) {
+	var review model.DocumentReview
+	err := r.db.Preload("Reviewers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("document_id = ? AND status = ?", documentID, model.ReviewStatusPending).
+		Order("id DESC").
+		First(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) ListByDocumentID(documentID uint) ([]model.DocumentReview, error) {
+	var reviews []model.DocumentReview
+	err := r.db.Preload("Reviewers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("document_id = ?", documentID).
+		Order("id DESC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *reviewRepository) ListByReviewer(reviewerID uint, decision model.ReviewDecision, page, pageSize int) ([]model.DocumentReview, int64, error) {
+	var reviews []model.DocumentReview
+	var total int64
+
+	reviewers := r.db.Model(&model.DocumentReviewer{}).Select("review_id").Where("reviewer_id = ?", reviewerID)
+	// 添加过滤条件
+	if decision != "" {
+		reviewers = reviewers.Where("decision = ?", decision)
+	}
+	// 不包含已删除文档的审核
+	query := r.db.Model(&model.DocumentReview{}).
+		Where("id IN (?) AND document_id IN (?)", reviewers, r.db.Model(&model.Document{}).Select("id"))
+
+	// 计算总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	err := query.Preload("Reviewers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Document", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, title, type, user_id")
+	}).Order("id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&reviews).Error
+
+	return reviews, total, err
+}
+
+func (r *reviewRepository) Update(review *model.DocumentReview) error {
+	return r.db.Omit("Reviewers").Save(review).Error
+}
+
+func (r *reviewRepository) UpdateReviewer(reviewer *model.DocumentReviewer) error {
+	return r.db.Save(reviewer).Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type SiteRepository interface {
+	Create(site *model.PublicSite) error
+	GetByIDAndUserID(id, userID uint) (*model.PublicSite, error)
+	GetBySlug(slug string) (*model.PublicSite, error)
+	GetByFolderID(folderID uint) (*model.PublicSite, error)
+	ListByUserID(userID uint) ([]model.PublicSite, error)
+	Update(site *model.PublicSite) error
+	// Delete 删除站点及其页面地址
+	Delete(id uint) error
+	ListPages(siteID uint) ([]model.PublicPage, error)
+	CreatePage(page *model.PublicPage) error
+}
+
+type siteRepository struct {
+	db *gorm.DB
+}
+
+func NewSiteRepository(db *gorm.DB) SiteRepository {
+	return &siteRepository{db: db}
+}
+
+func (r *siteRepository) Create(site *model.PublicSite) error {
+	return r.db.Create(site).Error
+}
+
+func (r *siteRepository) GetByIDAndUserID(id, userID uint) (*model.PublicSite, error) {
+	var site model.PublicSite
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&site).Error
+	if err != nil {
+		return nil, err
+	}
+	return &site, nil
+}
+
+func (r *siteRepository) GetBySlug(slug string) (*model.PublicSite, error) {
+	var site model.PublicSite
+	err := r.db.Where("slug = ?", slug).First(&site).Error
+	if err != nil {
+		return nil, err
+	}
+	return &site, nil
+}
+
+func (r *siteRepository) GetByFolderID(folderID uint) (*model.PublicSite, error) {
+	var site model.PublicSite
+	err := r.db.Where("folder_id = ?", folderID).First(&site).Error
+	if err != nil {
+		return nil, err
+	}
+	return &site, nil
+}
+
+func (r *siteRepository) ListByUserID(userID uint) ([]model.PublicSite, error) {
+	var sites []model.PublicSite
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&sites).Error
+	return sites, err
+}
+
+func (r *siteRepository) Update(site *model.PublicSite) error {
+	return r.db.Save(site).Error
+}
+
+func (r *siteRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("site_id = ?", id).Delete(&model.PublicPage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.PublicSite{}, id).Error
+	})
+}
+
+func (r *siteRepository) ListPages(siteID uint) ([]model.PublicPage, error) {
+	var pages []model.PublicPage
+	err := r.db.Where("site_id = ?", siteID).Find(&pages).Error
+	return pages, err
+}
+
+func (r *siteRepository) CreatePage(page *model.PublicPage) error {
+	return r.db.Create(page).Error
+}
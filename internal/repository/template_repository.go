@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type TemplateRepository interface {
+	Create(template *model.DocumentTemplate) error
+	Update(template *model.DocumentTemplate) error
+	Delete(id, userID uint) error
+	GetByID(id uint) (*model.DocumentTemplate, error)
+	// GetAvailable 查询用户可使用的模板：系统模板、团队模板和自己的个人模板
+	GetAvailable(id, userID uint) (*model.DocumentTemplate, error)
+	List(userID uint, req *model.TemplateListRequest) ([]model.DocumentTemplate, int64, error)
+	IncrementUsage(id uint) error
+}
+
+type templateRepository struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepository(db *gorm.DB) TemplateRepository {
+	return &templateRepository{db: db}
+}
+
+func (r *templateRepository) Create(template *model.DocumentTemplate) error {
+	return r.db.Create(template).Error
+}
+
+func (r *templateRepository) Update(template *model.DocumentTemplate) error {
+	return r.db.Save(template).Error
+}
+
+func (r *templateRepository) Delete(id, userID uint) error {
+	result := r.db.Where("id = ? AND user_id = ? AND scope <> ?", id, userID, model.TemplateScopeSystem).
+		Delete(&model.DocumentTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *templateRepository) GetByID(id uint) (*model.DocumentTemplate, error) {
+	var template model.DocumentTemplate
+	err := r.db.First(&template, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *templateRepository) GetAvailable(id, userID uint) (*model.DocumentTemplate, error) {
+	var template model.DocumentTemplate
+	err := r.available(userID).Where("id = ?", id).First(&template).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *templateRepository) List(userID uint, req *model.TemplateListRequest) ([]model.DocumentTemplate, int64, error) {
+	var templates []model.DocumentTemplate
+	var total int64
+
+	query := r.available(userID)
+
+	// 添加过滤条件
+	if req.Scope != "" {
+		query = query.Where("scope = ?", req.Scope)
+	}
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.Category != "" {
+		query = query.Where("category = ?", req.Category)
+	}
+	if req.Keyword != "" {
+		query = query.Where("name LIKE ? OR description LIKE ?", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+	}
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询，系统模板在前，其余按使用次数排序
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Omit("content").Offset(offset).Limit(req.PageSize).
+		Order("scope = 'system' DESC, usage_count DESC, id DESC").Find(&templates).Error
+
+	return templates, total, err
+}
+
+func (r *templateRepository) IncrementUsage(id uint) error {
+	return r.db.Model(&model.DocumentTemplate{}).Where("id = ?", id).
+		UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error
+}
+
+func (r *templateRepository) available(userID uint) *gorm.DB {
+	return r.db.Model(&model.DocumentTemplate{}).
+		Where("scope IN ? OR user_id = ?", []model.TemplateScope{model.TemplateScopeSystem, model.TemplateScopeTeam}, userID)
+}
@@ -10,10 +10,16 @@ type UserRepository interface {
 	GetByID(id uint) (*model.User, error)
 	GetByUsername(username string) (*model.User, error)
 	GetByEmail(email string) (*model.User, error)
+	GetByIDs(ids []uint) ([]model.User, error)
+	GetByUsernames(usernames []string) ([]model.User, error)
 	Update(user *model.User) error
 	UpdateLastLogin(id uint) error
 	Delete(id uint) error
 	List(offset, limit int) ([]model.User, int64, error)
+	Search(req *model.AdminUserListRequest) ([]model.User, int64, error)
+	UpdateStatus(id uint, status int) error
+	UpdateRole(id uint, role string) error
+	IncrementTokenVersion(id uint) error
 }
 
 type userRepository struct {
@@ -55,6 +61,24 @@ func (r *userRepository) GetByEmail(email string) (*model.User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) GetByIDs(ids []uint) ([]model.User, error) {
+	var users []model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) GetByUsernames(usernames []string) ([]model.User, error) {
+	var users []model.User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
 func (r *userRepository) Update(user *model.User) error {
 	return r.db.Save(user).Error
 }
@@ -78,4 +102,49 @@ func (r *userRepository) List(offset, limit int) ([]model.User, int64, error) {
 	
 	err = r.db.Offset(offset).Limit(limit).Find(&users).Error
 	return users, total, err
-} 
\ No newline at end of file
+}
+
+func (r *userRepository) Search(req *model.AdminUserListRequest) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	query := r.db.Model(&model.User{})
+
+	// 添加过滤条件
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("username LIKE ? OR email LIKE ? OR nickname LIKE ?", keyword, keyword, keyword)
+	}
+	if req.Role != "" {
+		query = query.Where("role = ?", req.Role)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Offset(offset).Limit(req.PageSize).
+		Order("created_at DESC").Find(&users).Error
+
+	return users, total, err
+}
+
+func (r *userRepository) UpdateStatus(id uint, status int) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *userRepository) UpdateRole(id uint, role string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+func (r *userRepository) IncrementTokenVersion(id uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"time"
+	"wz-wenzhan-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	Create(webhook *model.Webhook) error
+	Update(webhook *model.Webhook) error
+	Delete(id, userID uint) error
+	GetByID(id uint) (*model.Webhook, error)
+	GetByIDAndUserID(id, userID uint) (*model.Webhook, error)
+	ListByUserID(userID uint) ([]model.Webhook, error)
+	CountByUserID(userID uint) (int64, error)
+	ListActiveByUserID(userID uint) ([]model.Webhook, error)
+	TouchLastDelivery(id uint, deliveredAt time.Time) error
+
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	GetDelivery(id uint) (*model.WebhookDelivery, error)
+	ListDeliveries(webhookID uint, req *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error)
+	// ListDueDeliveries 查询到达重试时间的投递
+	ListDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error)
+	// ClaimDelivery 将下次投递时间推迟到lockUntil以占用该投递，返回是否占用成功，避免同一投递被并发发送
+	ClaimDelivery(id uint, now, lockUntil time.Time) (bool, error)
+	DeleteDeliveriesBefore(before time.Time) (int64, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(webhook *model.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *webhookRepository) Update(webhook *model.Webhook) error {
+	return r.db.Save(webhook).Error
+}
+
+func (r *webhookRepository) Delete(id, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error
+	})
+}
+
+func (r *webhookRepository) GetByID(id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.db.First(&webhook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetByIDAndUserID(id, userID uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) ListByUserID(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Webhook{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *webhookRepository) ListActiveByUserID(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := r.db.Where("user_id = ? AND is_active = ?", userID, true).Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) TouchLastDelivery(id uint, deliveredAt time.Time) error {
+	return r.db.Model(&model.Webhook{}).Where("id = ?", id).
+		UpdateColumn("last_delivery_at", deliveredAt).Error
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *webhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+func (r *webhookRepository) GetDelivery(id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.db.First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(webhookID uint, req *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&model.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	// 添加过滤条件
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Event != "" {
+		query = query.Where("event = ?", req.Event)
+	}
+
+	// 计算总数
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (req.Page - 1) * req.PageSize
+	err = query.Offset(offset).Limit(req.PageSize).
+		Order("id DESC").Find(&deliveries).Error
+
+	return deliveries, total, err
+}
+
+func (r *webhookRepository) ListDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.Where("status IN ? AND next_attempt_at <= ?",
+		[]model.WebhookDeliveryStatus{model.WebhookDeliveryPending, model.WebhookDeliveryRetrying}, now).
+		Order("next_attempt_at ASC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) ClaimDelivery(id uint, now, lockUntil time.Time) (bool, error) {
+	result := r.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status IN ? AND next_attempt_at <= ?", id,
+			[]model.WebhookDeliveryStatus{model.WebhookDeliveryPending, model.WebhookDeliveryRetrying}, now).
+		UpdateColumn("next_attempt_at", lockUntil)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *webhookRepository) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ? AND status IN ?", before,
+		[]model.WebhookDeliveryStatus{model.WebhookDeliverySuccess, model.WebhookDeliveryFailed}).
+		Delete(&model.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"sync"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// 常用文档只统计该时间内打开过的文档
+const frecencyWindow = 90 * 24 * time.Hour
+
+type AccessService interface {
+	// Record 记录用户打开文档并累加查看次数，userID为0时为通过分享链接查看，只累加查看次数
+	// 记录先在内存中合并，由后台任务批量写入，不阻塞请求
+	Record(userID, documentID uint)
+	// Recent 按最近打开时间倒序获取文档
+	Recent(userID uint, limit int) ([]model.AccessedDocumentResponse, error)
+	// Frequent 获取常用文档，按打开次数和最近打开时间综合排序
+	Frequent(userID uint, limit int) ([]model.AccessedDocumentResponse, error)
+	// StartWriter 定期批量写入打开记录和查看次数
+	StartWriter(interval time.Duration)
+}
+
+type accessKey struct {
+	userID     uint
+	documentID uint
+}
+
+type pendingAccess struct {
+	count        int
+	lastOpenedAt time.Time
+}
+
+type accessService struct {
+	accessRepo      repository.AccessRepository
+	documentRepo    repository.DocumentRepository
+	favoriteService FavoriteService
+	logger          *zap.Logger
+
+	mu      sync.Mutex
+	pending map[accessKey]*pendingAccess
+	flush   chan struct{} // 待写入记录过多时通知后台任务立即写入
+}
+
+func NewAccessService(
+	accessRepo repository.AccessRepository,
+	documentRepo repository.DocumentRepository,
+	favoriteService FavoriteService,
+	logger *zap.Logger) AccessService {
+	return &accessService{
+		accessRepo:      accessRepo,
+		documentRepo:    documentRepo,
+		favoriteService: favoriteService,
+		logger:          logger,
+		pending:         make(map[accessKey]*pendingAccess),
+		flush:           make(chan struct{}, 1),
+	}
+}
+
+func (s *accessService) Record(userID, documentID uint) {
+	s.mu.Lock()
+	key := accessKey{userID: userID, documentID: documentID}
+	access, ok := s.pending[key]
+	if !ok {
+		access = &pendingAccess{}
+		s.pending[key] = access
+	}
+	access.count++
+	access.lastOpenedAt = time.Now()
+	full := len(s.pending) >= model.AccessFlushBatchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *accessService) Recent(userID uint, limit int) ([]model.AccessedDocumentResponse, error) {
+	accesses, err := s.accessRepo.ListRecent(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.responses(userID, accesses)
+}
+
+func (s *accessService) Frequent(userID uint, limit int) ([]model.AccessedDocumentResponse, error) {
+	now := time.Now()
+	accesses, err := s.accessRepo.ListFrequent(userID, now.Add(-frecencyWindow), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.responses(userID, accesses)
+}
+
+func (s *accessService) StartWriter(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.flush:
+			}
+			s.write()
+		}
+	}()
+}
+
+// write 写入内存中合并的打开记录，写入失败的记录直接丢弃，查看次数和打开记录只作统计用途
+func (s *accessService) write() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[accessKey]*pendingAccess)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	var accesses []model.DocumentAccess
+	views := make(map[uint]int)
+	for key, access := range pending {
+		views[key.documentID] += access.count
+		if key.userID == 0 {
+			continue
+		}
+		accesses = append(accesses, model.DocumentAccess{
+			UserID:       key.userID,
+			DocumentID:   key.documentID,
+			OpenCount:    access.count,
+			Score:        float64(access.count),
+			LastOpenedAt: access.lastOpenedAt,
+		})
+	}
+
+	if err := s.accessRepo.Record(accesses); err != nil {
+		s.logger.Error("Failed to write document accesses", zap.Int("count", len(accesses)), zap.Error(err))
+	}
+	if err := s.documentRepo.AddViewCounts(views); err != nil {
+		s.logger.Error("Failed to update view counts", zap.Int("count", len(views)), zap.Error(err))
+	}
+}
+
+// responses 加载打开记录对应的文档，保持记录的顺序
+func (s *accessService) responses(userID uint, accesses []model.DocumentAccess) ([]model.AccessedDocumentResponse, error) {
+	ids := make([]uint, 0, len(accesses))
+	for _, access := range accesses {
+		ids = append(ids, access.DocumentID)
+	}
+
+	documents, err := s.documentRepo.GetByIDs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.Document, len(documents))
+	for _, document := range documents {
+		byID[document.ID] = document
+	}
+
+	favorited, pinned, err := s.favoriteService.Marks(userID, model.ResourceTypeDocument, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.AccessedDocumentResponse, 0, len(accesses))
+	for _, access := range accesses {
+		document, ok := byID[access.DocumentID]
+		if !ok {
+			continue
+		}
+		responses = append(responses, model.AccessedDocumentResponse{
+			DocumentResponse: model.DocumentResponse{
+				ID:        document.ID,
+				Title:     document.Title,
+				Type:      document.Type,
+				Status:    document.Status,
+				FolderID:  document.FolderID,
+				Tags:      document.Tags,
+				Size:      document.Size,
+				ViewCount: document.ViewCount,
+				IsShared:  document.IsShared,
+				PublishAt: document.PublishAt,
+				Favorited: favorited[document.ID],
+				Pinned:    pinned[document.ID],
+				CreatedAt: document.CreatedAt,
+				UpdatedAt: document.UpdatedAt,
+			},
+			OpenCount:    access.OpenCount,
+			LastOpenedAt: access.LastOpenedAt,
+		})
+	}
+
+	return responses, nil
+}
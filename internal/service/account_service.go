@@ -0,0 +1,681 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type AccountService interface {
+	CreateExport(userID uint) (*model.DataExport, error)
+	ListExports(userID uint) ([]model.DataExport, error)
+	GetExportFile(id, userID uint) (*model.DataExport, error)
+	RequestDeletion(userID uint, req *model.RequestAccountDeletionRequest) (*model.AccountDeletion, error)
+	CancelDeletion(userID uint) error
+	GetDeletion(userID uint) (*model.AccountDeletion, error)
+	StartScheduler(interval time.Duration)
+}
+
+type accountService struct {
+	accountRepo  repository.AccountRepository
+	userRepo     repository.UserRepository
+	fileService  FileService
+	auditService AuditService
+	exportPath   string
+	logger       *zap.Logger
+	running      sync.Map // 正在执行的导出任务ID，避免重复执行
+}
+
+func NewAccountService(accountRepo repository.AccountRepository, userRepo repository.UserRepository, fileService FileService, auditService AuditService, exportPath string, logger *zap.Logger) AccountService {
+	// 导出文件不能放在公开的上传目录下
+	if err := os.MkdirAll(exportPath, 0700); err != nil {
+		logger.Error("Failed to create export directory", zap.Error(err))
+	}
+
+	return &accountService{
+		accountRepo:  accountRepo,
+		userRepo:     userRepo,
+		fileService:  fileService,
+		auditService: auditService,
+		exportPath:   exportPath,
+		logger:       logger,
+	}
+}
+
+func (s *accountService) CreateExport(userID uint) (*model.DataExport, error) {
+	exports, err := s.accountRepo.ListExportsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.Status == model.DataExportStatusPending || export.Status == model.DataExportStatusProcessing {
+			return nil, errors.New("已有正在进行的导出任务，请稍后再试")
+		}
+	}
+
+	export := &model.DataExport{
+		UserID: userID,
+		Status: model.DataExportStatusPending,
+	}
+	if err := s.accountRepo.CreateExport(export); err != nil {
+		return nil, err
+	}
+
+	go s.runExport(*export)
+
+	s.logger.Info("Data export requested",
+		zap.Uint("user_id", userID),
+		zap.Uint("export_id", export.ID))
+
+	return export, nil
+}
+
+func (s *accountService) ListExports(userID uint) ([]model.DataExport, error) {
+	return s.accountRepo.ListExportsByUserID(userID)
+}
+
+func (s *accountService) GetExportFile(id, userID uint) (*model.DataExport, error) {
+	export, err := s.accountRepo.GetExportByIDAndUserID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("导出任务不存在")
+		}
+		return nil, err
+	}
+
+	if export.Status != model.DataExportStatusCompleted {
+		return nil, errors.New("导出文件尚未生成或已过期")
+	}
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		return nil, errors.New("导出文件已过期")
+	}
+
+	return export, nil
+}
+
+func (s *accountService) RequestDeletion(userID uint, req *model.RequestAccountDeletionRequest) (*model.AccountDeletion, error) {
+	if _, err := s.accountRepo.GetPendingDeletion(userID); err == nil {
+		return nil, errors.New("已提交注销申请，请勿重复提交")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	// 注销前需要再次验证密码
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	if err != nil {
+		return nil, errors.New("密码错误")
+	}
+
+	if user.Role == model.UserRoleAdmin {
+		return nil, errors.New("管理员账号不能注销，请先移交管理员角色")
+	}
+
+	deletion := &model.AccountDeletion{
+		UserID:        userID,
+		Status:        model.AccountDeletionStatusPending,
+		ContentAction: req.ContentAction,
+		Reason:        req.Reason,
+		ScheduledAt:   time.Now().Add(model.AccountDeletionGracePeriod),
+	}
+
+	if req.ContentAction == model.ContentActionTransfer {
+		if req.TransferToUsername == "" {
+			return nil, errors.New("请指定接收内容的用户")
+		}
+		target, err := s.userRepo.GetByUsername(req.TransferToUsername)
+		if err != nil {
+			return nil, errors.New("接收内容的用户不存在")
+		}
+		if target.ID == userID {
+			return nil, errors.New("不能将内容转移给自己")
+		}
+		if target.Status != model.UserStatusActive {
+			return nil, errors.New("接收内容的用户已被禁用")
+		}
+		deletion.TransferToUserID = &target.ID
+	}
+
+	if err := s.accountRepo.CreateDeletion(deletion); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Account deletion requested",
+		zap.Uint("user_id", userID),
+		zap.String("content_action", req.ContentAction),
+		zap.Time("scheduled_at", deletion.ScheduledAt))
+
+	return deletion, nil
+}
+
+func (s *accountService) CancelDeletion(userID uint) error {
+	deletion, err := s.accountRepo.GetPendingDeletion(userID)
+	if err != nil {
+		return errors.New("没有待处理的注销申请")
+	}
+
+	deletion.Status = model.AccountDeletionStatusCancelled
+	if err := s.accountRepo.UpdateDeletion(deletion); err != nil {
+		return err
+	}
+
+	s.logger.Info("Account deletion cancelled", zap.Uint("user_id", userID))
+
+	return nil
+}
+
+func (s *accountService) GetDeletion(userID uint) (*model.AccountDeletion, error) {
+	deletion, err := s.accountRepo.GetPendingDeletion(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// StartScheduler 启动后台任务：执行到期的注销申请、清理过期导出文件、恢复中断的导出任务
+func (s *accountService) StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.resumeExports()
+			s.cleanupExpiredExports()
+			s.processDueDeletions()
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *accountService) resumeExports() {
+	for _, status := range []model.DataExportStatus{model.DataExportStatusPending, model.DataExportStatusProcessing} {
+		exports, err := s.accountRepo.ListExportsByStatus(status)
+		if err != nil {
+			s.logger.Error("Failed to list data exports", zap.Error(err))
+			return
+		}
+		for _, export := range exports {
+			go s.runExport(export)
+		}
+	}
+}
+
+func (s *accountService) cleanupExpiredExports() {
+	exports, err := s.accountRepo.ListExpiredExports(time.Now())
+	if err != nil {
+		s.logger.Error("Failed to list expired data exports", zap.Error(err))
+		return
+	}
+
+	for i := range exports {
+		export := &exports[i]
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			s.logger.Error("Failed to remove expired export file", zap.Uint("export_id", export.ID), zap.Error(err))
+			continue
+		}
+		export.Status = model.DataExportStatusExpired
+		export.FilePath = ""
+		if err := s.accountRepo.UpdateExport(export); err != nil {
+			s.logger.Error("Failed to update data export", zap.Uint("export_id", export.ID), zap.Error(err))
+		}
+	}
+}
+
+func (s *accountService) processDueDeletions() {
+	deletions, err := s.accountRepo.ListDueDeletions(time.Now())
+	if err != nil {
+		s.logger.Error("Failed to list due account deletions", zap.Error(err))
+		return
+	}
+
+	for i := range deletions {
+		if err := s.executeDeletion(&deletions[i]); err != nil {
+			// 保持待处理状态，下次调度时重试
+			s.logger.Error("Failed to delete account",
+				zap.Uint("user_id", deletions[i].UserID),
+				zap.Error(err))
+		}
+	}
+}
+
+// executeDeletion 执行注销：处理用户内容、清理文件、匿名化账号并使所有登录凭证失效
+func (s *accountService) executeDeletion(deletion *model.AccountDeletion) error {
+	userID := deletion.UserID
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if deletion.ContentAction == model.ContentActionTransfer && deletion.TransferToUserID != nil {
+		target, err := s.userRepo.GetByID(*deletion.TransferToUserID)
+		if err != nil {
+			return fmt.Errorf("接收内容的用户不存在: %w", err)
+		}
+
+		name := user.Nickname
+		if name == "" {
+			name = user.Username
+		}
+		rootFolderName := fmt.Sprintf("%s 转移的内容", name)
+		if err := s.accountRepo.TransferContent(userID, target.ID, rootFolderName); err != nil {
+			return err
+		}
+
+		// 文档中可能引用了上传的文件，转移时保留，只删除头像
+		if err := s.fileService.DeleteUserFiles(userID, "avatar"); err != nil {
+			return err
+		}
+	} else {
+		if err := s.accountRepo.PurgeContent(userID); err != nil {
+			return err
+		}
+		if err := s.fileService.DeleteUserFiles(userID, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(s.userExportDir(userID)); err != nil {
+		return err
+	}
+	if err := s.accountRepo.DeleteExportsByUserID(userID); err != nil {
+		return err
+	}
+
+	if err := s.accountRepo.AnonymizeUser(userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deletion.Status = model.AccountDeletionStatusCompleted
+	deletion.CompletedAt = &now
+	if err := s.accountRepo.UpdateDeletion(deletion); err != nil {
+		return err
+	}
+
+	s.auditService.Record(&model.AuditEntry{
+		ActorID:      userID,
+		ActorRole:    user.Role,
+		Action:       model.AuditActionAccountDelete,
+		ResourceType: model.AuditResourceUser,
+		ResourceID:   userID,
+		After:        map[string]interface{}{"content_action": deletion.ContentAction, "transfer_to_user_id": deletion.TransferToUserID},
+	})
+
+	s.logger.Info("Account deleted",
+		zap.Uint("user_id", userID),
+		zap.String("content_action", deletion.ContentAction))
+
+	return nil
+}
+
+func (s *accountService) runExport(export model.DataExport) {
+	if _, loaded := s.running.LoadOrStore(export.ID, true); loaded {
+		return
+	}
+	defer s.running.Delete(export.ID)
+
+	// 调度器读取的状态可能已过时，重新确认任务仍未完成
+	current, err := s.accountRepo.GetExportByIDAndUserID(export.ID, export.UserID)
+	if err != nil {
+		return
+	}
+	if current.Status != model.DataExportStatusPending && current.Status != model.DataExportStatusProcessing {
+		return
+	}
+	export = *current
+
+	export.Status = model.DataExportStatusProcessing
+	if err := s.accountRepo.UpdateExport(&export); err != nil {
+		s.logger.Error("Failed to update data export", zap.Uint("export_id", export.ID), zap.Error(err))
+		return
+	}
+
+	dir := s.userExportDir(export.UserID)
+	suffix, err := generateRandomString(16)
+	if err != nil {
+		s.logger.Error("Failed to generate export file name", zap.Error(err))
+		return
+	}
+	filePath := filepath.Join(dir, fmt.Sprintf("export_%d_%s.zip", export.ID, suffix))
+	size, err := s.writeExportFile(export.UserID, dir, filePath)
+	if err != nil {
+		os.Remove(filePath)
+		export.Status = model.DataExportStatusFailed
+		export.Error = err.Error()
+		s.logger.Error("Data export failed",
+			zap.Uint("user_id", export.UserID),
+			zap.Uint("export_id", export.ID),
+			zap.Error(err))
+	} else {
+		now := time.Now()
+		expiresAt := now.Add(model.DataExportRetention)
+		export.Status = model.DataExportStatusCompleted
+		export.FilePath = filePath
+		export.FileSize = size
+		export.CompletedAt = &now
+		export.ExpiresAt = &expiresAt
+		s.logger.Info("Data export completed",
+			zap.Uint("user_id", export.UserID),
+			zap.Uint("export_id", export.ID),
+			zap.Int64("size", size))
+	}
+
+	if err := s.accountRepo.UpdateExport(&export); err != nil {
+		s.logger.Error("Failed to update data export", zap.Uint("export_id", export.ID), zap.Error(err))
+	}
+}
+
+// writeExportFile 先写入临时文件，完成后再重命名，避免下载到不完整的压缩包
+func (s *accountService) writeExportFile(userID uint, dir, filePath string) (int64, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, err
+	}
+
+	tmpPath := filePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmpPath)
+
+	zw := zip.NewWriter(file)
+	err = s.buildExport(zw, userID)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// buildExport 生成导出压缩包：个人资料、文件夹和文档元数据为JSON，文档正文为Markdown，
+// 上传的文件保持原样放在files目录下
+func (s *accountService) buildExport(zw *zip.Writer, userID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "profile.json", user); err != nil {
+		return err
+	}
+
+	folders, err := s.accountRepo.GetUserFolders(userID)
+	if err != nil {
+		return err
+	}
+	folderResponses := make([]model.FolderResponse, 0, len(folders))
+	for _, folder := range folders {
+		folderResponses = append(folderResponses, model.FolderResponse{
+			ID:        folder.ID,
+			Name:      folder.Name,
+			ParentID:  folder.ParentID,
+			CreatedAt: folder.CreatedAt,
+			UpdatedAt: folder.UpdatedAt,
+		})
+	}
+	if err := writeZipJSON(zw, "folders.json", folderResponses); err != nil {
+		return err
+	}
+
+	attachments, err := s.accountRepo.GetUserAttachments(userID)
+	if err != nil {
+		return err
+	}
+	documentAttachments := make(map[uint][]model.Attachment)
+	for _, attachment := range attachments {
+		documentAttachments[attachment.DocumentID] = append(documentAttachments[attachment.DocumentID], attachment)
+	}
+
+	folderPaths := buildFolderPaths(folders)
+	var documents []model.ExportDocument
+	err = s.accountRepo.FindUserDocuments(userID, func(batch []model.Document) error {
+		for _, document := range batch {
+			path := fmt.Sprintf("%d-%s.md", document.ID, sanitizeExportName(document.Title))
+			if document.FolderID != nil && folderPaths[*document.FolderID] != "" {
+				path = folderPaths[*document.FolderID] + "/" + path
+			}
+			path = "documents/" + path
+
+			w, err := zw.Create(path)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, documentMarkdown(&document)); err != nil {
+				return err
+			}
+
+			attachmentPaths, err := s.writeExportAttachments(zw, documentAttachments[document.ID])
+			if err != nil {
+				return err
+			}
+
+			documents = append(documents, model.ExportDocument{
+				ID:          document.ID,
+				Title:       document.Title,
+				Type:        document.Type,
+				Status:      document.Status,
+				FolderID:    document.FolderID,
+				Tags:        document.Tags,
+				Path:        path,
+				CreatedAt:   document.CreatedAt,
+				UpdatedAt:   document.UpdatedAt,
+				Attachments: attachmentPaths,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "documents.json", documents); err != nil {
+		return err
+	}
+
+	// 活动记录可能很多，分批写入JSON数组
+	w, err := zw.Create("activities.json")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	err = s.accountRepo.FindUserActivities(userID, func(batch []model.Activity) error {
+		for _, activity := range batch {
+			data, err := json.Marshal(model.ActivityResponse{
+				ID:           activity.ID,
+				Type:         activity.Type,
+				ResourceType: activity.ResourceType,
+				ResourceID:   activity.ResourceID,
+				ResourceName: activity.ResourceName,
+				Description:  activity.Description,
+				CreatedAt:    activity.CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return err
+	}
+
+	return s.fileService.WalkUserFiles(userID, func(relPath string, r io.Reader) error {
+		// 附件已按文档导出
+		if strings.HasPrefix(relPath, attachmentDir+"/") {
+			return nil
+		}
+		w, err := zw.Create("files/" + relPath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, r)
+		return err
+	})
+}
+
+// writeExportAttachments 将文档附件以上传时的文件名写入压缩包，文件缺失的附件跳过
+func (s *accountService) writeExportAttachments(zw *zip.Writer, attachments []model.Attachment) ([]string, error) {
+	var paths []string
+	for _, attachment := range attachments {
+		file, err := s.fileService.OpenFile(attachment.FilePath)
+		if err != nil {
+			s.logger.Warn("Attachment file missing in export",
+				zap.Uint("attachment_id", attachment.ID),
+				zap.String("path", attachment.FilePath),
+				zap.Error(err))
+			continue
+		}
+
+		path := fmt.Sprintf("attachments/%d/%d-%s", attachment.DocumentID, attachment.ID, sanitizeExportName(attachment.FileName))
+		w, err := zw.Create(path)
+		if err == nil {
+			_, err = io.Copy(w, file)
+		}
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (s *accountService) userExportDir(userID uint) string {
+	return filepath.Join(s.exportPath, fmt.Sprintf("user_%d", userID))
+}
+
+func writeZipJSON(zw *zip.Writer, name string, value interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// buildFolderPaths 计算每个文件夹在压缩包中的目录路径
+func buildFolderPaths(folders []model.Folder) map[uint]string {
+	byID := make(map[uint]*model.Folder, len(folders))
+	for i := range folders {
+		byID[folders[i].ID] = &folders[i]
+	}
+
+	paths := make(map[uint]string, len(folders))
+	var resolve func(id uint, depth int) string
+	resolve = func(id uint, depth int) string {
+		if path, ok := paths[id]; ok {
+			return path
+		}
+		folder, ok := byID[id]
+		// 防止异常数据造成循环引用
+		if !ok || depth > len(folders) {
+			return ""
+		}
+
+		path := sanitizeExportName(folder.Name)
+		if folder.ParentID != nil {
+			if parent := resolve(*folder.ParentID, depth+1); parent != "" {
+				path = parent + "/" + path
+			}
+		}
+		paths[id] = path
+		return path
+	}
+
+	for _, folder := range folders {
+		resolve(folder.ID, 0)
+	}
+	return paths
+}
+
+// documentMarkdown 生成带元数据头的Markdown
+func documentMarkdown(document *model.Document) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %q\n", document.Title)
+	fmt.Fprintf(&b, "type: %s\n", document.Type)
+	if document.Tags != "" {
+		fmt.Fprintf(&b, "tags: %q\n", document.Tags)
+	}
+	fmt.Fprintf(&b, "created_at: %s\n", document.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "updated_at: %s\n", document.UpdatedAt.Format(time.RFC3339))
+	b.WriteString("---\n\n")
+	content := document.Content
+	if document.Type == model.DocumentTypeWord {
+		content = richTextMarkdown(content)
+	}
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// sanitizeExportName 去除文件名中的非法字符
+func sanitizeExportName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+
+	name = strings.Trim(name, ".")
+	if runes := []rune(name); len(runes) > 80 {
+		name = string(runes[:80])
+	}
+	if name == "" {
+		name = "untitled"
+	}
+	return name
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"time"
 	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/repository"
 
@@ -9,17 +12,26 @@ import (
 
 type ActivityService interface {
 	Create(userID uint, req *model.CreateActivityRequest, ipAddress, userAgent string) error
-	List(userID uint, req *model.ActivityListRequest) ([]model.ActivityResponse, int64, error)
+	// List 分页查询个人足迹，合并编辑会话时不返回总数
+	List(userID uint, req *model.ActivityListRequest) (*model.ActivityListResponse, error)
+	// ListDocumentHistory 查询文档上所有用户的操作记录，仅文档所有者可查看
+	ListDocumentHistory(documentID, userID uint, req *model.ActivityListRequest) (*model.ActivityListResponse, error)
+	// ExportTimeline 按时间正序导出足迹，documentID为0时导出个人足迹，否则导出该文档的历史
+	ExportTimeline(userID, documentID uint, req *model.ActivityListRequest, fn func(item *model.ActivityResponse) error) error
+	// HandleEvent 事件订阅者，为文档、文件夹和评论的操作记录足迹，重复查看只记录一次
+	HandleEvent(event *model.Event) error
 }
 
 type activityService struct {
 	activityRepo repository.ActivityRepository
+	documentRepo repository.DocumentRepository
 	logger       *zap.Logger
 }
 
-func NewActivityService(activityRepo repository.ActivityRepository, logger *zap.Logger) ActivityService {
+func NewActivityService(activityRepo repository.ActivityRepository, documentRepo repository.DocumentRepository, logger *zap.Logger) ActivityService {
 	return &activityService{
 		activityRepo: activityRepo,
+		documentRepo: documentRepo,
 		logger:       logger,
 	}
 }
@@ -50,24 +62,336 @@ func (s *activityService) Create(userID uint, req *model.CreateActivityRequest,
 	return nil
 }
 
-func (s *activityService) List(userID uint, req *model.ActivityListRequest) ([]model.ActivityResponse, int64, error) {
+func (s *activityService) List(userID uint, req *model.ActivityListRequest) (*model.ActivityListResponse, error) {
+	if req.Group {
+		return s.listGrouped(userID, 0, req)
+	}
+
 	activities, total, err := s.activityRepo.List(userID, req)
 	if err != nil {
-		return nil, 0, err
-	}
-
-	var responses []model.ActivityResponse
-	for _, activity := range activities {
-		responses = append(responses, model.ActivityResponse{
-			ID:           activity.ID,
-			Type:         activity.Type,
-			ResourceType: activity.ResourceType,
-			ResourceID:   activity.ResourceID,
-			ResourceName: activity.ResourceName,
-			Description:  activity.Description,
-			CreatedAt:    activity.CreatedAt,
+		return nil, err
+	}
+	return activityPage(activities, total, req), nil
+}
+
+func (s *activityService) ListDocumentHistory(documentID, userID uint, req *model.ActivityListRequest) (*model.ActivityListResponse, error) {
+	if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+
+	if req.Group {
+		return s.listGrouped(userID, documentID, req)
+	}
+
+	activities, total, err := s.activityRepo.ListByResource(model.ResourceTypeDocument, documentID, req)
+	if err != nil {
+		return nil, err
+	}
+	return activityPage(activities, total, req), nil
+}
+
+// activityPage 未合并时的分页结果，包含总数
+func activityPage(activities []model.Activity, total int64, req *model.ActivityListRequest) *model.ActivityListResponse {
+	responses := make([]model.ActivityResponse, 0, len(activities))
+	for i := range activities {
+		responses = append(responses, *activityResponse(&activities[i]))
+	}
+	return &model.ActivityListResponse{
+		Items:   responses,
+		Total:   &total,
+		HasMore: int64(req.Page*req.PageSize) < total,
+		Page:    req.Page,
+		Size:    req.PageSize,
+	}
+}
+
+func (s *activityService) ExportTimeline(userID, documentID uint, req *model.ActivityListRequest, fn func(item *model.ActivityResponse) error) error {
+	if documentID != 0 {
+		if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+			return errors.New("文档不存在或无权访问")
+		}
+	}
+
+	if !req.Group {
+		return s.activityRepo.FindInBatches(userID, model.ResourceTypeDocument, documentID, req, 500, func(activities []model.Activity) error {
+			for i := range activities {
+				if err := fn(activityResponse(&activities[i])); err != nil {
+					return err
+				}
+			}
+			return nil
 		})
 	}
 
-	return responses, total, nil
+	grouper := &activityGrouper{emit: fn}
+	err := s.activityRepo.FindInBatches(userID, model.ResourceTypeDocument, documentID, req, 500, func(activities []model.Activity) error {
+		for i := range activities {
+			if err := grouper.add(&activities[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return grouper.flush()
+}
+
+// listGrouped 从最新的记录开始倒序合并，合并出当前页及其后的一条记录后即停止，不遍历全部历史。
+// 会话跨越分页边界时需要读到会话开始才算合并完成。合并后的总数未知，只返回是否有下一页
+func (s *activityService) listGrouped(userID, documentID uint, req *model.ActivityListRequest) (*model.ActivityListResponse, error) {
+	limit := req.Page*req.PageSize + 1
+	var items []model.ActivityResponse
+	grouper := &activityGrouper{desc: true, emit: func(item *model.ActivityResponse) error {
+		items = append(items, *item)
+		return nil
+	}}
+
+	err := s.activityRepo.FindInBatchesDesc(userID, model.ResourceTypeDocument, documentID, req, 500, func(activities []model.Activity) (bool, error) {
+		for i := range activities {
+			if err := grouper.add(&activities[i]); err != nil {
+				return false, err
+			}
+			if len(items) >= limit {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) < limit {
+		if err := grouper.flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &model.ActivityListResponse{
+		Items:   []model.ActivityResponse{},
+		HasMore: len(items) >= limit,
+		Page:    req.Page,
+		Size:    req.PageSize,
+	}
+	start := (req.Page - 1) * req.PageSize
+	if start < len(items) {
+		end := start + req.PageSize
+		if end > len(items) {
+			end = len(items)
+		}
+		response.Items = items[start:end]
+	}
+	return response, nil
+}
+
+func activityResponse(activity *model.Activity) *model.ActivityResponse {
+	return &model.ActivityResponse{
+		ID:           activity.ID,
+		Type:         activity.Type,
+		ResourceType: activity.ResourceType,
+		ResourceID:   activity.ResourceID,
+		ResourceName: activity.ResourceName,
+		Description:  activity.Description,
+		UserID:       activity.UserID,
+		Username:     activity.User.Username,
+		CreatedAt:    activity.CreatedAt,
+	}
+}
+
+// activityGrouper 按时间顺序接收足迹，将同一用户对同一资源的连续编辑合并为一次编辑会话
+type activityGrouper struct {
+	emit        func(item *model.ActivityResponse) error
+	desc        bool // 按时间倒序接收，会话保留最后一次编辑的记录，向前延伸开始时间
+	current     *model.ActivityResponse
+	description string // 合并前的描述，用于判断是否为同类编辑
+}
+
+func (g *activityGrouper) add(activity *model.Activity) error {
+	if g.current != nil && g.mergeable(activity) {
+		if g.current.Session == nil {
+			g.current.Session = &model.ActivitySession{
+				Count:     1,
+				StartedAt: g.current.CreatedAt,
+				EndedAt:   g.current.CreatedAt,
+				FirstID:   g.current.ID,
+			}
+		}
+		g.current.Session.Count++
+		if g.desc {
+			g.current.Session.StartedAt = activity.CreatedAt
+			g.current.Session.FirstID = activity.ID
+			return nil
+		}
+		g.current.Session.EndedAt = activity.CreatedAt
+		g.current.ID = activity.ID
+		g.current.ResourceName = activity.ResourceName
+		g.current.CreatedAt = activity.CreatedAt
+		return nil
+	}
+
+	if err := g.flush(); err != nil {
+		return err
+	}
+	g.current = activityResponse(activity)
+	g.description = activity.Description
+	return nil
+}
+
+// mergeable 只合并更新类记录，发布文档等描述不同的更新单独展示
+func (g *activityGrouper) mergeable(activity *model.Activity) bool {
+	gap := activity.CreatedAt.Sub(g.current.CreatedAt)
+	if g.desc {
+		startedAt := g.current.CreatedAt
+		if g.current.Session != nil {
+			startedAt = g.current.Session.StartedAt
+		}
+		gap = startedAt.Sub(activity.CreatedAt)
+	}
+	return activity.Type == model.ActivityTypeUpdate &&
+		g.current.Type == model.ActivityTypeUpdate &&
+		activity.UserID == g.current.UserID &&
+		activity.ResourceType == g.current.ResourceType &&
+		activity.ResourceID == g.current.ResourceID &&
+		activity.Description == g.description &&
+		gap <= model.ActivitySessionGap
+}
+
+func (g *activityGrouper) flush() error {
+	if g.current == nil {
+		return nil
+	}
+	item := g.current
+	g.current = nil
+
+	if session := item.Session; session != nil {
+		item.Description = fmt.Sprintf("%s至%s之间编辑了%d次",
+			formatSessionTime(session.StartedAt, session.StartedAt),
+			formatSessionTime(session.EndedAt, session.StartedAt),
+			session.Count)
+	}
+	return g.emit(item)
+}
+
+// formatSessionTime 与会话开始时间同一天时只显示时分
+func formatSessionTime(t, start time.Time) string {
+	t, start = t.Local(), start.Local()
+	if t.Year() == start.Year() && t.YearDay() == start.YearDay() {
+		return t.Format("15:04")
+	}
+	return t.Format("01-02 15:04")
+}
+
+func (s *activityService) HandleEvent(event *model.Event) error {
+	activity := activityFromEvent(event)
+	if activity == nil {
+		return nil
+	}
+
+	if activity.Type == model.ActivityTypeView {
+		since := event.OccurredAt.Add(-model.ViewActivityDedupWindow)
+		exists, err := s.activityRepo.ExistsSince(activity.UserID, activity.Type, activity.ResourceType, activity.ResourceID, since)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	return s.activityRepo.Create(activity)
+}
+
+// activityFromEvent 将事件转换为足迹记录，不需要记录的事件返回nil
+func activityFromEvent(event *model.Event) *model.Activity {
+	activity := &model.Activity{UserID: event.ActorID}
+	if event.Client != nil {
+		activity.IPAddress = event.Client.IPAddress
+		activity.UserAgent = truncateRunes(event.Client.UserAgent, 450)
+	}
+
+	switch {
+	case event.Document != nil:
+		activity.ResourceType = model.ResourceTypeDocument
+		activity.ResourceID = event.Document.ID
+		activity.ResourceName = event.Document.Title
+	case event.Folder != nil:
+		activity.ResourceType = model.ResourceTypeFolder
+		activity.ResourceID = event.Folder.ID
+		activity.ResourceName = event.Folder.Name
+	case event.Share != nil:
+		activity.ResourceType = model.ResourceTypeDocument
+		activity.ResourceID = event.Share.DocumentID
+		activity.ResourceName = event.Share.Title
+	case event.Comment != nil:
+		activity.ResourceType = model.ResourceTypeDocument
+		activity.ResourceID = event.Comment.DocumentID
+		activity.ResourceName = event.Comment.DocumentTitle
+	default:
+		return nil
+	}
+
+	switch event.Type {
+	case model.EventDocumentCreated:
+		activity.Type, activity.Description = model.ActivityTypeCreate, "创建文档"
+	case model.EventDocumentCopied:
+		activity.Type, activity.Description = model.ActivityTypeCopy, "复制文档"
+	case model.EventDocumentUpdated:
+		activity.Type, activity.Description = model.ActivityTypeUpdate, "更新文档"
+		status, previousStatus := event.Document.Status, event.Document.PreviousStatus
+		switch {
+		case status == previousStatus:
+		case status == model.DocumentStatusPublished:
+			activity.Description = "发布文档"
+		case status == model.DocumentStatusArchived:
+			activity.Description = "归档文档"
+		case previousStatus == model.DocumentStatusArchived:
+			activity.Description = "取消归档"
+		case previousStatus == model.DocumentStatusPublished:
+			activity.Description = "取消发布"
+		}
+	case model.EventDocumentDeleted:
+		activity.Type, activity.Description = model.ActivityTypeDelete, "删除文档"
+	case model.EventDocumentRestored:
+		activity.Type, activity.Description = model.ActivityTypeRestore, "从回收站恢复文档"
+	case model.EventDocumentMoved:
+		activity.Type, activity.Description = model.ActivityTypeMove, "移动文档"
+	case model.EventDocumentSubmitted:
+		activity.Type, activity.Description = model.ActivityTypeReview, "提交审核"
+	case model.EventDocumentWithdrawn:
+		activity.Type, activity.Description = model.ActivityTypeReview, "撤回审核"
+	case model.EventDocumentReviewed:
+		activity.Type, activity.Description = model.ActivityTypeReview, "审核通过"
+		if event.Review.Decision == model.ReviewDecisionRejected {
+			activity.Description = truncateRunes("驳回审核："+event.Review.Comment, 250)
+		}
+	case model.EventDocumentScheduled:
+		activity.Type, activity.Description = model.ActivityTypeUpdate, "取消定时发布"
+		if event.Document.PublishAt != nil {
+			activity.Description = "设置定时发布：" + event.Document.PublishAt.Format("2006-01-02 15:04")
+		}
+	case model.EventDocumentViewed:
+		activity.Type, activity.Description = model.ActivityTypeView, "查看文档"
+	case model.EventDocumentShared:
+		activity.Type, activity.Description = model.ActivityTypeShare, "分享文档"
+	case model.EventFolderCreated:
+		activity.Type, activity.Description = model.ActivityTypeCreate, "创建文件夹"
+	case model.EventFolderUpdated:
+		activity.Type = model.ActivityTypeUpdate
+		activity.Description = "更新文件夹：" + event.Folder.PreviousName + " -> " + event.Folder.Name
+	case model.EventFolderMoved:
+		activity.Type, activity.Description = model.ActivityTypeMove, "移动文件夹"
+	case model.EventFolderDeleted:
+		activity.Type, activity.Description = model.ActivityTypeDelete, "删除文件夹"
+	case model.EventCommentCreated:
+		activity.Type, activity.Description = model.ActivityTypeComment, "发表评论"
+		if event.Comment.ParentID != nil {
+			activity.Description = "回复评论"
+		}
+	default:
+		// 发布事件已随更新事件记录，其余事件不记录足迹
+		return nil
+	}
+
+	return activity
 }
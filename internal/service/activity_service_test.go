@@ -0,0 +1,126 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// testActivityRepo 按时间倒序保存足迹，只实现分页合并用到的方法
+type testActivityRepo struct {
+	repository.ActivityRepository
+	activities []model.Activity
+	read       int
+}
+
+func (r *testActivityRepo) FindInBatchesDesc(userID uint, resourceType model.ResourceType, resourceID uint, req *model.ActivityListRequest, batchSize int, fn func(activities []model.Activity) (bool, error)) error {
+	for start := 0; start < len(r.activities); start += batchSize {
+		end := start + batchSize
+		if end > len(r.activities) {
+			end = len(r.activities)
+		}
+		r.read = end
+		more, err := fn(r.activities[start:end])
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestListGroupedPaging(t *testing.T) {
+	// 从新到旧：3次连续编辑、1次创建、2次连续编辑、1次创建，合并后共4条
+	now := time.Now()
+	kinds := []model.ActivityType{
+		model.ActivityTypeUpdate, model.ActivityTypeUpdate, model.ActivityTypeUpdate,
+		model.ActivityTypeCreate,
+		model.ActivityTypeUpdate, model.ActivityTypeUpdate,
+		model.ActivityTypeCreate,
+	}
+	var activities []model.Activity
+	for i, kind := range kinds {
+		activities = append(activities, model.Activity{
+			ID:           uint(len(kinds) - i),
+			UserID:       1,
+			Type:         kind,
+			ResourceType: model.ResourceTypeDocument,
+			ResourceID:   1,
+			CreatedAt:    now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+
+	tests := []struct {
+		name      string
+		page      int
+		pageSize  int
+		wantIDs   []uint
+		wantCount []int // 每条记录合并的编辑次数，未合并为0
+		wantMore  bool
+	}{
+		{"第一页", 1, 2, []uint{7, 4}, []int{3, 0}, true},
+		{"最后一页", 2, 2, []uint{3, 1}, []int{2, 0}, false},
+		{"恰好一页", 1, 4, []uint{7, 4, 3, 1}, []int{3, 0, 2, 0}, false},
+		{"超出范围", 3, 2, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &testActivityRepo{activities: activities}
+			s := NewActivityService(repo, nil, zap.NewNop())
+
+			result, err := s.List(1, &model.ActivityListRequest{Page: tt.page, PageSize: tt.pageSize, Group: true})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if result.Total != nil {
+				t.Errorf("total = %d, want nil", *result.Total)
+			}
+			if result.HasMore != tt.wantMore {
+				t.Errorf("has_more = %v, want %v", result.HasMore, tt.wantMore)
+			}
+			if len(result.Items) != len(tt.wantIDs) {
+				t.Fatalf("got %d items, want %d", len(result.Items), len(tt.wantIDs))
+			}
+			for i, item := range result.Items {
+				count := 0
+				if item.Session != nil {
+					count = item.Session.Count
+				}
+				if item.ID != tt.wantIDs[i] || count != tt.wantCount[i] {
+					t.Errorf("item %d = id %d count %d, want id %d count %d", i, item.ID, count, tt.wantIDs[i], tt.wantCount[i])
+				}
+			}
+		})
+	}
+}
+
+func TestListGroupedStopsAfterPage(t *testing.T) {
+	now := time.Now()
+	var activities []model.Activity
+	for i := 0; i < 2000; i++ {
+		activities = append(activities, model.Activity{
+			ID:           uint(2000 - i),
+			UserID:       1,
+			Type:         model.ActivityTypeCreate,
+			ResourceType: model.ResourceTypeDocument,
+			ResourceID:   uint(i),
+			CreatedAt:    now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	repo := &testActivityRepo{activities: activities}
+	s := NewActivityService(repo, nil, zap.NewNop())
+
+	result, err := s.List(1, &model.ActivityListRequest{Page: 1, PageSize: 20, Group: true})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if !result.HasMore || len(result.Items) != 20 {
+		t.Errorf("got %d items, has_more %v, want 20 items and more", len(result.Items), result.HasMore)
+	}
+	if repo.read > 500 {
+		t.Errorf("read %d activities, want only the first batch", repo.read)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"errors"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type AdminService interface {
+	ListUsers(req *model.AdminUserListRequest) ([]model.AdminUserResponse, int64, error)
+	GetUser(id uint) (*model.AdminUserResponse, error)
+	UpdateUserStatus(adminID, userID uint, status int) error
+	UpdateUserRole(adminID, userID uint, role string) error
+	ResetPassword(adminID, userID uint, req *model.ResetPasswordRequest) (*model.ResetPasswordResponse, error)
+	GetSystemStats() (*model.SystemStats, error)
+}
+
+type adminService struct {
+	userRepo     repository.UserRepository
+	apiTokenRepo repository.APITokenRepository
+	adminRepo    repository.AdminRepository
+	logger       *zap.Logger
+}
+
+func NewAdminService(
+	userRepo repository.UserRepository,
+	apiTokenRepo repository.APITokenRepository,
+	adminRepo repository.AdminRepository,
+	logger *zap.Logger) AdminService {
+	return &adminService{
+		userRepo:     userRepo,
+		apiTokenRepo: apiTokenRepo,
+		adminRepo:    adminRepo,
+		logger:       logger,
+	}
+}
+
+func (s *adminService) ListUsers(req *model.AdminUserListRequest) ([]model.AdminUserResponse, int64, error) {
+	users, total, err := s.userRepo.Search(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]model.AdminUserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, toAdminUserResponse(&users[i]))
+	}
+
+	return responses, total, nil
+}
+
+func (s *adminService) GetUser(id uint) (*model.AdminUserResponse, error) {
+	user, err := s.getUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toAdminUserResponse(user)
+	return &response, nil
+}
+
+func (s *adminService) UpdateUserStatus(adminID, userID uint, status int) error {
+	if adminID == userID {
+		return errors.New("不能修改自己的账号状态")
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Status == status {
+		return nil
+	}
+
+	err = s.userRepo.UpdateStatus(userID, status)
+	if err != nil {
+		return err
+	}
+
+	// 禁用账号时使已签发的令牌全部失效
+	if status == model.UserStatusDisabled {
+		if err := s.revokeCredentials(userID); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("User status updated by admin",
+		zap.Uint("admin_id", adminID),
+		zap.Uint("user_id", userID),
+		zap.Int("status", status))
+
+	return nil
+}
+
+func (s *adminService) UpdateUserRole(adminID, userID uint, role string) error {
+	if !model.IsValidUserRole(role) {
+		return errors.New("无效的角色")
+	}
+	if adminID == userID {
+		return errors.New("不能修改自己的角色")
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	err = s.userRepo.UpdateRole(userID, role)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("User role updated by admin",
+		zap.Uint("admin_id", adminID),
+		zap.Uint("user_id", userID),
+		zap.String("old_role", user.Role),
+		zap.String("new_role", role))
+
+	return nil
+}
+
+func (s *adminService) ResetPassword(adminID, userID uint, req *model.ResetPasswordRequest) (*model.ResetPasswordResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.ResetPasswordResponse{}
+	newPassword := req.NewPassword
+	if newPassword == "" {
+		newPassword, err = generateRandomString(12)
+		if err != nil {
+			return nil, err
+		}
+		response.TemporaryPassword = newPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = string(hashedPassword)
+	err = s.userRepo.Update(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重置密码后需要重新登录
+	if err := s.revokeCredentials(userID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User password reset by admin",
+		zap.Uint("admin_id", adminID),
+		zap.Uint("user_id", userID))
+
+	return response, nil
+}
+
+func (s *adminService) GetSystemStats() (*model.SystemStats, error) {
+	return s.adminRepo.GetSystemStats()
+}
+
+func (s *adminService) getUser(id uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("用户不存在")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// revokeCredentials 使用户的登录令牌和个人访问令牌全部失效
+func (s *adminService) revokeCredentials(userID uint) error {
+	if err := s.userRepo.IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+	return s.apiTokenRepo.DeleteByUserID(userID)
+}
+
+func toAdminUserResponse(user *model.User) model.AdminUserResponse {
+	return model.AdminUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Nickname:  user.Nickname,
+		Avatar:    user.Avatar,
+		Status:    user.Status,
+		Role:      user.Role,
+		LastLogin: user.LastLogin,
+		CreatedAt: user.CreatedAt,
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// RateLimitError 超出调用频率或额度，RetryAfter为建议的等待时间
+type RateLimitError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Message
+}
+
+type AIUsageService interface {
+	// Acquire 调用模型前检查每分钟请求数和当日token额度，超出时返回*RateLimitError
+	Acquire(userID uint) error
+	// Record 记录一次调用的用量，err不为nil时记为失败
+	Record(userID uint, feature model.AIFeature, provider LLMProvider, usage *model.LLMUsage, duration time.Duration, err error)
+	Summary(userID uint) (*model.AIUsageSummary, error)
+}
+
+type aiUsageService struct {
+	usageRepo         repository.AIUsageRepository
+	requestsPerMinute int
+	dailyTokenLimit   int64
+	logger            *zap.Logger
+
+	// 每个用户最近一分钟内的请求时间，只在单个实例内生效
+	mu       sync.Mutex
+	requests map[uint][]time.Time
+}
+
+// NewAIUsageService requestsPerMinute和dailyTokenLimit为0时不限制
+func NewAIUsageService(usageRepo repository.AIUsageRepository, requestsPerMinute int, dailyTokenLimit int64, logger *zap.Logger) AIUsageService {
+	return &aiUsageService{
+		usageRepo:         usageRepo,
+		requestsPerMinute: requestsPerMinute,
+		dailyTokenLimit:   dailyTokenLimit,
+		logger:            logger,
+		requests:          make(map[uint][]time.Time),
+	}
+}
+
+func (s *aiUsageService) Acquire(userID uint) error {
+	now := time.Now()
+
+	if s.dailyTokenLimit > 0 {
+		tokens, err := s.usageRepo.SumTokensSince(userID, startOfDay(now))
+		if err != nil {
+			return err
+		}
+		if tokens >= s.dailyTokenLimit {
+			return &RateLimitError{
+				Message:    fmt.Sprintf("今日AI用量已达上限(%d tokens)，请明天再试", s.dailyTokenLimit),
+				RetryAfter: startOfDay(now).AddDate(0, 0, 1).Sub(now),
+			}
+		}
+	}
+
+	if s.requestsPerMinute <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window := now.Add(-time.Minute)
+	recent := s.requests[userID][:0]
+	for _, t := range s.requests[userID] {
+		if t.After(window) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= s.requestsPerMinute {
+		s.requests[userID] = recent
+		return &RateLimitError{
+			Message:    "AI请求过于频繁，请稍后再试",
+			RetryAfter: recent[0].Add(time.Minute).Sub(now),
+		}
+	}
+	s.requests[userID] = append(recent, now)
+
+	// 顺带清理长时间未使用的用户，避免map持续增长
+	if len(s.requests) > 1000 {
+		for id, times := range s.requests {
+			if len(times) == 0 || !times[len(times)-1].After(window) {
+				delete(s.requests, id)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *aiUsageService) Record(userID uint, feature model.AIFeature, provider LLMProvider, usage *model.LLMUsage, duration time.Duration, err error) {
+	record := &model.AIUsage{
+		UserID:     userID,
+		Feature:    feature,
+		Provider:   provider.Name(),
+		Model:      provider.Model(),
+		Status:     model.AIUsageSuccess,
+		DurationMs: duration.Milliseconds(),
+	}
+	if usage != nil {
+		record.PromptTokens = usage.PromptTokens
+		record.CompletionTokens = usage.CompletionTokens
+	}
+	if err != nil {
+		record.Status = model.AIUsageFailed
+	}
+
+	if err := s.usageRepo.Create(record); err != nil {
+		s.logger.Error("Failed to record AI usage",
+			zap.Uint("user_id", userID),
+			zap.String("feature", string(feature)),
+			zap.Error(err))
+	}
+}
+
+func (s *aiUsageService) Summary(userID uint) (*model.AIUsageSummary, error) {
+	now := time.Now()
+	tokens, err := s.usageRepo.SumTokensSince(userID, startOfDay(now))
+	if err != nil {
+		return nil, err
+	}
+	features, err := s.usageRepo.SummaryByFeature(userID, startOfDay(now).AddDate(0, 0, -29))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AIUsageSummary{
+		TokensToday:       tokens,
+		DailyTokenLimit:   s.dailyTokenLimit,
+		RequestsPerMinute: s.requestsPerMinute,
+		Last30Days:        features,
+	}, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
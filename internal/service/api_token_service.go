@@ -0,0 +1,181 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAPITokenExpiryDays = 30
+	// 最后使用时间的刷新间隔，避免每次请求都写库
+	apiTokenLastUsedInterval = time.Minute
+)
+
+type APITokenService interface {
+	Create(userID uint, req *model.CreateAPITokenRequest) (*model.CreateAPITokenResponse, error)
+	List(userID uint) ([]model.APITokenResponse, error)
+	Revoke(id, userID uint) error
+	Authenticate(rawToken, ipAddress string) (*model.APIToken, error)
+}
+
+type apiTokenService struct {
+	apiTokenRepo repository.APITokenRepository
+	userRepo     repository.UserRepository
+	logger       *zap.Logger
+}
+
+func NewAPITokenService(
+	apiTokenRepo repository.APITokenRepository,
+	userRepo repository.UserRepository,
+	logger *zap.Logger) APITokenService {
+	return &apiTokenService{
+		apiTokenRepo: apiTokenRepo,
+		userRepo:     userRepo,
+		logger:       logger,
+	}
+}
+
+func (s *apiTokenService) Create(userID uint, req *model.CreateAPITokenRequest) (*model.CreateAPITokenResponse, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !model.IsValidTokenScope(scope) {
+			return nil, errors.New("无效的权限范围: " + scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	expiryDays := req.ExpiresInDays
+	if expiryDays == 0 {
+		expiryDays = defaultAPITokenExpiryDays
+	}
+
+	secret, err := generateRandomString(40)
+	if err != nil {
+		return nil, err
+	}
+	rawToken := model.APITokenPrefix + secret
+
+	token := &model.APIToken{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    rawToken[:len(model.APITokenPrefix)+6],
+		TokenHash: hashAPIToken(rawToken),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: time.Now().AddDate(0, 0, expiryDays),
+	}
+
+	err = s.apiTokenRepo.Create(token)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("API token created",
+		zap.Uint("user_id", userID),
+		zap.Uint("token_id", token.ID),
+		zap.String("scopes", token.Scopes))
+
+	return &model.CreateAPITokenResponse{
+		APITokenResponse: toAPITokenResponse(token),
+		Token:            rawToken,
+	}, nil
+}
+
+func (s *apiTokenService) List(userID uint) ([]model.APITokenResponse, error) {
+	tokens, err := s.apiTokenRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.APITokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responses = append(responses, toAPITokenResponse(&tokens[i]))
+	}
+
+	return responses, nil
+}
+
+func (s *apiTokenService) Revoke(id, userID uint) error {
+	_, err := s.apiTokenRepo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("访问令牌不存在")
+		}
+		return err
+	}
+
+	err = s.apiTokenRepo.Delete(id, userID)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("API token revoked",
+		zap.Uint("user_id", userID),
+		zap.Uint("token_id", id))
+
+	return nil
+}
+
+func (s *apiTokenService) Authenticate(rawToken, ipAddress string) (*model.APIToken, error) {
+	token, err := s.apiTokenRepo.GetByHash(hashAPIToken(rawToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("访问令牌无效")
+		}
+		return nil, err
+	}
+
+	if token.IsExpired() {
+		return nil, errors.New("访问令牌已过期")
+	}
+
+	// 令牌所属用户被禁用时令牌不可用
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != model.UserStatusActive {
+		return nil, errors.New("用户已被禁用")
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenLastUsedInterval || token.LastUsedIP != ipAddress {
+		if err := s.apiTokenRepo.UpdateLastUsed(token.ID, now, ipAddress); err != nil {
+			s.logger.Error("Failed to update API token last used", zap.Error(err))
+		}
+		token.LastUsedAt = &now
+		token.LastUsedIP = ipAddress
+	}
+
+	return token, nil
+}
+
+func hashAPIToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAPITokenResponse(token *model.APIToken) model.APITokenResponse {
+	return model.APITokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		CreatedAt:  token.CreatedAt,
+	}
+}
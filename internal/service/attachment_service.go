@@ -0,0 +1,289 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// 附件保存在用户目录下的子目录
+const attachmentDir = "attachments"
+
+// attachmentExtensions 允许上传的附件类型及保存时使用的扩展名，不使用上传的文件名中的扩展名，避免静态文件被按其他类型访问
+var attachmentExtensions = map[string]string{
+	"image/jpeg":         ".jpg",
+	"image/png":          ".png",
+	"image/gif":          ".gif",
+	"image/webp":         ".webp",
+	"application/pdf":    ".pdf",
+	"application/msword": ".doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/vnd.ms-excel": ".xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.ms-powerpoint":                                             ".ppt",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+	"text/markdown":   ".md",
+	"text/csv":        ".csv",
+}
+
+type AttachmentService interface {
+	List(documentID, userID uint) ([]model.AttachmentResponse, error)
+	Upload(documentID, userID uint, fileHeader *multipart.FileHeader) (*model.AttachmentResponse, error)
+	// Open 打开附件文件用于下载，调用方负责关闭
+	Open(documentID, attachmentID, userID uint) (*model.Attachment, io.ReadCloser, error)
+	Delete(documentID, attachmentID, userID uint) error
+	// TotalSize 返回文档附件的总大小，计入文档大小
+	TotalSize(documentID uint) (int64, error)
+	// CopyAll 复制文档时将附件复制到副本，并更新副本的大小
+	CopyAll(sourceID, targetID, userID uint) error
+	// DeleteByDocument 删除文档的全部附件和文件，文档从回收站永久删除时调用
+	DeleteByDocument(documentID uint) error
+}
+
+type attachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	documentRepo   repository.DocumentRepository
+	fileService    FileService
+	logger         *zap.Logger
+}
+
+func NewAttachmentService(attachmentRepo repository.AttachmentRepository, documentRepo repository.DocumentRepository, fileService FileService, logger *zap.Logger) AttachmentService {
+	return &attachmentService{
+		attachmentRepo: attachmentRepo,
+		documentRepo:   documentRepo,
+		fileService:    fileService,
+		logger:         logger,
+	}
+}
+
+func (s *attachmentService) List(documentID, userID uint) ([]model.AttachmentResponse, error) {
+	if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+
+	attachments, err := s.attachmentRepo.ListByDocumentID(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.AttachmentResponse, 0, len(attachments))
+	for i := range attachments {
+		responses = append(responses, s.toResponse(&attachments[i]))
+	}
+	return responses, nil
+}
+
+func (s *attachmentService) Upload(documentID, userID uint, fileHeader *multipart.FileHeader) (*model.AttachmentResponse, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+
+	if fileHeader.Size > model.AttachmentMaxSize {
+		return nil, fmt.Errorf("附件大小超过限制，最大支持%dMB", model.AttachmentMaxSize>>20)
+	}
+	contentType, _, err := mime.ParseMediaType(fileHeader.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, errors.New("无法识别文件类型")
+	}
+	ext, ok := attachmentExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("不支持的文件类型: %s", contentType)
+	}
+
+	count, err := s.attachmentRepo.CountByDocumentID(documentID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= model.AttachmentMaxPerDocument {
+		return nil, fmt.Errorf("每个文档最多上传%d个附件", model.AttachmentMaxPerDocument)
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	attachment := &model.Attachment{
+		UserID:     userID,
+		DocumentID: document.ID,
+		FileName:   truncateRunes(fileHeader.Filename, 200),
+		MimeType:   contentType,
+		Size:       fileHeader.Size,
+	}
+	if err := s.store(attachment, ext, src); err != nil {
+		return nil, err
+	}
+	if err := s.attachmentRepo.RefreshDocumentSize(document.ID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Attachment uploaded",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.Uint("attachment_id", attachment.ID),
+		zap.Int64("size", attachment.Size))
+
+	response := s.toResponse(attachment)
+	return &response, nil
+}
+
+func (s *attachmentService) Open(documentID, attachmentID, userID uint) (*model.Attachment, io.ReadCloser, error) {
+	if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+		return nil, nil, errors.New("文档不存在或无权访问")
+	}
+
+	attachment, err := s.attachmentRepo.GetByIDAndDocumentID(attachmentID, documentID)
+	if err != nil {
+		return nil, nil, errors.New("附件不存在")
+	}
+
+	file, err := s.fileService.OpenFile(attachment.FilePath)
+	if err != nil {
+		s.logger.Error("Failed to open attachment",
+			zap.Uint("attachment_id", attachment.ID),
+			zap.String("path", attachment.FilePath),
+			zap.Error(err))
+		return nil, nil, errors.New("附件文件不存在")
+	}
+	return attachment, file, nil
+}
+
+func (s *attachmentService) Delete(documentID, attachmentID, userID uint) error {
+	if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+		return errors.New("文档不存在或无权访问")
+	}
+
+	attachment, err := s.attachmentRepo.GetByIDAndDocumentID(attachmentID, documentID)
+	if err != nil {
+		return errors.New("附件不存在")
+	}
+
+	if err := s.attachmentRepo.Delete(attachment.ID); err != nil {
+		return err
+	}
+	s.removeFile(attachment)
+	if err := s.attachmentRepo.RefreshDocumentSize(documentID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Attachment deleted",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", documentID),
+		zap.Uint("attachment_id", attachment.ID))
+
+	return nil
+}
+
+func (s *attachmentService) TotalSize(documentID uint) (int64, error) {
+	return s.attachmentRepo.SumSizeByDocumentID(documentID)
+}
+
+func (s *attachmentService) CopyAll(sourceID, targetID, userID uint) error {
+	attachments, err := s.attachmentRepo.ListByDocumentID(sourceID)
+	if err != nil {
+		return err
+	}
+
+	// 部分附件复制失败时仍按实际复制的附件更新副本大小
+	var copyErr error
+	for _, source := range attachments {
+		if err := s.copyAttachment(&source, targetID, userID); err != nil {
+			copyErr = err
+			break
+		}
+	}
+	if err := s.attachmentRepo.RefreshDocumentSize(targetID); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	return copyErr
+}
+
+func (s *attachmentService) DeleteByDocument(documentID uint) error {
+	attachments, err := s.attachmentRepo.ListByDocumentID(documentID)
+	if err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	if err := s.attachmentRepo.DeleteByDocumentID(documentID); err != nil {
+		return err
+	}
+	for i := range attachments {
+		s.removeFile(&attachments[i])
+	}
+
+	s.logger.Info("Document attachments deleted",
+		zap.Uint("document_id", documentID),
+		zap.Int("count", len(attachments)))
+
+	return nil
+}
+
+func (s *attachmentService) copyAttachment(source *model.Attachment, targetID, userID uint) error {
+	src, err := s.fileService.OpenFile(source.FilePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	attachment := &model.Attachment{
+		UserID:     userID,
+		DocumentID: targetID,
+		FileName:   source.FileName,
+		MimeType:   source.MimeType,
+		Size:       source.Size,
+	}
+	return s.store(attachment, attachmentExtensions[source.MimeType], src)
+}
+
+// store 以随机文件名保存附件文件并写入记录，写入记录失败时删除文件
+func (s *attachmentService) store(attachment *model.Attachment, ext string, r io.Reader) error {
+	name, err := generateRandomString(32)
+	if err != nil {
+		return err
+	}
+
+	attachment.FilePath, err = s.fileService.StoreUserFile(attachment.UserID, attachmentDir, name+ext, r)
+	if err != nil {
+		return err
+	}
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		s.removeFile(attachment)
+		return err
+	}
+	return nil
+}
+
+// removeFile 删除附件文件，失败时只记录日志，记录已删除的附件不会再被访问
+func (s *attachmentService) removeFile(attachment *model.Attachment) {
+	if err := s.fileService.RemoveFile(attachment.FilePath); err != nil {
+		s.logger.Warn("Failed to remove attachment file",
+			zap.Uint("attachment_id", attachment.ID),
+			zap.String("path", attachment.FilePath),
+			zap.Error(err))
+	}
+}
+
+func (s *attachmentService) toResponse(attachment *model.Attachment) model.AttachmentResponse {
+	return model.AttachmentResponse{
+		ID:         attachment.ID,
+		DocumentID: attachment.DocumentID,
+		FileName:   attachment.FileName,
+		MimeType:   attachment.MimeType,
+		Size:       attachment.Size,
+		URL:        s.fileService.GetFileURL(attachment.FilePath),
+		CreatedAt:  attachment.CreatedAt,
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// errAuditChainBroken 用于在批量校验中提前结束遍历
+var errAuditChainBroken = errors.New("audit chain broken")
+
+type AuditService interface {
+	Record(entry *model.AuditEntry) error
+	List(req *model.AuditLogListRequest) ([]model.AuditLog, int64, error)
+	Export(req *model.AuditLogListRequest, fn func(log *model.AuditLog) error) error
+	Verify() (*model.AuditVerifyResponse, error)
+}
+
+type auditService struct {
+	auditLogRepo repository.AuditLogRepository
+	logger       *zap.Logger
+	mu           sync.Mutex
+}
+
+func NewAuditService(auditLogRepo repository.AuditLogRepository, logger *zap.Logger) AuditService {
+	return &auditService{
+		auditLogRepo: auditLogRepo,
+		logger:       logger,
+	}
+}
+
+func (s *auditService) Record(entry *model.AuditEntry) error {
+	before, err := marshalAuditValue(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditValue(entry.After)
+	if err != nil {
+		return err
+	}
+
+	log := &model.AuditLog{
+		ActorID:      entry.ActorID,
+		ActorRole:    entry.ActorRole,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		IPAddress:    entry.IPAddress,
+		// 超出字段长度的UserAgent会被数据库截断，导致Hash校验失败，计算Hash前先截断
+		UserAgent: truncateRunes(entry.UserAgent, 450),
+		Before:    before,
+		After:     after,
+		// 数据库只保存到秒，计算Hash前先截断，保证校验时一致
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+
+	// 串行写入，保证链条顺序
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.auditLogRepo.Append(log, func(prevHash string) string {
+		return computeAuditHash(prevHash, log)
+	})
+	if err != nil {
+		s.logger.Error("Failed to record audit log",
+			zap.String("action", string(entry.Action)),
+			zap.Uint("actor_id", entry.ActorID),
+			zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *auditService) List(req *model.AuditLogListRequest) ([]model.AuditLog, int64, error) {
+	return s.auditLogRepo.List(req)
+}
+
+func (s *auditService) Export(req *model.AuditLogListRequest, fn func(log *model.AuditLog) error) error {
+	return s.auditLogRepo.FindInBatches(req, 500, func(logs []model.AuditLog) error {
+		for i := range logs {
+			if err := fn(&logs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *auditService) Verify() (*model.AuditVerifyResponse, error) {
+	response := &model.AuditVerifyResponse{Valid: true}
+	prevHash := ""
+
+	err := s.auditLogRepo.FindInBatches(&model.AuditLogListRequest{}, 1000, func(logs []model.AuditLog) error {
+		for i := range logs {
+			log := &logs[i]
+			if log.PrevHash != prevHash || computeAuditHash(log.PrevHash, log) != log.Hash {
+				response.Valid = false
+				response.BrokenID = log.ID
+				return errAuditChainBroken
+			}
+			prevHash = log.Hash
+			response.CheckedCount++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return nil, err
+	}
+
+	if response.Valid {
+		response.Message = "审计日志完整"
+	} else {
+		response.Message = fmt.Sprintf("审计日志在记录%d处校验失败，可能已被篡改", response.BrokenID)
+		s.logger.Warn("Audit log chain verification failed", zap.Uint("broken_id", response.BrokenID))
+	}
+
+	return response, nil
+}
+
+// computeAuditHash 计算审计记录的Hash，每个字段带长度前缀，避免拼接歧义
+func computeAuditHash(prevHash string, log *model.AuditLog) string {
+	fields := []string{
+		prevHash,
+		strconv.FormatUint(uint64(log.ActorID), 10),
+		log.ActorRole,
+		string(log.Action),
+		log.ResourceType,
+		strconv.FormatUint(uint64(log.ResourceID), 10),
+		log.IPAddress,
+		log.UserAgent,
+		log.Before,
+		log.After,
+		strconv.FormatInt(log.CreatedAt.Unix(), 10),
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(h, "%d:%s", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func marshalAuditValue(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,518 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxCommentMentions 单条评论最多@的用户数
+const maxCommentMentions = 20
+
+type CommentService interface {
+	Create(documentID, userID uint, shareToken string, req *model.CreateCommentRequest) (*model.CommentResponse, error)
+	Update(documentID, commentID, userID uint, shareToken string, req *model.UpdateCommentRequest) (*model.CommentResponse, error)
+	Delete(documentID, commentID, userID uint, shareToken string) error
+	SetResolved(documentID, commentID, userID uint, shareToken string, resolved bool) error
+	List(documentID, userID uint, shareToken string, req *model.CommentListRequest) ([]model.CommentResponse, int64, error)
+}
+
+type commentService struct {
+	commentRepo  repository.CommentRepository
+	documentRepo repository.DocumentRepository
+	userRepo     repository.UserRepository
+	reviewRepo   repository.ReviewRepository
+	events       EventBus
+	logger       *zap.Logger
+}
+
+func NewCommentService(commentRepo repository.CommentRepository, documentRepo repository.DocumentRepository, userRepo repository.UserRepository, reviewRepo repository.ReviewRepository, events EventBus, logger *zap.Logger) CommentService {
+	return &commentService{
+		commentRepo:  commentRepo,
+		documentRepo: documentRepo,
+		userRepo:     userRepo,
+		reviewRepo:   reviewRepo,
+		events:       events,
+		logger:       logger,
+	}
+}
+
+func (s *commentService) Create(documentID, userID uint, shareToken string, req *model.CreateCommentRequest) (*model.CommentResponse, error) {
+	document, _, err := s.checkAccess(documentID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &model.Comment{
+		DocumentID: documentID,
+		UserID:     userID,
+		Content:    req.Content,
+	}
+
+	var threadAuthorID uint
+	if req.ParentID != nil {
+		parent, err := s.getDocumentComment(documentID, *req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		// 回复统一挂在讨论串首条评论下
+		thread := parent
+		if parent.ParentID != nil {
+			if thread, err = s.getDocumentComment(documentID, *parent.ParentID); err != nil {
+				return nil, err
+			}
+		}
+		comment.ParentID = &thread.ID
+		threadAuthorID = thread.UserID
+	} else if req.AnchorType != model.CommentAnchorNone {
+		if req.AnchorType == model.CommentAnchorRange && req.AnchorEnd <= req.AnchorStart {
+			return nil, errors.New("无效的文本范围")
+		}
+		if req.AnchorType == model.CommentAnchorBlock && req.AnchorBlockID == "" {
+			return nil, errors.New("请指定评论的内容块")
+		}
+		comment.AnchorType = req.AnchorType
+		comment.AnchorStart = req.AnchorStart
+		comment.AnchorEnd = req.AnchorEnd
+		comment.AnchorBlockID = req.AnchorBlockID
+		comment.Quote = req.Quote
+	}
+
+	mentions, err := s.resolveMentions(document, req.Content, userID)
+	if err != nil {
+		return nil, err
+	}
+	comment.Mentions = joinUintIDs(mentions)
+
+	if err := s.commentRepo.Create(comment); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Comment created",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", documentID),
+		zap.Uint("comment_id", comment.ID))
+
+	response, err := s.getResponse(comment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := commentEventData(document, response)
+	data.ThreadAuthorID = threadAuthorID
+	data.Mentions = mentions
+	s.events.Publish(&model.Event{
+		Type:    model.EventCommentCreated,
+		ActorID: userID,
+		Comment: data,
+	})
+
+	return response, nil
+}
+
+func (s *commentService) Update(documentID, commentID, userID uint, shareToken string, req *model.UpdateCommentRequest) (*model.CommentResponse, error) {
+	document, _, err := s.checkAccess(documentID, userID, shareToken)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.getDocumentComment(documentID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, errors.New("只能编辑自己的评论")
+	}
+
+	mentions, err := s.resolveMentions(document, req.Content, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 编辑前已@过的用户不再重复通知
+	previous := make(map[uint]bool)
+	for _, id := range splitUintIDs(comment.Mentions) {
+		previous[id] = true
+	}
+	var added []uint
+	for _, id := range mentions {
+		if !previous[id] {
+			added = append(added, id)
+		}
+	}
+
+	now := time.Now()
+	comment.Content = req.Content
+	comment.Mentions = joinUintIDs(mentions)
+	comment.EditedAt = &now
+	if err := s.commentRepo.Update(comment); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Comment updated",
+		zap.Uint("user_id", userID),
+		zap.Uint("comment_id", commentID))
+
+	response, err := s.getResponse(comment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := commentEventData(document, response)
+	data.Mentions = added
+	s.events.Publish(&model.Event{
+		Type:    model.EventCommentUpdated,
+		ActorID: userID,
+		Comment: data,
+	})
+
+	return response, nil
+}
+
+func (s *commentService) Delete(documentID, commentID, userID uint, shareToken string) error {
+	document, isOwner, err := s.checkAccess(documentID, userID, shareToken)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.getDocumentComment(documentID, commentID)
+	if err != nil {
+		return err
+	}
+	// 评论作者可以删除自己的评论，文档所有者可以删除任何评论
+	if comment.UserID != userID && !isOwner {
+		return errors.New("无权删除该评论")
+	}
+
+	if err := s.commentRepo.Delete(comment.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Comment deleted",
+		zap.Uint("user_id", userID),
+		zap.Uint("comment_id", commentID))
+
+	s.events.Publish(&model.Event{
+		Type:    model.EventCommentDeleted,
+		ActorID: userID,
+		Comment: &model.CommentEventData{
+			ID:              comment.ID,
+			DocumentID:      document.ID,
+			DocumentTitle:   document.Title,
+			DocumentOwnerID: document.UserID,
+			ParentID:        comment.ParentID,
+		},
+	})
+
+	return nil
+}
+
+func (s *commentService) SetResolved(documentID, commentID, userID uint, shareToken string, resolved bool) error {
+	document, isOwner, err := s.checkAccess(documentID, userID, shareToken)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.getDocumentComment(documentID, commentID)
+	if err != nil {
+		return err
+	}
+	if !comment.IsThread() {
+		return errors.New("只能解决讨论串的首条评论")
+	}
+	if comment.UserID != userID && !isOwner {
+		return errors.New("只有评论作者或文档所有者可以操作")
+	}
+	if comment.Resolved == resolved {
+		return nil
+	}
+
+	comment.Resolved = resolved
+	if resolved {
+		now := time.Now()
+		comment.ResolvedBy = &userID
+		comment.ResolvedAt = &now
+	} else {
+		comment.ResolvedBy = nil
+		comment.ResolvedAt = nil
+	}
+	if err := s.commentRepo.Update(comment); err != nil {
+		return err
+	}
+
+	s.logger.Info("Comment thread resolved state changed",
+		zap.Uint("user_id", userID),
+		zap.Uint("comment_id", commentID),
+		zap.Bool("resolved", resolved))
+
+	eventType := model.EventCommentReopened
+	if resolved {
+		eventType = model.EventCommentResolved
+	}
+	s.events.Publish(&model.Event{
+		Type:    eventType,
+		ActorID: userID,
+		Comment: &model.CommentEventData{
+			ID:              comment.ID,
+			DocumentID:      document.ID,
+			DocumentTitle:   document.Title,
+			DocumentOwnerID: document.UserID,
+		},
+	})
+
+	return nil
+}
+
+func (s *commentService) List(documentID, userID uint, shareToken string, req *model.CommentListRequest) ([]model.CommentResponse, int64, error) {
+	if _, _, err := s.checkAccess(documentID, userID, shareToken); err != nil {
+		return nil, 0, err
+	}
+
+	threads, total, err := s.commentRepo.ListThreads(documentID, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	threadIDs := make([]uint, 0, len(threads))
+	for _, thread := range threads {
+		threadIDs = append(threadIDs, thread.ID)
+	}
+	replies, err := s.commentRepo.ListReplies(threadIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	mentionUsers, err := s.loadMentionUsers(append(threads, replies...))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	repliesByThread := make(map[uint][]model.CommentResponse)
+	for i := range replies {
+		reply := &replies[i]
+		repliesByThread[*reply.ParentID] = append(repliesByThread[*reply.ParentID], toCommentResponse(reply, mentionUsers))
+	}
+
+	responses := make([]model.CommentResponse, 0, len(threads))
+	for i := range threads {
+		response := toCommentResponse(&threads[i], mentionUsers)
+		response.Replies = repliesByThread[threads[i].ID]
+		responses = append(responses, response)
+	}
+
+	return responses, total, nil
+}
+
+// commentEventData 根据评论响应生成事件数据
+func commentEventData(document *model.Document, comment *model.CommentResponse) *model.CommentEventData {
+	authorName := comment.Author.Nickname
+	if authorName == "" {
+		authorName = comment.Author.Username
+	}
+
+	return &model.CommentEventData{
+		ID:              comment.ID,
+		DocumentID:      document.ID,
+		DocumentTitle:   document.Title,
+		DocumentOwnerID: document.UserID,
+		ParentID:        comment.ParentID,
+		AuthorName:      authorName,
+		Content:         comment.Content,
+	}
+}
+
+// checkAccess 文档所有者可以访问评论；其他用户需要提供有效的分享令牌
+func (s *commentService) checkAccess(documentID, userID uint, shareToken string) (*model.Document, bool, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err == nil {
+		return document, true, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	if shareToken != "" {
+		document, err = s.documentRepo.GetByShareToken(shareToken)
+		if err == nil && document.ID == documentID {
+			return document, false, nil
+		}
+	}
+
+	return nil, false, errors.New("文档不存在或无权访问")
+}
+
+func (s *commentService) getDocumentComment(documentID, commentID uint) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetByID(commentID)
+	if err != nil || comment.DocumentID != documentID {
+		return nil, errors.New("评论不存在")
+	}
+	return comment, nil
+}
+
+func (s *commentService) getResponse(commentID uint) (*model.CommentResponse, error) {
+	comment, err := s.commentRepo.GetByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	mentionUsers, err := s.loadMentionUsers([]model.Comment{*comment})
+	if err != nil {
+		return nil, err
+	}
+	response := toCommentResponse(comment, mentionUsers)
+	return &response, nil
+}
+
+// resolveMentions 解析内容中@的用户名，返回可以访问文档的用户ID（不含作者本人）
+// 只有文档所有者、审核人和当前有效分享链接的分享对象可以被@，其他用户名直接忽略
+func (s *commentService) resolveMentions(document *model.Document, content string, authorID uint) ([]uint, error) {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range model.MentionPattern.FindAllStringSubmatch(content, -1) {
+		username := model.MentionedUsername(match[1])
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	if len(usernames) > maxCommentMentions {
+		return nil, errors.New("单条评论最多@20位用户")
+	}
+
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	users, err := s.userRepo.GetByUsernames(usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.documentMembers(document)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		if user.ID != authorID && allowed[user.ID] {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+// documentMembers 获取可以访问文档的用户：所有者、审核人和当前有效分享链接的分享对象
+func (s *commentService) documentMembers(document *model.Document) (map[uint]bool, error) {
+	members := map[uint]bool{document.UserID: true}
+
+	reviews, err := s.reviewRepo.ListByDocumentID(document.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		for _, reviewer := range review.Reviewers {
+			members[reviewer.ReviewerID] = true
+		}
+	}
+
+	recipients, err := s.documentRepo.ListShareRecipientIDs(document.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range recipients {
+		members[id] = true
+	}
+
+	return members, nil
+}
+
+func (s *commentService) loadMentionUsers(comments []model.Comment) (map[uint]model.CommentUser, error) {
+	var ids []uint
+	for _, comment := range comments {
+		ids = append(ids, splitUintIDs(comment.Mentions)...)
+	}
+
+	users, err := s.userRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]model.CommentUser, len(users))
+	for i := range users {
+		result[users[i].ID] = toCommentUser(&users[i])
+	}
+	return result, nil
+}
+
+func toCommentResponse(comment *model.Comment, mentionUsers map[uint]model.CommentUser) model.CommentResponse {
+	mentions := make([]model.CommentUser, 0)
+	for _, id := range splitUintIDs(comment.Mentions) {
+		if user, ok := mentionUsers[id]; ok {
+			mentions = append(mentions, user)
+		}
+	}
+
+	return model.CommentResponse{
+		ID:            comment.ID,
+		DocumentID:    comment.DocumentID,
+		ParentID:      comment.ParentID,
+		Author:        toCommentUser(&comment.User),
+		Content:       comment.Content,
+		AnchorType:    comment.AnchorType,
+		AnchorStart:   comment.AnchorStart,
+		AnchorEnd:     comment.AnchorEnd,
+		AnchorBlockID: comment.AnchorBlockID,
+		Quote:         comment.Quote,
+		Mentions:      mentions,
+		Resolved:      comment.Resolved,
+		ResolvedBy:    comment.ResolvedBy,
+		ResolvedAt:    comment.ResolvedAt,
+		EditedAt:      comment.EditedAt,
+		CreatedAt:     comment.CreatedAt,
+	}
+}
+
+func toCommentUser(user *model.User) model.CommentUser {
+	return model.CommentUser{
+		ID:       user.ID,
+		Username: user.Username,
+		Nickname: user.Nickname,
+		Avatar:   avatarOrDefault(user),
+	}
+}
+
+// joinUintIDs 将ID列表保存为逗号分隔的字符串
+func joinUintIDs(ids []uint) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitUintIDs 解析逗号分隔的ID列表，忽略无效值
+func splitUintIDs(value string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err == nil && id > 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// truncateRunes 按字符截断文本，超出部分用省略号代替
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit]) + "..."
+}
@@ -0,0 +1,41 @@
+package service
+
+import "sync"
+
+// documentLocks 按文档加锁，使同一文档的读取-修改-保存依次执行，只在单个实例内生效
+type documentLocks struct {
+	mu    sync.Mutex
+	locks map[uint]*documentLock
+}
+
+type documentLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newDocumentLocks() *documentLocks {
+	return &documentLocks{locks: make(map[uint]*documentLock)}
+}
+
+// lock 锁定文档，返回解锁函数
+func (d *documentLocks) lock(documentID uint) func() {
+	d.mu.Lock()
+	l, ok := d.locks[documentID]
+	if !ok {
+		l = &documentLock{}
+		d.locks[documentID] = l
+	}
+	l.refs++
+	d.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		d.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(d.locks, documentID)
+		}
+		d.mu.Unlock()
+	}
+}
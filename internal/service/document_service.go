@@ -12,30 +12,45 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrDocumentConflict 文档在读取后已被其他请求修改
+var ErrDocumentConflict = errors.New("文档已被修改，请刷新后重试")
+
 type DocumentService interface {
-	Create(userID uint, req *model.CreateDocumentRequest) (*model.Document, error)
-	GetByID(id, userID uint) (*model.DocumentDetailResponse, error)
-	Update(id, userID uint, req *model.UpdateDocumentRequest) error
-	Delete(id, userID uint) error
+	Create(userID uint, req *model.CreateDocumentRequest, client *model.ClientInfo) (*model.Document, error)
+	GetByID(id, userID uint, client *model.ClientInfo) (*model.DocumentDetailResponse, error)
+	Update(id, userID uint, req *model.UpdateDocumentRequest, client *model.ClientInfo) error
+	Delete(id, userID uint, client *model.ClientInfo) error
 	List(userID uint, req *model.DocumentListRequest) ([]model.DocumentResponse, int64, error)
-	Share(id, userID uint, req *model.ShareDocumentRequest) (string, error)
-	Copy(id, userID uint) (*model.Document, error)
+	Share(id, userID uint, req *model.ShareDocumentRequest, client *model.ClientInfo) (string, error)
+	Copy(id, userID uint, client *model.ClientInfo) (*model.Document, error)
 	GetByShareToken(token string) (*model.DocumentDetailResponse, error)
 }
 
 type documentService struct {
-	documentRepo repository.DocumentRepository
-	logger       *zap.Logger
+	documentRepo      repository.DocumentRepository
+	userRepo          repository.UserRepository
+	templateService   TemplateService
+	attachmentService AttachmentService
+	favoriteService   FavoriteService
+	accessService     AccessService
+	events            EventBus
+	logger            *zap.Logger
 }
 
-func NewDocumentService(documentRepo repository.DocumentRepository, logger *zap.Logger) DocumentService {
+func NewDocumentService(documentRepo repository.DocumentRepository, userRepo repository.UserRepository, templateService TemplateService, attachmentService AttachmentService, favoriteService FavoriteService, accessService AccessService, events EventBus, logger *zap.Logger) DocumentService {
 	return &documentService{
-		documentRepo: documentRepo,
-		logger:       logger,
+		documentRepo:      documentRepo,
+		userRepo:          userRepo,
+		templateService:   templateService,
+		attachmentService: attachmentService,
+		favoriteService:   favoriteService,
+		accessService:     accessService,
+		events:            events,
+		logger:            logger,
 	}
 }
 
-func (s *documentService) Create(userID uint, req *model.CreateDocumentRequest) (*model.Document, error) {
+func (s *documentService) Create(userID uint, req *model.CreateDocumentRequest, client *model.ClientInfo) (*model.Document, error) {
 	document := &model.Document{
 		Title:    req.Title,
 		Content:  req.Content,
@@ -47,30 +62,79 @@ func (s *documentService) Create(userID uint, req *model.CreateDocumentRequest)
 		Size:     int64(len(req.Content)),
 	}
 
+	// 从模板创建时，请求中未填写的标题、内容和标签使用模板填充占位符后的值
+	if req.TemplateID != 0 {
+		rendered, err := s.templateService.Render(req.TemplateID, userID, req.Variables)
+		if err != nil {
+			return nil, err
+		}
+		if req.Type != "" && req.Type != rendered.Type {
+			return nil, errors.New("文档类型与模板不一致")
+		}
+		document.Type = rendered.Type
+		if document.Title == "" {
+			document.Title = rendered.Title
+		}
+		if document.Content == "" {
+			document.Content = rendered.Content
+			document.Size = int64(len(rendered.Content))
+		}
+		if document.Tags == "" {
+			document.Tags = rendered.Tags
+		}
+	}
+
+	// 表格和思维导图的内容统一转换为结构化格式，表格同时计算公式
+	if document.Content != "" {
+		content, err := normalizeStructuredContent(document.Type, document.Content)
+		if err != nil {
+			return nil, err
+		}
+		document.Content = content
+		document.Size = int64(len(content))
+	}
+
 	err := s.documentRepo.Create(document)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.TemplateID != 0 {
+		s.templateService.RecordUsage(req.TemplateID)
+	}
+
 	s.logger.Info("Document created", 
 		zap.Uint("user_id", userID), 
 		zap.Uint("document_id", document.ID),
 		zap.String("title", document.Title))
 
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentCreated,
+		ActorID:  userID,
+		Client:   client,
+		Document: documentEventData(document),
+	})
+
 	return document, nil
 }
 
-func (s *documentService) GetByID(id, userID uint) (*model.DocumentDetailResponse, error) {
+func (s *documentService) GetByID(id, userID uint, client *model.ClientInfo) (*model.DocumentDetailResponse, error) {
 	document, err := s.documentRepo.GetByIDAndUserID(id, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 更新查看次数
-	go func() {
-		s.documentRepo.UpdateViewCount(id)
-	}()
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentViewed,
+		ActorID:  userID,
+		Client:   client,
+		Document: documentEventData(document),
+	})
+
+	// 记录打开时间并更新查看次数
+	s.accessService.Record(userID, document.ID)
 
+	content, toc := documentDetailContent(document)
 	response := &model.DocumentDetailResponse{
 		DocumentResponse: model.DocumentResponse{
 			ID:        document.ID,
@@ -82,30 +146,71 @@ func (s *documentService) GetByID(id, userID uint) (*model.DocumentDetailRespons
 			Size:      document.Size,
 			ViewCount: document.ViewCount,
 			IsShared:  document.IsShared,
+			PublishAt: document.PublishAt,
 			CreatedAt: document.CreatedAt,
 			UpdatedAt: document.UpdatedAt,
 		},
-		Content: document.Content,
+		Content: content,
+		Version: document.Version,
+		TOC:     toc,
+	}
+
+	attachments, err := s.attachmentService.List(document.ID, userID)
+	if err != nil {
+		s.logger.Warn("Failed to list attachments", zap.Uint("document_id", document.ID), zap.Error(err))
+	}
+	response.Attachments = attachments
+
+	favorited, pinned, err := s.favoriteService.Marks(userID, model.ResourceTypeDocument, []uint{document.ID})
+	if err != nil {
+		s.logger.Warn("Failed to load favorite marks", zap.Uint("document_id", document.ID), zap.Error(err))
 	}
+	response.Favorited = favorited[document.ID]
+	response.Pinned = pinned[document.ID]
 
 	return response, nil
 }
 
-func (s *documentService) Update(id, userID uint, req *model.UpdateDocumentRequest) error {
+func (s *documentService) Update(id, userID uint, req *model.UpdateDocumentRequest, client *model.ClientInfo) error {
 	document, err := s.documentRepo.GetByIDAndUserID(id, userID)
 	if err != nil {
 		return err
 	}
 
+	if req.Version != nil && *req.Version != document.Version {
+		return ErrDocumentConflict
+	}
+
+	previousStatus := document.Status
+	previousFolderID := document.FolderID
+	// 只修改了所在文件夹时视为移动，不再记录为更新
+	contentChanged := req.Title != "" || req.Content != "" || req.Status != nil || req.Tags != ""
+	edited := req.Title != "" || req.Content != "" || req.Tags != ""
+
+	status, err := updatedDocumentStatus(document.Status, edited, req.Status)
+	if err != nil {
+		return err
+	}
+
 	if req.Title != "" {
 		document.Title = req.Title
 	}
 	if req.Content != "" {
-		document.Content = req.Content
-		document.Size = int64(len(req.Content))
+		content, err := normalizeStructuredContent(document.Type, req.Content)
+		if err != nil {
+			return err
+		}
+		// 文档大小包含附件
+		attachmentSize, err := s.attachmentService.TotalSize(id)
+		if err != nil {
+			return err
+		}
+		document.Content = content
+		document.Size = int64(len(content)) + attachmentSize
 	}
-	if req.Status != nil {
-		document.Status = *req.Status
+	document.Status = status
+	if document.Status != previousStatus {
+		document.PublishAt = nil
 	}
 	if req.FolderID != nil {
 		document.FolderID = req.FolderID
@@ -114,26 +219,84 @@ func (s *documentService) Update(id, userID uint, req *model.UpdateDocumentReque
 		document.Tags = req.Tags
 	}
 
-	err = s.documentRepo.Update(document)
+	// 只在文档读取后未被修改时更新，避免覆盖审核、定时发布设置的状态或其他请求保存的内容
+	document.UpdatedAt = time.Now()
+	updated, err := s.documentRepo.UpdateIfUnchanged(document, previousStatus)
 	if err != nil {
 		return err
 	}
+	if !updated {
+		current, err := s.documentRepo.GetByIDAndUserID(id, userID)
+		if err == nil && current.Status != previousStatus {
+			return errors.New("文档状态已变化，请刷新后重试")
+		}
+		return ErrDocumentConflict
+	}
 
 	s.logger.Info("Document updated", 
 		zap.Uint("user_id", userID), 
 		zap.Uint("document_id", id))
 
+	data := documentEventData(document)
+	data.PreviousStatus = previousStatus
+	moved := !sameFolder(previousFolderID, document.FolderID)
+	if contentChanged || !moved {
+		s.events.Publish(&model.Event{
+			Type:     model.EventDocumentUpdated,
+			ActorID:  userID,
+			Client:   client,
+			Document: data,
+		})
+	}
+	if moved {
+		moveData := *data
+		moveData.PreviousFolderID = previousFolderID
+		s.events.Publish(&model.Event{
+			Type:     model.EventDocumentMoved,
+			ActorID:  userID,
+			Client:   client,
+			Document: &moveData,
+		})
+	}
+	if previousStatus != model.DocumentStatusPublished && document.Status == model.DocumentStatusPublished {
+		s.events.Publish(&model.Event{
+			Type:     model.EventDocumentPublished,
+			ActorID:  userID,
+			Client:   client,
+			Document: data,
+		})
+	}
+
 	return nil
 }
 
-func (s *documentService) Delete(id, userID uint) error {
+func (s *documentService) Delete(id, userID uint, client *model.ClientInfo) error {
 	document, err := s.documentRepo.GetByIDAndUserID(id, userID)
 	if err != nil {
 		return err
 	}
 
-	// 直接删除文档（简化版本，不移动到回收站）
-	err = s.documentRepo.Delete(id, userID)
+	// 软删除文档并放入回收站，删除事件与之在同一事务中写入发件箱，附件在永久删除时清理
+	autoDelete := time.Now().Add(model.RecycleRetention)
+	item := &model.RecycleItem{
+		UserID:       userID,
+		ResourceType: model.ResourceTypeDocument,
+		ResourceID:   document.ID,
+		ResourceName: document.Title,
+		AutoDelete:   &autoDelete,
+	}
+	event := &model.Event{
+		Type:     model.EventDocumentDeleted,
+		ActorID:  userID,
+		Client:   client,
+		Document: documentEventData(document),
+	}
+	var dispatch func()
+	err = s.documentRepo.DeleteToRecycle(id, userID, item, func(tx *gorm.DB) error {
+		var err error
+		dispatch, err = s.events.PublishTx(tx, event)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -142,6 +305,8 @@ func (s *documentService) Delete(id, userID uint) error {
 		zap.Uint("user_id", userID), 
 		zap.Uint("document_id", id))
 
+	dispatch()
+
 	return nil
 }
 
@@ -151,6 +316,15 @@ func (s *documentService) List(userID uint, req *model.DocumentListRequest) ([]m
 		return nil, 0, err
 	}
 
+	ids := make([]uint, 0, len(documents))
+	for _, doc := range documents {
+		ids = append(ids, doc.ID)
+	}
+	favorited, pinned, err := s.favoriteService.Marks(userID, model.ResourceTypeDocument, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var responses []model.DocumentResponse
 	for _, doc := range documents {
 		responses = append(responses, model.DocumentResponse{
@@ -163,6 +337,9 @@ func (s *documentService) List(userID uint, req *model.DocumentListRequest) ([]m
 			Size:      doc.Size,
 			ViewCount: doc.ViewCount,
 			IsShared:  doc.IsShared,
+			PublishAt: doc.PublishAt,
+			Favorited: favorited[doc.ID],
+			Pinned:    pinned[doc.ID],
 			CreatedAt: doc.CreatedAt,
 			UpdatedAt: doc.UpdatedAt,
 		})
@@ -171,7 +348,7 @@ func (s *documentService) List(userID uint, req *model.DocumentListRequest) ([]m
 	return responses, total, nil
 }
 
-func (s *documentService) Share(id, userID uint, req *model.ShareDocumentRequest) (string, error) {
+func (s *documentService) Share(id, userID uint, req *model.ShareDocumentRequest, client *model.ClientInfo) (string, error) {
 	document, err := s.documentRepo.GetByIDAndUserID(id, userID)
 	if err != nil {
 		return "", err
@@ -189,20 +366,56 @@ func (s *documentService) Share(id, userID uint, req *model.ShareDocumentRequest
 	expiry := time.Now().Add(time.Duration(req.ExpiryHours) * time.Hour)
 	document.ShareExpiry = &expiry
 
-	err = s.documentRepo.Update(document)
+	// 只更新分享相关的列，不覆盖并发的状态变更和内容修改
+	err = s.documentRepo.UpdateShare(document.ID, token, document.ShareExpiry)
+	if err != nil {
+		return "", err
+	}
+
+	// 记录分享给的用户，不存在或已禁用的用户名直接忽略
+	users, err := s.userRepo.GetByUsernames(req.Recipients)
 	if err != nil {
 		return "", err
 	}
+	var recipients []model.ShareRecipient
+	var recipientIDs []uint
+	for _, user := range users {
+		if user.ID == userID || user.Status == model.UserStatusDisabled {
+			continue
+		}
+		recipients = append(recipients, model.ShareRecipient{
+			DocumentID: document.ID,
+			ShareToken: token,
+			UserID:     user.ID,
+		})
+		recipientIDs = append(recipientIDs, user.ID)
+	}
+	if err := s.documentRepo.AddShareRecipients(recipients); err != nil {
+		return "", err
+	}
 
 	s.logger.Info("Document shared", 
 		zap.Uint("user_id", userID), 
 		zap.Uint("document_id", id),
 		zap.String("token", token))
 
+	s.events.Publish(&model.Event{
+		Type:    model.EventDocumentShared,
+		ActorID: userID,
+		Client:  client,
+		Share: &model.ShareEventData{
+			DocumentID:  document.ID,
+			Title:       document.Title,
+			ShareURL:    "/api/v1/share/" + token,
+			ShareExpiry: document.ShareExpiry,
+			Recipients:  recipientIDs,
+		},
+	})
+
 	return token, nil
 }
 
-func (s *documentService) Copy(id, userID uint) (*model.Document, error) {
+func (s *documentService) Copy(id, userID uint, client *model.ClientInfo) (*model.Document, error) {
 	original, err := s.documentRepo.GetByIDAndUserID(id, userID)
 	if err != nil {
 		return nil, err
@@ -225,11 +438,28 @@ func (s *documentService) Copy(id, userID uint) (*model.Document, error) {
 		return nil, err
 	}
 
+	// 附件复制失败时副本仍保留，大小按实际复制的附件计算
+	if err := s.attachmentService.CopyAll(original.ID, copy.ID, userID); err != nil {
+		s.logger.Warn("Failed to copy attachments",
+			zap.Uint("original_id", original.ID),
+			zap.Uint("copy_id", copy.ID),
+			zap.Error(err))
+	}
+
 	s.logger.Info("Document copied", 
 		zap.Uint("user_id", userID), 
 		zap.Uint("original_id", id),
 		zap.Uint("copy_id", copy.ID))
 
+	data := documentEventData(copy)
+	data.SourceID = original.ID
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentCopied,
+		ActorID:  userID,
+		Client:   client,
+		Document: data,
+	})
+
 	return copy, nil
 }
 
@@ -242,6 +472,7 @@ func (s *documentService) GetByShareToken(token string) (*model.DocumentDetailRe
 		return nil, err
 	}
 
+	content, toc := documentDetailContent(document)
 	response := &model.DocumentDetailResponse{
 		DocumentResponse: model.DocumentResponse{
 			ID:        document.ID,
@@ -253,20 +484,74 @@ func (s *documentService) GetByShareToken(token string) (*model.DocumentDetailRe
 			Size:      document.Size,
 			ViewCount: document.ViewCount,
 			IsShared:  document.IsShared,
+			PublishAt: document.PublishAt,
 			CreatedAt: document.CreatedAt,
 			UpdatedAt: document.UpdatedAt,
 		},
-		Content: document.Content,
+		Content: content,
+		TOC:     toc,
 	}
 
-	// 更新查看次数
-	go func() {
-		s.documentRepo.UpdateViewCount(document.ID)
-	}()
+	// 通过分享链接查看只更新查看次数
+	s.accessService.Record(0, document.ID)
 
 	return response, nil
 }
 
+// normalizeStructuredContent 校验并规范化Word、表格、演示文稿和思维导图的内容，其他类型原样返回
+func normalizeStructuredContent(documentType model.DocumentType, content string) (string, error) {
+	switch documentType {
+	case model.DocumentTypeWord:
+		return normalizeRichTextContent(content)
+	case model.DocumentTypeExcel:
+		return normalizeSheetContent(content)
+	case model.DocumentTypePPT:
+		return normalizePresentationContent(content)
+	case model.DocumentTypeMindMap:
+		return normalizeMindMapContent(content)
+	}
+	return content, nil
+}
+
+// documentText 返回文档的文本内容，用于搜索索引和AI处理。Word文档转换为Markdown，表格、演示文稿和思维导图提取其中的文本
+func documentText(document *model.Document) string {
+	switch document.Type {
+	case model.DocumentTypeWord:
+		return richTextMarkdown(document.Content)
+	case model.DocumentTypeExcel:
+		return workbookText(document.Content)
+	case model.DocumentTypePPT:
+		return presentationText(document.Content)
+	case model.DocumentTypeMindMap:
+		return mindMapText(document.Content)
+	}
+	return document.Content
+}
+
+// documentDetailContent 返回详情中的内容和目录。早期以Markdown保存的Word文档在此转换为块结构
+func documentDetailContent(document *model.Document) (string, []model.TOCEntry) {
+	if document.Type != model.DocumentTypeWord || document.Content == "" {
+		return document.Content, nil
+	}
+	richText, err := parseRichText(document.Content)
+	if err != nil {
+		return document.Content, nil
+	}
+	content, err := encodeRichText(richText)
+	if err != nil {
+		return document.Content, nil
+	}
+	return content, richTextTOC(richText)
+}
+
+// sameFolder 比较两个可为空的文件夹ID
+func sameFolder(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func generateRandomString(length int) (string, error) {
 	bytes := make([]byte, length/2)
 	if _, err := rand.Read(bytes); err != nil {
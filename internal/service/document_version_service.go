@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type DocumentVersionService interface {
+	// Snapshot 在内容被替换前保存文档当前内容，与最新版本相同时不重复保存
+	Snapshot(document *model.Document, userID uint) error
+	// Create 将文档当前内容保存为新版本
+	Create(document *model.Document, userID uint, source model.DocumentVersionSource, note string) (*model.DocumentVersion, error)
+	List(documentID, userID uint, req *model.DocumentVersionListRequest) ([]model.DocumentVersionResponse, int64, error)
+	GetByID(documentID, versionID, userID uint) (*model.DocumentVersionDetailResponse, error)
+	// Restore 将文档恢复到指定版本，恢复前的内容会先保存为版本
+	Restore(documentID, versionID, userID uint, client *model.ClientInfo) (*model.DocumentVersionResponse, error)
+}
+
+type documentVersionService struct {
+	versionRepo     repository.DocumentVersionRepository
+	documentRepo    repository.DocumentRepository
+	documentService DocumentService
+	logger          *zap.Logger
+}
+
+func NewDocumentVersionService(versionRepo repository.DocumentVersionRepository, documentRepo repository.DocumentRepository, documentService DocumentService, logger *zap.Logger) DocumentVersionService {
+	return &documentVersionService{
+		versionRepo:     versionRepo,
+		documentRepo:    documentRepo,
+		documentService: documentService,
+		logger:          logger,
+	}
+}
+
+func (s *documentVersionService) Snapshot(document *model.Document, userID uint) error {
+	latest, err := s.versionRepo.GetLatest(document.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if latest != nil && latest.Title == document.Title && latest.Content == document.Content {
+		return nil
+	}
+
+	_, err = s.Create(document, userID, model.VersionSourceSnapshot, "")
+	return err
+}
+
+func (s *documentVersionService) Create(document *model.Document, userID uint, source model.DocumentVersionSource, note string) (*model.DocumentVersion, error) {
+	version := &model.DocumentVersion{
+		DocumentID: document.ID,
+		UserID:     userID,
+		Title:      document.Title,
+		Content:    document.Content,
+		Size:       int64(len(document.Content)),
+		Source:     source,
+		Note:       note,
+	}
+	if err := s.versionRepo.Create(version); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Document version created",
+		zap.Uint("document_id", document.ID),
+		zap.Int("version", version.Version),
+		zap.String("source", string(source)))
+
+	return version, nil
+}
+
+func (s *documentVersionService) List(documentID, userID uint, req *model.DocumentVersionListRequest) ([]model.DocumentVersionResponse, int64, error) {
+	if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+		return nil, 0, errors.New("文档不存在或无权访问")
+	}
+
+	versions, total, err := s.versionRepo.List(documentID, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]model.DocumentVersionResponse, 0, len(versions))
+	for i := range versions {
+		responses = append(responses, toDocumentVersionResponse(&versions[i]))
+	}
+	return responses, total, nil
+}
+
+func (s *documentVersionService) GetByID(documentID, versionID, userID uint) (*model.DocumentVersionDetailResponse, error) {
+	if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+
+	version, err := s.versionRepo.GetByID(documentID, versionID)
+	if err != nil {
+		return nil, errors.New("版本不存在")
+	}
+
+	return &model.DocumentVersionDetailResponse{
+		DocumentVersionResponse: toDocumentVersionResponse(version),
+		Content:                 version.Content,
+	}, nil
+}
+
+func (s *documentVersionService) Restore(documentID, versionID, userID uint, client *model.ClientInfo) (*model.DocumentVersionResponse, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+	version, err := s.versionRepo.GetByID(documentID, versionID)
+	if err != nil {
+		return nil, errors.New("版本不存在")
+	}
+	if version.Content == "" {
+		return nil, errors.New("该版本内容为空，无法恢复")
+	}
+
+	if err := s.Snapshot(document, userID); err != nil {
+		return nil, err
+	}
+
+	err = s.documentService.Update(documentID, userID, &model.UpdateDocumentRequest{
+		Title:   version.Title,
+		Content: version.Content,
+	}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	document.Title = version.Title
+	document.Content = version.Content
+	restored, err := s.Create(document, userID, model.VersionSourceRestore, fmt.Sprintf("恢复到版本%d", version.Version))
+	if err != nil {
+		return nil, err
+	}
+
+	response := toDocumentVersionResponse(restored)
+	return &response, nil
+}
+
+func toDocumentVersionResponse(version *model.DocumentVersion) model.DocumentVersionResponse {
+	return model.DocumentVersionResponse{
+		ID:         version.ID,
+		DocumentID: version.DocumentID,
+		Version:    version.Version,
+		UserID:     version.UserID,
+		Title:      version.Title,
+		Size:       version.Size,
+		Source:     version.Source,
+		Note:       version.Note,
+		CreatedAt:  version.CreatedAt,
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EmbeddingProvider 文本向量服务商，返回的向量与输入一一对应且维度相同
+type EmbeddingProvider interface {
+	Name() string
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbeddingProvider 按配置创建向量服务商。local为本地的确定性实现，不依赖外部服务，
+// 只能匹配字面相近的内容，用于开发和测试
+func NewEmbeddingProvider(provider, baseURL, apiKey, modelName string, dimensions int, timeout time.Duration) (EmbeddingProvider, error) {
+	switch provider {
+	case "openai":
+		return NewOpenAIEmbeddingProvider(baseURL, apiKey, modelName, dimensions, timeout), nil
+	case "local", "":
+		return NewLocalEmbeddingProvider(dimensions), nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %s", provider)
+	}
+}
+
+// openAIEmbeddingBatchSize 单次请求的最大文本数
+const openAIEmbeddingBatchSize = 64
+
+type openAIEmbeddingProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	dimensions int
+	client     *http.Client
+}
+
+// NewOpenAIEmbeddingProvider 兼容OpenAI Embeddings接口的服务，dimensions为0时使用模型的默认维度
+func NewOpenAIEmbeddingProvider(baseURL, apiKey, modelName string, dimensions int, timeout time.Duration) EmbeddingProvider {
+	return &openAIEmbeddingProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      modelName,
+		dimensions: dimensions,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *openAIEmbeddingProvider) Name() string {
+	return "openai"
+}
+
+func (p *openAIEmbeddingProvider) Model() string {
+	return p.model
+}
+
+type openAIEmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *openAIError `json:"error"`
+}
+
+func (p *openAIEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += openAIEmbeddingBatchSize {
+		end := start + openAIEmbeddingBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := p.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (p *openAIEmbeddingProvider) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(openAIEmbeddingRequest{
+		Model:      p.model,
+		Input:      texts,
+		Dimensions: p.dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("请求向量服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取向量服务响应失败: %w", err)
+	}
+
+	var result openAIEmbeddingResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("向量服务返回错误(%d): %s", resp.StatusCode, truncateRunes(string(data), 200))
+		}
+		return nil, fmt.Errorf("无法解析向量服务响应: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("向量服务返回错误(%d): %s", resp.StatusCode, result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("向量服务返回错误(%d)", resp.StatusCode)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("向量服务返回了%d个向量，预期%d个", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("向量服务返回了无效的序号: %d", item.Index)
+		}
+		vectors[item.Index] = normalizeVector(item.Embedding)
+	}
+	return vectors, nil
+}
+
+type localEmbeddingProvider struct {
+	dimensions int
+}
+
+// NewLocalEmbeddingProvider 基于特征哈希的本地向量：英文和数字按单词、中文按相邻两个字计算特征，
+// 相同输入总是得到相同向量
+func NewLocalEmbeddingProvider(dimensions int) EmbeddingProvider {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &localEmbeddingProvider{dimensions: dimensions}
+}
+
+func (p *localEmbeddingProvider) Name() string {
+	return "local"
+}
+
+func (p *localEmbeddingProvider) Model() string {
+	return fmt.Sprintf("local-hash-%d", p.dimensions)
+}
+
+func (p *localEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vector := make([]float32, p.dimensions)
+		for _, feature := range localFeatures(text) {
+			h := fnv.New32a()
+			h.Write([]byte(feature))
+			sum := h.Sum32()
+			// 最高位决定符号，减少哈希冲突带来的偏差
+			if sum&0x80000000 != 0 {
+				vector[int(sum%uint32(p.dimensions))] -= 1
+			} else {
+				vector[int(sum%uint32(p.dimensions))] += 1
+			}
+		}
+		vectors = append(vectors, normalizeVector(vector))
+	}
+	return vectors, nil
+}
+
+// localFeatures 提取文本特征：连续的字母数字为一个单词，汉字等其他文字取相邻两个字
+func localFeatures(text string) []string {
+	var features []string
+	var word []rune
+	var prev rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			features = append(features, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word = append(word, r)
+			prev = 0
+		case unicode.IsLetter(r):
+			flushWord()
+			if prev != 0 {
+				features = append(features, string([]rune{prev, r}))
+			} else {
+				features = append(features, string(r))
+			}
+			prev = r
+		default:
+			flushWord()
+			prev = 0
+		}
+	}
+	flushWord()
+	return features
+}
+
+// normalizeVector 归一化为单位向量，之后用点积即可计算余弦相似度
+func normalizeVector(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 事件落库后由发布方立即分发，超过该时间仍未处理完的视为分发方已崩溃，由补偿任务接管
+	outboxLease       = time.Minute
+	outboxMaxAttempts = 5
+	outboxRetryDelay  = time.Minute
+	outboxBatchSize   = 100
+	// 已处理事件的保留时间
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// 查看文档的事件量大且只用于活动记录，不写入发件箱，进程崩溃时可能丢失
+var outboxSkipped = map[model.EventType]bool{
+	model.EventDocumentViewed: true,
+}
+
+// EventHandler 事件订阅者，返回错误时启用了发件箱的事件会被重试
+type EventHandler func(event *model.Event) error
+
+// EventBus 进程内事件总线。服务在数据变更成功后发布事件，活动记录、通知、Webhook等副作用由订阅者处理。
+// 事件在独立的goroutine中分发，不保证不同事件之间的处理顺序。
+type EventBus interface {
+	// Subscribe 注册订阅者，name用于日志和发件箱中记录处理进度，需唯一
+	Subscribe(name string, handler EventHandler)
+	// Publish 在数据变更提交后发布事件，发件箱记录单独写入，变更提交与写入之间崩溃时事件会丢失
+	Publish(event *model.Event)
+	// PublishTx 在调用方的事务中写入发件箱记录，与数据变更一同提交，返回事务提交后调用的分发函数。
+	// 返回错误时调用方应回滚事务，事务回滚时不能调用分发函数
+	PublishTx(tx *gorm.DB, event *model.Event) (func(), error)
+	// StartRelay 定时重新分发发件箱中未处理完成的事件，未启用发件箱时不执行任何操作
+	StartRelay(interval time.Duration)
+}
+
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+type eventBus struct {
+	outboxRepo  repository.OutboxRepository
+	logger      *zap.Logger
+	mu          sync.RWMutex
+	subscribers []eventSubscriber
+}
+
+// NewEventBus outboxRepo为nil时不使用发件箱，事件只在内存中分发
+func NewEventBus(outboxRepo repository.OutboxRepository, logger *zap.Logger) EventBus {
+	return &eventBus{
+		outboxRepo: outboxRepo,
+		logger:     logger,
+	}
+}
+
+func (b *eventBus) Subscribe(name string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, eventSubscriber{name: name, handler: handler})
+}
+
+func (b *eventBus) Publish(event *model.Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	var record *model.OutboxEvent
+	if b.outboxRepo != nil && !outboxSkipped[event.Type] {
+		record = b.saveOutbox(event)
+	}
+
+	go b.dispatch(event, record)
+}
+
+func (b *eventBus) PublishTx(tx *gorm.DB, event *model.Event) (func(), error) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	var record *model.OutboxEvent
+	if b.outboxRepo != nil && !outboxSkipped[event.Type] {
+		var err error
+		record, err = newOutboxRecord(event)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.outboxRepo.CreateTx(tx, record); err != nil {
+			return nil, err
+		}
+	}
+
+	return func() {
+		go b.dispatch(event, record)
+	}, nil
+}
+
+func (b *eventBus) StartRelay(interval time.Duration) {
+	if b.outboxRepo == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastCleanup time.Time
+		for {
+			b.relay()
+			if time.Since(lastCleanup) >= time.Hour {
+				if _, err := b.outboxRepo.DeleteDoneBefore(time.Now().Add(-outboxRetention)); err != nil {
+					b.logger.Error("Failed to clean up outbox events", zap.Error(err))
+				}
+				lastCleanup = time.Now()
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// saveOutbox 事件落库失败时仍在内存中分发，只是失去崩溃后的补偿
+func (b *eventBus) saveOutbox(event *model.Event) *model.OutboxEvent {
+	record, err := newOutboxRecord(event)
+	if err != nil {
+		b.logger.Error("Failed to encode event", zap.String("type", string(event.Type)), zap.Error(err))
+		return nil
+	}
+	if err := b.outboxRepo.Create(record); err != nil {
+		b.logger.Error("Failed to save outbox event", zap.String("type", string(event.Type)), zap.Error(err))
+		return nil
+	}
+	return record
+}
+
+func newOutboxRecord(event *model.Event) (*model.OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		Type:          event.Type,
+		Payload:       string(payload),
+		Status:        model.OutboxStatusPending,
+		NextAttemptAt: time.Now().Add(outboxLease),
+	}, nil
+}
+
+func (b *eventBus) relay() {
+	now := time.Now()
+	records, err := b.outboxRepo.ListDue(now, outboxBatchSize)
+	if err != nil {
+		b.logger.Error("Failed to list outbox events", zap.Error(err))
+		return
+	}
+
+	for i := range records {
+		record := &records[i]
+		claimed, err := b.outboxRepo.Claim(record.ID, now, now.Add(outboxLease))
+		if err != nil || !claimed {
+			continue
+		}
+
+		var event model.Event
+		if err := json.Unmarshal([]byte(record.Payload), &event); err != nil {
+			record.Status = model.OutboxStatusFailed
+			record.LastError = truncateRunes(err.Error(), 900)
+			b.saveRecord(record)
+			continue
+		}
+		b.dispatch(&event, record)
+	}
+}
+
+// dispatch 依次调用订阅者，单个订阅者出错或panic不影响其他订阅者
+func (b *eventBus) dispatch(event *model.Event, record *model.OutboxEvent) {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	handled := make(map[string]bool)
+	if record != nil {
+		for _, name := range strings.Split(record.Handled, ",") {
+			if name != "" {
+				handled[name] = true
+			}
+		}
+	}
+
+	var failures []string
+	for _, subscriber := range subscribers {
+		if handled[subscriber.name] {
+			continue
+		}
+		if err := b.invoke(subscriber, event); err != nil {
+			b.logger.Error("Event handler failed",
+				zap.String("type", string(event.Type)),
+				zap.String("subscriber", subscriber.name),
+				zap.Error(err))
+			failures = append(failures, subscriber.name+": "+err.Error())
+			continue
+		}
+		handled[subscriber.name] = true
+	}
+
+	if record == nil {
+		return
+	}
+
+	names := make([]string, 0, len(handled))
+	for _, subscriber := range subscribers {
+		if handled[subscriber.name] {
+			names = append(names, subscriber.name)
+		}
+	}
+	record.Handled = strings.Join(names, ",")
+	record.Attempts++
+
+	if len(failures) == 0 {
+		record.Status = model.OutboxStatusDone
+		record.LastError = ""
+	} else {
+		record.LastError = truncateRunes(strings.Join(failures, "; "), 900)
+		if record.Attempts >= outboxMaxAttempts {
+			record.Status = model.OutboxStatusFailed
+		} else {
+			record.NextAttemptAt = time.Now().Add(outboxRetryDelay * time.Duration(1<<(record.Attempts-1)))
+		}
+	}
+	b.saveRecord(record)
+}
+
+func (b *eventBus) invoke(subscriber eventSubscriber, event *model.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return subscriber.handler(event)
+}
+
+func (b *eventBus) saveRecord(record *model.OutboxEvent) {
+	if err := b.outboxRepo.Update(record); err != nil {
+		b.logger.Error("Failed to update outbox event", zap.Uint("outbox_id", record.ID), zap.Error(err))
+	}
+}
+
+func documentEventData(document *model.Document) *model.DocumentEventData {
+	return &model.DocumentEventData{
+		ID:        document.ID,
+		Title:     document.Title,
+		Type:      document.Type,
+		Status:    document.Status,
+		FolderID:  document.FolderID,
+		Tags:      document.Tags,
+		PublishAt: document.PublishAt,
+		UpdatedAt: document.UpdatedAt,
+	}
+}
+
+func folderEventData(folder *model.Folder) *model.FolderEventData {
+	return &model.FolderEventData{
+		ID:       folder.ID,
+		Name:     folder.Name,
+		ParentID: folder.ParentID,
+	}
+}
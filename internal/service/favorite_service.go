@@ -0,0 +1,414 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type FavoriteService interface {
+	// ListFavorites 按收藏时间倒序获取收藏的文档和文件夹，已删除的资源不返回
+	ListFavorites(userID uint, req *model.FavoriteListRequest) ([]model.MarkedItemResponse, error)
+	// RecentFavorites 获取最近收藏的文档和文件夹，用于工作台
+	RecentFavorites(userID uint, limit int) ([]model.MarkedItemResponse, error)
+	// AddFavorite 收藏文档或文件夹，重复收藏不报错
+	AddFavorite(userID uint, req *model.MarkRequest) error
+	RemoveFavorite(userID uint, resourceType model.ResourceType, resourceID uint) error
+	// ListPins 按置顶顺序获取置顶的文档和文件夹
+	ListPins(userID uint) ([]model.MarkedItemResponse, error)
+	// Pin 置顶文档或文件夹，新置顶项排在最后，重复置顶不报错
+	Pin(userID uint, req *model.MarkRequest) error
+	Unpin(userID uint, resourceType model.ResourceType, resourceID uint) error
+	// ReorderPins 按给定顺序排列置顶项，未列出的置顶项保持原有顺序排在后面
+	ReorderPins(userID uint, req *model.ReorderPinsRequest) ([]model.MarkedItemResponse, error)
+	// Marks 返回ids中已收藏和已置顶的资源
+	Marks(userID uint, resourceType model.ResourceType, ids []uint) (favorited, pinned map[uint]bool, err error)
+	// HandleEvent 文档或文件夹删除后清除其收藏和置顶，避免失效的记录占用收藏和置顶数量
+	HandleEvent(event *model.Event) error
+}
+
+type favoriteService struct {
+	favoriteRepo repository.FavoriteRepository
+	documentRepo repository.DocumentRepository
+	folderRepo   repository.FolderRepository
+	locks        *documentLocks // 按用户锁定，保证置顶顺序连续
+	logger       *zap.Logger
+}
+
+func NewFavoriteService(
+	favoriteRepo repository.FavoriteRepository,
+	documentRepo repository.DocumentRepository,
+	folderRepo repository.FolderRepository,
+	logger *zap.Logger) FavoriteService {
+	return &favoriteService{
+		favoriteRepo: favoriteRepo,
+		documentRepo: documentRepo,
+		folderRepo:   folderRepo,
+		locks:        newDocumentLocks(),
+		logger:       logger,
+	}
+}
+
+// markedResource 收藏或置顶的资源，解析后生成响应
+type markedResource struct {
+	resourceType model.ResourceType
+	resourceID   uint
+	position     int
+	markedAt     time.Time
+}
+
+func (s *favoriteService) ListFavorites(userID uint, req *model.FavoriteListRequest) ([]model.MarkedItemResponse, error) {
+	favorites, err := s.favoriteRepo.ListFavorites(userID, req.ResourceType, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolve(userID, favoriteResources(favorites))
+}
+
+func (s *favoriteService) RecentFavorites(userID uint, limit int) ([]model.MarkedItemResponse, error) {
+	favorites, err := s.favoriteRepo.ListFavorites(userID, "", limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolve(userID, favoriteResources(favorites))
+}
+
+func (s *favoriteService) AddFavorite(userID uint, req *model.MarkRequest) error {
+	if err := s.checkResource(userID, req.ResourceType, req.ResourceID); err != nil {
+		return err
+	}
+
+	if _, err := s.favoriteRepo.GetFavorite(userID, req.ResourceType, req.ResourceID); err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	count, err := s.favoriteRepo.CountFavorites(userID)
+	if err != nil {
+		return err
+	}
+	if count >= model.FavoriteMaxPerUser {
+		return fmt.Errorf("最多收藏%d个文档和文件夹", model.FavoriteMaxPerUser)
+	}
+
+	favorite := &model.Favorite{
+		UserID:       userID,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+	}
+	if err := s.favoriteRepo.CreateFavorite(favorite); err != nil {
+		return err
+	}
+
+	s.logger.Info("Favorite added",
+		zap.Uint("user_id", userID),
+		zap.String("resource_type", string(req.ResourceType)),
+		zap.Uint("resource_id", req.ResourceID))
+
+	return nil
+}
+
+func (s *favoriteService) RemoveFavorite(userID uint, resourceType model.ResourceType, resourceID uint) error {
+	favorite, err := s.favoriteRepo.GetFavorite(userID, resourceType, resourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("收藏不存在")
+		}
+		return err
+	}
+
+	if err := s.favoriteRepo.DeleteFavorite(favorite.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Favorite removed",
+		zap.Uint("user_id", userID),
+		zap.String("resource_type", string(resourceType)),
+		zap.Uint("resource_id", resourceID))
+
+	return nil
+}
+
+func (s *favoriteService) ListPins(userID uint) ([]model.MarkedItemResponse, error) {
+	pins, err := s.favoriteRepo.ListPins(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolve(userID, pinResources(pins))
+}
+
+func (s *favoriteService) Pin(userID uint, req *model.MarkRequest) error {
+	if err := s.checkResource(userID, req.ResourceType, req.ResourceID); err != nil {
+		return err
+	}
+
+	unlock := s.locks.lock(userID)
+	defer unlock()
+
+	pins, err := s.favoriteRepo.ListPins(userID)
+	if err != nil {
+		return err
+	}
+	for _, pin := range pins {
+		if pin.ResourceType == req.ResourceType && pin.ResourceID == req.ResourceID {
+			return nil
+		}
+	}
+	if len(pins) >= model.PinMaxPerUser {
+		return fmt.Errorf("最多置顶%d个文档和文件夹", model.PinMaxPerUser)
+	}
+
+	position := 0
+	if len(pins) > 0 {
+		position = pins[len(pins)-1].Position + 1
+	}
+	pin := &model.Pin{
+		UserID:       userID,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+		Position:     position,
+	}
+	if err := s.favoriteRepo.CreatePin(pin); err != nil {
+		return err
+	}
+
+	s.logger.Info("Resource pinned",
+		zap.Uint("user_id", userID),
+		zap.String("resource_type", string(req.ResourceType)),
+		zap.Uint("resource_id", req.ResourceID))
+
+	return nil
+}
+
+func (s *favoriteService) Unpin(userID uint, resourceType model.ResourceType, resourceID uint) error {
+	pin, err := s.favoriteRepo.GetPin(userID, resourceType, resourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("置顶项不存在")
+		}
+		return err
+	}
+
+	if err := s.favoriteRepo.DeletePin(pin.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Resource unpinned",
+		zap.Uint("user_id", userID),
+		zap.String("resource_type", string(resourceType)),
+		zap.Uint("resource_id", resourceID))
+
+	return nil
+}
+
+func (s *favoriteService) ReorderPins(userID uint, req *model.ReorderPinsRequest) ([]model.MarkedItemResponse, error) {
+	unlock := s.locks.lock(userID)
+	defer unlock()
+
+	pins, err := s.favoriteRepo.ListPins(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type pinKey struct {
+		resourceType model.ResourceType
+		resourceID   uint
+	}
+	index := make(map[pinKey]int, len(pins))
+	for i, pin := range pins {
+		index[pinKey{pin.ResourceType, pin.ResourceID}] = i
+	}
+
+	// 先按请求顺序排列，再追加未列出的置顶项
+	ordered := make([]model.Pin, 0, len(pins))
+	placed := make(map[int]bool, len(pins))
+	for _, item := range req.Items {
+		i, ok := index[pinKey{item.ResourceType, item.ResourceID}]
+		if !ok {
+			return nil, errors.New("置顶项不存在")
+		}
+		if placed[i] {
+			continue
+		}
+		placed[i] = true
+		ordered = append(ordered, pins[i])
+	}
+	for i, pin := range pins {
+		if !placed[i] {
+			ordered = append(ordered, pin)
+		}
+	}
+
+	positions := make(map[uint]int)
+	for i := range ordered {
+		if ordered[i].Position != i {
+			positions[ordered[i].ID] = i
+		}
+		ordered[i].Position = i
+	}
+	if len(positions) > 0 {
+		if err := s.favoriteRepo.UpdatePinPositions(positions); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Info("Pins reordered",
+		zap.Uint("user_id", userID),
+		zap.Int("count", len(ordered)))
+
+	return s.resolve(userID, pinResources(ordered))
+}
+
+func (s *favoriteService) Marks(userID uint, resourceType model.ResourceType, ids []uint) (map[uint]bool, map[uint]bool, error) {
+	favorited := make(map[uint]bool)
+	pinned := make(map[uint]bool)
+	if len(ids) == 0 {
+		return favorited, pinned, nil
+	}
+
+	favoriteIDs, err := s.favoriteRepo.FavoritedIDs(userID, resourceType, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range favoriteIDs {
+		favorited[id] = true
+	}
+
+	pinIDs, err := s.favoriteRepo.PinnedIDs(userID, resourceType, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range pinIDs {
+		pinned[id] = true
+	}
+
+	return favorited, pinned, nil
+}
+
+func (s *favoriteService) HandleEvent(event *model.Event) error {
+	switch event.Type {
+	case model.EventDocumentDeleted:
+		return s.favoriteRepo.DeleteByResource(model.ResourceTypeDocument, event.Document.ID)
+	case model.EventFolderDeleted:
+		return s.favoriteRepo.DeleteByResource(model.ResourceTypeFolder, event.Folder.ID)
+	}
+	return nil
+}
+
+// checkResource 检查文档或文件夹存在且属于当前用户
+func (s *favoriteService) checkResource(userID uint, resourceType model.ResourceType, resourceID uint) error {
+	switch resourceType {
+	case model.ResourceTypeDocument:
+		if _, err := s.documentRepo.GetByIDAndUserID(resourceID, userID); err != nil {
+			return errors.New("文档不存在")
+		}
+	case model.ResourceTypeFolder:
+		if _, err := s.folderRepo.GetByIDAndUserID(resourceID, userID); err != nil {
+			return errors.New("文件夹不存在")
+		}
+	default:
+		return errors.New("只能收藏或置顶文档和文件夹")
+	}
+	return nil
+}
+
+// resolve 加载资源的标题等信息，跳过已删除的文档和文件夹，保持原有顺序
+func (s *favoriteService) resolve(userID uint, resources []markedResource) ([]model.MarkedItemResponse, error) {
+	var documentIDs []uint
+	hasFolders := false
+	for _, resource := range resources {
+		switch resource.resourceType {
+		case model.ResourceTypeDocument:
+			documentIDs = append(documentIDs, resource.resourceID)
+		case model.ResourceTypeFolder:
+			hasFolders = true
+		}
+	}
+
+	documents := make(map[uint]model.Document)
+	if len(documentIDs) > 0 {
+		list, err := s.documentRepo.GetByIDs(userID, documentIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range list {
+			documents[document.ID] = document
+		}
+	}
+
+	folders := make(map[uint]model.Folder)
+	if hasFolders {
+		list, err := s.folderRepo.GetUserFolders(userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, folder := range list {
+			folders[folder.ID] = folder
+		}
+	}
+
+	responses := make([]model.MarkedItemResponse, 0, len(resources))
+	for _, resource := range resources {
+		item := model.MarkedItemResponse{
+			ResourceType: resource.resourceType,
+			ResourceID:   resource.resourceID,
+			Position:     resource.position,
+			MarkedAt:     resource.markedAt,
+		}
+		switch resource.resourceType {
+		case model.ResourceTypeDocument:
+			document, ok := documents[resource.resourceID]
+			if !ok {
+				continue
+			}
+			item.Title = document.Title
+			item.DocumentType = document.Type
+			item.FolderID = document.FolderID
+			item.UpdatedAt = document.UpdatedAt
+		case model.ResourceTypeFolder:
+			folder, ok := folders[resource.resourceID]
+			if !ok {
+				continue
+			}
+			item.Title = folder.Name
+			item.FolderID = folder.ParentID
+			item.UpdatedAt = folder.UpdatedAt
+		default:
+			continue
+		}
+		responses = append(responses, item)
+	}
+
+	return responses, nil
+}
+
+func favoriteResources(favorites []model.Favorite) []markedResource {
+	resources := make([]markedResource, 0, len(favorites))
+	for _, favorite := range favorites {
+		resources = append(resources, markedResource{
+			resourceType: favorite.ResourceType,
+			resourceID:   favorite.ResourceID,
+			markedAt:     favorite.CreatedAt,
+		})
+	}
+	return resources
+}
+
+func pinResources(pins []model.Pin) []markedResource {
+	resources := make([]markedResource, 0, len(pins))
+	for _, pin := range pins {
+		resources = append(resources, markedResource{
+			resourceType: pin.ResourceType,
+			resourceID:   pin.ResourceID,
+			position:     pin.Position,
+			markedAt:     pin.CreatedAt,
+		})
+	}
+	return resources
+}
@@ -18,6 +18,12 @@ type FileService interface {
 	UploadFile(userID uint, file *multipart.FileHeader) (*model.FileUploadResponse, error)
 	DeleteFile(userID uint, fileID string) error
 	GetFileURL(fileID string) string
+	SaveUserFile(userID uint, subDir, fileName string, data []byte) (string, error)
+	WalkUserFiles(userID uint, fn func(relPath string, r io.Reader) error) error
+	DeleteUserFiles(userID uint, subDir string) error
+	StoreUserFile(userID uint, subDir, fileName string, r io.Reader) (string, error)
+	OpenFile(relPath string) (io.ReadCloser, error)
+	RemoveFile(relPath string) error
 }
 
 type fileService struct {
@@ -159,3 +165,125 @@ func (s *fileService) generateFileID(userID uint, filename string) string {
 	hash := md5.Sum([]byte(data))
 	return fmt.Sprintf("%x", hash)
 }
+
+// SaveUserFile 将生成的文件保存到用户目录下，返回访问URL
+func (s *fileService) SaveUserFile(userID uint, subDir, fileName string, data []byte) (string, error) {
+	userDir := filepath.Join(s.uploadPath, fmt.Sprintf("user_%d", userID), subDir)
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(userDir, filepath.Base(fileName))
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", err
+	}
+
+	fileURL := fmt.Sprintf("%s/uploads/user_%d/%s/%s", s.baseURL, userID, subDir, filepath.Base(fileName))
+
+	s.logger.Info("User file saved",
+		zap.Uint("user_id", userID),
+		zap.String("path", filePath),
+		zap.Int("size", len(data)))
+
+	return fileURL, nil
+}
+
+// WalkUserFiles 遍历用户目录下的所有文件，relPath为相对用户目录的路径
+func (s *fileService) WalkUserFiles(userID uint, fn func(relPath string, r io.Reader) error) error {
+	userDir := filepath.Join(s.uploadPath, fmt.Sprintf("user_%d", userID))
+	if _, err := os.Stat(userDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(userDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(userDir, path)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return fn(filepath.ToSlash(relPath), file)
+	})
+}
+
+// DeleteUserFiles 删除用户目录下的子目录，subDir为空时删除整个用户目录
+func (s *fileService) DeleteUserFiles(userID uint, subDir string) error {
+	dir := filepath.Join(s.uploadPath, fmt.Sprintf("user_%d", userID), subDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	s.logger.Info("User files deleted",
+		zap.Uint("user_id", userID),
+		zap.String("path", dir))
+
+	return nil
+}
+
+// StoreUserFile 将文件流保存到用户目录下，返回相对上传目录的路径
+func (s *fileService) StoreUserFile(userID uint, subDir, fileName string, r io.Reader) (string, error) {
+	relPath := filepath.ToSlash(filepath.Join(fmt.Sprintf("user_%d", userID), subDir, filepath.Base(fileName)))
+	filePath := filepath.Join(s.uploadPath, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", err
+	}
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	size, err := io.Copy(dst, r)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return "", err
+	}
+
+	s.logger.Info("User file stored",
+		zap.Uint("user_id", userID),
+		zap.String("path", relPath),
+		zap.Int64("size", size))
+
+	return relPath, nil
+}
+
+// OpenFile 打开相对上传目录的文件
+func (s *fileService) OpenFile(relPath string) (io.ReadCloser, error) {
+	filePath, err := s.resolvePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filePath)
+}
+
+// RemoveFile 删除相对上传目录的文件，文件不存在时忽略
+func (s *fileService) RemoveFile(relPath string) error {
+	filePath, err := s.resolvePath(relPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// resolvePath 将相对路径转换为上传目录下的路径，拒绝指向上传目录之外的路径
+func (s *fileService) resolvePath(relPath string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(relPath))
+	if cleaned == "." || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("无效的文件路径")
+	}
+	return filepath.Join(s.uploadPath, cleaned), nil
+}
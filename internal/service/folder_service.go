@@ -20,22 +20,25 @@ type FolderService interface {
 }
 
 type folderService struct {
-	folderRepo   repository.FolderRepository
-	documentRepo repository.DocumentRepository
-	activityRepo repository.ActivityRepository
-	logger       *zap.Logger
+	folderRepo      repository.FolderRepository
+	documentRepo    repository.DocumentRepository
+	favoriteService FavoriteService
+	events          EventBus
+	logger          *zap.Logger
 }
 
 func NewFolderService(
 	folderRepo repository.FolderRepository,
 	documentRepo repository.DocumentRepository,
-	activityRepo repository.ActivityRepository,
+	favoriteService FavoriteService,
+	events EventBus,
 	logger *zap.Logger) FolderService {
 	return &folderService{
-		folderRepo:   folderRepo,
-		documentRepo: documentRepo,
-		activityRepo: activityRepo,
-		logger:       logger,
+		folderRepo:      folderRepo,
+		documentRepo:    documentRepo,
+		favoriteService: favoriteService,
+		events:          events,
+		logger:          logger,
 	}
 }
 
@@ -71,24 +74,17 @@ func (s *folderService) Create(userID uint, req *model.CreateFolderRequest) (*mo
 		return nil, err
 	}
 
-	// 记录活动
-	go func() {
-		activity := &model.Activity{
-			UserID:       userID,
-			Type:         model.ActivityTypeCreate,
-			ResourceType: model.ResourceTypeFolder,
-			ResourceID:   folder.ID,
-			ResourceName: folder.Name,
-			Description:  "创建文件夹",
-		}
-		s.activityRepo.Create(activity)
-	}()
-
 	s.logger.Info("Folder created",
 		zap.Uint("user_id", userID),
 		zap.Uint("folder_id", folder.ID),
 		zap.String("name", folder.Name))
 
+	s.events.Publish(&model.Event{
+		Type:    model.EventFolderCreated,
+		ActorID: userID,
+		Folder:  folderEventData(folder),
+	})
+
 	return folder, nil
 }
 
@@ -121,23 +117,18 @@ func (s *folderService) Update(id, userID uint, req *model.UpdateFolderRequest)
 		return err
 	}
 
-	// 记录活动
-	go func() {
-		activity := &model.Activity{
-			UserID:       userID,
-			Type:         model.ActivityTypeUpdate,
-			ResourceType: model.ResourceTypeFolder,
-			ResourceID:   folder.ID,
-			ResourceName: folder.Name,
-			Description:  "更新文件夹：" + oldName + " -> " + folder.Name,
-		}
-		s.activityRepo.Create(activity)
-	}()
-
 	s.logger.Info("Folder updated",
 		zap.Uint("user_id", userID),
 		zap.Uint("folder_id", id))
 
+	data := folderEventData(folder)
+	data.PreviousName = oldName
+	s.events.Publish(&model.Event{
+		Type:    model.EventFolderUpdated,
+		ActorID: userID,
+		Folder:  data,
+	})
+
 	return nil
 }
 
@@ -161,23 +152,16 @@ func (s *folderService) Delete(id, userID uint) error {
 		return err
 	}
 
-	// 记录活动
-	go func() {
-		activity := &model.Activity{
-			UserID:       userID,
-			Type:         model.ActivityTypeDelete,
-			ResourceType: model.ResourceTypeFolder,
-			ResourceID:   folder.ID,
-			ResourceName: folder.Name,
-			Description:  "删除文件夹",
-		}
-		s.activityRepo.Create(activity)
-	}()
-
 	s.logger.Info("Folder deleted",
 		zap.Uint("user_id", userID),
 		zap.Uint("folder_id", id))
 
+	s.events.Publish(&model.Event{
+		Type:    model.EventFolderDeleted,
+		ActorID: userID,
+		Folder:  folderEventData(folder),
+	})
+
 	return nil
 }
 
@@ -217,12 +201,23 @@ func (s *folderService) GetSubFolders(parentID, userID uint) ([]model.FolderResp
 		return nil, err
 	}
 
+	ids := make([]uint, 0, len(folders))
+	for _, folder := range folders {
+		ids = append(ids, folder.ID)
+	}
+	favorited, pinned, err := s.favoriteService.Marks(userID, model.ResourceTypeFolder, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	var responses []model.FolderResponse
 	for _, folder := range folders {
 		responses = append(responses, model.FolderResponse{
 			ID:        folder.ID,
 			Name:      folder.Name,
 			ParentID:  folder.ParentID,
+			Favorited: favorited[folder.ID],
+			Pinned:    pinned[folder.ID],
 			CreatedAt: folder.CreatedAt,
 			UpdatedAt: folder.UpdatedAt,
 		})
@@ -233,7 +228,7 @@ func (s *folderService) GetSubFolders(parentID, userID uint) ([]model.FolderResp
 
 func (s *folderService) MoveFolder(id, newParentID, userID uint) error {
 	// 检查文件夹是否存在且属于当前用户
-	_, err := s.folderRepo.GetByIDAndUserID(id, userID)
+	folder, err := s.folderRepo.GetByIDAndUserID(id, userID)
 	if err != nil {
 		return err
 	}
@@ -260,5 +255,15 @@ func (s *folderService) MoveFolder(id, newParentID, userID uint) error {
 		zap.Uint("folder_id", id),
 		zap.Uint("new_parent_id", newParentID))
 
+	folder.ParentID = nil
+	if newParentID != 0 {
+		folder.ParentID = &newParentID
+	}
+	s.events.Publish(&model.Event{
+		Type:    model.EventFolderMoved,
+		ActorID: userID,
+		Folder:  folderEventData(folder),
+	})
+
 	return nil
 }
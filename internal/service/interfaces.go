@@ -52,7 +52,6 @@ type SearchService interface {
 type WorkspaceService interface {
 	// 工作台首页接口
 	GetRecentDocuments(userID uint, limit int) ([]*model.DocumentResponse, error)
-	GetImportantDocuments(userID uint, limit int) ([]*model.DocumentResponse, error)
 	GetRecentActivities(userID uint, limit int) ([]*model.ActivityResponse, error)
 	
 	// 统计信息接口
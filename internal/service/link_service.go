@@ -0,0 +1,349 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 单个文档最多记录的出链数
+	linkMaxPerDocument = 1000
+	linkContextRunes   = 60
+	linkBackfillBatch  = 200
+	// 图谱中范围内文档和关联文档各自的上限
+	graphMaxNodes = 500
+)
+
+var (
+	// [[标题]]、[[标题|显示文本]]和[[#123]]
+	wikiLinkPattern = regexp.MustCompile(`\[\[\s*([^\[\]|\n]+?)\s*(?:\|[^\[\]\n]*)?\]\]`)
+	// 站内文档地址，如Markdown链接中的/documents/123
+	documentPathPattern = regexp.MustCompile(`(?:^|[\s(<"'])/documents/(\d+)\b`)
+)
+
+type LinkService interface {
+	// GetLinks 返回文档的出链和反向链接
+	GetLinks(documentID, userID uint) (*model.DocumentLinksResponse, error)
+	// Backlinks 返回文档详情中的反向链接，查询失败时返回空
+	Backlinks(documentID, userID uint) []model.LinkedDocument
+	ListBroken(userID uint, req *model.BrokenLinkListRequest) ([]model.BrokenLink, int64, error)
+	// Graph 返回范围内文档及其链接组成的图谱
+	Graph(userID uint, req *model.DocumentGraphRequest) (*model.DocumentGraph, error)
+	// HandleEvent 事件订阅者，文档保存或恢复后重新解析出链并匹配同名的标题链接，删除时移除出链
+	HandleEvent(event *model.Event) error
+	// Backfill 链接表为空时为已有文档解析链接
+	Backfill()
+}
+
+type linkService struct {
+	documentRepo repository.DocumentRepository
+	linkRepo     repository.DocumentLinkRepository
+	logger       *zap.Logger
+}
+
+func NewLinkService(documentRepo repository.DocumentRepository, linkRepo repository.DocumentLinkRepository, logger *zap.Logger) LinkService {
+	return &linkService{
+		documentRepo: documentRepo,
+		linkRepo:     linkRepo,
+		logger:       logger,
+	}
+}
+
+func (s *linkService) GetLinks(documentID, userID uint) (*model.DocumentLinksResponse, error) {
+	if _, err := s.documentRepo.GetByIDAndUserID(documentID, userID); err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+
+	outgoing, err := s.linkRepo.ListOutgoing(documentID)
+	if err != nil {
+		return nil, err
+	}
+	backlinks, err := s.linkRepo.ListBacklinks(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.DocumentLinksResponse{Outgoing: outgoing, Backlinks: backlinks}
+	if response.Outgoing == nil {
+		response.Outgoing = []model.LinkedDocument{}
+	}
+	if response.Backlinks == nil {
+		response.Backlinks = []model.LinkedDocument{}
+	}
+	return response, nil
+}
+
+func (s *linkService) Backlinks(documentID, userID uint) []model.LinkedDocument {
+	backlinks, err := s.linkRepo.ListBacklinks(documentID, userID)
+	if err != nil {
+		s.logger.Warn("Failed to list backlinks", zap.Uint("document_id", documentID), zap.Error(err))
+		return nil
+	}
+	return backlinks
+}
+
+func (s *linkService) ListBroken(userID uint, req *model.BrokenLinkListRequest) ([]model.BrokenLink, int64, error) {
+	return s.linkRepo.ListBroken(userID, req.Page, req.PageSize)
+}
+
+func (s *linkService) Graph(userID uint, req *model.DocumentGraphRequest) (*model.DocumentGraph, error) {
+	documents, err := s.linkRepo.ListGraphDocuments(userID, req, graphMaxNodes+1)
+	if err != nil {
+		return nil, err
+	}
+	graph := &model.DocumentGraph{Nodes: []model.GraphNode{}, Edges: []model.GraphEdge{}}
+	if len(documents) > graphMaxNodes {
+		documents = documents[:graphMaxNodes]
+		graph.Truncated = true
+	}
+
+	nodes := make(map[uint]*model.GraphNode, len(documents))
+	ids := make([]uint, 0, len(documents))
+	for _, document := range documents {
+		nodes[document.ID] = &model.GraphNode{ID: document.ID, Title: document.Title, Type: document.Type, InScope: true}
+		ids = append(ids, document.ID)
+	}
+
+	links, err := s.linkRepo.ListValidLinks(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// 合并同一对文档之间的链接，并收集范围外的关联文档
+	weights := make(map[[2]uint]int)
+	var neighbors []uint
+	added := make(map[uint]bool)
+	for _, link := range links {
+		if link.TargetID == nil || *link.TargetID == link.SourceID {
+			continue
+		}
+		for _, id := range []uint{link.SourceID, *link.TargetID} {
+			if _, ok := nodes[id]; !ok && !added[id] {
+				added[id] = true
+				neighbors = append(neighbors, id)
+			}
+		}
+		weights[[2]uint{link.SourceID, *link.TargetID}]++
+	}
+
+	if len(neighbors) > graphMaxNodes {
+		neighbors = neighbors[:graphMaxNodes]
+		graph.Truncated = true
+	}
+	related, err := s.documentRepo.GetByIDs(userID, neighbors)
+	if err != nil {
+		return nil, err
+	}
+	for _, document := range related {
+		nodes[document.ID] = &model.GraphNode{ID: document.ID, Title: document.Title, Type: document.Type}
+	}
+
+	for pair, weight := range weights {
+		source, target := nodes[pair[0]], nodes[pair[1]]
+		if source == nil || target == nil {
+			continue
+		}
+		source.Degree += weight
+		target.Degree += weight
+		graph.Edges = append(graph.Edges, model.GraphEdge{Source: pair[0], Target: pair[1], Weight: weight})
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Source != graph.Edges[j].Source {
+			return graph.Edges[i].Source < graph.Edges[j].Source
+		}
+		return graph.Edges[i].Target < graph.Edges[j].Target
+	})
+
+	for _, id := range ids {
+		graph.Nodes = append(graph.Nodes, *nodes[id])
+	}
+	for _, document := range related {
+		graph.Nodes = append(graph.Nodes, *nodes[document.ID])
+	}
+	return graph, nil
+}
+
+func (s *linkService) HandleEvent(event *model.Event) error {
+	if event.Document == nil {
+		return nil
+	}
+
+	switch event.Type {
+	case model.EventDocumentCreated, model.EventDocumentCopied, model.EventDocumentUpdated, model.EventDocumentRestored:
+		document, err := s.documentRepo.GetByIDAndUserID(event.Document.ID, event.ActorID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 事件处理前文档已被删除
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.indexDocument(document); err != nil {
+			return err
+		}
+		// 改名后按旧标题引用的链接失效，引用新标题且尚未找到目标的链接指向该文档
+		if err := s.linkRepo.UnresolveRenamed(document.ID, document.Title); err != nil {
+			return err
+		}
+		return s.linkRepo.ResolveTitle(document.UserID, document.Title, document.ID)
+	case model.EventDocumentDeleted:
+		// 指向该文档的链接保留，查询时视为失效，文档恢复后自动恢复
+		return s.linkRepo.DeleteBySourceID(event.Document.ID)
+	}
+	return nil
+}
+
+func (s *linkService) Backfill() {
+	count, err := s.linkRepo.Count()
+	if err != nil {
+		s.logger.Error("Failed to count document links", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	var afterID uint
+	indexed := 0
+	for {
+		documents, err := s.linkRepo.ListDocumentsAfter(afterID, linkBackfillBatch)
+		if err != nil {
+			s.logger.Error("Failed to list documents for links", zap.Error(err))
+			return
+		}
+		if len(documents) == 0 {
+			break
+		}
+
+		for i := range documents {
+			afterID = documents[i].ID
+			if err := s.indexDocument(&documents[i]); err != nil {
+				s.logger.Warn("Failed to index document links",
+					zap.Uint("document_id", documents[i].ID),
+					zap.Error(err))
+				continue
+			}
+			indexed++
+		}
+	}
+
+	if indexed > 0 {
+		s.logger.Info("Document links indexed", zap.Int("documents", indexed))
+	}
+}
+
+// indexDocument 解析文档内容中的链接并替换原有的出链，标题链接按同名文档匹配目标
+func (s *linkService) indexDocument(document *model.Document) error {
+	text := documentText(document)
+	if document.Type == model.DocumentTypeWord {
+		// 转换后的Markdown中方括号被转义
+		text = unescapeMarkdown(text)
+	}
+	parsed := parseDocumentLinks(text)
+
+	var titles []string
+	for _, link := range parsed {
+		if link.LinkType == model.DocumentLinkByTitle {
+			titles = append(titles, link.TargetTitle)
+		}
+	}
+	ids, err := s.linkRepo.FindIDsByTitles(document.UserID, titles)
+	if err != nil {
+		return err
+	}
+
+	links := make([]model.DocumentLink, 0, len(parsed))
+	for _, link := range parsed {
+		if link.LinkType == model.DocumentLinkByTitle {
+			if id, ok := ids[strings.ToLower(link.TargetTitle)]; ok {
+				link.TargetID = &id
+			}
+		}
+		// 不记录指向自身的链接
+		if link.TargetID != nil && *link.TargetID == document.ID {
+			continue
+		}
+		link.UserID = document.UserID
+		link.SourceID = document.ID
+		links = append(links, link)
+	}
+	return s.linkRepo.ReplaceForDocument(document.ID, links)
+}
+
+// parseDocumentLinks 从文本中解析[[标题]]、[[#ID]]和/documents/ID形式的链接，同一目标只保留第一次出现的位置
+func parseDocumentLinks(text string) []model.DocumentLink {
+	type match struct {
+		start, end int
+		link       model.DocumentLink
+	}
+	var matches []match
+
+	for _, m := range wikiLinkPattern.FindAllStringSubmatchIndex(text, -1) {
+		target := strings.TrimSpace(text[m[2]:m[3]])
+		if target == "" {
+			continue
+		}
+		link := model.DocumentLink{LinkType: model.DocumentLinkByTitle, TargetTitle: truncateRunes(target, 255)}
+		if strings.HasPrefix(target, "#") {
+			id, err := strconv.ParseUint(target[1:], 10, 32)
+			if err != nil || id == 0 {
+				continue
+			}
+			targetID := uint(id)
+			link = model.DocumentLink{LinkType: model.DocumentLinkByID, TargetID: &targetID}
+		}
+		matches = append(matches, match{m[0], m[1], link})
+	}
+	for _, m := range documentPathPattern.FindAllStringSubmatchIndex(text, -1) {
+		id, err := strconv.ParseUint(text[m[2]:m[3]], 10, 32)
+		if err != nil || id == 0 {
+			continue
+		}
+		targetID := uint(id)
+		matches = append(matches, match{m[2], m[3], model.DocumentLink{LinkType: model.DocumentLinkByID, TargetID: &targetID}})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	seen := make(map[string]bool)
+	var links []model.DocumentLink
+	for _, m := range matches {
+		key := "title:" + strings.ToLower(m.link.TargetTitle)
+		if m.link.TargetID != nil {
+			key = "id:" + strconv.FormatUint(uint64(*m.link.TargetID), 10)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		m.link.Context = linkContext(text, m.start, m.end)
+		links = append(links, m.link)
+		if len(links) >= linkMaxPerDocument {
+			break
+		}
+	}
+	return links
+}
+
+// linkContext 截取链接前后的文本，换行替换为空格
+func linkContext(text string, start, end int) string {
+	before := []rune(text[:start])
+	if len(before) > linkContextRunes {
+		before = before[len(before)-linkContextRunes:]
+	}
+	after := []rune(text[end:])
+	if len(after) > linkContextRunes {
+		after = after[:linkContextRunes]
+	}
+	context := string(before) + text[start:end] + string(after)
+	context = strings.Join(strings.Fields(context), " ")
+	return truncateRunes(context, 490)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// LLMProvider 大模型服务商。实现需要支持流式输出，每收到一段内容调用一次onDelta，
+// onDelta返回错误或ctx取消时应尽快停止生成。
+type LLMProvider interface {
+	Name() string
+	Model() string
+	Stream(ctx context.Context, req *model.LLMRequest, onDelta func(delta string) error) (*model.LLMUsage, error)
+}
+
+// NewLLMProvider 按配置创建服务商，目前只支持兼容OpenAI Chat Completions接口的服务
+func NewLLMProvider(provider, baseURL, apiKey, modelName string, timeout time.Duration) (LLMProvider, error) {
+	switch provider {
+	case "openai", "":
+		return NewOpenAIProvider(baseURL, apiKey, modelName, timeout), nil
+	default:
+		return nil, fmt.Errorf("unsupported llm provider: %s", provider)
+	}
+}
+
+type openAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewOpenAIProvider baseURL如 https://api.openai.com/v1，本地调试时可指向兼容的模拟服务，apiKey为空时不发送认证头
+func NewOpenAIProvider(baseURL, apiKey, modelName string, timeout time.Duration) LLMProvider {
+	return &openAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   modelName,
+		timeout: timeout,
+		// 流式响应的总时长由ctx控制，不设置整体超时
+		client: &http.Client{},
+	}
+}
+
+func (p *openAIProvider) Name() string {
+	return "openai"
+}
+
+func (p *openAIProvider) Model() string {
+	return p.model
+}
+
+type openAIChatRequest struct {
+	Model         string             `json:"model"`
+	Messages      []model.LLMMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens,omitempty"`
+	Temperature   float64            `json:"temperature"`
+	Stream        bool               `json:"stream"`
+	StreamOptions map[string]bool    `json:"stream_options,omitempty"`
+}
+
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *model.LLMUsage `json:"usage"`
+	Error *openAIError    `json:"error"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, req *model.LLMRequest, onDelta func(delta string) error) (*model.LLMUsage, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(openAIChatRequest{
+		Model:         p.model,
+		Messages:      req.Messages,
+		MaxTokens:     req.MaxTokens,
+		Temperature:   req.Temperature,
+		Stream:        true,
+		StreamOptions: map[string]bool{"include_usage": true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("请求模型服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var errResp struct {
+			Error *openAIError `json:"error"`
+		}
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error != nil {
+			return nil, fmt.Errorf("模型服务返回错误(%d): %s", resp.StatusCode, errResp.Error.Message)
+		}
+		return nil, fmt.Errorf("模型服务返回错误(%d): %s", resp.StatusCode, truncateRunes(string(data), 200))
+	}
+
+	usage := &model.LLMUsage{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return usage, nil
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("无法解析模型响应: %w", err)
+		}
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("模型服务返回错误: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取模型响应失败: %w", err)
+	}
+
+	// 部分兼容服务不发送[DONE]，连接正常关闭即视为结束
+	return usage, nil
+}
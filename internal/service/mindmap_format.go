@@ -0,0 +1,328 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// 思维导图与OPML、Markdown大纲、FreeMind格式的相互转换
+
+var (
+	markdownHeadingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownListItemPattern = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	markdownQuotePattern    = regexp.MustCompile(`^[ \t]*>\s?(.*)$`)
+	htmlBreakPattern        = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	htmlTagPattern          = regexp.MustCompile(`<[^>]*>`)
+)
+
+type opmlDocument struct {
+	XMLName xml.Name       `xml:"opml"`
+	Version string         `xml:"version,attr"`
+	Title   string         `xml:"head>title"`
+	Outline []*opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Text     string         `xml:"text,attr"`
+	Title    string         `xml:"title,attr,omitempty"`
+	Note     string         `xml:"_note,attr,omitempty"`
+	Outlines []*opmlOutline `xml:"outline"`
+}
+
+func exportOPML(title string, mindMap *model.MindMap) ([]byte, error) {
+	var convert func(node *model.MindMapNode) *opmlOutline
+	convert = func(node *model.MindMapNode) *opmlOutline {
+		outline := &opmlOutline{Text: node.Text, Note: node.Note}
+		for _, child := range node.Children {
+			outline.Outlines = append(outline.Outlines, convert(child))
+		}
+		return outline
+	}
+
+	document := opmlDocument{
+		Version: "2.0",
+		Title:   title,
+		Outline: []*opmlOutline{convert(mindMap.Root)},
+	}
+	data, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// importOPML body中只有一个大纲节点时作为中心主题，有多个时以文档标题作为中心主题
+func importOPML(data []byte) (*model.MindMap, error) {
+	var document opmlDocument
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, errors.New("无法解析OPML文件")
+	}
+	if len(document.Outline) == 0 {
+		return nil, errors.New("文件中没有可导入的内容")
+	}
+
+	var convert func(outline *opmlOutline) *model.MindMapNode
+	convert = func(outline *opmlOutline) *model.MindMapNode {
+		text := outline.Text
+		if text == "" {
+			text = outline.Title
+		}
+		node := &model.MindMapNode{Text: text, Note: outline.Note, Children: []*model.MindMapNode{}}
+		for _, child := range outline.Outlines {
+			node.Children = append(node.Children, convert(child))
+		}
+		return node
+	}
+
+	if len(document.Outline) == 1 {
+		return &model.MindMap{Root: convert(document.Outline[0])}, nil
+	}
+	topic := strings.TrimSpace(document.Title)
+	if topic == "" {
+		topic = mindMapDefaultTopic
+	}
+	root := &model.MindMapNode{Text: topic, Children: []*model.MindMapNode{}}
+	for _, outline := range document.Outline {
+		root.Children = append(root.Children, convert(outline))
+	}
+	return &model.MindMap{Root: root}, nil
+}
+
+// exportMindMapMarkdown 中心主题为一级标题，其余节点为嵌套列表，备注以引用块写在节点下方
+func exportMindMapMarkdown(mindMap *model.MindMap) []byte {
+	var b bytes.Buffer
+	writeNote := func(indent, note string) {
+		for _, line := range strings.Split(note, "\n") {
+			b.WriteString(indent)
+			b.WriteString("> ")
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("# ")
+	b.WriteString(markdownLine(mindMap.Root.Text))
+	b.WriteString("\n")
+	if mindMap.Root.Note != "" {
+		b.WriteString("\n")
+		writeNote("", mindMap.Root.Note)
+	}
+	if len(mindMap.Root.Children) > 0 {
+		b.WriteString("\n")
+	}
+
+	var walk func(node *model.MindMapNode, depth int)
+	walk = func(node *model.MindMapNode, depth int) {
+		indent := strings.Repeat("  ", depth)
+		b.WriteString(indent)
+		b.WriteString("- ")
+		b.WriteString(markdownLine(node.Text))
+		b.WriteString("\n")
+		if node.Note != "" {
+			writeNote(indent+"  ", node.Note)
+		}
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	for _, child := range mindMap.Root.Children {
+		walk(child, 0)
+	}
+	return b.Bytes()
+}
+
+// markdownLine 节点文本中的换行替换为空格，避免破坏列表结构
+func markdownLine(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, "\n", " ")), " ")
+}
+
+// importMindMapMarkdown 标题和列表项按层级转换为节点，引用块和普通段落作为上一个节点的备注。
+// 第一个标题作为中心主题，之后同级或更高级的标题都作为中心主题的子节点
+func importMindMapMarkdown(data []byte) (*model.MindMap, error) {
+	type entry struct {
+		level int
+		node  *model.MindMapNode
+	}
+
+	var root *model.MindMapNode
+	var stack []entry
+	var last *model.MindMapNode
+	headingLevel := 0
+	inCodeBlock := false
+
+	add := func(level int, text string, heading bool) {
+		node := &model.MindMapNode{Text: text, Children: []*model.MindMapNode{}}
+		last = node
+		if root == nil {
+			if heading {
+				root = node
+				stack = []entry{{level, node}}
+				return
+			}
+			// 第一个节点是列表项时，使用默认主题作为中心主题，列表项都在其下
+			root = &model.MindMapNode{Text: mindMapDefaultTopic, Children: []*model.MindMapNode{}}
+			stack = []entry{{0, root}}
+		}
+		for len(stack) > 1 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+		parent.Children = append(parent.Children, node)
+		stack = append(stack, entry{level, node})
+	}
+	appendNote := func(line string) {
+		if last == nil {
+			return
+		}
+		if last.Note != "" {
+			last.Note += "\n"
+		}
+		last.Note += line
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), mindMapMaxImportSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if m := markdownHeadingPattern.FindStringSubmatch(line); m != nil {
+			headingLevel = len(m[1])
+			add(headingLevel, m[2], true)
+			continue
+		}
+		if m := markdownListItemPattern.FindStringSubmatch(line); m != nil {
+			indent := len(strings.ReplaceAll(m[1], "\t", "  "))
+			// 列表项位于最近的标题之下，每两个空格的缩进增加一级
+			add(headingLevel+1+indent/2, m[3], false)
+			continue
+		}
+		if m := markdownQuotePattern.FindStringSubmatch(line); m != nil {
+			appendNote(m[1])
+			continue
+		}
+		appendNote(strings.TrimSpace(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("无法解析Markdown文件")
+	}
+	if root == nil {
+		return nil, errors.New("文件中没有可导入的内容")
+	}
+	return &model.MindMap{Root: root}, nil
+}
+
+type freeMindMap struct {
+	XMLName xml.Name      `xml:"map"`
+	Version string        `xml:"version,attr"`
+	Node    *freeMindNode `xml:"node"`
+}
+
+type freeMindNode struct {
+	ID          string                `xml:"ID,attr,omitempty"`
+	Text        string                `xml:"TEXT,attr,omitempty"`
+	Folded      string                `xml:"FOLDED,attr,omitempty"`
+	RichContent []freeMindRichContent `xml:"richcontent"`
+	Nodes       []*freeMindNode       `xml:"node"`
+}
+
+type freeMindRichContent struct {
+	Type  string `xml:"TYPE,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+func exportFreeMind(mindMap *model.MindMap) ([]byte, error) {
+	var convert func(node *model.MindMapNode) *freeMindNode
+	convert = func(node *model.MindMapNode) *freeMindNode {
+		fm := &freeMindNode{ID: "ID_" + node.ID, Text: node.Text}
+		if node.Collapsed && len(node.Children) > 0 {
+			fm.Folded = "true"
+		}
+		if node.Note != "" {
+			var note strings.Builder
+			note.WriteString("<html><head></head><body>")
+			for _, line := range strings.Split(node.Note, "\n") {
+				note.WriteString("<p>")
+				note.WriteString(html.EscapeString(line))
+				note.WriteString("</p>")
+			}
+			note.WriteString("</body></html>")
+			fm.RichContent = []freeMindRichContent{{Type: "NOTE", Inner: note.String()}}
+		}
+		for _, child := range node.Children {
+			fm.Nodes = append(fm.Nodes, convert(child))
+		}
+		return fm
+	}
+
+	data, err := xml.MarshalIndent(freeMindMap{Version: "1.0.1", Node: convert(mindMap.Root)}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func importFreeMind(data []byte) (*model.MindMap, error) {
+	var fm freeMindMap
+	if err := xml.Unmarshal(data, &fm); err != nil {
+		return nil, errors.New("无法解析FreeMind文件")
+	}
+	if fm.Node == nil {
+		return nil, errors.New("文件中没有可导入的内容")
+	}
+
+	var convert func(fn *freeMindNode) *model.MindMapNode
+	convert = func(fn *freeMindNode) *model.MindMapNode {
+		node := &model.MindMapNode{
+			ID:        strings.TrimPrefix(fn.ID, "ID_"),
+			Text:      fn.Text,
+			Collapsed: fn.Folded == "true",
+			Children:  []*model.MindMapNode{},
+		}
+		for _, rc := range fn.RichContent {
+			switch strings.ToUpper(rc.Type) {
+			case "NODE":
+				// 富文本节点没有TEXT属性
+				if node.Text == "" {
+					node.Text = strings.ReplaceAll(htmlToText(rc.Inner), "\n", " ")
+				}
+			case "NOTE":
+				node.Note = htmlToText(rc.Inner)
+			}
+		}
+		for _, child := range fn.Nodes {
+			node.Children = append(node.Children, convert(child))
+		}
+		return node
+	}
+	return &model.MindMap{Root: convert(fm.Node)}, nil
+}
+
+// htmlToText 去掉FreeMind富文本中的标签，段落和换行转换为换行符
+func htmlToText(content string) string {
+	if i := strings.Index(strings.ToLower(content), "</head>"); i >= 0 {
+		content = content[i+len("</head>"):]
+	}
+	content = htmlBreakPattern.ReplaceAllString(content, "\n")
+	content = html.UnescapeString(htmlTagPattern.ReplaceAllString(content, ""))
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
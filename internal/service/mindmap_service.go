@@ -0,0 +1,553 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	mindMapMaxNodes      = 5000
+	mindMapMaxDepth      = 100
+	mindMapMaxTextLength = 1000
+	mindMapMaxNoteLength = 10000
+	mindMapNodeIDLength  = 12
+	// 导入文件的最大大小
+	mindMapMaxImportSize = 5 << 20
+	mindMapBackfillBatch = 100
+	mindMapDefaultTopic  = "中心主题"
+)
+
+type MindMapService interface {
+	Get(documentID, userID uint) (*model.MindMap, error)
+	// ApplyOperations 依次执行节点操作并保存，任一操作失败时全部不生效
+	ApplyOperations(documentID, userID uint, operations []model.MindMapOperation, client *model.ClientInfo) (*model.MindMapOperationsResponse, error)
+	// Export 导出为指定格式，返回文件名和文件内容
+	Export(documentID, userID uint, format model.MindMapFormat) (string, []byte, error)
+	// Import 从OPML、Markdown或FreeMind文件创建思维导图，按扩展名识别格式
+	Import(userID uint, filename string, r io.Reader, req *model.ImportMindMapRequest, client *model.ClientInfo) (*model.Document, error)
+	SearchNodes(userID uint, req *model.MindMapNodeSearchRequest) ([]model.MindMapNodeSearchResult, int64, error)
+	// HandleEvent 事件订阅者，思维导图创建、复制、更新和恢复时重建节点索引，删除时移除
+	HandleEvent(event *model.Event) error
+	// Backfill 为还没有节点索引的思维导图建立索引
+	Backfill()
+}
+
+type mindMapService struct {
+	documentRepo    repository.DocumentRepository
+	nodeRepo        repository.MindMapNodeRepository
+	documentService DocumentService
+	logger          *zap.Logger
+
+	// 同一文档的节点操作依次执行
+	locks *documentLocks
+}
+
+func NewMindMapService(documentRepo repository.DocumentRepository, nodeRepo repository.MindMapNodeRepository, documentService DocumentService, logger *zap.Logger) MindMapService {
+	return &mindMapService{
+		documentRepo:    documentRepo,
+		nodeRepo:        nodeRepo,
+		documentService: documentService,
+		logger:          logger,
+		locks:           newDocumentLocks(),
+	}
+}
+
+func (s *mindMapService) Get(documentID, userID uint) (*model.MindMap, error) {
+	document, err := s.getMindMapDocument(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return parseMindMap(document.Content)
+}
+
+func (s *mindMapService) ApplyOperations(documentID, userID uint, operations []model.MindMapOperation, client *model.ClientInfo) (*model.MindMapOperationsResponse, error) {
+	unlock := s.locks.lock(documentID)
+	defer unlock()
+
+	document, err := s.getMindMapDocument(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	mindMap, err := parseMindMap(document.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIDs := make([]string, len(operations))
+	for i := range operations {
+		nodeID, err := applyMindMapOperation(mindMap, &operations[i])
+		if err != nil {
+			if len(operations) > 1 {
+				return nil, fmt.Errorf("第%d个操作失败：%w", i+1, err)
+			}
+			return nil, err
+		}
+		nodeIDs[i] = nodeID
+	}
+
+	content, err := encodeMindMap(mindMap)
+	if err != nil {
+		return nil, err
+	}
+	err = s.documentService.Update(documentID, userID, &model.UpdateDocumentRequest{Content: content, Version: &document.Version}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := parseMindMap(updated.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.MindMapOperationsResponse{
+		MindMap:   saved,
+		NodeIDs:   nodeIDs,
+		UpdatedAt: updated.UpdatedAt,
+	}, nil
+}
+
+func (s *mindMapService) Export(documentID, userID uint, format model.MindMapFormat) (string, []byte, error) {
+	document, err := s.getMindMapDocument(documentID, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	mindMap, err := parseMindMap(document.Content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch format {
+	case model.MindMapFormatOPML:
+		data, err := exportOPML(document.Title, mindMap)
+		return document.Title + ".opml", data, err
+	case model.MindMapFormatMarkdown:
+		return document.Title + ".md", exportMindMapMarkdown(mindMap), nil
+	case model.MindMapFormatFreeMind:
+		data, err := exportFreeMind(mindMap)
+		return document.Title + ".mm", data, err
+	}
+	return "", nil, errors.New("不支持的导出格式")
+}
+
+func (s *mindMapService) Import(userID uint, filename string, r io.Reader, req *model.ImportMindMapRequest, client *model.ClientInfo) (*model.Document, error) {
+	var parse func(data []byte) (*model.MindMap, error)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".opml", ".xml":
+		parse = importOPML
+	case ".md", ".markdown":
+		parse = importMindMapMarkdown
+	case ".mm":
+		parse = importFreeMind
+	default:
+		return nil, errors.New("只支持导入OPML、Markdown和FreeMind格式的文件")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, mindMapMaxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > mindMapMaxImportSize {
+		return nil, fmt.Errorf("文件不能超过%dMB", mindMapMaxImportSize>>20)
+	}
+
+	mindMap, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMindMap(mindMap); err != nil {
+		return nil, err
+	}
+	content, err := encodeMindMap(mindMap)
+	if err != nil {
+		return nil, err
+	}
+
+	title := req.Title
+	if title == "" {
+		title = truncateRunes(mindMap.Root.Text, 250)
+	}
+	if strings.TrimSpace(title) == "" {
+		title = truncateRunes(strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)), 250)
+	}
+
+	document, err := s.documentService.Create(userID, &model.CreateDocumentRequest{
+		Title:    title,
+		Content:  content,
+		Type:     model.DocumentTypeMindMap,
+		FolderID: req.FolderID,
+	}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Mind map imported",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.String("filename", filename))
+
+	return document, nil
+}
+
+func (s *mindMapService) SearchNodes(userID uint, req *model.MindMapNodeSearchRequest) ([]model.MindMapNodeSearchResult, int64, error) {
+	keyword := strings.TrimSpace(req.Query)
+	if keyword == "" {
+		return nil, 0, errors.New("搜索关键词不能为空")
+	}
+	return s.nodeRepo.Search(userID, keyword, req.Page, req.PageSize)
+}
+
+func (s *mindMapService) HandleEvent(event *model.Event) error {
+	if event.Document == nil {
+		return nil
+	}
+
+	switch event.Type {
+	case model.EventDocumentCreated, model.EventDocumentCopied, model.EventDocumentUpdated, model.EventDocumentRestored:
+		if event.Document.Type != model.DocumentTypeMindMap {
+			return nil
+		}
+		document, err := s.documentRepo.GetByIDAndUserID(event.Document.ID, event.ActorID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 事件处理前文档已被删除
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.indexDocument(document)
+	case model.EventDocumentDeleted:
+		return s.nodeRepo.DeleteByDocumentID(event.Document.ID)
+	}
+	return nil
+}
+
+func (s *mindMapService) Backfill() {
+	var afterID uint
+	indexed := 0
+	for {
+		documents, err := s.nodeRepo.ListUnindexedDocuments(afterID, mindMapBackfillBatch)
+		if err != nil {
+			s.logger.Error("Failed to list unindexed mind maps", zap.Error(err))
+			return
+		}
+		if len(documents) == 0 {
+			break
+		}
+
+		for i := range documents {
+			afterID = documents[i].ID
+			// 内容无效的导图跳过，不影响其他文档
+			if err := s.indexDocument(&documents[i]); err != nil {
+				s.logger.Warn("Failed to index mind map",
+					zap.Uint("document_id", documents[i].ID),
+					zap.Error(err))
+				continue
+			}
+			indexed++
+		}
+	}
+
+	if indexed > 0 {
+		s.logger.Info("Mind map nodes indexed", zap.Int("documents", indexed))
+	}
+}
+
+// indexDocument 按节点重建文档的搜索索引
+func (s *mindMapService) indexDocument(document *model.Document) error {
+	mindMap, err := parseMindMap(document.Content)
+	if err != nil {
+		return err
+	}
+
+	var nodes []model.MindMapNodeIndex
+	var walk func(node *model.MindMapNode, parentID string, path []string)
+	walk = func(node *model.MindMapNode, parentID string, path []string) {
+		nodes = append(nodes, model.MindMapNodeIndex{
+			DocumentID: document.ID,
+			UserID:     document.UserID,
+			NodeID:     node.ID,
+			ParentID:   parentID,
+			Depth:      len(path),
+			Text:       node.Text,
+			Note:       node.Note,
+			Path:       truncateRunes(strings.Join(path, " > "), 1990),
+		})
+		childPath := append(path[:len(path):len(path)], node.Text)
+		for _, child := range node.Children {
+			walk(child, node.ID, childPath)
+		}
+	}
+	walk(mindMap.Root, "", nil)
+
+	return s.nodeRepo.ReplaceForDocument(document.ID, nodes)
+}
+
+func (s *mindMapService) getMindMapDocument(documentID, userID uint) (*model.Document, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+	if document.Type != model.DocumentTypeMindMap {
+		return nil, errors.New("该文档不是思维导图")
+	}
+	return document, nil
+}
+
+// parseMindMap 解析并校验导图内容，为缺少ID的节点补充ID。内容为空时返回只有中心主题的导图
+func parseMindMap(content string) (*model.MindMap, error) {
+	if strings.TrimSpace(content) == "" {
+		return newMindMap(mindMapDefaultTopic), nil
+	}
+
+	var mindMap model.MindMap
+	if err := json.Unmarshal([]byte(content), &mindMap); err != nil {
+		return nil, errors.New("思维导图内容不是有效的JSON")
+	}
+	if err := validateMindMap(&mindMap); err != nil {
+		return nil, err
+	}
+	return &mindMap, nil
+}
+
+// normalizeMindMapContent 校验导图内容并重新序列化，保存思维导图前调用
+func normalizeMindMapContent(content string) (string, error) {
+	mindMap, err := parseMindMap(content)
+	if err != nil {
+		return "", err
+	}
+	return encodeMindMap(mindMap)
+}
+
+func encodeMindMap(mindMap *model.MindMap) (string, error) {
+	data, err := json.Marshal(mindMap)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func newMindMap(topic string) *model.MindMap {
+	return &model.MindMap{Root: &model.MindMapNode{
+		ID:       newMindMapNodeID(),
+		Text:     topic,
+		Children: []*model.MindMapNode{},
+	}}
+}
+
+func newMindMapNodeID() string {
+	id, _ := generateRandomString(mindMapNodeIDLength)
+	return id
+}
+
+// validateMindMap 检查节点数量、层级和文本长度。缺少ID的节点按位置生成ID，
+// 未保存过的旧内容多次读取时ID保持不变；ID重复的节点生成随机ID
+func validateMindMap(mindMap *model.MindMap) error {
+	if mindMap.Root == nil {
+		return errors.New("思维导图缺少中心主题")
+	}
+
+	seen := make(map[string]bool)
+	count := 0
+	var walk func(node *model.MindMapNode, depth int, position string) error
+	walk = func(node *model.MindMapNode, depth int, position string) error {
+		count++
+		if count > mindMapMaxNodes {
+			return fmt.Errorf("思维导图的节点不能超过%d个", mindMapMaxNodes)
+		}
+		if depth > mindMapMaxDepth {
+			return fmt.Errorf("思维导图的层级不能超过%d层", mindMapMaxDepth)
+		}
+		if utf8.RuneCountInString(node.Text) > mindMapMaxTextLength {
+			return fmt.Errorf("节点文本不能超过%d个字符", mindMapMaxTextLength)
+		}
+		if utf8.RuneCountInString(node.Note) > mindMapMaxNoteLength {
+			return fmt.Errorf("节点备注不能超过%d个字符", mindMapMaxNoteLength)
+		}
+		if node.ID == "" && len(position) <= 32 {
+			node.ID = position
+		}
+		if node.ID == "" || len(node.ID) > 32 || seen[node.ID] {
+			node.ID = newMindMapNodeID()
+		}
+		seen[node.ID] = true
+		if node.Children == nil {
+			node.Children = []*model.MindMapNode{}
+		}
+
+		children := node.Children[:0]
+		for _, child := range node.Children {
+			if child == nil {
+				continue
+			}
+			if err := walk(child, depth+1, fmt.Sprintf("%s-%d", position, len(children))); err != nil {
+				return err
+			}
+			children = append(children, child)
+		}
+		node.Children = children
+		return nil
+	}
+	return walk(mindMap.Root, 1, "n0")
+}
+
+// findMindMapNode 查找节点及其父节点，根节点的父节点为nil
+func findMindMapNode(root *model.MindMapNode, id string) (node, parent *model.MindMapNode) {
+	var walk func(current, currentParent *model.MindMapNode) bool
+	walk = func(current, currentParent *model.MindMapNode) bool {
+		if current.ID == id {
+			node, parent = current, currentParent
+			return true
+		}
+		for _, child := range current.Children {
+			if walk(child, current) {
+				return true
+			}
+		}
+		return false
+	}
+	walk(root, nil)
+	return node, parent
+}
+
+// isMindMapDescendant 判断target是否为node本身或其子孙节点
+func isMindMapDescendant(node, target *model.MindMapNode) bool {
+	if node == target {
+		return true
+	}
+	for _, child := range node.Children {
+		if isMindMapDescendant(child, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func insertMindMapNode(parent, node *model.MindMapNode, index *int) {
+	position := len(parent.Children)
+	if index != nil && *index < position {
+		position = *index
+	}
+	parent.Children = append(parent.Children, nil)
+	copy(parent.Children[position+1:], parent.Children[position:])
+	parent.Children[position] = node
+}
+
+func removeMindMapNode(parent, node *model.MindMapNode) {
+	for i, child := range parent.Children {
+		if child == node {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			return
+		}
+	}
+}
+
+// applyMindMapOperation 执行单个节点操作，返回操作涉及的节点ID
+func applyMindMapOperation(mindMap *model.MindMap, op *model.MindMapOperation) (string, error) {
+	if op.Op == model.MindMapOperationAdd {
+		if op.Text == nil {
+			return "", errors.New("新增节点需要填写文本")
+		}
+		parent, _ := findMindMapNode(mindMap.Root, op.ParentID)
+		if parent == nil {
+			return "", fmt.Errorf("父节点不存在：%s", op.ParentID)
+		}
+		id := op.NodeID
+		if id == "" {
+			id = newMindMapNodeID()
+		} else if existing, _ := findMindMapNode(mindMap.Root, id); existing != nil {
+			return "", fmt.Errorf("节点ID已存在：%s", id)
+		}
+		node := &model.MindMapNode{ID: id, Text: *op.Text, Children: []*model.MindMapNode{}}
+		if op.Note != nil {
+			node.Note = *op.Note
+		}
+		insertMindMapNode(parent, node, op.Index)
+		// 展开父节点，使新节点可见
+		parent.Collapsed = false
+		return id, validateMindMap(mindMap)
+	}
+
+	node, parent := findMindMapNode(mindMap.Root, op.NodeID)
+	if node == nil {
+		return "", fmt.Errorf("节点不存在：%s", op.NodeID)
+	}
+
+	switch op.Op {
+	case model.MindMapOperationUpdate:
+		if op.Text == nil && op.Note == nil {
+			return "", errors.New("请填写要修改的文本或备注")
+		}
+		if op.Text != nil {
+			node.Text = *op.Text
+		}
+		if op.Note != nil {
+			node.Note = *op.Note
+		}
+	case model.MindMapOperationMove:
+		if parent == nil {
+			return "", errors.New("不能移动中心主题")
+		}
+		target, _ := findMindMapNode(mindMap.Root, op.ParentID)
+		if target == nil {
+			return "", fmt.Errorf("父节点不存在：%s", op.ParentID)
+		}
+		if isMindMapDescendant(node, target) {
+			return "", errors.New("不能将节点移动到自身或其子节点下")
+		}
+		removeMindMapNode(parent, node)
+		insertMindMapNode(target, node, op.Index)
+	case model.MindMapOperationDelete:
+		if parent == nil {
+			return "", errors.New("不能删除中心主题")
+		}
+		removeMindMapNode(parent, node)
+	case model.MindMapOperationCollapse:
+		node.Collapsed = true
+	case model.MindMapOperationExpand:
+		node.Collapsed = false
+	default:
+		return "", errors.New("不支持的节点操作")
+	}
+
+	return node.ID, validateMindMap(mindMap)
+}
+
+// mindMapText 按层级缩进输出节点文本和备注，用于语义搜索
+func mindMapText(content string) string {
+	mindMap, err := parseMindMap(content)
+	if err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	var walk func(node *model.MindMapNode, depth int)
+	walk = func(node *model.MindMapNode, depth int) {
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(node.Text)
+		sb.WriteString("\n")
+		if node.Note != "" {
+			sb.WriteString(strings.Repeat("  ", depth+1))
+			sb.WriteString(node.Note)
+			sb.WriteString("\n")
+		}
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(mindMap.Root, 0)
+	return sb.String()
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// notificationBufferSize 每个实时连接缓存的通知数，客户端处理过慢时丢弃多余通知
+const notificationBufferSize = 16
+
+// streamTicketTTL 实时通知连接票据的有效期，签发后应立即用于建立连接
+const streamTicketTTL = 30 * time.Second
+
+type NotificationService interface {
+	// Notify 按用户偏好保存通知并推送给在线连接，类型被关闭时不会发送
+	Notify(notification *model.Notification) error
+	// NotifyOnce 同一用户、类型和资源只发送一次通知
+	NotifyOnce(notification *model.Notification) error
+	List(userID uint, req *model.NotificationListRequest) ([]model.Notification, int64, error)
+	UnreadCount(userID uint) (int64, error)
+	MarkRead(id, userID uint) error
+	MarkAllRead(userID uint) error
+	GetPreferences(userID uint) ([]model.NotificationPreferenceItem, error)
+	UpdatePreferences(userID uint, req *model.UpdateNotificationPreferencesRequest) error
+	// Subscribe 订阅用户的实时通知，返回的函数用于取消订阅
+	Subscribe(userID uint) (<-chan *model.Notification, func())
+	// IssueStreamTicket 签发建立实时通知连接用的一次性票据。EventSource无法设置请求头，
+	// 使用短期票据代替在查询参数中传递登录令牌，避免长期有效的令牌出现在访问日志中
+	IssueStreamTicket(userID uint) (*model.StreamTicketResponse, error)
+	// RedeemStreamTicket 兑换票据，返回票据签发给的用户ID，每张票据只能使用一次
+	RedeemStreamTicket(ticket string) (uint, error)
+	// HandleEvent 事件订阅者，为分享和评论事件生成通知
+	HandleEvent(event *model.Event) error
+}
+
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	logger           *zap.Logger
+
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan *model.Notification]struct{}
+
+	// 票据与实时连接一样只在当前进程内有效
+	ticketMu sync.Mutex
+	tickets  map[string]streamTicket
+}
+
+type streamTicket struct {
+	userID    uint
+	expiresAt time.Time
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, logger *zap.Logger) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		logger:           logger,
+		subscribers:      make(map[uint]map[chan *model.Notification]struct{}),
+		tickets:          make(map[string]streamTicket),
+	}
+}
+
+func (s *notificationService) Notify(notification *model.Notification) error {
+	enabled, err := s.isEnabled(notification.UserID, notification.Type)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	if err := s.notificationRepo.Create(notification); err != nil {
+		s.logger.Error("Failed to create notification",
+			zap.Uint("user_id", notification.UserID),
+			zap.String("type", string(notification.Type)),
+			zap.Error(err))
+		return err
+	}
+
+	s.publish(notification)
+
+	return nil
+}
+
+func (s *notificationService) NotifyOnce(notification *model.Notification) error {
+	exists, err := s.notificationRepo.Exists(notification.UserID, notification.Type, notification.ResourceType, notification.ResourceID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return s.Notify(notification)
+}
+
+func (s *notificationService) List(userID uint, req *model.NotificationListRequest) ([]model.Notification, int64, error) {
+	return s.notificationRepo.List(userID, req)
+}
+
+func (s *notificationService) UnreadCount(userID uint) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+func (s *notificationService) MarkRead(id, userID uint) error {
+	err := s.notificationRepo.MarkRead(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("通知不存在")
+	}
+	return err
+}
+
+func (s *notificationService) MarkAllRead(userID uint) error {
+	return s.notificationRepo.MarkAllRead(userID)
+}
+
+func (s *notificationService) GetPreferences(userID uint) ([]model.NotificationPreferenceItem, error) {
+	preferences, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := make(map[model.NotificationType]bool, len(preferences))
+	for _, preference := range preferences {
+		enabled[preference.Type] = preference.Enabled
+	}
+
+	items := make([]model.NotificationPreferenceItem, 0, len(model.NotificationTypes))
+	for _, notificationType := range model.NotificationTypes {
+		value, ok := enabled[notificationType]
+		items = append(items, model.NotificationPreferenceItem{
+			Type:    notificationType,
+			Enabled: !ok || value,
+		})
+	}
+	return items, nil
+}
+
+func (s *notificationService) UpdatePreferences(userID uint, req *model.UpdateNotificationPreferencesRequest) error {
+	preferences := make([]model.NotificationPreference, 0, len(req.Preferences))
+	for _, item := range req.Preferences {
+		if !model.IsValidNotificationType(item.Type) {
+			return errors.New("无效的通知类型: " + string(item.Type))
+		}
+		preferences = append(preferences, model.NotificationPreference{
+			UserID:  userID,
+			Type:    item.Type,
+			Enabled: item.Enabled,
+		})
+	}
+
+	if err := s.notificationRepo.SavePreferences(preferences); err != nil {
+		return err
+	}
+
+	s.logger.Info("Notification preferences updated", zap.Uint("user_id", userID))
+
+	return nil
+}
+
+func (s *notificationService) Subscribe(userID uint) (<-chan *model.Notification, func()) {
+	ch := make(chan *model.Notification, notificationBufferSize)
+
+	s.mu.Lock()
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[chan *model.Notification]struct{})
+	}
+	s.subscribers[userID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers[userID], ch)
+			if len(s.subscribers[userID]) == 0 {
+				delete(s.subscribers, userID)
+			}
+			s.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+func (s *notificationService) IssueStreamTicket(userID uint) (*model.StreamTicketResponse, error) {
+	ticket, err := generateRandomString(64)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(streamTicketTTL)
+
+	s.ticketMu.Lock()
+	// 顺便清理过期未使用的票据
+	for key, t := range s.tickets {
+		if now.After(t.expiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[ticket] = streamTicket{userID: userID, expiresAt: expiresAt}
+	s.ticketMu.Unlock()
+
+	return &model.StreamTicketResponse{Ticket: ticket, ExpiresAt: expiresAt}, nil
+}
+
+func (s *notificationService) RedeemStreamTicket(ticket string) (uint, error) {
+	s.ticketMu.Lock()
+	t, ok := s.tickets[ticket]
+	delete(s.tickets, ticket)
+	s.ticketMu.Unlock()
+
+	if !ok || time.Now().After(t.expiresAt) {
+		return 0, errors.New("票据无效或已过期")
+	}
+	return t.userID, nil
+}
+
+func (s *notificationService) HandleEvent(event *model.Event) error {
+	switch event.Type {
+	case model.EventDocumentShared:
+		return s.notifyShareRecipients(event)
+	case model.EventCommentCreated, model.EventCommentUpdated:
+		return s.notifyComment(event)
+	case model.EventDocumentSubmitted, model.EventDocumentReviewed:
+		return s.notifyReview(event)
+	}
+	return nil
+}
+
+// notifyShareRecipients 通知分享对象，分享时已记录为分享对象的用户才会收到通知
+func (s *notificationService) notifyShareRecipients(event *model.Event) error {
+	share := event.Share
+	if len(share.Recipients) == 0 {
+		return nil
+	}
+
+	sharer, err := s.userRepo.GetByID(event.ActorID)
+	if err != nil {
+		return err
+	}
+	sharerName := sharer.Nickname
+	if sharerName == "" {
+		sharerName = sharer.Username
+	}
+
+	recipients, err := s.userRepo.GetByIDs(share.Recipients)
+	if err != nil {
+		return err
+	}
+
+	actorID := event.ActorID
+	for _, recipient := range recipients {
+		if recipient.ID == actorID {
+			continue
+		}
+		content := ""
+		if share.ShareExpiry != nil {
+			content = fmt.Sprintf("分享链接有效期至 %s", share.ShareExpiry.Format("2006-01-02 15:04"))
+		}
+		err := s.Notify(&model.Notification{
+			UserID:       recipient.ID,
+			Type:         model.NotificationTypeDocumentShared,
+			Title:        fmt.Sprintf("%s 与你分享了文档《%s》", sharerName, share.Title),
+			Content:      content,
+			ActorID:      &actorID,
+			ResourceType: model.ResourceTypeDocument,
+			ResourceID:   share.DocumentID,
+			Link:         share.ShareURL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyComment 通知被@的用户、讨论串作者和文档所有者，每人只通知一次。
+// 编辑评论只通知新增@的用户。
+func (s *notificationService) notifyComment(event *model.Event) error {
+	comment := event.Comment
+	notified := map[uint]bool{event.ActorID: true}
+
+	type recipient struct {
+		userID           uint
+		notificationType model.NotificationType
+		action           string
+	}
+	var recipients []recipient
+	for _, mentionedID := range comment.Mentions {
+		recipients = append(recipients, recipient{mentionedID, model.NotificationTypeCommentMention, "在评论中提到了你"})
+	}
+	if event.Type == model.EventCommentCreated {
+		if comment.ThreadAuthorID != 0 {
+			recipients = append(recipients, recipient{comment.ThreadAuthorID, model.NotificationTypeCommentReply, "回复了你参与的讨论"})
+		}
+		recipients = append(recipients, recipient{comment.DocumentOwnerID, model.NotificationTypeCommentCreated, "评论了你的文档"})
+	}
+
+	actorID := event.ActorID
+	for _, r := range recipients {
+		if notified[r.userID] {
+			continue
+		}
+		notified[r.userID] = true
+
+		err := s.Notify(&model.Notification{
+			UserID:       r.userID,
+			Type:         r.notificationType,
+			Title:        fmt.Sprintf("%s %s《%s》", comment.AuthorName, r.action, comment.DocumentTitle),
+			Content:      truncateRunes(comment.Content, 200),
+			ActorID:      &actorID,
+			ResourceType: model.ResourceTypeDocument,
+			ResourceID:   comment.DocumentID,
+			Link:         fmt.Sprintf("/documents/%d#comment-%d", comment.DocumentID, comment.ID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyReview 提交审核时通知审核人，审核结束时通知提交人
+func (s *notificationService) notifyReview(event *model.Event) error {
+	review, document := event.Review, event.Document
+
+	var recipients []uint
+	var notificationType model.NotificationType
+	var action string
+	switch {
+	case event.Type == model.EventDocumentSubmitted:
+		recipients, notificationType, action = review.Reviewers, model.NotificationTypeReviewRequested, "请你审核文档"
+	case review.Status == model.ReviewStatusApproved:
+		recipients, notificationType, action = []uint{review.SubmitterID}, model.NotificationTypeReviewCompleted, "审核通过了你的文档"
+	case review.Status == model.ReviewStatusRejected:
+		recipients, notificationType, action = []uint{review.SubmitterID}, model.NotificationTypeReviewCompleted, "驳回了你的文档"
+	default:
+		// 审核尚未结束
+		return nil
+	}
+
+	actor, err := s.userRepo.GetByID(event.ActorID)
+	if err != nil {
+		return err
+	}
+	actorName := actor.Nickname
+	if actorName == "" {
+		actorName = actor.Username
+	}
+
+	actorID := event.ActorID
+	for _, userID := range recipients {
+		if userID == actorID {
+			continue
+		}
+		err := s.Notify(&model.Notification{
+			UserID:       userID,
+			Type:         notificationType,
+			Title:        fmt.Sprintf("%s %s《%s》", actorName, action, document.Title),
+			Content:      truncateRunes(review.Comment, 200),
+			ActorID:      &actorID,
+			ResourceType: model.ResourceTypeDocument,
+			ResourceID:   document.ID,
+			Link:         fmt.Sprintf("/reviews/%d", review.ID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *notificationService) publish(notification *model.Notification) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ch := range s.subscribers[notification.UserID] {
+		select {
+		case ch <- notification:
+		default:
+			s.logger.Warn("Notification dropped for slow subscriber",
+				zap.Uint("user_id", notification.UserID),
+				zap.Uint("notification_id", notification.ID))
+		}
+	}
+}
+
+func (s *notificationService) isEnabled(userID uint, notificationType model.NotificationType) (bool, error) {
+	preferences, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, preference := range preferences {
+		if preference.Type == notificationType {
+			return preference.Enabled, nil
+		}
+	}
+	return true, nil
+}
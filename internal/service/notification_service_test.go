@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestStreamTicket(t *testing.T) {
+	s := NewNotificationService(nil, nil, zap.NewNop()).(*notificationService)
+
+	ticket, err := s.IssueStreamTicket(7)
+	if err != nil {
+		t.Fatalf("IssueStreamTicket() error = %v", err)
+	}
+	if len(ticket.Ticket) != 64 {
+		t.Errorf("ticket length = %d, want 64", len(ticket.Ticket))
+	}
+
+	userID, err := s.RedeemStreamTicket(ticket.Ticket)
+	if err != nil || userID != 7 {
+		t.Fatalf("RedeemStreamTicket() = %d, %v, want 7", userID, err)
+	}
+	if _, err := s.RedeemStreamTicket(ticket.Ticket); err == nil {
+		t.Error("ticket redeemed twice")
+	}
+
+	expired, err := s.IssueStreamTicket(7)
+	if err != nil {
+		t.Fatalf("IssueStreamTicket() error = %v", err)
+	}
+	s.tickets[expired.Ticket] = streamTicket{userID: 7, expiresAt: time.Now().Add(-time.Second)}
+	if _, err := s.RedeemStreamTicket(expired.Ticket); err == nil {
+		t.Error("expired ticket redeemed")
+	}
+
+	for _, invalid := range []string{"", "unknown"} {
+		if _, err := s.RedeemStreamTicket(invalid); err == nil {
+			t.Errorf("RedeemStreamTicket(%q) succeeded, want error", invalid)
+		}
+	}
+}
@@ -0,0 +1,726 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strings"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// 只实现演示文稿结构需要的PresentationML子集：标题、副标题和正文占位符、背景色、隐藏和演讲者备注。
+// 图片不嵌入文件，导出为带链接的文本，导入时忽略
+
+const (
+	pptxNSMain         = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	pptxNSDrawing      = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	pptxRelTypePrefix  = xlsxNSRelationships + "/"
+	pptxContentPrefix  = "application/vnd.openxmlformats-officedocument.presentationml."
+	pptxSlideHeight    = 6858000 // EMU
+	pptxWideWidth      = 12192000
+	pptxStandardWidth  = 9144000
+	pptxMargin         = 457200
+	pptxMaxPartSize    = 20 << 20
+	pptxNamespaceAttrs = `xmlns:a="` + pptxNSDrawing + `" xmlns:r="` + xlsxNSRelationships + `" xmlns:p="` + pptxNSMain + `"`
+)
+
+// pptxPart 文件中的一个部件
+type pptxPart struct {
+	name        string
+	contentType string
+	data        string
+}
+
+// writePPTX 将演示文稿写为pptx文件，所有幻灯片共用一个空白版式，内容以带位置的占位符写入
+func writePPTX(w io.Writer, title string, presentation *model.Presentation) error {
+	width := pptxWideWidth
+	if presentation.Aspect == model.SlideAspectStandard {
+		width = pptxStandardWidth
+	}
+
+	parts := []pptxPart{
+		{"ppt/theme/theme1.xml", "application/vnd.openxmlformats-officedocument.theme+xml", pptxTheme},
+		{"ppt/theme/theme2.xml", "application/vnd.openxmlformats-officedocument.theme+xml", pptxTheme},
+		{"ppt/slideMasters/slideMaster1.xml", pptxContentPrefix + "slideMaster+xml", pptxSlideMaster},
+		{"ppt/slideMasters/_rels/slideMaster1.xml.rels", "", pptxRels(
+			[2]string{"slideLayout", "../slideLayouts/slideLayout1.xml"},
+			[2]string{"theme", "../theme/theme1.xml"},
+		)},
+		{"ppt/slideLayouts/slideLayout1.xml", pptxContentPrefix + "slideLayout+xml", pptxSlideLayout},
+		{"ppt/slideLayouts/_rels/slideLayout1.xml.rels", "", pptxRels(
+			[2]string{"slideMaster", "../slideMasters/slideMaster1.xml"},
+		)},
+		{"ppt/notesMasters/notesMaster1.xml", pptxContentPrefix + "notesMaster+xml", pptxNotesMaster},
+		{"ppt/notesMasters/_rels/notesMaster1.xml.rels", "", pptxRels(
+			[2]string{"theme", "../theme/theme2.xml"},
+		)},
+	}
+
+	var slideList, presentationRels strings.Builder
+	presentationRels.WriteString(xml.Header)
+	presentationRels.WriteString(`<Relationships xmlns="` + xlsxNSPackageRels + `">`)
+	presentationRels.WriteString(`<Relationship Id="rId1" Type="` + pptxRelTypePrefix + `slideMaster" Target="slideMasters/slideMaster1.xml"/>`)
+	presentationRels.WriteString(`<Relationship Id="rId2" Type="` + pptxRelTypePrefix + `notesMaster" Target="notesMasters/notesMaster1.xml"/>`)
+	presentationRels.WriteString(`<Relationship Id="rId3" Type="` + pptxRelTypePrefix + `theme" Target="theme/theme1.xml"/>`)
+
+	for i, slide := range presentation.Slides {
+		n := i + 1
+		rels := [][2]string{{"slideLayout", "../slideLayouts/slideLayout1.xml"}}
+		if slide.Notes != "" {
+			rels = append(rels, [2]string{"notesSlide", fmt.Sprintf("../notesSlides/notesSlide%d.xml", n)})
+		}
+		// 图片链接使用rId10
+		var link string
+		if slide.Layout == model.SlideLayoutImage && slide.Image != nil && isAbsoluteHTTPURL(slide.Image.URL) {
+			link = slide.Image.URL
+		}
+
+		slideRels := pptxRels(rels...)
+		if link != "" {
+			slideRels = strings.TrimSuffix(slideRels, `</Relationships>`) +
+				`<Relationship Id="rId10" Type="` + pptxRelTypePrefix + `hyperlink" Target="` + xmlEscape(link) + `" TargetMode="External"/></Relationships>`
+		}
+
+		parts = append(parts,
+			pptxPart{fmt.Sprintf("ppt/slides/slide%d.xml", n), pptxContentPrefix + "slide+xml", buildSlideXML(slide, width, link != "")},
+			pptxPart{fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", n), "", slideRels},
+		)
+		if slide.Notes != "" {
+			parts = append(parts,
+				pptxPart{fmt.Sprintf("ppt/notesSlides/notesSlide%d.xml", n), pptxContentPrefix + "notesSlide+xml", buildNotesSlideXML(slide.Notes)},
+				pptxPart{fmt.Sprintf("ppt/notesSlides/_rels/notesSlide%d.xml.rels", n), "", pptxRels(
+					[2]string{"notesMaster", "../notesMasters/notesMaster1.xml"},
+					[2]string{"slide", fmt.Sprintf("../slides/slide%d.xml", n)},
+				)},
+			)
+		}
+
+		fmt.Fprintf(&slideList, `<p:sldId id="%d" r:id="rId%d"/>`, 255+n, 100+n)
+		fmt.Fprintf(&presentationRels, `<Relationship Id="rId%d" Type="`+pptxRelTypePrefix+`slide" Target="slides/slide%d.xml"/>`, 100+n, n)
+	}
+	presentationRels.WriteString(`</Relationships>`)
+
+	presentationXML := xml.Header + `<p:presentation ` + pptxNamespaceAttrs + ` saveSubsetFonts="1">` +
+		`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>` +
+		`<p:notesMasterIdLst><p:notesMasterId r:id="rId2"/></p:notesMasterIdLst>` +
+		`<p:sldIdLst>` + slideList.String() + `</p:sldIdLst>` +
+		fmt.Sprintf(`<p:sldSz cx="%d" cy="%d"/><p:notesSz cx="6858000" cy="9144000"/>`, width, pptxSlideHeight) +
+		`</p:presentation>`
+
+	coreXML := xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:title>` + xmlEscape(title) + `</dc:title></cp:coreProperties>`
+
+	parts = append(parts,
+		pptxPart{"ppt/presentation.xml", pptxContentPrefix + "presentation.main+xml", presentationXML},
+		pptxPart{"ppt/_rels/presentation.xml.rels", "", presentationRels.String()},
+		pptxPart{"docProps/core.xml", "application/vnd.openxmlformats-package.core-properties+xml", coreXML},
+	)
+
+	var contentTypes strings.Builder
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	for _, part := range parts {
+		if part.contentType != "" {
+			fmt.Fprintf(&contentTypes, `<Override PartName="/%s" ContentType="%s"/>`, part.name, part.contentType)
+		}
+	}
+	contentTypes.WriteString(`</Types>`)
+
+	rootRels := xml.Header + `<Relationships xmlns="` + xlsxNSPackageRels + `">` +
+		`<Relationship Id="rId1" Type="` + pptxRelTypePrefix + `officeDocument" Target="ppt/presentation.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+		`</Relationships>`
+
+	parts = append([]pptxPart{
+		{"[Content_Types].xml", "", contentTypes.String()},
+		{"_rels/.rels", "", rootRels},
+	}, parts...)
+
+	zw := zip.NewWriter(w)
+	for _, part := range parts {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, part.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// pptxRels 生成关系文件，关系ID按顺序为rId1、rId2...
+func pptxRels(rels ...[2]string) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="` + xlsxNSPackageRels + `">`)
+	for i, rel := range rels {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="%s%s" Target="%s"/>`, i+1, pptxRelTypePrefix, rel[0], rel[1])
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func isAbsoluteHTTPURL(u string) bool {
+	u = strings.ToLower(strings.TrimSpace(u))
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
+
+// pptxShape 占位符形状的位置和内容
+type pptxShape struct {
+	placeholder  string // title, ctrTitle, subTitle, body
+	index        int    // 正文占位符的idx
+	x, y, cx, cy int
+	paragraphs   []model.SlideParagraph
+	centered     bool
+	bullets      bool // 正文显示项目符号
+	link         bool // 文字带rId10的超链接
+}
+
+func buildSlideXML(slide *model.Slide, width int, link bool) string {
+	height := pptxSlideHeight
+	contentWidth := width - 2*pptxMargin
+	titleShape := pptxShape{placeholder: "title", x: pptxMargin, y: 274638, cx: contentWidth, cy: 1143000}
+	bodyY := 1600200
+	bodyHeight := height - bodyY - pptxMargin
+	lines := func(text string) []model.SlideParagraph {
+		if text == "" {
+			return nil
+		}
+		return []model.SlideParagraph{{Text: text}}
+	}
+
+	var shapes []pptxShape
+	switch slide.Layout {
+	case model.SlideLayoutTitle:
+		shapes = append(shapes,
+			pptxShape{placeholder: "ctrTitle", x: width / 10, y: height * 3 / 10, cx: width * 8 / 10, cy: height / 5, paragraphs: lines(slide.Title), centered: true},
+			pptxShape{placeholder: "subTitle", index: 1, x: width * 3 / 20, y: height * 52 / 100, cx: width * 7 / 10, cy: height * 15 / 100, paragraphs: lines(slide.Subtitle), centered: true},
+		)
+	case model.SlideLayoutSection:
+		shapes = append(shapes,
+			pptxShape{placeholder: "title", x: pptxMargin, y: height * 35 / 100, cx: contentWidth, cy: height / 5, paragraphs: lines(slide.Title)},
+			pptxShape{placeholder: "body", index: 1, x: pptxMargin, y: height * 56 / 100, cx: contentWidth, cy: height / 8, paragraphs: lines(slide.Subtitle)},
+		)
+	case model.SlideLayoutContent:
+		titleShape.paragraphs = lines(slide.Title)
+		shapes = append(shapes, titleShape,
+			pptxShape{placeholder: "body", index: 1, x: pptxMargin, y: bodyY, cx: contentWidth, cy: bodyHeight, paragraphs: slide.Body, bullets: true})
+	case model.SlideLayoutTwoColumn:
+		titleShape.paragraphs = lines(slide.Title)
+		columnWidth := (contentWidth - pptxMargin/2) / 2
+		shapes = append(shapes, titleShape,
+			pptxShape{placeholder: "body", index: 1, x: pptxMargin, y: bodyY, cx: columnWidth, cy: bodyHeight, paragraphs: slide.Body, bullets: true},
+			pptxShape{placeholder: "body", index: 2, x: width - pptxMargin - columnWidth, y: bodyY, cx: columnWidth, cy: bodyHeight, paragraphs: slide.Body2, bullets: true})
+	case model.SlideLayoutImage:
+		titleShape.paragraphs = lines(slide.Title)
+		shapes = append(shapes, titleShape)
+		if slide.Image != nil {
+			text := slide.Image.Alt
+			if text == "" {
+				text = slide.Image.URL
+			}
+			shapes = append(shapes, pptxShape{x: pptxMargin, y: bodyY, cx: contentWidth, cy: bodyHeight, paragraphs: lines(text), centered: true, link: link})
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<p:sld ` + pptxNamespaceAttrs)
+	if slide.Hidden {
+		b.WriteString(` show="0"`)
+	}
+	b.WriteString(`><p:cSld>`)
+	if slide.Background != "" {
+		fmt.Fprintf(&b, `<p:bg><p:bgPr><a:solidFill><a:srgbClr val="%s"/></a:solidFill><a:effectLst/></p:bgPr></p:bg>`, strings.ToUpper(slide.Background[1:]))
+	}
+	b.WriteString(pptxGroupShapeStart)
+	for i, shape := range shapes {
+		if len(shape.paragraphs) == 0 {
+			continue
+		}
+		writePPTXShape(&b, i+2, &shape)
+	}
+	b.WriteString(`</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sld>`)
+	return b.String()
+}
+
+func writePPTXShape(b *strings.Builder, id int, shape *pptxShape) {
+	fmt.Fprintf(b, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="Shape %d"/>`, id, id-1)
+	if shape.placeholder != "" {
+		b.WriteString(`<p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr><p:ph type="` + shape.placeholder + `"`)
+		if shape.index > 0 {
+			fmt.Fprintf(b, ` idx="%d"`, shape.index)
+		}
+		b.WriteString(`/></p:nvPr>`)
+	} else {
+		b.WriteString(`<p:cNvSpPr txBox="1"/><p:nvPr/>`)
+	}
+	fmt.Fprintf(b, `</p:nvSpPr><p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm>`, shape.x, shape.y, shape.cx, shape.cy)
+	if shape.placeholder == "" {
+		b.WriteString(`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom>`)
+	}
+	b.WriteString(`</p:spPr><p:txBody><a:bodyPr wrap="square"><a:normAutofit/></a:bodyPr><a:lstStyle/>`)
+
+	// 副标题和文本框不显示项目符号，标题的样式中本身没有项目符号
+	noBullet := !shape.bullets && shape.placeholder != "title" && shape.placeholder != "ctrTitle"
+	for _, paragraph := range shape.paragraphs {
+		b.WriteString(`<a:p>`)
+		var attrs string
+		if paragraph.Level > 0 {
+			attrs += fmt.Sprintf(` lvl="%d"`, paragraph.Level)
+		}
+		if noBullet {
+			attrs += ` marL="0" indent="0"`
+		}
+		if shape.centered {
+			attrs += ` algn="ctr"`
+		}
+		if attrs != "" {
+			b.WriteString(`<a:pPr` + attrs + `>`)
+			if noBullet {
+				b.WriteString(`<a:buNone/>`)
+			}
+			b.WriteString(`</a:pPr>`)
+		}
+		for i, line := range strings.Split(paragraph.Text, "\n") {
+			if i > 0 {
+				b.WriteString(`<a:br><a:rPr lang="zh-CN"/></a:br>`)
+			}
+			if line == "" {
+				continue
+			}
+			b.WriteString(`<a:r><a:rPr lang="zh-CN" dirty="0">`)
+			if shape.link {
+				b.WriteString(`<a:hlinkClick r:id="rId10"/>`)
+			}
+			b.WriteString(`</a:rPr><a:t>` + xmlEscape(line) + `</a:t></a:r>`)
+		}
+		b.WriteString(`<a:endParaRPr lang="zh-CN"/></a:p>`)
+	}
+	b.WriteString(`</p:txBody></p:sp>`)
+}
+
+func buildNotesSlideXML(notes string) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<p:notes ` + pptxNamespaceAttrs + `><p:cSld>`)
+	b.WriteString(pptxGroupShapeStart)
+	b.WriteString(`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Notes Placeholder 1"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>` +
+		`<p:nvPr><p:ph type="body" idx="1"/></p:nvPr></p:nvSpPr><p:spPr/><p:txBody><a:bodyPr/><a:lstStyle/>`)
+	for _, line := range strings.Split(notes, "\n") {
+		b.WriteString(`<a:p>`)
+		if line != "" {
+			b.WriteString(`<a:r><a:rPr lang="zh-CN" dirty="0"/><a:t>` + xmlEscape(line) + `</a:t></a:r>`)
+		}
+		b.WriteString(`<a:endParaRPr lang="zh-CN"/></a:p>`)
+	}
+	b.WriteString(`</p:txBody></p:sp></p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:notes>`)
+	return b.String()
+}
+
+const pptxGroupShapeStart = `<p:spTree><p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+	`<p:grpSpPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/><a:chOff x="0" y="0"/><a:chExt cx="0" cy="0"/></a:xfrm></p:grpSpPr>`
+
+const pptxClrMap = `<p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" ` +
+	`accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>`
+
+var pptxSlideMaster = xml.Header + `<p:sldMaster ` + pptxNamespaceAttrs + `><p:cSld>` +
+	`<p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg>` +
+	pptxGroupShapeStart + `</p:spTree></p:cSld>` + pptxClrMap +
+	`<p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/></p:sldLayoutIdLst>` +
+	`<p:txStyles><p:titleStyle><a:lvl1pPr algn="l"><a:defRPr sz="4000" kern="1200"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill>` +
+	`<a:latin typeface="+mj-lt"/><a:ea typeface="+mj-ea"/><a:cs typeface="+mj-cs"/></a:defRPr></a:lvl1pPr></p:titleStyle>` +
+	`<p:bodyStyle>` + pptxBodyLevels() + `</p:bodyStyle>` +
+	`<p:otherStyle><a:defPPr><a:defRPr lang="zh-CN"/></a:defPPr></p:otherStyle></p:txStyles></p:sldMaster>`
+
+// pptxBodyLevels 正文各级别的缩进、项目符号和字号
+func pptxBodyLevels() string {
+	sizes := []int{2800, 2400, 2000, 1800, 1800}
+	var b strings.Builder
+	for i, size := range sizes {
+		fmt.Fprintf(&b, `<a:lvl%dpPr marL="%d" indent="-228600"><a:spcBef><a:spcPts val="1000"/></a:spcBef>`+
+			`<a:buFont typeface="Arial"/><a:buChar char="•"/><a:defRPr sz="%d" kern="1200"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill>`+
+			`<a:latin typeface="+mn-lt"/><a:ea typeface="+mn-ea"/><a:cs typeface="+mn-cs"/></a:defRPr></a:lvl%dpPr>`, i+1, 228600+457200*i, size, i+1)
+	}
+	return b.String()
+}
+
+var pptxSlideLayout = xml.Header + `<p:sldLayout ` + pptxNamespaceAttrs + ` type="blank" preserve="1"><p:cSld name="Blank">` +
+	pptxGroupShapeStart + `</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sldLayout>`
+
+var pptxNotesMaster = xml.Header + `<p:notesMaster ` + pptxNamespaceAttrs + `><p:cSld>` +
+	pptxGroupShapeStart + `</p:spTree></p:cSld>` + pptxClrMap + `</p:notesMaster>`
+
+var pptxTheme = xml.Header + `<a:theme xmlns:a="` + pptxNSDrawing + `" name="Office"><a:themeElements>` +
+	`<a:clrScheme name="Office"><a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1><a:lt1><a:sysClr val="window" lastClr="FFFFFF"/></a:lt1>` +
+	`<a:dk2><a:srgbClr val="44546A"/></a:dk2><a:lt2><a:srgbClr val="E7E6E6"/></a:lt2>` +
+	`<a:accent1><a:srgbClr val="4472C4"/></a:accent1><a:accent2><a:srgbClr val="ED7D31"/></a:accent2><a:accent3><a:srgbClr val="A5A5A5"/></a:accent3>` +
+	`<a:accent4><a:srgbClr val="FFC000"/></a:accent4><a:accent5><a:srgbClr val="5B9BD5"/></a:accent5><a:accent6><a:srgbClr val="70AD47"/></a:accent6>` +
+	`<a:hlink><a:srgbClr val="0563C1"/></a:hlink><a:folHlink><a:srgbClr val="954F72"/></a:folHlink></a:clrScheme>` +
+	`<a:fontScheme name="Office"><a:majorFont><a:latin typeface="Calibri Light"/><a:ea typeface="等线 Light"/><a:cs typeface=""/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Calibri"/><a:ea typeface="等线"/><a:cs typeface=""/></a:minorFont></a:fontScheme>` +
+	`<a:fmtScheme name="Office"><a:fillStyleLst>` + strings.Repeat(`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>`, 3) + `</a:fillStyleLst>` +
+	`<a:lnStyleLst>` + strings.Repeat(`<a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>`, 3) + `</a:lnStyleLst>` +
+	`<a:effectStyleLst>` + strings.Repeat(`<a:effectStyle><a:effectLst/></a:effectStyle>`, 3) + `</a:effectStyleLst>` +
+	`<a:bgFillStyleLst>` + strings.Repeat(`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>`, 3) + `</a:bgFillStyleLst>` +
+	`</a:fmtScheme></a:themeElements></a:theme>`
+
+// 导入
+
+type pptxPresentationXML struct {
+	Slides []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sldIdLst>sldId"`
+	Size struct {
+		CX int64 `xml:"cx,attr"`
+		CY int64 `xml:"cy,attr"`
+	} `xml:"sldSz"`
+}
+
+type pptxRelationshipsXML struct {
+	Relationships []struct {
+		ID         string `xml:"Id,attr"`
+		Type       string `xml:"Type,attr"`
+		Target     string `xml:"Target,attr"`
+		TargetMode string `xml:"TargetMode,attr"`
+	} `xml:"Relationship"`
+}
+
+// pptxReadShape 导入时读取的形状文本
+type pptxReadShape struct {
+	placeholder string
+	paragraphs  []model.SlideParagraph
+}
+
+// readPPTX 解析pptx文件，按占位符类型识别标题、副标题和正文，其他文本框作为正文，同时读取演讲者备注
+func readPPTX(r io.ReaderAt, size int64) (*model.Presentation, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("无法读取文件，请确认是有效的pptx文件")
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var presentationXML pptxPresentationXML
+	if err := decodePPTXPart(files, "ppt/presentation.xml", &presentationXML); err != nil {
+		return nil, err
+	}
+	if len(presentationXML.Slides) == 0 {
+		return nil, errors.New("文件中没有幻灯片")
+	}
+	if len(presentationXML.Slides) > slideMaxSlides {
+		return nil, fmt.Errorf("幻灯片不能超过%d张", slideMaxSlides)
+	}
+
+	presentation := &model.Presentation{Aspect: model.SlideAspectWide}
+	if cx, cy := presentationXML.Size.CX, presentationXML.Size.CY; cy > 0 && math.Abs(float64(cx)/float64(cy)-4.0/3) < 0.05 {
+		presentation.Aspect = model.SlideAspectStandard
+	}
+
+	targets, err := readPPTXRels(files, "ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range presentationXML.Slides {
+		slidePath, ok := targets[ref.RID]
+		if !ok {
+			return nil, fmt.Errorf("找不到幻灯片%s", ref.RID)
+		}
+		slide, err := readPPTXSlide(files, slidePath)
+		if err != nil {
+			return nil, err
+		}
+		presentation.Slides = append(presentation.Slides, slide)
+	}
+
+	if err := validatePresentation(presentation); err != nil {
+		return nil, err
+	}
+	return presentation, nil
+}
+
+func readPPTXSlide(files map[string]*zip.File, name string) (*model.Slide, error) {
+	data, err := readPPTXPart(files, name)
+	if err != nil {
+		return nil, err
+	}
+	shapes, hidden, background, err := parsePPTXShapes(data)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析%s: %w", name, err)
+	}
+
+	slide := &model.Slide{Hidden: hidden, Background: background}
+	var bodies [][]model.SlideParagraph
+	centerTitle := false
+	for _, shape := range shapes {
+		switch shape.placeholder {
+		case "title", "ctrTitle":
+			if slide.Title == "" {
+				slide.Title = truncateRunes(joinSlideParagraphs(shape.paragraphs, " "), slideMaxTextLength)
+				centerTitle = shape.placeholder == "ctrTitle"
+			}
+		case "subTitle":
+			slide.Subtitle = truncateRunes(joinSlideParagraphs(shape.paragraphs, "\n"), slideMaxTextLength)
+		case "dt", "ftr", "sldNum", "hdr":
+			// 日期、页脚和页码不导入
+		default:
+			bodies = append(bodies, shape.paragraphs)
+		}
+	}
+
+	switch {
+	case centerTitle || slide.Subtitle != "" && len(bodies) == 0:
+		slide.Layout = model.SlideLayoutTitle
+		if slide.Subtitle == "" && len(bodies) > 0 {
+			slide.Subtitle = truncateRunes(joinSlideParagraphs(bodies[0], "\n"), slideMaxTextLength)
+		}
+	case len(bodies) >= 2:
+		slide.Layout = model.SlideLayoutTwoColumn
+		slide.Body = limitSlideParagraphs(bodies[0])
+		var rest []model.SlideParagraph
+		for _, body := range bodies[1:] {
+			rest = append(rest, body...)
+		}
+		slide.Body2 = limitSlideParagraphs(rest)
+	case len(bodies) == 1 || slide.Title != "":
+		slide.Layout = model.SlideLayoutContent
+		if len(bodies) == 1 {
+			slide.Body = limitSlideParagraphs(bodies[0])
+		}
+	default:
+		slide.Layout = model.SlideLayoutBlank
+	}
+
+	// 演讲者备注
+	rels, err := readPPTXRelsWithType(files, name)
+	if err != nil {
+		return nil, err
+	}
+	if notesPath, ok := rels["notesSlide"]; ok {
+		data, err := readPPTXPart(files, notesPath)
+		if err != nil {
+			return nil, err
+		}
+		notesShapes, _, _, err := parsePPTXShapes(data)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析%s: %w", notesPath, err)
+		}
+		for _, shape := range notesShapes {
+			if shape.placeholder == "body" {
+				slide.Notes = truncateRunes(joinSlideParagraphs(shape.paragraphs, "\n"), slideMaxNotesLength)
+				break
+			}
+		}
+	}
+	return slide, nil
+}
+
+// parsePPTXShapes 读取幻灯片中各形状的占位符类型和段落，包括组合中的形状
+func parsePPTXShapes(data []byte) ([]pptxReadShape, bool, string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var shapes []pptxReadShape
+	var current *pptxReadShape
+	var paragraph *model.SlideParagraph
+	var text strings.Builder
+	hidden := false
+	background := ""
+	inBackground, inText := false, false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == pptxNSMain && (t.Name.Local == "sld" || t.Name.Local == "notes"):
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "show" && (attr.Value == "0" || attr.Value == "false") {
+						hidden = true
+					}
+				}
+			case t.Name.Space == pptxNSMain && t.Name.Local == "bg":
+				inBackground = true
+			case inBackground && t.Name.Local == "srgbClr":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "val" && len(attr.Value) == 6 {
+						background = "#" + strings.ToUpper(attr.Value)
+					}
+				}
+			case t.Name.Space == pptxNSMain && t.Name.Local == "sp":
+				current = &pptxReadShape{}
+			case current != nil && t.Name.Space == pptxNSMain && t.Name.Local == "ph":
+				current.placeholder = "body"
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "type" {
+						current.placeholder = attr.Value
+					}
+				}
+			case current != nil && t.Name.Space == pptxNSDrawing && t.Name.Local == "p":
+				paragraph = &model.SlideParagraph{}
+				text.Reset()
+			case paragraph != nil && t.Name.Space == pptxNSDrawing && t.Name.Local == "pPr":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "lvl" {
+						fmt.Sscanf(attr.Value, "%d", &paragraph.Level)
+					}
+				}
+			case paragraph != nil && t.Name.Space == pptxNSDrawing && t.Name.Local == "br":
+				text.WriteString("\n")
+			case paragraph != nil && t.Name.Space == pptxNSDrawing && t.Name.Local == "t":
+				inText = true
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == pptxNSMain && t.Name.Local == "bg":
+				inBackground = false
+			case t.Name.Space == pptxNSDrawing && t.Name.Local == "t":
+				inText = false
+			case paragraph != nil && t.Name.Space == pptxNSDrawing && t.Name.Local == "p":
+				paragraph.Text = truncateRunes(text.String(), slideMaxParagraph)
+				if paragraph.Level > slideMaxLevel {
+					paragraph.Level = slideMaxLevel
+				}
+				if paragraph.Level < 0 {
+					paragraph.Level = 0
+				}
+				current.paragraphs = append(current.paragraphs, *paragraph)
+				paragraph = nil
+			case current != nil && t.Name.Space == pptxNSMain && t.Name.Local == "sp":
+				// 去掉首尾的空段落
+				paragraphs := current.paragraphs
+				for len(paragraphs) > 0 && strings.TrimSpace(paragraphs[0].Text) == "" {
+					paragraphs = paragraphs[1:]
+				}
+				for len(paragraphs) > 0 && strings.TrimSpace(paragraphs[len(paragraphs)-1].Text) == "" {
+					paragraphs = paragraphs[:len(paragraphs)-1]
+				}
+				if len(paragraphs) > 0 {
+					current.paragraphs = paragraphs
+					shapes = append(shapes, *current)
+				}
+				current = nil
+			}
+		}
+	}
+	return shapes, hidden, background, nil
+}
+
+func joinSlideParagraphs(paragraphs []model.SlideParagraph, sep string) string {
+	texts := make([]string, len(paragraphs))
+	for i, paragraph := range paragraphs {
+		texts[i] = paragraph.Text
+	}
+	return strings.Join(texts, sep)
+}
+
+func limitSlideParagraphs(paragraphs []model.SlideParagraph) []model.SlideParagraph {
+	if len(paragraphs) > slideMaxParagraphs {
+		return paragraphs[:slideMaxParagraphs]
+	}
+	return paragraphs
+}
+
+// readPPTXRels 读取部件的关系文件，返回关系ID到部件路径的映射
+func readPPTXRels(files map[string]*zip.File, name string) (map[string]string, error) {
+	rels, err := decodePPTXRels(files, name)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		targets[rel.ID] = resolvePPTXTarget(name, rel.Target)
+	}
+	return targets, nil
+}
+
+// readPPTXRelsWithType 读取部件的关系文件，返回关系类型（不含前缀）到部件路径的映射
+func readPPTXRelsWithType(files map[string]*zip.File, name string) (map[string]string, error) {
+	rels, err := decodePPTXRels(files, name)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if rel.TargetMode == "External" {
+			continue
+		}
+		targets[path.Base(rel.Type)] = resolvePPTXTarget(name, rel.Target)
+	}
+	return targets, nil
+}
+
+func decodePPTXRels(files map[string]*zip.File, name string) (*pptxRelationshipsXML, error) {
+	relsName := path.Join(path.Dir(name), "_rels", path.Base(name)+".rels")
+	var rels pptxRelationshipsXML
+	if _, ok := files[relsName]; !ok {
+		return &rels, nil
+	}
+	if err := decodePPTXPart(files, relsName, &rels); err != nil {
+		return nil, err
+	}
+	return &rels, nil
+}
+
+func resolvePPTXTarget(source, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(path.Dir(source), target)
+}
+
+func decodePPTXPart(files map[string]*zip.File, name string, v interface{}) error {
+	data, err := readPPTXPart(files, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("无法解析%s: %w", name, err)
+	}
+	return nil
+}
+
+// readPPTXPart 读取部件内容，限制解压后的大小，防止压缩炸弹
+func readPPTXPart(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("文件缺少%s，请确认是有效的pptx文件", name)
+	}
+	if f.UncompressedSize64 > pptxMaxPartSize {
+		return nil, errors.New("文件内容过大")
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, pptxMaxPartSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > pptxMaxPartSize {
+		return nil, errors.New("文件内容过大")
+	}
+	return data, nil
+}
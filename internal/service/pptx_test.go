@@ -0,0 +1,241 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// testPPTXShape 返回一个占位符形状，placeholder为空时是普通文本框
+func testPPTXShape(placeholder string, paragraphs ...string) string {
+	var b strings.Builder
+	b.WriteString(`<p:sp><p:nvSpPr><p:nvPr>`)
+	if placeholder != "" {
+		b.WriteString(`<p:ph type="` + placeholder + `"/>`)
+	}
+	b.WriteString(`</p:nvPr></p:nvSpPr><p:txBody>`)
+	for _, p := range paragraphs {
+		b.WriteString(`<a:p>` + p + `</a:p>`)
+	}
+	b.WriteString(`</p:txBody></p:sp>`)
+	return b.String()
+}
+
+// testPPTXSlide 返回包含指定形状的幻灯片
+func testPPTXSlide(shapes string) string {
+	return `<p:sld ` + pptxNamespaceAttrs + `><p:cSld><p:spTree>` + shapes + `</p:spTree></p:cSld></p:sld>`
+}
+
+// testPPTXParts 返回包含指定幻灯片的最小pptx文件内容
+func testPPTXParts(slides ...string) map[string]string {
+	var ids, rels strings.Builder
+	parts := make(map[string]string, len(slides)+2)
+	for i, slide := range slides {
+		fmt.Fprintf(&ids, `<p:sldId r:id="rId%d"/>`, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Target="slides/slide%d.xml"/>`, i+1, i+1)
+		parts[fmt.Sprintf("ppt/slides/slide%d.xml", i+1)] = slide
+	}
+	parts["ppt/presentation.xml"] = `<p:presentation ` + pptxNamespaceAttrs + `><p:sldIdLst>` + ids.String() +
+		`</p:sldIdLst><p:sldSz cx="12192000" cy="6858000"/></p:presentation>`
+	parts["ppt/_rels/presentation.xml.rels"] = `<Relationships>` + rels.String() + `</Relationships>`
+	return parts
+}
+
+func TestReadPPTX(t *testing.T) {
+	tests := []struct {
+		name  string
+		slide string
+		want  model.Slide
+	}{
+		{
+			name:  "标题和正文",
+			slide: testPPTXSlide(testPPTXShape("title", `<a:r><a:t>标题</a:t></a:r>`) + testPPTXShape("body", `<a:r><a:t>第一段</a:t></a:r>`, `<a:pPr lvl="1"/><a:r><a:t>第二段</a:t></a:r>`)),
+			want:  model.Slide{Layout: model.SlideLayoutContent, Title: "标题", Body: []model.SlideParagraph{{Text: "第一段"}, {Text: "第二段", Level: 1}}},
+		},
+		{
+			name:  "居中标题作为标题页",
+			slide: testPPTXSlide(testPPTXShape("ctrTitle", `<a:r><a:t>封面</a:t></a:r>`) + testPPTXShape("subTitle", `<a:r><a:t>副标题</a:t></a:r>`)),
+			want:  model.Slide{Layout: model.SlideLayoutTitle, Title: "封面", Subtitle: "副标题"},
+		},
+		{
+			name:  "两个正文形状作为两栏",
+			slide: testPPTXSlide(testPPTXShape("", `<a:r><a:t>左</a:t></a:r>`) + testPPTXShape("", `<a:r><a:t>右</a:t></a:r>`)),
+			want:  model.Slide{Layout: model.SlideLayoutTwoColumn, Body: []model.SlideParagraph{{Text: "左"}}, Body2: []model.SlideParagraph{{Text: "右"}}},
+		},
+		{
+			name:  "忽略页脚和页码",
+			slide: testPPTXSlide(testPPTXShape("title", `<a:r><a:t>标题</a:t></a:r>`) + testPPTXShape("ftr", `<a:r><a:t>页脚</a:t></a:r>`) + testPPTXShape("sldNum", `<a:r><a:t>1</a:t></a:r>`)),
+			want:  model.Slide{Layout: model.SlideLayoutContent, Title: "标题"},
+		},
+		{
+			name:  "去掉首尾的空段落",
+			slide: testPPTXSlide(testPPTXShape("body", ``, `<a:r><a:t>内容</a:t></a:r>`, `<a:r><a:t> </a:t></a:r>`)),
+			want:  model.Slide{Layout: model.SlideLayoutContent, Body: []model.SlideParagraph{{Text: "内容"}}},
+		},
+		{
+			name:  "段落层级超出范围",
+			slide: testPPTXSlide(testPPTXShape("body", `<a:pPr lvl="9"/><a:r><a:t>深</a:t></a:r>`, `<a:pPr lvl="-1"/><a:r><a:t>浅</a:t></a:r>`)),
+			want:  model.Slide{Layout: model.SlideLayoutContent, Body: []model.SlideParagraph{{Text: "深", Level: slideMaxLevel}, {Text: "浅"}}},
+		},
+		{
+			name:  "隐藏的幻灯片和背景色",
+			slide: `<p:sld ` + pptxNamespaceAttrs + ` show="0"><p:cSld><p:bg><p:bgPr><a:solidFill><a:srgbClr val="ff0000"/></a:solidFill></p:bgPr></p:bg><p:spTree/></p:cSld></p:sld>`,
+			want:  model.Slide{Layout: model.SlideLayoutBlank, Hidden: true, Background: "#FF0000"},
+		},
+		{
+			name:  "组合中的形状",
+			slide: testPPTXSlide(`<p:grpSp>` + testPPTXShape("", `<a:r><a:t>组合</a:t></a:r>`) + `</p:grpSp>`),
+			want:  model.Slide{Layout: model.SlideLayoutContent, Body: []model.SlideParagraph{{Text: "组合"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testZip(t, testPPTXParts(tt.slide))
+			presentation, err := readPPTX(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("readPPTX() error = %v", err)
+			}
+			if len(presentation.Slides) != 1 {
+				t.Fatalf("got %d slides, want 1", len(presentation.Slides))
+			}
+			got := *presentation.Slides[0]
+			got.ID = ""
+			if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", tt.want) {
+				t.Errorf("slide = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPPTXMalformed(t *testing.T) {
+	slide := testPPTXSlide(testPPTXShape("title", `<a:r><a:t>标题</a:t></a:r>`))
+	withPart := func(name, content string) map[string]string {
+		parts := testPPTXParts(slide)
+		if content == "" {
+			delete(parts, name)
+		} else {
+			parts[name] = content
+		}
+		return parts
+	}
+	tooManySlides := make([]string, slideMaxSlides+1)
+	for i := range tooManySlides {
+		tooManySlides[i] = slide
+	}
+	withNotes := testPPTXParts(slide)
+	withNotes["ppt/slides/_rels/slide1.xml.rels"] = `<Relationships><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide" Target="../notesSlides/notesSlide1.xml"/></Relationships>`
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"不是zip文件", []byte("not a zip file"), "无法读取文件"},
+		{"空文件", nil, "无法读取文件"},
+		{"缺少presentation.xml", testZip(t, withPart("ppt/presentation.xml", "")), "文件缺少ppt/presentation.xml"},
+		{"presentation.xml不是xml", testZip(t, withPart("ppt/presentation.xml", "<p:presentation")), "无法解析ppt/presentation.xml"},
+		{"没有幻灯片", testZip(t, testPPTXParts()), "文件中没有幻灯片"},
+		{"幻灯片过多", testZip(t, testPPTXParts(tooManySlides...)), fmt.Sprintf("幻灯片不能超过%d张", slideMaxSlides)},
+		{"缺少关系文件", testZip(t, withPart("ppt/_rels/presentation.xml.rels", "")), "找不到幻灯片rId1"},
+		{"关系ID不存在", testZip(t, withPart("ppt/_rels/presentation.xml.rels", "<Relationships/>")), "找不到幻灯片rId1"},
+		{"缺少幻灯片文件", testZip(t, withPart("ppt/slides/slide1.xml", "")), "文件缺少ppt/slides/slide1.xml"},
+		{"幻灯片不是xml", testZip(t, withPart("ppt/slides/slide1.xml", "<p:sld><p:cSld>")), "无法解析ppt/slides/slide1.xml"},
+		{"缺少备注文件", testZip(t, withNotes), "文件缺少ppt/notesSlides/notesSlide1.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readPPTX(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readPPTX() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadPPTXTruncated(t *testing.T) {
+	data := testZip(t, testPPTXParts(testPPTXSlide(testPPTXShape("title", `<a:r><a:t>标题</a:t></a:r>`))))
+	for _, n := range []int{len(data) / 4, len(data) / 2, len(data) - 1} {
+		if _, err := readPPTX(bytes.NewReader(data[:n]), int64(n)); err == nil {
+			t.Errorf("readPPTX() of %d/%d bytes succeeded, want error", n, len(data))
+		}
+	}
+}
+
+func TestReadPPTXOversizedPart(t *testing.T) {
+	slide := testPPTXSlide(testPPTXShape("title", `<a:r><a:t>标题</a:t></a:r>`))
+
+	t.Run("声明的解压后大小过大", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range testPPTXParts(slide) {
+			header := &zip.FileHeader{Name: name, Method: zip.Store}
+			header.CompressedSize64 = uint64(len(content))
+			header.UncompressedSize64 = uint64(len(content))
+			if name == "ppt/slides/slide1.xml" {
+				header.UncompressedSize64 = pptxMaxPartSize + 1
+			}
+			w, err := zw.CreateRaw(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		data := buf.Bytes()
+		_, err := readPPTX(bytes.NewReader(data), int64(len(data)))
+		if err == nil || !strings.Contains(err.Error(), "文件内容过大") {
+			t.Errorf("readPPTX() error = %v, want 文件内容过大", err)
+		}
+	})
+
+	t.Run("实际解压后大小过大", func(t *testing.T) {
+		parts := testPPTXParts(slide)
+		parts["ppt/slides/slide1.xml"] = testPPTXSlide(testPPTXShape("title", `<a:r><a:t>`+strings.Repeat("a", pptxMaxPartSize)+`</a:t></a:r>`))
+		data := testZip(t, parts)
+		_, err := readPPTX(bytes.NewReader(data), int64(len(data)))
+		if err == nil || !strings.Contains(err.Error(), "文件内容过大") {
+			t.Errorf("readPPTX() error = %v, want 文件内容过大", err)
+		}
+	})
+}
+
+func TestPPTXRoundTrip(t *testing.T) {
+	presentation := &model.Presentation{Aspect: model.SlideAspectStandard, Slides: []*model.Slide{
+		{Layout: model.SlideLayoutTitle, Title: "封面", Subtitle: "副标题"},
+		{Layout: model.SlideLayoutContent, Title: "内容", Body: []model.SlideParagraph{{Text: "一"}, {Text: "二", Level: 1}}, Notes: "备注"},
+		{Layout: model.SlideLayoutTwoColumn, Title: "两栏", Body: []model.SlideParagraph{{Text: "左"}}, Body2: []model.SlideParagraph{{Text: "右"}}, Hidden: true, Background: "#336699"},
+	}}
+
+	var buf bytes.Buffer
+	if err := writePPTX(&buf, "测试", presentation); err != nil {
+		t.Fatalf("writePPTX() error = %v", err)
+	}
+	got, err := readPPTX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("readPPTX() error = %v", err)
+	}
+
+	if got.Aspect != presentation.Aspect {
+		t.Errorf("aspect = %s, want %s", got.Aspect, presentation.Aspect)
+	}
+	if len(got.Slides) != len(presentation.Slides) {
+		t.Fatalf("got %d slides, want %d", len(got.Slides), len(presentation.Slides))
+	}
+	for i, want := range presentation.Slides {
+		slide := *got.Slides[i]
+		slide.ID = ""
+		if fmt.Sprintf("%+v", slide) != fmt.Sprintf("%+v", *want) {
+			t.Errorf("slide %d = %+v, want %+v", i, slide, *want)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"time"
 	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/repository"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type RecycleService interface {
@@ -12,18 +16,28 @@ type RecycleService interface {
 	Restore(id, userID uint, req *model.RestoreRequest) error
 	DeletePermanently(id, userID uint) error
 	DeleteBatch(userID uint, req *model.DeleteBatchRequest) error
+	StartScheduler(interval time.Duration)
 }
 
 type recycleService struct {
-	recycleRepo  repository.RecycleRepository
-	documentRepo repository.DocumentRepository
-	logger       *zap.Logger
+	recycleRepo       repository.RecycleRepository
+	documentRepo      repository.DocumentRepository
+	folderRepo        repository.FolderRepository
+	attachmentService AttachmentService
+	notifier          NotificationService
+	events            EventBus
+	logger            *zap.Logger
 }
 
-func NewRecycleService(recycleRepo repository.RecycleRepository, logger *zap.Logger) RecycleService {
+func NewRecycleService(recycleRepo repository.RecycleRepository, documentRepo repository.DocumentRepository, folderRepo repository.FolderRepository, attachmentService AttachmentService, notifier NotificationService, events EventBus, logger *zap.Logger) RecycleService {
 	return &recycleService{
-		recycleRepo: recycleRepo,
-		logger:      logger,
+		recycleRepo:       recycleRepo,
+		documentRepo:      documentRepo,
+		folderRepo:        folderRepo,
+		attachmentService: attachmentService,
+		notifier:          notifier,
+		events:            events,
+		logger:            logger,
 	}
 }
 
@@ -51,11 +65,25 @@ func (s *recycleService) List(userID uint, req *model.RecycleListRequest) ([]mod
 }
 
 func (s *recycleService) Restore(id, userID uint, req *model.RestoreRequest) error {
-	// TODO: 实现恢复逻辑
-	// 1. 获取回收站项目
-	// 2. 根据资源类型恢复相应的资源
-	// 3. 删除回收站记录
-	
+	item, err := s.recycleRepo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return errors.New("回收站项目不存在")
+	}
+
+	switch item.ResourceType {
+	case model.ResourceTypeDocument:
+		if err := s.restoreDocument(item, req); err != nil {
+			return err
+		}
+	default:
+		return errors.New("暂不支持恢复该类型的项目")
+	}
+
+	err = s.recycleRepo.Delete(id, userID)
+	if err != nil {
+		return err
+	}
+
 	s.logger.Info("Item restored from recycle", 
 		zap.Uint("user_id", userID),
 		zap.Uint("recycle_id", id))
@@ -63,8 +91,51 @@ func (s *recycleService) Restore(id, userID uint, req *model.RestoreRequest) err
 	return nil
 }
 
+// restoreDocument 恢复文档及其附件，原文件夹已删除且未指定文件夹时恢复到根目录
+func (s *recycleService) restoreDocument(item *model.RecycleItem, req *model.RestoreRequest) error {
+	document, err := s.documentRepo.GetDeletedByIDAndUserID(item.ResourceID, item.UserID)
+	if err != nil {
+		return errors.New("文档不存在或已被恢复")
+	}
+
+	folderID := document.FolderID
+	if req.FolderID != nil {
+		if _, err := s.folderRepo.GetByIDAndUserID(*req.FolderID, item.UserID); err != nil {
+			return errors.New("目标文件夹不存在")
+		}
+		folderID = req.FolderID
+	} else if folderID != nil {
+		if _, err := s.folderRepo.GetByIDAndUserID(*folderID, item.UserID); errors.Is(err, gorm.ErrRecordNotFound) {
+			folderID = nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := s.documentRepo.Restore(document.ID, item.UserID, folderID); err != nil {
+		return err
+	}
+	document.FolderID = folderID
+
+	// 恢复后重新建立链接、向量和思维导图节点索引
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentRestored,
+		ActorID:  item.UserID,
+		Document: documentEventData(document),
+	})
+	return nil
+}
+
 func (s *recycleService) DeletePermanently(id, userID uint) error {
-	err := s.recycleRepo.Delete(id, userID)
+	item, err := s.recycleRepo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return errors.New("回收站项目不存在")
+	}
+	if err := s.purgeResource(item); err != nil {
+		return err
+	}
+
+	err = s.recycleRepo.Delete(id, userID)
 	if err != nil {
 		return err
 	}
@@ -73,11 +144,27 @@ func (s *recycleService) DeletePermanently(id, userID uint) error {
 		zap.Uint("user_id", userID),
 		zap.Uint("recycle_id", id))
 
+	s.events.Publish(&model.Event{
+		Type:    model.EventRecyclePurged,
+		ActorID: userID,
+		Recycle: &model.RecycleEventData{IDs: []uint{id}},
+	})
+
 	return nil
 }
 
 func (s *recycleService) DeleteBatch(userID uint, req *model.DeleteBatchRequest) error {
-	err := s.recycleRepo.DeleteBatch(req.IDs, userID)
+	items, err := s.recycleRepo.GetByIDs(req.IDs, userID)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if err := s.purgeResource(&items[i]); err != nil {
+			return err
+		}
+	}
+
+	err = s.recycleRepo.DeleteBatch(req.IDs, userID)
 	if err != nil {
 		return err
 	}
@@ -86,5 +173,87 @@ func (s *recycleService) DeleteBatch(userID uint, req *model.DeleteBatchRequest)
 		zap.Uint("user_id", userID),
 		zap.Int("count", len(req.IDs)))
 
+	s.events.Publish(&model.Event{
+		Type:    model.EventRecyclePurged,
+		ActorID: userID,
+		Recycle: &model.RecycleEventData{IDs: req.IDs},
+	})
+
 	return nil
 }
+
+// StartScheduler 定期提醒即将被清理的回收站内容，并清理已过期的项目
+func (s *recycleService) StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.notifyExpiring()
+			s.purgeExpired()
+			<-ticker.C
+		}
+	}()
+}
+
+// purgeResource 永久删除回收站项目对应的内容，包括文档记录、附件文件和文档的关联数据
+func (s *recycleService) purgeResource(item *model.RecycleItem) error {
+	if item.ResourceType != model.ResourceTypeDocument {
+		return nil
+	}
+	// 文档已恢复或已被清理时不再处理
+	if _, err := s.documentRepo.GetDeletedByIDAndUserID(item.ResourceID, item.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := s.attachmentService.DeleteByDocument(item.ResourceID); err != nil {
+		return err
+	}
+	return s.recycleRepo.PurgeDocument(item.ResourceID)
+}
+
+// purgeExpired 清理已过期的项目，单个项目失败时下次继续清理
+func (s *recycleService) purgeExpired() {
+	items, err := s.recycleRepo.ListExpired()
+	if err != nil {
+		s.logger.Error("Failed to list expired recycle items", zap.Error(err))
+		return
+	}
+
+	for i := range items {
+		if err := s.purgeResource(&items[i]); err != nil {
+			s.logger.Error("Failed to purge expired recycle item",
+				zap.Uint("recycle_id", items[i].ID),
+				zap.Error(err))
+			continue
+		}
+		if err := s.recycleRepo.Delete(items[i].ID, items[i].UserID); err != nil {
+			s.logger.Error("Failed to delete expired recycle item",
+				zap.Uint("recycle_id", items[i].ID),
+				zap.Error(err))
+		}
+	}
+}
+
+func (s *recycleService) notifyExpiring() {
+	items, err := s.recycleRepo.ListExpiring(time.Now().Add(model.RecycleExpiryNoticeWindow))
+	if err != nil {
+		s.logger.Error("Failed to list expiring recycle items", zap.Error(err))
+		return
+	}
+
+	for _, item := range items {
+		// 每个项目只提醒一次
+		s.notifier.NotifyOnce(&model.Notification{
+			UserID:       item.UserID,
+			Type:         model.NotificationTypeRecycleExpiring,
+			Title:        fmt.Sprintf("《%s》即将从回收站中永久删除", item.ResourceName),
+			Content:      fmt.Sprintf("将于 %s 自动清理，如需保留请及时恢复", item.AutoDelete.Format("2006-01-02 15:04")),
+			ResourceType: model.ResourceTypeRecycleItem,
+			ResourceID:   item.ID,
+			Link:         "/recycle",
+		})
+	}
+}
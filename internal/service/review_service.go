@@ -0,0 +1,683 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 每轮定时发布最多处理的文档数
+const scheduledPublishBatch = 100
+
+var documentStatusLabels = map[model.DocumentStatus]string{
+	model.DocumentStatusDraft:     "草稿",
+	model.DocumentStatusPublished: "已发布",
+	model.DocumentStatusArchived:  "已归档",
+	model.DocumentStatusInReview:  "审核中",
+	model.DocumentStatusApproved:  "审核通过",
+}
+
+var documentActionLabels = map[model.DocumentAction]string{
+	model.DocumentActionSubmit:    "提交审核",
+	model.DocumentActionWithdraw:  "撤回审核",
+	model.DocumentActionApprove:   "审核通过",
+	model.DocumentActionReject:    "驳回",
+	model.DocumentActionPublish:   "发布",
+	model.DocumentActionSchedule:  "设置定时发布",
+	model.DocumentActionUnpublish: "取消发布",
+	model.DocumentActionArchive:   "归档",
+	model.DocumentActionUnarchive: "取消归档",
+}
+
+// directStatusActions 不需要审核人参与、可以由文档所有者直接执行的操作，修改文档状态时也按这些操作校验
+var directStatusActions = []model.DocumentAction{
+	model.DocumentActionPublish,
+	model.DocumentActionUnpublish,
+	model.DocumentActionArchive,
+	model.DocumentActionUnarchive,
+}
+
+type ReviewService interface {
+	// Submit 提交审核，文档进入审核中状态，审核期间不能修改
+	Submit(documentID, userID uint, req *model.SubmitReviewRequest, client *model.ClientInfo) (*model.ReviewResponse, error)
+	// Withdraw 撤回等待中的审核，文档退回草稿
+	Withdraw(documentID, userID uint, client *model.ClientInfo) error
+	ListByDocument(documentID, userID uint) ([]model.ReviewResponse, error)
+	// ListAssigned 分页查询分配给当前用户的审核
+	ListAssigned(userID uint, req *model.ReviewListRequest) ([]model.ReviewResponse, int64, error)
+	// GetByID 获取审核详情及文档内容，仅提交人、审核人和文档所有者可以查看
+	GetByID(id, userID uint) (*model.ReviewDetailResponse, error)
+	Approve(id, userID uint, req *model.ReviewDecisionRequest, client *model.ClientInfo) (*model.ReviewResponse, error)
+	Reject(id, userID uint, req *model.ReviewDecisionRequest, client *model.ClientInfo) (*model.ReviewResponse, error)
+	// Transition 执行发布、取消发布、归档和取消归档
+	Transition(documentID, userID uint, action model.DocumentAction, client *model.ClientInfo) error
+	// Schedule 设置审核通过的文档的定时发布时间，publishAt为空时取消定时发布
+	Schedule(documentID, userID uint, publishAt *time.Time, client *model.ClientInfo) error
+	// StartScheduler 定期发布到达定时发布时间的文档
+	StartScheduler(interval time.Duration)
+}
+
+type reviewService struct {
+	reviewRepo   repository.ReviewRepository
+	documentRepo repository.DocumentRepository
+	userRepo     repository.UserRepository
+	events       EventBus
+	locks        *documentLocks
+	logger       *zap.Logger
+}
+
+func NewReviewService(reviewRepo repository.ReviewRepository, documentRepo repository.DocumentRepository, userRepo repository.UserRepository, events EventBus, logger *zap.Logger) ReviewService {
+	return &reviewService{
+		reviewRepo:   reviewRepo,
+		documentRepo: documentRepo,
+		userRepo:     userRepo,
+		events:       events,
+		locks:        newDocumentLocks(),
+		logger:       logger,
+	}
+}
+
+func (s *reviewService) Submit(documentID, userID uint, req *model.SubmitReviewRequest, client *model.ClientInfo) (*model.ReviewResponse, error) {
+	unlock := s.locks.lock(documentID)
+	defer unlock()
+
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+	next, err := nextDocumentStatus(document.Status, model.DocumentActionSubmit)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewers, err := s.resolveReviewers(req.Reviewers, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.documentRepo.TransitionStatus(document.ID, document.Status, next, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errors.New("文档状态已变更，请刷新后重试")
+	}
+
+	review := &model.DocumentReview{
+		DocumentID:  document.ID,
+		SubmitterID: userID,
+		Status:      model.ReviewStatusPending,
+		Message:     req.Message,
+	}
+	reviewerIDs := make([]uint, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		review.Reviewers = append(review.Reviewers, model.DocumentReviewer{
+			ReviewerID: reviewer.ID,
+			Decision:   model.ReviewDecisionPending,
+		})
+		reviewerIDs = append(reviewerIDs, reviewer.ID)
+	}
+	if err := s.reviewRepo.Create(review); err != nil {
+		// 创建审核失败时恢复文档状态
+		if _, rollbackErr := s.documentRepo.TransitionStatus(document.ID, next, document.Status, nil); rollbackErr != nil {
+			s.logger.Error("Failed to restore document status", zap.Uint("document_id", document.ID), zap.Error(rollbackErr))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Document submitted for review",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.Uint("review_id", review.ID))
+
+	document.Status = next
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentSubmitted,
+		ActorID:  userID,
+		Client:   client,
+		Document: documentEventData(document),
+		Review: &model.ReviewEventData{
+			ID:          review.ID,
+			Status:      review.Status,
+			SubmitterID: userID,
+			Reviewers:   reviewerIDs,
+		},
+	})
+
+	review.Document = document
+	responses, err := s.toResponses([]model.DocumentReview{*review})
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
+}
+
+func (s *reviewService) Withdraw(documentID, userID uint, client *model.ClientInfo) error {
+	unlock := s.locks.lock(documentID)
+	defer unlock()
+
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return errors.New("文档不存在或无权访问")
+	}
+	next, err := nextDocumentStatus(document.Status, model.DocumentActionWithdraw)
+	if err != nil {
+		return err
+	}
+	review, err := s.reviewRepo.GetPendingByDocumentID(document.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("文档没有等待中的审核")
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	review.Status = model.ReviewStatusWithdrawn
+	review.CompletedAt = &now
+	if err := s.reviewRepo.Update(review); err != nil {
+		return err
+	}
+	if _, err := s.documentRepo.TransitionStatus(document.ID, document.Status, next, nil); err != nil {
+		return err
+	}
+
+	s.logger.Info("Document review withdrawn",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.Uint("review_id", review.ID))
+
+	document.Status = next
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentWithdrawn,
+		ActorID:  userID,
+		Client:   client,
+		Document: documentEventData(document),
+		Review: &model.ReviewEventData{
+			ID:          review.ID,
+			Status:      review.Status,
+			SubmitterID: review.SubmitterID,
+		},
+	})
+
+	return nil
+}
+
+func (s *reviewService) ListByDocument(documentID, userID uint) ([]model.ReviewResponse, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+
+	reviews, err := s.reviewRepo.ListByDocumentID(document.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reviews {
+		reviews[i].Document = document
+	}
+	return s.toResponses(reviews)
+}
+
+func (s *reviewService) ListAssigned(userID uint, req *model.ReviewListRequest) ([]model.ReviewResponse, int64, error) {
+	reviews, total, err := s.reviewRepo.ListByReviewer(userID, req.Decision, req.Page, req.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses, err := s.toResponses(reviews)
+	if err != nil {
+		return nil, 0, err
+	}
+	return responses, total, nil
+}
+
+func (s *reviewService) GetByID(id, userID uint) (*model.ReviewDetailResponse, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("审核不存在")
+	}
+	document, err := s.documentRepo.GetByID(review.DocumentID)
+	if err != nil {
+		return nil, errors.New("审核不存在")
+	}
+	if review.SubmitterID != userID && document.UserID != userID && findReviewer(review, userID) == nil {
+		return nil, errors.New("审核不存在")
+	}
+
+	review.Document = document
+	responses, err := s.toResponses([]model.DocumentReview{*review})
+	if err != nil {
+		return nil, err
+	}
+
+	content, _ := documentDetailContent(document)
+	return &model.ReviewDetailResponse{
+		ReviewResponse: responses[0],
+		DocumentType:   document.Type,
+		Content:        content,
+	}, nil
+}
+
+func (s *reviewService) Approve(id, userID uint, req *model.ReviewDecisionRequest, client *model.ClientInfo) (*model.ReviewResponse, error) {
+	return s.decide(id, userID, model.ReviewDecisionApproved, strings.TrimSpace(req.Comment), client)
+}
+
+func (s *reviewService) Reject(id, userID uint, req *model.ReviewDecisionRequest, client *model.ClientInfo) (*model.ReviewResponse, error) {
+	comment := strings.TrimSpace(req.Comment)
+	if comment == "" {
+		return nil, errors.New("驳回时请填写审核意见")
+	}
+	return s.decide(id, userID, model.ReviewDecisionRejected, comment, client)
+}
+
+// decide 记录审核人的意见。任一审核人驳回时审核结束并将文档退回草稿，所有审核人通过后文档进入审核通过状态
+func (s *reviewService) decide(id, userID uint, decision model.ReviewDecision, comment string, client *model.ClientInfo) (*model.ReviewResponse, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil || findReviewer(review, userID) == nil {
+		return nil, errors.New("审核不存在")
+	}
+
+	unlock := s.locks.lock(review.DocumentID)
+	defer unlock()
+
+	// 加锁后重新读取，避免与其他审核人或撤回操作同时修改
+	review, err = s.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != model.ReviewStatusPending {
+		return nil, errors.New("该审核已结束")
+	}
+	reviewer := findReviewer(review, userID)
+	if reviewer.Decision != model.ReviewDecisionPending {
+		return nil, errors.New("你已提交过审核意见")
+	}
+	document, err := s.documentRepo.GetByID(review.DocumentID)
+	if err != nil {
+		return nil, errors.New("文档不存在或已删除")
+	}
+
+	now := time.Now()
+	reviewer.Decision = decision
+	reviewer.Comment = comment
+	reviewer.DecidedAt = &now
+	if err := s.reviewRepo.UpdateReviewer(reviewer); err != nil {
+		return nil, err
+	}
+
+	action := model.DocumentAction("")
+	if decision == model.ReviewDecisionRejected {
+		review.Status = model.ReviewStatusRejected
+		action = model.DocumentActionReject
+	} else if allReviewersApproved(review) {
+		review.Status = model.ReviewStatusApproved
+		action = model.DocumentActionApprove
+	}
+
+	if action != "" {
+		next, err := nextDocumentStatus(document.Status, action)
+		if err != nil {
+			return nil, err
+		}
+		review.CompletedAt = &now
+		if err := s.reviewRepo.Update(review); err != nil {
+			return nil, err
+		}
+		if _, err := s.documentRepo.TransitionStatus(document.ID, document.Status, next, nil); err != nil {
+			return nil, err
+		}
+		document.Status = next
+	}
+
+	s.logger.Info("Document reviewed",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.Uint("review_id", review.ID),
+		zap.String("decision", string(decision)))
+
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentReviewed,
+		ActorID:  userID,
+		Client:   client,
+		Document: documentEventData(document),
+		Review: &model.ReviewEventData{
+			ID:          review.ID,
+			Status:      review.Status,
+			SubmitterID: review.SubmitterID,
+			Decision:    decision,
+			Comment:     comment,
+		},
+	})
+
+	review.Document = document
+	responses, err := s.toResponses([]model.DocumentReview{*review})
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
+}
+
+func (s *reviewService) Transition(documentID, userID uint, action model.DocumentAction, client *model.ClientInfo) error {
+	if !containsAction(directStatusActions, action) {
+		return fmt.Errorf("不支持的操作: %s", action)
+	}
+
+	unlock := s.locks.lock(documentID)
+	defer unlock()
+
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return errors.New("文档不存在或无权访问")
+	}
+	next, err := nextDocumentStatus(document.Status, action)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.documentRepo.TransitionStatus(document.ID, document.Status, next, nil)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("文档状态已变更，请刷新后重试")
+	}
+
+	s.logger.Info("Document status changed",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.String("action", string(action)))
+
+	previousStatus := document.Status
+	document.Status = next
+	document.PublishAt = nil
+	s.publishStatusChanged(document, previousStatus, userID, client)
+	return nil
+}
+
+func (s *reviewService) Schedule(documentID, userID uint, publishAt *time.Time, client *model.ClientInfo) error {
+	if publishAt != nil && publishAt.Before(time.Now().Add(model.SchedulePublishMinDelay)) {
+		return errors.New("定时发布时间必须晚于当前时间至少1分钟")
+	}
+
+	unlock := s.locks.lock(documentID)
+	defer unlock()
+
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return errors.New("文档不存在或无权访问")
+	}
+	next, err := nextDocumentStatus(document.Status, model.DocumentActionSchedule)
+	if err != nil {
+		return err
+	}
+	if publishAt == nil && document.PublishAt == nil {
+		return errors.New("文档没有设置定时发布")
+	}
+
+	updated, err := s.documentRepo.TransitionStatus(document.ID, document.Status, next, publishAt)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("文档状态已变更，请刷新后重试")
+	}
+
+	s.logger.Info("Document publish scheduled",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.Timep("publish_at", publishAt))
+
+	document.PublishAt = publishAt
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentScheduled,
+		ActorID:  userID,
+		Client:   client,
+		Document: documentEventData(document),
+	})
+
+	return nil
+}
+
+func (s *reviewService) StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.publishScheduled()
+			<-ticker.C
+		}
+	}()
+}
+
+// publishScheduled 发布到达定时发布时间的文档，事件以文档所有者的身份发布
+func (s *reviewService) publishScheduled() {
+	documents, err := s.documentRepo.ListDueScheduled(time.Now(), scheduledPublishBatch)
+	if err != nil {
+		s.logger.Error("Failed to list scheduled documents", zap.Error(err))
+		return
+	}
+
+	for i := range documents {
+		document := &documents[i]
+		unlock := s.locks.lock(document.ID)
+		updated, err := s.documentRepo.TransitionStatus(document.ID, model.DocumentStatusApproved, model.DocumentStatusPublished, nil)
+		unlock()
+		if err != nil {
+			s.logger.Error("Failed to publish scheduled document",
+				zap.Uint("document_id", document.ID),
+				zap.Error(err))
+			continue
+		}
+		if !updated {
+			continue
+		}
+
+		s.logger.Info("Scheduled document published", zap.Uint("document_id", document.ID))
+
+		document.Status = model.DocumentStatusPublished
+		document.PublishAt = nil
+		s.publishStatusChanged(document, model.DocumentStatusApproved, document.UserID, nil)
+	}
+}
+
+// publishStatusChanged 状态变更按文档更新事件发布，发布时同时发出发布事件
+func (s *reviewService) publishStatusChanged(document *model.Document, previousStatus model.DocumentStatus, actorID uint, client *model.ClientInfo) {
+	data := documentEventData(document)
+	data.PreviousStatus = previousStatus
+	s.events.Publish(&model.Event{
+		Type:     model.EventDocumentUpdated,
+		ActorID:  actorID,
+		Client:   client,
+		Document: data,
+	})
+	if document.Status == model.DocumentStatusPublished {
+		s.events.Publish(&model.Event{
+			Type:     model.EventDocumentPublished,
+			ActorID:  actorID,
+			Client:   client,
+			Document: data,
+		})
+	}
+}
+
+// resolveReviewers 按用户名查找审核人，去除重复的用户名，不能指定自己或已禁用的用户
+func (s *reviewService) resolveReviewers(usernames []string, submitterID uint) ([]model.User, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range usernames {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("请指定审核人")
+	}
+
+	users, err := s.userRepo.GetByUsernames(names)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]model.User, len(users))
+	for _, user := range users {
+		if user.Status != model.UserStatusDisabled {
+			found[user.Username] = user
+		}
+	}
+
+	var missing []string
+	reviewers := make([]model.User, 0, len(names))
+	for _, name := range names {
+		user, ok := found[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if user.ID == submitterID {
+			return nil, errors.New("不能指定自己为审核人")
+		}
+		reviewers = append(reviewers, user)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("审核人不存在: %s", strings.Join(missing, ", "))
+	}
+	return reviewers, nil
+}
+
+// toResponses 转换为响应，批量查询提交人和审核人的用户信息
+func (s *reviewService) toResponses(reviews []model.DocumentReview) ([]model.ReviewResponse, error) {
+	var userIDs []uint
+	for _, review := range reviews {
+		userIDs = append(userIDs, review.SubmitterID)
+		for _, reviewer := range review.Reviewers {
+			userIDs = append(userIDs, reviewer.ReviewerID)
+		}
+	}
+	users, err := s.userRepo.GetByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[uint]model.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+
+	responses := make([]model.ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		submitter := usersByID[review.SubmitterID]
+		submitterName := submitter.Nickname
+		if submitterName == "" {
+			submitterName = submitter.Username
+		}
+		response := model.ReviewResponse{
+			ID:            review.ID,
+			DocumentID:    review.DocumentID,
+			SubmitterID:   review.SubmitterID,
+			SubmitterName: submitterName,
+			Status:        review.Status,
+			Message:       review.Message,
+			Reviewers:     make([]model.ReviewerResponse, 0, len(review.Reviewers)),
+			CreatedAt:     review.CreatedAt,
+			CompletedAt:   review.CompletedAt,
+		}
+		if review.Document != nil {
+			response.DocumentTitle = review.Document.Title
+		}
+		for _, reviewer := range review.Reviewers {
+			user := usersByID[reviewer.ReviewerID]
+			response.Reviewers = append(response.Reviewers, model.ReviewerResponse{
+				UserID:    reviewer.ReviewerID,
+				Username:  user.Username,
+				Nickname:  user.Nickname,
+				Decision:  reviewer.Decision,
+				Comment:   reviewer.Comment,
+				DecidedAt: reviewer.DecidedAt,
+			})
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// nextDocumentStatus 按状态机校验操作，不允许时返回说明当前状态的错误
+func nextDocumentStatus(status model.DocumentStatus, action model.DocumentAction) (model.DocumentStatus, error) {
+	next, ok := model.NextDocumentStatus(status, action)
+	if !ok {
+		return 0, fmt.Errorf("文档当前为%s状态，无法%s", documentStatusLabels[status], documentActionLabels[action])
+	}
+	return next, nil
+}
+
+// statusUpdateAction 修改文档时直接指定状态，找到对应的操作并按状态机校验
+func statusUpdateAction(from, to model.DocumentStatus) (model.DocumentAction, error) {
+	for _, action := range directStatusActions {
+		if next, ok := model.NextDocumentStatus(from, action); ok && next == to {
+			return action, nil
+		}
+	}
+	if to == model.DocumentStatusPublished {
+		return "", errors.New("文档需要审核通过后才能发布")
+	}
+	if _, ok := documentStatusLabels[to]; !ok {
+		return "", errors.New("无效的文档状态")
+	}
+	return "", fmt.Errorf("文档不能从%s直接变更为%s，请使用审核流程", documentStatusLabels[from], documentStatusLabels[to])
+}
+
+// updatedDocumentStatus 修改文档后的状态。审核中的文档不能修改，修改的内容未经审核，不能在同一请求中发布或变更状态；
+// 修改审核通过或已发布的文档后退回草稿，需要重新审核后才能发布
+func updatedDocumentStatus(status model.DocumentStatus, edited bool, requested *model.DocumentStatus) (model.DocumentStatus, error) {
+	if edited && status == model.DocumentStatusInReview {
+		return 0, errors.New("文档正在审核中，请撤回审核后再修改")
+	}
+	if requested != nil && *requested != status {
+		if edited {
+			return 0, errors.New("修改文档内容时不能同时修改状态")
+		}
+		if _, err := statusUpdateAction(status, *requested); err != nil {
+			return 0, err
+		}
+		return *requested, nil
+	}
+	if edited && (status == model.DocumentStatusApproved || status == model.DocumentStatusPublished) {
+		return model.DocumentStatusDraft, nil
+	}
+	return status, nil
+}
+
+func findReviewer(review *model.DocumentReview, userID uint) *model.DocumentReviewer {
+	for i := range review.Reviewers {
+		if review.Reviewers[i].ReviewerID == userID {
+			return &review.Reviewers[i]
+		}
+	}
+	return nil
+}
+
+func allReviewersApproved(review *model.DocumentReview) bool {
+	for _, reviewer := range review.Reviewers {
+		if reviewer.Decision != model.ReviewDecisionApproved {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAction(actions []model.DocumentAction, action model.DocumentAction) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
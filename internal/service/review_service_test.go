@@ -0,0 +1,246 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	testDocumentStatuses = []model.DocumentStatus{
+		model.DocumentStatusDraft,
+		model.DocumentStatusInReview,
+		model.DocumentStatusApproved,
+		model.DocumentStatusPublished,
+		model.DocumentStatusArchived,
+	}
+	testDocumentActions = []model.DocumentAction{
+		model.DocumentActionSubmit,
+		model.DocumentActionWithdraw,
+		model.DocumentActionApprove,
+		model.DocumentActionReject,
+		model.DocumentActionPublish,
+		model.DocumentActionSchedule,
+		model.DocumentActionUnpublish,
+		model.DocumentActionArchive,
+		model.DocumentActionUnarchive,
+	}
+)
+
+func statusPtr(status model.DocumentStatus) *model.DocumentStatus {
+	return &status
+}
+
+func TestNextDocumentStatus(t *testing.T) {
+	// 未列出的状态和操作组合都不允许
+	allowed := map[model.DocumentStatus]map[model.DocumentAction]model.DocumentStatus{
+		model.DocumentStatusDraft: {
+			model.DocumentActionSubmit:  model.DocumentStatusInReview,
+			model.DocumentActionArchive: model.DocumentStatusArchived,
+		},
+		model.DocumentStatusInReview: {
+			model.DocumentActionWithdraw: model.DocumentStatusDraft,
+			model.DocumentActionApprove:  model.DocumentStatusApproved,
+			model.DocumentActionReject:   model.DocumentStatusDraft,
+		},
+		model.DocumentStatusApproved: {
+			model.DocumentActionPublish:  model.DocumentStatusPublished,
+			model.DocumentActionSchedule: model.DocumentStatusApproved,
+			model.DocumentActionArchive:  model.DocumentStatusArchived,
+		},
+		model.DocumentStatusPublished: {
+			model.DocumentActionUnpublish: model.DocumentStatusDraft,
+			model.DocumentActionArchive:   model.DocumentStatusArchived,
+		},
+		model.DocumentStatusArchived: {
+			model.DocumentActionUnarchive: model.DocumentStatusDraft,
+		},
+	}
+
+	for _, status := range testDocumentStatuses {
+		for _, action := range testDocumentActions {
+			want, wantOK := allowed[status][action]
+			next, err := nextDocumentStatus(status, action)
+			if wantOK && (err != nil || next != want) {
+				t.Errorf("%s %s = %v, %v, want %v", documentStatusLabels[status], action, next, err, want)
+			}
+			if !wantOK && err == nil {
+				t.Errorf("%s %s = %v, want error", documentStatusLabels[status], action, next)
+			}
+		}
+	}
+}
+
+func TestUpdatedDocumentStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    model.DocumentStatus
+		edited    bool
+		requested *model.DocumentStatus
+		want      model.DocumentStatus
+		wantErr   string
+	}{
+		{"修改草稿保持草稿", model.DocumentStatusDraft, true, nil, model.DocumentStatusDraft, ""},
+		{"不能修改审核中的文档", model.DocumentStatusInReview, true, nil, 0, "正在审核中"},
+		{"修改审核通过的文档退回草稿", model.DocumentStatusApproved, true, nil, model.DocumentStatusDraft, ""},
+		{"修改已发布的文档退回草稿", model.DocumentStatusPublished, true, nil, model.DocumentStatusDraft, ""},
+		{"修改已归档的文档保持归档", model.DocumentStatusArchived, true, nil, model.DocumentStatusArchived, ""},
+
+		{"只移动审核中的文档", model.DocumentStatusInReview, false, nil, model.DocumentStatusInReview, ""},
+		{"只移动已发布的文档保持发布", model.DocumentStatusPublished, false, nil, model.DocumentStatusPublished, ""},
+		{"修改已发布的文档时提交原状态仍退回草稿", model.DocumentStatusPublished, true, statusPtr(model.DocumentStatusPublished), model.DocumentStatusDraft, ""},
+
+		{"发布审核通过的文档", model.DocumentStatusApproved, false, statusPtr(model.DocumentStatusPublished), model.DocumentStatusPublished, ""},
+		{"草稿不能直接发布", model.DocumentStatusDraft, false, statusPtr(model.DocumentStatusPublished), 0, "需要审核通过后才能发布"},
+		{"不能直接设为审核通过", model.DocumentStatusDraft, false, statusPtr(model.DocumentStatusApproved), 0, "请使用审核流程"},
+		{"不能直接撤出审核", model.DocumentStatusInReview, false, statusPtr(model.DocumentStatusDraft), 0, "请使用审核流程"},
+		{"取消发布", model.DocumentStatusPublished, false, statusPtr(model.DocumentStatusDraft), model.DocumentStatusDraft, ""},
+		{"归档草稿", model.DocumentStatusDraft, false, statusPtr(model.DocumentStatusArchived), model.DocumentStatusArchived, ""},
+		{"取消归档", model.DocumentStatusArchived, false, statusPtr(model.DocumentStatusDraft), model.DocumentStatusDraft, ""},
+		{"无效的状态", model.DocumentStatusDraft, false, statusPtr(99), 0, "无效的文档状态"},
+
+		{"修改内容时不能同时发布", model.DocumentStatusApproved, true, statusPtr(model.DocumentStatusPublished), 0, "不能同时修改状态"},
+		{"修改内容时不能同时归档", model.DocumentStatusDraft, true, statusPtr(model.DocumentStatusArchived), 0, "不能同时修改状态"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updatedDocumentStatus(tt.status, tt.edited, tt.requested)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// testDocumentRepo 只实现更新文档用到的方法，其他方法未实现
+type testDocumentRepo struct {
+	repository.DocumentRepository
+	document *model.Document
+	saved    *model.Document
+}
+
+func (r *testDocumentRepo) GetByIDAndUserID(id, userID uint) (*model.Document, error) {
+	if r.document == nil || r.document.ID != id || r.document.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	document := *r.document
+	return &document, nil
+}
+
+func (r *testDocumentRepo) UpdateIfUnchanged(document *model.Document, status model.DocumentStatus) (bool, error) {
+	if r.document.Status != status || r.document.Version != document.Version {
+		return false, nil
+	}
+	document.Version++
+	saved := *document
+	r.saved = &saved
+	r.document = &saved
+	return true, nil
+}
+
+// testEventBus 记录发布的事件
+type testEventBus struct {
+	EventBus
+	events []*model.Event
+}
+
+func (b *testEventBus) Publish(event *model.Event) {
+	b.events = append(b.events, event)
+}
+
+func TestDocumentServiceUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     model.DocumentStatus
+		req        model.UpdateDocumentRequest
+		want       model.DocumentStatus
+		wantErr    bool
+		wantEvents []model.EventType
+	}{
+		{"修改已发布的文档退回草稿", model.DocumentStatusPublished, model.UpdateDocumentRequest{Title: "新标题"}, model.DocumentStatusDraft, false, []model.EventType{model.EventDocumentUpdated}},
+		{"修改审核通过的文档退回草稿", model.DocumentStatusApproved, model.UpdateDocumentRequest{Title: "新标题"}, model.DocumentStatusDraft, false, []model.EventType{model.EventDocumentUpdated}},
+		{"修改草稿", model.DocumentStatusDraft, model.UpdateDocumentRequest{Title: "新标题"}, model.DocumentStatusDraft, false, []model.EventType{model.EventDocumentUpdated}},
+		{"修改审核中的文档", model.DocumentStatusInReview, model.UpdateDocumentRequest{Title: "新标题"}, model.DocumentStatusInReview, true, nil},
+		{"修改已发布的文档并要求保持发布", model.DocumentStatusPublished, model.UpdateDocumentRequest{Title: "新标题", Status: statusPtr(model.DocumentStatusPublished)}, model.DocumentStatusDraft, false, []model.EventType{model.EventDocumentUpdated}},
+		{"发布审核通过的文档", model.DocumentStatusApproved, model.UpdateDocumentRequest{Status: statusPtr(model.DocumentStatusPublished)}, model.DocumentStatusPublished, false, []model.EventType{model.EventDocumentUpdated, model.EventDocumentPublished}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &testDocumentRepo{document: &model.Document{ID: 1, UserID: 2, Title: "标题", Status: tt.status}}
+			events := &testEventBus{}
+			service := NewDocumentService(repo, nil, nil, nil, nil, nil, events, zap.NewNop())
+
+			err := service.Update(1, 2, &tt.req, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Update() succeeded, want error")
+				}
+				if repo.saved != nil {
+					t.Errorf("document saved with status %v", repo.saved.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if repo.saved.Status != tt.want {
+				t.Errorf("status = %v, want %v", repo.saved.Status, tt.want)
+			}
+
+			var got []model.EventType
+			for _, event := range events.events {
+				got = append(got, event.Type)
+				if event.Document.PreviousStatus != tt.status {
+					t.Errorf("%s previous status = %v, want %v", event.Type, event.Document.PreviousStatus, tt.status)
+				}
+			}
+			if len(got) != len(tt.wantEvents) {
+				t.Fatalf("events = %v, want %v", got, tt.wantEvents)
+			}
+			for i := range got {
+				if got[i] != tt.wantEvents[i] {
+					t.Errorf("events = %v, want %v", got, tt.wantEvents)
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentServiceUpdateStatusChanged(t *testing.T) {
+	// 读取文档后状态被审核或定时发布修改，不能覆盖新的状态
+	repo := &testDocumentRepo{document: &model.Document{ID: 1, UserID: 2, Status: model.DocumentStatusDraft}}
+	stale := &staleDocumentRepo{testDocumentRepo: repo, status: model.DocumentStatusInReview}
+	service := NewDocumentService(stale, nil, nil, nil, nil, nil, &testEventBus{}, zap.NewNop())
+
+	err := service.Update(1, 2, &model.UpdateDocumentRequest{Title: "新标题"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "文档状态已变化") {
+		t.Errorf("Update() error = %v, want 文档状态已变化", err)
+	}
+	if repo.saved != nil {
+		t.Error("document saved after status changed")
+	}
+}
+
+// staleDocumentRepo 读取文档后将状态改为status，模拟并发的状态变更
+type staleDocumentRepo struct {
+	*testDocumentRepo
+	status model.DocumentStatus
+}
+
+func (r *staleDocumentRepo) GetByIDAndUserID(id, userID uint) (*model.Document, error) {
+	document, err := r.testDocumentRepo.GetByIDAndUserID(id, userID)
+	r.document.Status = r.status
+	return document, err
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+	"wz-wenzhan-backend/internal/model"
+)
+
+const (
+	richTextVersion       = 1
+	richTextMaxBlocks     = 10000
+	richTextMaxTextLength = 20000
+	richTextMaxCode       = 100000
+	richTextMaxListDepth  = 10
+	richTextMaxListItems  = 5000
+	richTextMaxTableRows  = 500
+	richTextMaxTableCols  = 50
+	richTextMaxURLLength  = 2048
+)
+
+// 与评论的anchor_block_id长度一致
+var richTextBlockIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// parseRichText 解析Word文档内容。内容不是块结构的JSON时按Markdown转换，兼容早期以纯文本保存的文档
+func parseRichText(content string) (*model.RichTextDocument, error) {
+	if trimmed := strings.TrimSpace(content); strings.HasPrefix(trimmed, "{") {
+		var raw map[string]json.RawMessage
+		if json.Unmarshal([]byte(trimmed), &raw) == nil {
+			if _, ok := raw["blocks"]; ok {
+				var document model.RichTextDocument
+				if err := json.Unmarshal([]byte(trimmed), &document); err != nil {
+					return nil, errors.New("文档内容的块结构无效")
+				}
+				if err := validateRichText(&document); err != nil {
+					return nil, err
+				}
+				return &document, nil
+			}
+		}
+	}
+
+	document := markdownToRichText(content)
+	if err := validateRichText(document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// normalizeRichTextContent 校验并过滤Word文档内容，Markdown转换为块结构，保存Word文档前调用
+func normalizeRichTextContent(content string) (string, error) {
+	document, err := parseRichText(content)
+	if err != nil {
+		return "", err
+	}
+	return encodeRichText(document)
+}
+
+// encodeRichText 不转义HTML字符，保持内容中的文本可以被关键词搜索匹配
+func encodeRichText(document *model.RichTextDocument) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// validateRichText 检查各类型内容块的字段，过滤行内HTML，去掉与类型无关的字段，并为缺少ID的块生成ID
+func validateRichText(document *model.RichTextDocument) error {
+	document.Version = richTextVersion
+	if len(document.Blocks) > richTextMaxBlocks {
+		return fmt.Errorf("文档的内容块不能超过%d个", richTextMaxBlocks)
+	}
+
+	blocks := make([]*model.RichTextBlock, 0, len(document.Blocks))
+	for i, block := range document.Blocks {
+		if block == nil {
+			continue
+		}
+		normalized, err := normalizeRichTextBlock(block)
+		if err != nil {
+			return fmt.Errorf("第%d个内容块：%w", i+1, err)
+		}
+		blocks = append(blocks, normalized)
+	}
+	document.Blocks = blocks
+
+	assignRichTextBlockIDs(blocks)
+	return nil
+}
+
+func normalizeRichTextBlock(block *model.RichTextBlock) (*model.RichTextBlock, error) {
+	normalized := &model.RichTextBlock{ID: block.ID, Type: block.Type}
+
+	switch block.Type {
+	case model.RichTextBlockHeading:
+		if block.Level < 1 || block.Level > 6 {
+			return nil, errors.New("标题级别需要在1到6之间")
+		}
+		normalized.Level = block.Level
+		normalized.Text = strings.TrimSpace(sanitizeInlineHTML(block.Text))
+	case model.RichTextBlockParagraph, model.RichTextBlockQuote:
+		normalized.Text = sanitizeInlineHTML(block.Text)
+	case model.RichTextBlockList:
+		if len(block.Items) == 0 {
+			return nil, errors.New("列表至少需要一项")
+		}
+		count := 0
+		items, err := normalizeRichTextListItems(block.Items, 1, &count)
+		if err != nil {
+			return nil, err
+		}
+		normalized.Ordered = block.Ordered
+		normalized.Items = items
+	case model.RichTextBlockTable:
+		if len(block.Rows) == 0 {
+			return nil, errors.New("表格至少需要一行")
+		}
+		if len(block.Rows) > richTextMaxTableRows {
+			return nil, fmt.Errorf("表格不能超过%d行", richTextMaxTableRows)
+		}
+		cols := 0
+		for _, row := range block.Rows {
+			if len(row) > cols {
+				cols = len(row)
+			}
+		}
+		if cols == 0 || cols > richTextMaxTableCols {
+			return nil, fmt.Errorf("表格需要1到%d列", richTextMaxTableCols)
+		}
+		// 各行补齐为相同的列数
+		normalized.Rows = make([][]string, len(block.Rows))
+		for i, row := range block.Rows {
+			normalized.Rows[i] = make([]string, cols)
+			for j, cell := range row {
+				if utf8.RuneCountInString(cell) > richTextMaxTextLength {
+					return nil, fmt.Errorf("单元格内容不能超过%d个字符", richTextMaxTextLength)
+				}
+				normalized.Rows[i][j] = sanitizeInlineHTML(cell)
+			}
+		}
+		normalized.Header = block.Header
+	case model.RichTextBlockImage:
+		if len(block.URL) > richTextMaxURLLength || !isSafeURL(block.URL, false) {
+			return nil, errors.New("图片地址只支持http(s)链接或站内路径")
+		}
+		normalized.URL = strings.TrimSpace(block.URL)
+		normalized.Alt = truncateRunes(block.Alt, 500)
+		normalized.Caption = truncateRunes(block.Caption, 1000)
+	case model.RichTextBlockCode:
+		if utf8.RuneCountInString(block.Code) > richTextMaxCode {
+			return nil, fmt.Errorf("代码不能超过%d个字符", richTextMaxCode)
+		}
+		normalized.Language = truncateRunes(block.Language, 30)
+		normalized.Code = block.Code
+	case model.RichTextBlockDivider:
+	default:
+		return nil, fmt.Errorf("不支持的内容块类型：%s", block.Type)
+	}
+
+	if utf8.RuneCountInString(normalized.Text) > richTextMaxTextLength {
+		return nil, fmt.Errorf("内容不能超过%d个字符", richTextMaxTextLength)
+	}
+	return normalized, nil
+}
+
+func normalizeRichTextListItems(items []*model.RichTextListItem, depth int, count *int) ([]*model.RichTextListItem, error) {
+	if depth > richTextMaxListDepth {
+		return nil, fmt.Errorf("列表不能超过%d层", richTextMaxListDepth)
+	}
+
+	normalized := make([]*model.RichTextListItem, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		*count++
+		if *count > richTextMaxListItems {
+			return nil, fmt.Errorf("列表不能超过%d项", richTextMaxListItems)
+		}
+		if utf8.RuneCountInString(item.Text) > richTextMaxTextLength {
+			return nil, fmt.Errorf("列表项不能超过%d个字符", richTextMaxTextLength)
+		}
+		children, err := normalizeRichTextListItems(item.Items, depth+1, count)
+		if err != nil {
+			return nil, err
+		}
+		entry := &model.RichTextListItem{Text: sanitizeInlineHTML(item.Text), Checked: item.Checked}
+		if len(children) > 0 {
+			entry.Items = children
+		}
+		normalized = append(normalized, entry)
+	}
+	return normalized, nil
+}
+
+// assignRichTextBlockIDs 缺少ID的块按内容生成ID，内容未变化的块在多次从Markdown转换时ID保持不变
+func assignRichTextBlockIDs(blocks []*model.RichTextBlock) {
+	seen := make(map[string]bool, len(blocks))
+	for _, block := range blocks {
+		if block.ID != "" && richTextBlockIDPattern.MatchString(block.ID) && !seen[block.ID] {
+			seen[block.ID] = true
+			continue
+		}
+
+		block.ID = ""
+		data, _ := json.Marshal(block)
+		sum := sha256.Sum256(data)
+		base := "b" + hex.EncodeToString(sum[:5])
+		id := base
+		for n := 2; seen[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		block.ID = id
+		seen[id] = true
+	}
+}
+
+// richTextTOC 由标题生成目录
+func richTextTOC(document *model.RichTextDocument) []model.TOCEntry {
+	var toc []model.TOCEntry
+	for _, block := range document.Blocks {
+		if block.Type != model.RichTextBlockHeading {
+			continue
+		}
+		text := inlineHTMLToText(block.Text)
+		if text == "" {
+			continue
+		}
+		toc = append(toc, model.TOCEntry{BlockID: block.ID, Level: block.Level, Text: text})
+	}
+	return toc
+}
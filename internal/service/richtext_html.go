@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// documentHTML 将文档内容渲染为HTML，用于公开站点。Word文档和以Markdown保存的文档按块结构渲染并生成目录，
+// 表格、演示文稿和思维导图按提取的纯文本展示
+func documentHTML(document *model.Document) (string, []model.TOCEntry) {
+	switch document.Type {
+	case model.DocumentTypeWord, model.DocumentTypeNote, model.DocumentTypeAIDraft, model.DocumentTypeImported:
+		richText, err := parseRichText(document.Content)
+		if err == nil {
+			return richTextToHTML(richText), richTextTOC(richText)
+		}
+	}
+	return `<pre class="plain-text">` + html.EscapeString(documentText(document)) + `</pre>`, nil
+}
+
+// richTextToHTML 将块结构转换为HTML。保存时已过滤行内HTML，渲染时再过滤一次，早期保存的内容同样安全
+func richTextToHTML(document *model.RichTextDocument) string {
+	var b strings.Builder
+	for _, block := range document.Blocks {
+		id := ""
+		if block.ID != "" {
+			id = ` id="` + html.EscapeString(block.ID) + `"`
+		}
+		switch block.Type {
+		case model.RichTextBlockHeading:
+			level := block.Level
+			if level < 1 || level > 6 {
+				level = 1
+			}
+			fmt.Fprintf(&b, "<h%d%s>%s</h%d>\n", level, id, sanitizeInlineHTML(block.Text), level)
+		case model.RichTextBlockParagraph:
+			b.WriteString("<p" + id + ">" + sanitizeInlineHTML(block.Text) + "</p>\n")
+		case model.RichTextBlockQuote:
+			b.WriteString("<blockquote" + id + ">" + sanitizeInlineHTML(block.Text) + "</blockquote>\n")
+		case model.RichTextBlockList:
+			writeHTMLList(&b, block.Items, block.Ordered, id)
+		case model.RichTextBlockTable:
+			writeHTMLTable(&b, block, id)
+		case model.RichTextBlockImage:
+			if !isSafeURL(block.URL, false) {
+				continue
+			}
+			b.WriteString("<figure" + id + `><img src="` + html.EscapeString(block.URL) + `" alt="` + html.EscapeString(block.Alt) + `">`)
+			if block.Caption != "" {
+				b.WriteString("<figcaption>" + html.EscapeString(block.Caption) + "</figcaption>")
+			}
+			b.WriteString("</figure>\n")
+		case model.RichTextBlockCode:
+			class := ""
+			if block.Language != "" {
+				class = ` class="language-` + html.EscapeString(block.Language) + `"`
+			}
+			b.WriteString("<pre" + id + "><code" + class + ">" + html.EscapeString(block.Code) + "</code></pre>\n")
+		case model.RichTextBlockDivider:
+			b.WriteString("<hr" + id + ">\n")
+		}
+	}
+	return b.String()
+}
+
+func writeHTMLList(b *strings.Builder, items []*model.RichTextListItem, ordered bool, id string) {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag + id + ">")
+	for _, item := range items {
+		b.WriteString("<li>")
+		if item.Checked != nil {
+			checked := ""
+			if *item.Checked {
+				checked = " checked"
+			}
+			b.WriteString(`<input type="checkbox" disabled` + checked + `> `)
+		}
+		b.WriteString(sanitizeInlineHTML(item.Text))
+		if len(item.Items) > 0 {
+			writeHTMLList(b, item.Items, ordered, "")
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</" + tag + ">\n")
+}
+
+func writeHTMLTable(b *strings.Builder, block *model.RichTextBlock, id string) {
+	b.WriteString("<table" + id + ">")
+	for i, row := range block.Rows {
+		cell := "td"
+		if i == 0 && block.Header {
+			cell = "th"
+		}
+		b.WriteString("<tr>")
+		for _, text := range row {
+			b.WriteString("<" + cell + ">" + sanitizeInlineHTML(text) + "</" + cell + ">")
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</table>\n")
+}
@@ -0,0 +1,504 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// Word文档块结构与Markdown的相互转换，支持CommonMark常用语法和GFM的表格、任务列表、删除线
+
+var (
+	markdownFencePattern       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+	markdownDividerPattern     = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	markdownTaskPattern        = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	markdownImagePattern       = regexp.MustCompile(`^\s*!\[([^\]]*)\]\(\s*([^)\s]+)(?:\s+"([^"]*)")?\s*\)\s*$`)
+	markdownTableSepPattern    = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(?:\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	markdownCodeSpanPattern    = regexp.MustCompile("(`+)(.+?)(`+)")
+	markdownEscapePattern      = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!|~<>])")
+	markdownInlineImgPattern   = regexp.MustCompile(`!\[([^\]]*)\]\(\s*([^)\s]+)(?:\s+"[^"]*")?\s*\)`)
+	markdownLinkPattern        = regexp.MustCompile(`\[([^\]]+)\]\(\s*([^)\s]+)(?:\s+"([^"]*)")?\s*\)`)
+	markdownAutolinkPattern    = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	markdownStrongPattern      = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	markdownEmphasisPattern    = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*|(?:^|[^\w])_(\S(?:[^_]*?\S)?)_(?:$|[^\w])`)
+	markdownStrikePattern      = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	markdownPlaceholderPattern = regexp.MustCompile("\x00(\\d+)\x00")
+	markdownSpecialChars       = strings.NewReplacer(`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `~`, `\~`, `<`, `\<`, `|`, `\|`)
+)
+
+// markdownToRichText 将Markdown转换为块结构，行内格式转换为HTML
+func markdownToRichText(content string) *model.RichTextDocument {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	document := &model.RichTextDocument{Version: richTextVersion, Blocks: []*model.RichTextBlock{}}
+
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		document.Blocks = append(document.Blocks, &model.RichTextBlock{
+			Type: model.RichTextBlockParagraph,
+			Text: markdownLinesToHTML(paragraph),
+		})
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if m := markdownFencePattern.FindStringSubmatch(line); m != nil {
+			flush()
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				closing := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			document.Blocks = append(document.Blocks, &model.RichTextBlock{
+				Type:     model.RichTextBlockCode,
+				Language: m[2],
+				Code:     strings.Join(code, "\n"),
+			})
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+		case markdownHeadingPattern.MatchString(trimmed):
+			flush()
+			m := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			document.Blocks = append(document.Blocks, &model.RichTextBlock{
+				Type:  model.RichTextBlockHeading,
+				Level: len(m[1]),
+				Text:  markdownInlineToHTML(m[2]),
+			})
+		case markdownDividerPattern.MatchString(line):
+			flush()
+			document.Blocks = append(document.Blocks, &model.RichTextBlock{Type: model.RichTextBlockDivider})
+		case markdownQuotePattern.MatchString(line):
+			flush()
+			var quote []string
+			for ; i < len(lines); i++ {
+				m := markdownQuotePattern.FindStringSubmatch(lines[i])
+				if m == nil {
+					break
+				}
+				quote = append(quote, m[1])
+			}
+			i--
+			document.Blocks = append(document.Blocks, &model.RichTextBlock{
+				Type: model.RichTextBlockQuote,
+				Text: markdownLinesToHTML(quote),
+			})
+		case strings.Contains(line, "|") && i+1 < len(lines) && markdownTableSepPattern.MatchString(lines[i+1]):
+			flush()
+			header := splitMarkdownTableRow(line)
+			rows := [][]string{header}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, splitMarkdownTableRow(lines[i]))
+			}
+			i--
+			block := &model.RichTextBlock{Type: model.RichTextBlockTable, Header: true}
+			// 表头全部为空时视为没有表头
+			if strings.Join(header, "") == "" && len(rows) > 1 {
+				block.Header = false
+				rows = rows[1:]
+			}
+			for _, row := range rows {
+				cells := make([]string, len(row))
+				for j, cell := range row {
+					cells[j] = markdownInlineToHTML(cell)
+				}
+				block.Rows = append(block.Rows, cells)
+			}
+			document.Blocks = append(document.Blocks, block)
+		case markdownListItemPattern.MatchString(line):
+			flush()
+			var block *model.RichTextBlock
+			block, i = parseMarkdownList(lines, i)
+			document.Blocks = append(document.Blocks, block)
+		case markdownImagePattern.MatchString(line) && len(paragraph) == 0:
+			m := markdownImagePattern.FindStringSubmatch(line)
+			if !isSafeURL(m[2], false) {
+				paragraph = append(paragraph, m[1])
+				continue
+			}
+			document.Blocks = append(document.Blocks, &model.RichTextBlock{
+				Type:    model.RichTextBlockImage,
+				URL:     m[2],
+				Alt:     unescapeMarkdown(m[1]),
+				Caption: m[3],
+			})
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return document
+}
+
+// parseMarkdownList 从第start行开始解析连续的列表，按缩进确定层级，返回列表块和最后一行的行号
+func parseMarkdownList(lines []string, start int) (*model.RichTextBlock, int) {
+	type level struct {
+		indent int
+		items  *[]*model.RichTextListItem
+	}
+
+	block := &model.RichTextBlock{Type: model.RichTextBlockList}
+	var stack []level
+	var last *model.RichTextListItem
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		m := markdownListItemPattern.FindStringSubmatch(line)
+		if m == nil || markdownDividerPattern.MatchString(line) {
+			trimmed := strings.TrimSpace(line)
+			if m == nil && trimmed == "" {
+				// 空行之后还是列表项时列表继续
+				if i+1 < len(lines) && markdownListItemPattern.MatchString(lines[i+1]) && !markdownDividerPattern.MatchString(lines[i+1]) {
+					continue
+				}
+				break
+			}
+			// 缩进的续行属于上一个列表项
+			if m == nil && (line[0] == ' ' || line[0] == '\t') && !markdownFencePattern.MatchString(line) {
+				last.Text += " " + markdownInlineToHTML(trimmed)
+				continue
+			}
+			break
+		}
+
+		indent := len(strings.ReplaceAll(m[1], "\t", "    "))
+		ordered := m[2][0] >= '0' && m[2][0] <= '9'
+		switch {
+		case len(stack) == 0:
+			block.Ordered = ordered
+			stack = []level{{indent, &block.Items}}
+		case indent > stack[len(stack)-1].indent:
+			// 比当前层级缩进更多时作为上一项的子列表
+			stack = append(stack, level{indent, &last.Items})
+		default:
+			for len(stack) > 1 && indent < stack[len(stack)-1].indent {
+				stack = stack[:len(stack)-1]
+			}
+			// 顶层的列表类型变化时开始新的列表
+			if len(stack) == 1 && ordered != block.Ordered {
+				return block, i - 1
+			}
+		}
+
+		item := &model.RichTextListItem{}
+		text := m[3]
+		if tm := markdownTaskPattern.FindStringSubmatch(text); tm != nil {
+			checked := tm[1] != " "
+			item.Checked = &checked
+			text = tm[2]
+		}
+		item.Text = markdownInlineToHTML(text)
+		items := stack[len(stack)-1].items
+		*items = append(*items, item)
+		last = item
+	}
+	return block, i - 1
+}
+
+func splitMarkdownTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '|' {
+			cell.WriteString(`\|`)
+			i++
+			continue
+		}
+		if line[i] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[i])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// markdownLinesToHTML 合并段落中的多行，行之间保留换行，与常见中文编辑器的处理一致
+func markdownLinesToHTML(lines []string) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		// 行尾的反斜杠是Markdown的硬换行标记
+		if strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) {
+			line = strings.TrimSuffix(line, `\`)
+		}
+		parts[i] = markdownInlineToHTML(line)
+	}
+	return strings.Join(parts, "<br>")
+}
+
+// markdownInlineToHTML 转换行内的代码、链接、加粗、斜体和删除线，结果经过白名单过滤
+func markdownInlineToHTML(text string) string {
+	var placeholders []string
+	hold := func(s string) string {
+		placeholders = append(placeholders, s)
+		return fmt.Sprintf("\x00%d\x00", len(placeholders)-1)
+	}
+
+	text = markdownCodeSpanPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownCodeSpanPattern.FindStringSubmatch(s)
+		if len(m[1]) != len(m[3]) {
+			return s
+		}
+		return hold("<code>" + html.EscapeString(strings.TrimSpace(m[2])) + "</code>")
+	})
+	text = markdownEscapePattern.ReplaceAllStringFunc(text, func(s string) string {
+		return hold(html.EscapeString(s[1:]))
+	})
+	text = markdownInlineImgPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownInlineImgPattern.FindStringSubmatch(s)
+		if !isSafeURL(m[2], false) {
+			return m[1]
+		}
+		return hold(`<a href="`+html.EscapeString(m[2])+`">`) + m[1] + hold("</a>")
+	})
+	text = markdownLinkPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownLinkPattern.FindStringSubmatch(s)
+		if !isSafeURL(m[2], true) {
+			return m[1]
+		}
+		open := `<a href="` + html.EscapeString(m[2]) + `"`
+		if m[3] != "" {
+			open += ` title="` + html.EscapeString(m[3]) + `"`
+		}
+		return hold(open+">") + m[1] + hold("</a>")
+	})
+	text = markdownAutolinkPattern.ReplaceAllStringFunc(text, func(s string) string {
+		url := html.EscapeString(s[1 : len(s)-1])
+		return hold(`<a href="` + url + `">` + url + `</a>`)
+	})
+
+	text = markdownStrongPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownStrongPattern.FindStringSubmatch(s)
+		return "<strong>" + m[1] + m[2] + "</strong>"
+	})
+	text = markdownStrikePattern.ReplaceAllString(text, "<del>$1</del>")
+	text = markdownEmphasisPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownEmphasisPattern.FindStringSubmatch(s)
+		if m[1] != "" {
+			return "<em>" + m[1] + "</em>"
+		}
+		// 下划线斜体匹配时包含了前后的字符
+		start := strings.Index(s, "_")
+		end := strings.LastIndex(s, "_")
+		return s[:start] + "<em>" + m[2] + "</em>" + s[end+1:]
+	})
+
+	// 占位符中可能还有占位符，例如链接文本中的代码
+	for markdownPlaceholderPattern.MatchString(text) {
+		text = markdownPlaceholderPattern.ReplaceAllStringFunc(text, func(s string) string {
+			index, _ := strconv.Atoi(s[1 : len(s)-1])
+			return placeholders[index]
+		})
+	}
+	return sanitizeInlineHTML(text)
+}
+
+func unescapeMarkdown(text string) string {
+	return markdownEscapePattern.ReplaceAllString(text, "$1")
+}
+
+// richTextToMarkdown 将块结构转换为Markdown
+func richTextToMarkdown(document *model.RichTextDocument) string {
+	var b strings.Builder
+	for i, block := range document.Blocks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch block.Type {
+		case model.RichTextBlockHeading:
+			b.WriteString(strings.Repeat("#", block.Level))
+			b.WriteString(" ")
+			b.WriteString(inlineHTMLToMarkdown(block.Text, false))
+			b.WriteString("\n")
+		case model.RichTextBlockParagraph:
+			b.WriteString(inlineHTMLToMarkdown(block.Text, true))
+			b.WriteString("\n")
+		case model.RichTextBlockQuote:
+			for _, line := range strings.Split(inlineHTMLToMarkdown(block.Text, true), "\n") {
+				b.WriteString("> ")
+				b.WriteString(line)
+				b.WriteString("\n")
+			}
+		case model.RichTextBlockList:
+			writeMarkdownListItems(&b, block.Items, block.Ordered, "")
+		case model.RichTextBlockTable:
+			writeMarkdownTable(&b, block)
+		case model.RichTextBlockImage:
+			fmt.Fprintf(&b, "![%s](%s", markdownSpecialChars.Replace(block.Alt), block.URL)
+			if block.Caption != "" {
+				fmt.Fprintf(&b, " %q", block.Caption)
+			}
+			b.WriteString(")\n")
+		case model.RichTextBlockCode:
+			fence := "```"
+			for strings.Contains(block.Code, fence) {
+				fence += "`"
+			}
+			b.WriteString(fence)
+			b.WriteString(block.Language)
+			b.WriteString("\n")
+			b.WriteString(block.Code)
+			b.WriteString("\n")
+			b.WriteString(fence)
+			b.WriteString("\n")
+		case model.RichTextBlockDivider:
+			b.WriteString("---\n")
+		}
+	}
+	return b.String()
+}
+
+func writeMarkdownListItems(b *strings.Builder, items []*model.RichTextListItem, ordered bool, indent string) {
+	for i, item := range items {
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(i+1) + ". "
+		}
+		b.WriteString(indent)
+		b.WriteString(marker)
+		if item.Checked != nil {
+			if *item.Checked {
+				b.WriteString("[x] ")
+			} else {
+				b.WriteString("[ ] ")
+			}
+		}
+		b.WriteString(inlineHTMLToMarkdown(item.Text, false))
+		b.WriteString("\n")
+		writeMarkdownListItems(b, item.Items, ordered, indent+strings.Repeat(" ", len(marker)))
+	}
+}
+
+func writeMarkdownTable(b *strings.Builder, block *model.RichTextBlock) {
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for _, cell := range row {
+			b.WriteString(" ")
+			b.WriteString(inlineHTMLToMarkdown(cell, false))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	rows := block.Rows
+	header := make([]string, len(rows[0]))
+	if block.Header {
+		header, rows = rows[0], rows[1:]
+	}
+	writeRow(header)
+	b.WriteString("|")
+	for range header {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+}
+
+// inlineHTMLToMarkdown 将过滤后的行内HTML转换为Markdown，没有对应语法的标签保留为HTML。
+// multiline为false时换行转换为空格
+func inlineHTMLToMarkdown(s string, multiline bool) string {
+	var b strings.Builder
+	var links []string
+	inCode := false
+
+	for _, token := range tokenizeHTML(s) {
+		switch token.kind {
+		case htmlTextToken:
+			if inCode {
+				b.WriteString(token.text)
+			} else {
+				b.WriteString(markdownSpecialChars.Replace(token.text))
+			}
+		case htmlStartToken:
+			switch token.name {
+			case "strong", "b":
+				b.WriteString("**")
+			case "em", "i":
+				b.WriteString("*")
+			case "del", "s":
+				b.WriteString("~~")
+			case "code":
+				b.WriteString("`")
+				inCode = true
+			case "a":
+				href := ""
+				for _, attr := range token.attrs {
+					if attr[0] == "href" {
+						href = attr[1]
+					}
+				}
+				// 链接地址被过滤掉时只保留文本
+				links = append(links, href)
+				if href != "" {
+					b.WriteString("[")
+				}
+			case "br":
+				if multiline {
+					b.WriteString("  \n")
+				} else {
+					b.WriteString(" ")
+				}
+			case "u", "mark", "sub", "sup":
+				b.WriteString("<" + token.name + ">")
+			}
+		case htmlEndToken:
+			switch token.name {
+			case "strong", "b":
+				b.WriteString("**")
+			case "em", "i":
+				b.WriteString("*")
+			case "del", "s":
+				b.WriteString("~~")
+			case "code":
+				b.WriteString("`")
+				inCode = false
+			case "a":
+				href := ""
+				if len(links) > 0 {
+					href, links = links[len(links)-1], links[:len(links)-1]
+				}
+				if href != "" {
+					b.WriteString("](" + href + ")")
+				}
+			case "u", "mark", "sub", "sup":
+				b.WriteString("</" + token.name + ">")
+			}
+		}
+	}
+	return b.String()
+}
+
+// richTextMarkdown 将Word文档内容转换为Markdown，用于搜索、AI辅助和导出，解析失败时返回原内容
+func richTextMarkdown(content string) string {
+	document, err := parseRichText(content)
+	if err != nil {
+		return content
+	}
+	return richTextToMarkdown(document)
+}
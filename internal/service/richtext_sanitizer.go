@@ -0,0 +1,278 @@
+package service
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// 行内HTML的白名单过滤。只处理内容块中的行内文本，块级结构由JSON表示，不需要完整的HTML解析
+
+// inlineHTMLTags 允许的标签及其允许的属性
+var inlineHTMLTags = map[string][]string{
+	"b":      nil,
+	"strong": nil,
+	"i":      nil,
+	"em":     nil,
+	"u":      nil,
+	"s":      nil,
+	"del":    nil,
+	"code":   nil,
+	"mark":   nil,
+	"sub":    nil,
+	"sup":    nil,
+	"br":     nil,
+	"a":      {"href", "title"},
+}
+
+// droppedHTMLTags 连同内容一并丢弃的标签
+var droppedHTMLTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+	"svg":      true,
+	"math":     true,
+}
+
+var htmlAttributePattern = regexp.MustCompile(`([^\s=/>"']+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+
+type htmlTokenKind int
+
+const (
+	htmlTextToken htmlTokenKind = iota
+	htmlStartToken
+	htmlEndToken
+)
+
+type htmlToken struct {
+	kind        htmlTokenKind
+	name        string
+	attrs       [][2]string
+	text        string // 解码后的文本
+	selfClosing bool
+}
+
+// tokenizeHTML 将HTML片段切分为文本、开始标签和结束标签，注释、DOCTYPE等被忽略
+func tokenizeHTML(s string) []htmlToken {
+	var tokens []htmlToken
+	appendText := func(raw string) {
+		if raw == "" {
+			return
+		}
+		text := html.UnescapeString(raw)
+		if n := len(tokens); n > 0 && tokens[n-1].kind == htmlTextToken {
+			tokens[n-1].text += text
+			return
+		}
+		tokens = append(tokens, htmlToken{kind: htmlTextToken, text: text})
+	}
+
+	i := 0
+	for i < len(s) {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			appendText(s[i:])
+			break
+		}
+		appendText(s[i : i+lt])
+		i += lt
+
+		if strings.HasPrefix(s[i:], "<!--") {
+			end := strings.Index(s[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		j := i + 1
+		closing := j < len(s) && s[j] == '/'
+		if closing {
+			j++
+		}
+		if j >= len(s) || !isASCIILetter(s[j]) {
+			if j < len(s) && (s[j] == '!' || s[j] == '?') {
+				// DOCTYPE、CDATA和处理指令
+				end := strings.IndexByte(s[j:], '>')
+				if end < 0 {
+					break
+				}
+				i = j + end + 1
+				continue
+			}
+			appendText("<")
+			i++
+			continue
+		}
+
+		// 查找标签结尾，跳过引号中的">"
+		k := j
+		var quote byte
+		for ; k < len(s); k++ {
+			c := s[k]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				}
+			} else if c == '"' || c == '\'' {
+				quote = c
+			} else if c == '>' {
+				break
+			}
+		}
+		if k >= len(s) {
+			// 标签没有结束，剩余内容按文本处理
+			appendText(s[i:])
+			break
+		}
+
+		inner := s[j:k]
+		nameEnd := strings.IndexAny(inner, " \t\r\n/")
+		if nameEnd < 0 {
+			nameEnd = len(inner)
+		}
+		token := htmlToken{kind: htmlStartToken, name: strings.ToLower(inner[:nameEnd])}
+		if closing {
+			token.kind = htmlEndToken
+		} else {
+			token.selfClosing = strings.HasSuffix(strings.TrimSpace(inner), "/")
+			for _, m := range htmlAttributePattern.FindAllStringSubmatch(inner[nameEnd:], -1) {
+				value := m[2] + m[3] + m[4]
+				token.attrs = append(token.attrs, [2]string{strings.ToLower(m[1]), html.UnescapeString(value)})
+			}
+		}
+		tokens = append(tokens, token)
+		i = k + 1
+	}
+	return tokens
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// sanitizeInlineHTML 只保留白名单中的标签和属性，链接只允许安全的协议，补全未关闭的标签
+func sanitizeInlineHTML(s string) string {
+	var b strings.Builder
+	var open []string
+	dropping := ""
+
+	for _, token := range tokenizeHTML(s) {
+		if dropping != "" {
+			if token.kind == htmlEndToken && token.name == dropping {
+				dropping = ""
+			}
+			continue
+		}
+
+		switch token.kind {
+		case htmlTextToken:
+			b.WriteString(html.EscapeString(token.text))
+		case htmlStartToken:
+			if droppedHTMLTags[token.name] {
+				if !token.selfClosing {
+					dropping = token.name
+				}
+				continue
+			}
+			allowed, ok := inlineHTMLTags[token.name]
+			if !ok {
+				continue
+			}
+			if token.name == "br" {
+				b.WriteString("<br>")
+				continue
+			}
+
+			b.WriteString("<")
+			b.WriteString(token.name)
+			for _, attr := range token.attrs {
+				if !containsString(allowed, attr[0]) {
+					continue
+				}
+				if attr[0] == "href" && !isSafeURL(attr[1], true) {
+					continue
+				}
+				b.WriteString(" ")
+				b.WriteString(attr[0])
+				b.WriteString(`="`)
+				b.WriteString(html.EscapeString(attr[1]))
+				b.WriteString(`"`)
+			}
+			b.WriteString(">")
+			if token.selfClosing {
+				b.WriteString("</" + token.name + ">")
+				continue
+			}
+			open = append(open, token.name)
+		case htmlEndToken:
+			index := -1
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.name {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				continue
+			}
+			// 交错的标签在此一并关闭
+			for i := len(open) - 1; i >= index; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			open = open[:index]
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// inlineHTMLToText 提取行内HTML中的纯文本，换行标签转换为空格
+func inlineHTMLToText(s string) string {
+	var b strings.Builder
+	dropping := ""
+	for _, token := range tokenizeHTML(s) {
+		if dropping != "" {
+			if token.kind == htmlEndToken && token.name == dropping {
+				dropping = ""
+			}
+			continue
+		}
+		switch {
+		case token.kind == htmlTextToken:
+			b.WriteString(token.text)
+		case token.kind == htmlStartToken && droppedHTMLTags[token.name] && !token.selfClosing:
+			dropping = token.name
+		case token.kind == htmlStartToken && token.name == "br":
+			b.WriteString(" ")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// isSafeURL 只允许http(s)、站内路径，链接还允许mailto和页内锚点
+func isSafeURL(rawURL string, link bool) bool {
+	u := strings.ToLower(strings.TrimSpace(rawURL))
+	switch {
+	case u == "":
+		return false
+	case strings.HasPrefix(u, "http://"), strings.HasPrefix(u, "https://"):
+		return true
+	case strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") && !strings.HasPrefix(u, "/\\"):
+		// 浏览器会把"/\"开头的地址当作"//"处理
+		return true
+	case link && (strings.HasPrefix(u, "mailto:") || strings.HasPrefix(u, "#")):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package service
+
+import "testing"
+
+func TestSanitizeInlineHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"纯文本", "hello", "hello"},
+		{"转义文本中的特殊字符", `a < b & "c"`, "a &lt; b &amp; &#34;c&#34;"},
+		{"保留白名单标签", "<b>bold</b> <em>em</em>", "<b>bold</b> <em>em</em>"},
+		{"标签名不区分大小写", "<STRONG>x</STRONG>", "<strong>x</strong>"},
+		{"去除不在白名单的标签保留内容", `<span class="x">text</span>`, "text"},
+		{"去除不在白名单的属性", `<b onclick="alert(1)" style="color:red">x</b>`, "<b>x</b>"},
+		{"换行标签", "a<br>b<br/>c", "a<br>b<br>c"},
+
+		{"允许http链接", `<a href="https://example.com" title="t">x</a>`, `<a href="https://example.com" title="t">x</a>`},
+		{"允许站内路径", `<a href="/documents/1">x</a>`, `<a href="/documents/1">x</a>`},
+		{"允许mailto和锚点", `<a href="mailto:a@b.c">m</a><a href="#h1">h</a>`, `<a href="mailto:a@b.c">m</a><a href="#h1">h</a>`},
+		{"拒绝javascript协议", `<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"拒绝大小写混合的javascript协议", `<a href=" JaVaScRiPt:alert(1)">x</a>`, "<a>x</a>"},
+		{"拒绝实体编码的协议名", `<a href="&#106;avascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"拒绝实体编码的冒号", `<a href="javascript&colon;alert(1)">x</a>`, "<a>x</a>"},
+		{"拒绝协议名中编码的制表符", `<a href="jav&#x09;ascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"拒绝data协议", `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, "<a>x</a>"},
+		{"拒绝协议相对地址", `<a href="//evil.example">x</a>`, "<a>x</a>"},
+		{"拒绝反斜杠开头的地址", `<a href="/\evil.example">x</a>`, "<a>x</a>"},
+
+		{"未加引号的属性", `<a href=https://example.com title=t>x</a>`, `<a href="https://example.com" title="t">x</a>`},
+		{"未加引号的javascript协议", `<a href=javascript:alert(1)>x</a>`, "<a>x</a>"},
+		{"以斜杠分隔的属性", `<a/href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"以斜杠分隔的事件属性", `<b/onmouseover="alert(1)">x</b>`, "<b>x</b>"},
+		{"单引号属性中的双引号被转义", `<a title='a"b'>x</a>`, `<a title="a&#34;b">x</a>`},
+		{"引号中的右尖括号不结束标签", `<a title="a>b">x</a>`, `<a title="a&gt;b">x</a>`},
+
+		{"补全未关闭的标签", "<b><i>x", "<b><i>x</i></b>"},
+		{"交错的标签一并关闭", "<b><i>x</b>y</i>", "<b><i>x</i></b>y"},
+		{"忽略多余的结束标签", "x</b></script>", "x"},
+		{"未结束的标签按文本处理", `x<a href="y`, "x&lt;a href=&#34;y"},
+		{"孤立的左尖括号", "1 < 2 <3", "1 &lt; 2 &lt;3"},
+		{"忽略注释", "a<!-- <script>alert(1)</script> -->b", "ab"},
+		{"忽略未结束的注释及其后的内容", "a<!-- <b>", "a"},
+		{"忽略DOCTYPE和处理指令", "<!DOCTYPE html><?xml version=\"1.0\"?>x", "x"},
+
+		{"丢弃script及其内容", "a<script>alert(1)</script>b", "ab"},
+		{"丢弃未关闭的script之后的全部内容", "a<script>alert(1)<b>x</b>", "a"},
+		{"丢弃style及其内容", "<style>b{}</style>x", "x"},
+		{"丢弃iframe", `<iframe src="https://example.com"></iframe>x`, "x"},
+		{"丢弃svg及其内容", `<svg onload="alert(1)"><a href="javascript:alert(1)">x</a></svg>y`, "y"},
+		{"丢弃svg中的script", "<svg><script>alert(1)</script></svg>y", "y"},
+		{"丢弃自闭合的svg", "<svg/onload=alert(1)/>y", "y"},
+		{"丢弃math及其内容", `<math><mi xlink:href="javascript:alert(1)">x</mi></math>y`, "y"},
+		{"嵌套的svg只丢弃到第一个结束标签", "<svg><svg></svg><b>x</b></svg>", "<b>x</b>"},
+		{"拆开的script标签只剩转义后的文本", "<scr<script>ipt>alert(1)</script>", "ipt&gt;alert(1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeInlineHTML(tt.input); got != tt.want {
+				t.Errorf("sanitizeInlineHTML(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInlineHTMLToText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"去除标签", "<b>bold</b> <i>it</i>", "bold it"},
+		{"解码实体", "a &amp; b &lt;c&gt;", "a & b <c>"},
+		{"换行转换为空格", "a<br>b", "a b"},
+		{"丢弃script内容", "a<script>alert(1)</script>b", "ab"},
+		{"丢弃svg内容", "a<svg><text>x</text></svg>b", "ab"},
+		{"去除首尾空白", "  <b> x </b>  ", "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inlineHTMLToText(tt.input); got != tt.want {
+				t.Errorf("inlineHTMLToText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSafeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		link bool
+		want bool
+	}{
+		{"https://example.com/a.png", false, true},
+		{"HTTP://EXAMPLE.COM", false, true},
+		{"/uploads/a.png", false, true},
+		{"", true, false},
+		{"//evil.example", true, false},
+		{"/\\evil.example", true, false},
+		{"javascript:alert(1)", true, false},
+		{"vbscript:msgbox(1)", true, false},
+		{"data:image/png;base64,AAAA", false, false},
+		{"mailto:a@b.c", true, true},
+		{"mailto:a@b.c", false, false},
+		{"#section", true, true},
+		{"#section", false, false},
+		{"relative/path", true, false},
+	}
+
+	for _, tt := range tests {
+		if got := isSafeURL(tt.url, tt.link); got != tt.want {
+			t.Errorf("isSafeURL(%q, %v) = %v, want %v", tt.url, tt.link, got, tt.want)
+		}
+	}
+}
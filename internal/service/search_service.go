@@ -4,19 +4,34 @@ import (
 	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/repository"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// folderIndexTTL 文件夹名称索引的有效期，文件夹变更事件会提前使其失效
+const folderIndexTTL = 5 * time.Minute
+
 type SearchService interface {
 	SearchDocuments(userID uint, req *model.SearchRequest) (*model.PaginationResponse, error)
 	SearchAll(userID uint, req *model.SearchRequest) (map[string]interface{}, error)
+	// HandleEvent 事件订阅者，文件夹变更时刷新该用户的文件夹索引
+	HandleEvent(event *model.Event) error
+}
+
+type folderIndex struct {
+	folders  []model.Folder
+	loadedAt time.Time
 }
 
 type searchService struct {
 	documentRepo repository.DocumentRepository
 	folderRepo   repository.FolderRepository
 	logger       *zap.Logger
+
+	mu            sync.RWMutex
+	folderIndexes map[uint]*folderIndex
 }
 
 func NewSearchService(
@@ -24,9 +39,10 @@ func NewSearchService(
 	folderRepo repository.FolderRepository,
 	logger *zap.Logger) SearchService {
 	return &searchService{
-		documentRepo: documentRepo,
-		folderRepo:   folderRepo,
-		logger:       logger,
+		documentRepo:  documentRepo,
+		folderRepo:    folderRepo,
+		logger:        logger,
+		folderIndexes: make(map[uint]*folderIndex),
 	}
 }
 
@@ -98,7 +114,7 @@ func (s *searchService) searchFolders(userID uint, keyword string) ([]model.Fold
 	}
 
 	// 获取用户所有文件夹，然后在应用层过滤
-	folders, err := s.folderRepo.GetUserFolders(userID)
+	folders, err := s.userFolders(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -113,3 +129,36 @@ func (s *searchService) searchFolders(userID uint, keyword string) ([]model.Fold
 
 	return matchedFolders, nil
 }
+
+func (s *searchService) HandleEvent(event *model.Event) error {
+	if event.Folder == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	delete(s.folderIndexes, event.ActorID)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// userFolders 返回用户的文件夹列表，优先使用未过期的索引
+func (s *searchService) userFolders(userID uint) ([]model.Folder, error) {
+	s.mu.RLock()
+	index, ok := s.folderIndexes[userID]
+	s.mu.RUnlock()
+	if ok && time.Since(index.loadedAt) < folderIndexTTL {
+		return index.folders, nil
+	}
+
+	folders, err := s.folderRepo.GetUserFolders(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.folderIndexes[userID] = &folderIndex{folders: folders, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return folders, nil
+}
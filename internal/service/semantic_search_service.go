@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 分块的最大字符数和相邻分块的重叠字符数
+	embeddingChunkSize    = 500
+	embeddingChunkOverlap = 50
+	// 单个文档最多计算的分块数，超出部分不参与语义搜索
+	embeddingMaxChunks = 64
+	// 用户向量索引的有效期，文档变更事件会提前使其失效
+	vectorIndexTTL = 10 * time.Minute
+	// 单次计算向量的超时时间
+	embeddingTimeout = 2 * time.Minute
+	// 补充计算时每批处理的文档数
+	embeddingBackfillBatch = 20
+
+	semanticSearchDefaultLimit = 10
+	// 相关文档的最低相似度
+	relatedDocumentMinScore = 0.3
+)
+
+type SemanticSearchService interface {
+	Enabled() bool
+	// Search 按语义搜索当前用户的文档，每个文档取最匹配的分块计分
+	Search(ctx context.Context, userID uint, req *model.SemanticSearchRequest) ([]model.SemanticSearchResult, error)
+	// Related 返回与文档语义相近的文档，未启用语义搜索或出错时返回空
+	Related(documentID, userID uint, limit int) []model.RelatedDocument
+	// IndexDocument 重新计算文档的向量，标题、内容和模型都未变化时跳过
+	IndexDocument(ctx context.Context, document *model.Document) error
+	// HandleEvent 事件订阅者，文档创建、复制、更新和恢复时计算向量，删除时移除
+	HandleEvent(event *model.Event) error
+	// StartScheduler 定期为还没有向量的文档补充计算，启用语义搜索前创建的文档由此建立索引
+	StartScheduler(interval time.Duration)
+}
+
+type vectorChunk struct {
+	documentID uint
+	content    string
+	vector     []float32
+}
+
+// vectorIndex 单个用户的内存向量索引，检索时逐个计算相似度
+type vectorIndex struct {
+	chunks    []vectorChunk
+	documents map[uint][]float32 // 文档各分块的平均向量，用于计算相关文档
+	loadedAt  time.Time
+}
+
+type semanticSearchService struct {
+	embeddingRepo repository.EmbeddingRepository
+	documentRepo  repository.DocumentRepository
+	provider      EmbeddingProvider
+	logger        *zap.Logger
+
+	mu      sync.RWMutex
+	indexes map[uint]*vectorIndex
+}
+
+// NewSemanticSearchService provider为nil时表示未启用语义搜索
+func NewSemanticSearchService(embeddingRepo repository.EmbeddingRepository, documentRepo repository.DocumentRepository, provider EmbeddingProvider, logger *zap.Logger) SemanticSearchService {
+	return &semanticSearchService{
+		embeddingRepo: embeddingRepo,
+		documentRepo:  documentRepo,
+		provider:      provider,
+		logger:        logger,
+		indexes:       make(map[uint]*vectorIndex),
+	}
+}
+
+func (s *semanticSearchService) Enabled() bool {
+	return s.provider != nil
+}
+
+func (s *semanticSearchService) Search(ctx context.Context, userID uint, req *model.SemanticSearchRequest) ([]model.SemanticSearchResult, error) {
+	if s.provider == nil {
+		return nil, errors.New("语义搜索未启用")
+	}
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, errors.New("搜索内容不能为空")
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = semanticSearchDefaultLimit
+	}
+
+	vectors, err := s.provider.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("向量服务未返回结果")
+	}
+
+	index, err := s.userIndex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个文档只保留得分最高的分块
+	type match struct {
+		score   float64
+		snippet string
+	}
+	matches := make(map[uint]match)
+	for _, chunk := range index.chunks {
+		score := dotProduct(vectors[0], chunk.vector)
+		if score <= 0 {
+			continue
+		}
+		if best, ok := matches[chunk.documentID]; !ok || score > best.score {
+			matches[chunk.documentID] = match{score: score, snippet: chunk.content}
+		}
+	}
+
+	scores := make(map[uint]float64, len(matches))
+	for id, m := range matches {
+		scores[id] = m.score
+	}
+	// 索引中可能有刚被删除的文档，或按文件夹过滤后数量不足，多取一些候选
+	documents, err := s.loadRanked(userID, scores, limit*3)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.SemanticSearchResult, 0, limit)
+	for _, document := range documents {
+		if req.FolderID != nil && !sameFolder(document.FolderID, req.FolderID) {
+			continue
+		}
+		results = append(results, model.SemanticSearchResult{
+			Document: model.DocumentResponse{
+				ID:        document.ID,
+				Title:     document.Title,
+				Type:      document.Type,
+				Status:    document.Status,
+				FolderID:  document.FolderID,
+				Tags:      document.Tags,
+				Size:      document.Size,
+				ViewCount: document.ViewCount,
+				IsShared:  document.IsShared,
+				CreatedAt: document.CreatedAt,
+				UpdatedAt: document.UpdatedAt,
+			},
+			Score:   scores[document.ID],
+			Snippet: matches[document.ID].snippet,
+		})
+		if len(results) >= limit {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (s *semanticSearchService) Related(documentID, userID uint, limit int) []model.RelatedDocument {
+	if s.provider == nil {
+		return nil
+	}
+
+	index, err := s.userIndex(userID)
+	if err != nil {
+		s.logger.Warn("Failed to load vector index", zap.Uint("user_id", userID), zap.Error(err))
+		return nil
+	}
+	target, ok := index.documents[documentID]
+	if !ok {
+		return nil
+	}
+
+	scores := make(map[uint]float64)
+	for id, vector := range index.documents {
+		if id == documentID {
+			continue
+		}
+		if score := dotProduct(target, vector); score >= relatedDocumentMinScore {
+			scores[id] = score
+		}
+	}
+
+	documents, err := s.loadRanked(userID, scores, limit)
+	if err != nil {
+		s.logger.Warn("Failed to load related documents", zap.Uint("document_id", documentID), zap.Error(err))
+		return nil
+	}
+
+	related := make([]model.RelatedDocument, 0, len(documents))
+	for _, document := range documents {
+		related = append(related, model.RelatedDocument{
+			ID:    document.ID,
+			Title: document.Title,
+			Type:  document.Type,
+			Score: scores[document.ID],
+		})
+	}
+	return related
+}
+
+func (s *semanticSearchService) IndexDocument(ctx context.Context, document *model.Document) error {
+	if s.provider == nil {
+		return nil
+	}
+
+	hash := contentHash(document.Title + "\n" + document.Content)
+	indexedHash, indexedModel, err := s.embeddingRepo.GetIndexState(document.ID)
+	if err != nil {
+		return err
+	}
+	if indexedHash == hash && indexedModel == s.provider.Model() {
+		return nil
+	}
+
+	// 结构化的内容以JSON保存，只对其中的文本建立索引
+	chunks := chunkDocument(document.Title, documentText(document))
+	vectors, err := s.provider.Embed(ctx, chunks)
+	if err != nil {
+		return err
+	}
+	if len(vectors) != len(chunks) {
+		return errors.New("向量数量与分块数量不一致")
+	}
+
+	embeddings := make([]model.DocumentEmbedding, 0, len(chunks))
+	for i, chunk := range chunks {
+		embeddings = append(embeddings, model.DocumentEmbedding{
+			DocumentID:  document.ID,
+			UserID:      document.UserID,
+			ChunkIndex:  i,
+			Content:     chunk,
+			ContentHash: hash,
+			Model:       s.provider.Model(),
+			Dimensions:  len(vectors[i]),
+			Vector:      encodeVector(vectors[i]),
+		})
+	}
+	if err := s.embeddingRepo.ReplaceForDocument(document.ID, embeddings); err != nil {
+		return err
+	}
+	s.invalidate(document.UserID)
+
+	s.logger.Debug("Document embeddings updated",
+		zap.Uint("document_id", document.ID),
+		zap.Int("chunks", len(chunks)))
+	return nil
+}
+
+func (s *semanticSearchService) HandleEvent(event *model.Event) error {
+	if s.provider == nil || event.Document == nil {
+		return nil
+	}
+
+	switch event.Type {
+	case model.EventDocumentCreated, model.EventDocumentCopied, model.EventDocumentUpdated, model.EventDocumentRestored:
+		document, err := s.documentRepo.GetByIDAndUserID(event.Document.ID, event.ActorID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 事件处理前文档已被删除
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), embeddingTimeout)
+		defer cancel()
+		return s.IndexDocument(ctx, document)
+	case model.EventDocumentDeleted:
+		if err := s.embeddingRepo.DeleteByDocumentID(event.Document.ID); err != nil {
+			return err
+		}
+		s.invalidate(event.ActorID)
+	}
+	return nil
+}
+
+func (s *semanticSearchService) StartScheduler(interval time.Duration) {
+	if s.provider == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.backfill()
+			<-ticker.C
+		}
+	}()
+}
+
+// backfill 分批计算缺少向量的文档，遇到错误时停止，等待下次执行
+func (s *semanticSearchService) backfill() {
+	indexed := 0
+	for {
+		documents, err := s.embeddingRepo.ListUnindexedDocuments(s.provider.Model(), embeddingBackfillBatch)
+		if err != nil {
+			s.logger.Error("Failed to list unindexed documents", zap.Error(err))
+			return
+		}
+
+		for i := range documents {
+			ctx, cancel := context.WithTimeout(context.Background(), embeddingTimeout)
+			err := s.IndexDocument(ctx, &documents[i])
+			cancel()
+			if err != nil {
+				s.logger.Error("Failed to index document",
+					zap.Uint("document_id", documents[i].ID),
+					zap.Error(err))
+				return
+			}
+			indexed++
+		}
+
+		if len(documents) < embeddingBackfillBatch {
+			break
+		}
+	}
+
+	if indexed > 0 {
+		s.logger.Info("Document embeddings backfilled", zap.Int("count", indexed))
+	}
+}
+
+// loadRanked 按得分从高到低取前limit个仍然存在的文档
+func (s *semanticSearchService) loadRanked(userID uint, scores map[uint]float64, limit int) ([]model.Document, error) {
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	documents, err := s.documentRepo.GetByIDs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]model.Document, len(documents))
+	for _, document := range documents {
+		byID[document.ID] = document
+	}
+	ranked := make([]model.Document, 0, len(documents))
+	for _, id := range ids {
+		if document, ok := byID[id]; ok {
+			ranked = append(ranked, document)
+		}
+	}
+	return ranked, nil
+}
+
+// userIndex 返回用户的向量索引，优先使用未过期的缓存
+func (s *semanticSearchService) userIndex(userID uint) (*vectorIndex, error) {
+	s.mu.RLock()
+	index, ok := s.indexes[userID]
+	s.mu.RUnlock()
+	if ok && time.Since(index.loadedAt) < vectorIndexTTL {
+		return index, nil
+	}
+
+	embeddings, err := s.embeddingRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	index = &vectorIndex{
+		chunks:    make([]vectorChunk, 0, len(embeddings)),
+		documents: make(map[uint][]float32),
+		loadedAt:  time.Now(),
+	}
+	counts := make(map[uint]int)
+	for _, embedding := range embeddings {
+		// 更换模型后旧向量与查询向量不可比较，等待重新计算
+		if embedding.Model != s.provider.Model() {
+			continue
+		}
+		vector := decodeVector(embedding.Vector)
+		index.chunks = append(index.chunks, vectorChunk{
+			documentID: embedding.DocumentID,
+			content:    embedding.Content,
+			vector:     vector,
+		})
+
+		sum, ok := index.documents[embedding.DocumentID]
+		if !ok {
+			sum = make([]float32, len(vector))
+			index.documents[embedding.DocumentID] = sum
+		}
+		if len(sum) == len(vector) {
+			for i, v := range vector {
+				sum[i] += v
+			}
+			counts[embedding.DocumentID]++
+		}
+	}
+	for id, sum := range index.documents {
+		if counts[id] > 0 {
+			normalizeVector(sum)
+		}
+	}
+
+	s.mu.Lock()
+	// 顺带清理过期的索引，避免map持续增长
+	if len(s.indexes) > 1000 {
+		for id, cached := range s.indexes {
+			if time.Since(cached.loadedAt) >= vectorIndexTTL {
+				delete(s.indexes, id)
+			}
+		}
+	}
+	s.indexes[userID] = index
+	s.mu.Unlock()
+
+	return index, nil
+}
+
+func (s *semanticSearchService) invalidate(userID uint) {
+	s.mu.Lock()
+	delete(s.indexes, userID)
+	s.mu.Unlock()
+}
+
+// chunkDocument 按段落切分文档内容，段落过长时按固定长度切分并保留少量重叠，标题并入第一个分块
+func chunkDocument(title, content string) []string {
+	var chunks []string
+	var current []rune
+
+	flush := func() {
+		if text := strings.TrimSpace(string(current)); text != "" {
+			chunks = append(chunks, text)
+		}
+		current = current[:0]
+	}
+
+	current = append(current, []rune(title)...)
+	for _, paragraph := range strings.Split(content, "\n") {
+		runes := []rune(strings.TrimSpace(paragraph))
+		if len(runes) == 0 {
+			continue
+		}
+		if len(current)+len(runes)+1 > embeddingChunkSize {
+			flush()
+		}
+		for len(runes) > embeddingChunkSize {
+			current = append(current, runes[:embeddingChunkSize]...)
+			flush()
+			runes = runes[embeddingChunkSize-embeddingChunkOverlap:]
+		}
+		if len(current) > 0 {
+			current = append(current, '\n')
+		}
+		current = append(current, runes...)
+		if len(chunks) >= embeddingMaxChunks {
+			break
+		}
+	}
+	flush()
+
+	if len(chunks) > embeddingMaxChunks {
+		chunks = chunks[:embeddingMaxChunks]
+	}
+	if len(chunks) == 0 {
+		// 标题和内容都为空时仍保存一个分块，避免被反复当作未索引的文档
+		chunks = append(chunks, title)
+	}
+	return chunks
+}
+
+func dotProduct(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func encodeVector(vector []float32) []byte {
+	data := make([]byte, len(vector)*4)
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+	return data
+}
+
+func decodeVector(data []byte) []float32 {
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector
+}
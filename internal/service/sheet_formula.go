@@ -0,0 +1,1280 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"wz-wenzhan-backend/internal/model"
+)
+
+const (
+	sheetMaxColumns = 16384
+	sheetMaxRows    = 1048576
+)
+
+// 公式错误值，与Excel保持一致，循环引用也按#REF!处理
+const (
+	formulaErrDiv0  = "#DIV/0!"
+	formulaErrValue = "#VALUE!"
+	formulaErrRef   = "#REF!"
+	formulaErrName  = "#NAME?"
+	formulaErrNum   = "#NUM!"
+)
+
+// columnName 列序号转列名，1对应A
+func columnName(col int) string {
+	var name []byte
+	for col > 0 {
+		col--
+		name = append([]byte{byte('A' + col%26)}, name...)
+		col /= 26
+	}
+	return string(name)
+}
+
+// columnIndex 列名转列序号，无效时返回0
+func columnIndex(name string) int {
+	if name == "" || len(name) > 3 {
+		return 0
+	}
+	col := 0
+	for _, r := range strings.ToUpper(name) {
+		if r < 'A' || r > 'Z' {
+			return 0
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	if col > sheetMaxColumns {
+		return 0
+	}
+	return col
+}
+
+func cellName(col, row int) string {
+	return columnName(col) + strconv.Itoa(row)
+}
+
+// parseCellRef 解析A1形式的单元格引用，允许$绝对引用标记
+func parseCellRef(ref string) (col, row int, ok bool) {
+	ref = strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(ref)), "$", "")
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		i++
+	}
+	if i == 0 || i == len(ref) {
+		return 0, 0, false
+	}
+	col = columnIndex(ref[:i])
+	row, err := strconv.Atoi(ref[i:])
+	if col == 0 || err != nil || row < 1 || row > sheetMaxRows || ref[i] == '0' {
+		return 0, 0, false
+	}
+	return col, row, true
+}
+
+// cellArea 单元格区域，起止均包含在内
+type cellArea struct {
+	col1, row1, col2, row2 int
+}
+
+func (a cellArea) contains(col, row int) bool {
+	return col >= a.col1 && col <= a.col2 && row >= a.row1 && row <= a.row2
+}
+
+// parseCellArea 解析"A1:C3"或单个单元格
+func parseCellArea(ref string) (cellArea, bool) {
+	start, end := ref, ref
+	if i := strings.Index(ref, ":"); i >= 0 {
+		start, end = ref[:i], ref[i+1:]
+	}
+	c1, r1, ok1 := parseCellRef(start)
+	c2, r2, ok2 := parseCellRef(end)
+	if !ok1 || !ok2 {
+		return cellArea{}, false
+	}
+	if c1 > c2 {
+		c1, c2 = c2, c1
+	}
+	if r1 > r2 {
+		r1, r2 = r2, r1
+	}
+	return cellArea{col1: c1, row1: r1, col2: c2, row2: r2}, true
+}
+
+// formulaValue 公式计算过程中的值
+type formulaValue struct {
+	kind valueKind
+	num  float64
+	str  string // 字符串值或错误值
+	b    bool
+}
+
+type valueKind int
+
+const (
+	valueEmpty valueKind = iota
+	valueNumber
+	valueString
+	valueBool
+	valueError
+)
+
+func numberValue(n float64) formulaValue {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return errorValue(formulaErrNum)
+	}
+	return formulaValue{kind: valueNumber, num: n}
+}
+
+func stringValue(s string) formulaValue { return formulaValue{kind: valueString, str: s} }
+func boolValue(b bool) formulaValue     { return formulaValue{kind: valueBool, b: b} }
+func errorValue(e string) formulaValue  { return formulaValue{kind: valueError, str: e} }
+
+// cellInputValue 单元格输入值转换为计算用的值
+func cellInputValue(v interface{}) formulaValue {
+	switch v := v.(type) {
+	case float64:
+		return numberValue(v)
+	case bool:
+		return boolValue(v)
+	case string:
+		if v == "" {
+			return formulaValue{}
+		}
+		return stringValue(v)
+	default:
+		return formulaValue{}
+	}
+}
+
+// toResult 计算结果转换为保存在单元格中的值
+func (v formulaValue) toResult() interface{} {
+	switch v.kind {
+	case valueNumber:
+		return roundSignificant(v.num)
+	case valueString, valueError:
+		return v.str
+	case valueBool:
+		return v.b
+	default:
+		return float64(0)
+	}
+}
+
+// roundSignificant 保留15位有效数字，消除0.1+0.2之类的浮点误差
+func roundSignificant(n float64) float64 {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(n, 'g', 15, 64), 64)
+	if err != nil {
+		return n
+	}
+	return rounded
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(roundSignificant(n), 'f', -1, 64)
+}
+
+func (v formulaValue) toNumber() (float64, *formulaValue) {
+	switch v.kind {
+	case valueNumber:
+		return v.num, nil
+	case valueBool:
+		if v.b {
+			return 1, nil
+		}
+		return 0, nil
+	case valueEmpty:
+		return 0, nil
+	case valueString:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v.str), 64)
+		if err != nil {
+			e := errorValue(formulaErrValue)
+			return 0, &e
+		}
+		return n, nil
+	default:
+		return 0, &v
+	}
+}
+
+func (v formulaValue) toText() string {
+	switch v.kind {
+	case valueNumber:
+		return formatNumber(v.num)
+	case valueBool:
+		if v.b {
+			return "TRUE"
+		}
+		return "FALSE"
+	case valueString, valueError:
+		return v.str
+	default:
+		return ""
+	}
+}
+
+func (v formulaValue) toBool() (bool, *formulaValue) {
+	switch v.kind {
+	case valueBool:
+		return v.b, nil
+	case valueNumber:
+		return v.num != 0, nil
+	case valueEmpty:
+		return false, nil
+	case valueString:
+		switch strings.ToUpper(v.str) {
+		case "TRUE":
+			return true, nil
+		case "FALSE":
+			return false, nil
+		}
+		e := errorValue(formulaErrValue)
+		return false, &e
+	default:
+		return false, &v
+	}
+}
+
+// 语法树
+
+type formulaNode interface{}
+
+type literalNode struct{ value formulaValue }
+
+type refNode struct {
+	sheet    string // 为空表示当前工作表
+	col, row int
+}
+
+type rangeNode struct {
+	sheet string
+	area  cellArea
+}
+
+type unaryNode struct {
+	op string
+	x  formulaNode
+}
+
+type binaryNode struct {
+	op   string
+	l, r formulaNode
+}
+
+type callNode struct {
+	name string
+	args []formulaNode
+}
+
+// 词法分析
+
+type formulaTokenKind int
+
+const (
+	tokEOF formulaTokenKind = iota
+	tokNumber
+	tokString
+	tokName  // 函数名、单元格引用、TRUE/FALSE
+	tokSheet // 工作表前缀，如Sheet1!或'预算 表'!
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokColon
+)
+
+type formulaToken struct {
+	kind formulaTokenKind
+	text string
+}
+
+func tokenizeFormula(formula string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	runes := []rune(formula)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, errors.New("字符串缺少结束引号")
+				}
+				if runes[i] == '"' {
+					// 两个连续引号表示一个引号字符
+					if i+1 < len(runes) && runes[i+1] == '"' {
+						sb.WriteRune('"')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, formulaToken{kind: tokString, text: sb.String()})
+		case r == '\'':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, errors.New("工作表名称缺少结束引号")
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) || runes[i] != '!' {
+				return nil, errors.New("工作表名称后缺少!")
+			}
+			i++
+			tokens = append(tokens, formulaToken{kind: tokSheet, text: sb.String()})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// 科学计数法
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					i = j
+					for i < len(runes) && unicode.IsDigit(runes[i]) {
+						i++
+					}
+				}
+			}
+			// 数字开头的工作表名或整行引用不支持，按名称处理以便报错
+			if i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == '_') {
+				for i < len(runes) && isFormulaNameRune(runes[i]) {
+					i++
+				}
+				tokens = append(tokens, formulaToken{kind: tokName, text: string(runes[start:i])})
+				continue
+			}
+			tokens = append(tokens, formulaToken{kind: tokNumber, text: string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			start := i
+			for i < len(runes) && isFormulaNameRune(runes[i]) {
+				i++
+			}
+			name := string(runes[start:i])
+			if i < len(runes) && runes[i] == '!' {
+				i++
+				tokens = append(tokens, formulaToken{kind: tokSheet, text: name})
+				continue
+			}
+			tokens = append(tokens, formulaToken{kind: tokName, text: name})
+		case r == '(':
+			tokens = append(tokens, formulaToken{kind: tokLParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, formulaToken{kind: tokRParen, text: ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, formulaToken{kind: tokComma, text: ","})
+			i++
+		case r == ':':
+			tokens = append(tokens, formulaToken{kind: tokColon, text: ":"})
+			i++
+		case r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				op += string(runes[i+1])
+				i++
+			}
+			tokens = append(tokens, formulaToken{kind: tokOp, text: op})
+			i++
+		case strings.ContainsRune("+-*/^&=%", r):
+			tokens = append(tokens, formulaToken{kind: tokOp, text: string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("无法识别的字符: %c", r)
+		}
+	}
+	return append(tokens, formulaToken{kind: tokEOF}), nil
+}
+
+func isFormulaNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '$'
+}
+
+// 语法分析，优先级从低到高：比较、连接(&)、加减、乘除、乘方、负号、百分号
+
+type formulaParser struct {
+	tokens []formulaToken
+	pos    int
+}
+
+func parseFormula(formula string) (formulaNode, error) {
+	tokens, err := tokenizeFormula(formula)
+	if err != nil {
+		return nil, err
+	}
+	p := &formulaParser{tokens: tokens}
+	node, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("多余的内容: %s", p.peek().text)
+	}
+	return node, nil
+}
+
+func (p *formulaParser) peek() formulaToken {
+	return p.tokens[p.pos]
+}
+
+func (p *formulaParser) next() formulaToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *formulaParser) parseBinary(ops []string, operand func() (formulaNode, error)) (formulaNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || !containsString(ops, t.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, l: left, r: right}
+	}
+}
+
+func (p *formulaParser) parseComparison() (formulaNode, error) {
+	return p.parseBinary([]string{"=", "<>", "<", ">", "<=", ">="}, p.parseConcat)
+}
+
+func (p *formulaParser) parseConcat() (formulaNode, error) {
+	return p.parseBinary([]string{"&"}, p.parseAdditive)
+}
+
+func (p *formulaParser) parseAdditive() (formulaNode, error) {
+	return p.parseBinary([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *formulaParser) parseMultiplicative() (formulaNode, error) {
+	return p.parseBinary([]string{"*", "/"}, p.parsePower)
+}
+
+func (p *formulaParser) parsePower() (formulaNode, error) {
+	return p.parseBinary([]string{"^"}, p.parseUnary)
+}
+
+func (p *formulaParser) parseUnary() (formulaNode, error) {
+	t := p.peek()
+	if t.kind == tokOp && (t.text == "-" || t.text == "+") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.text, x: x}, nil
+	}
+	return p.parsePercent()
+}
+
+func (p *formulaParser) parsePercent() (formulaNode, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "%" {
+		p.next()
+		x = &unaryNode{op: "%", x: x}
+	}
+	return x, nil
+}
+
+func (p *formulaParser) parsePrimary() (formulaNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字: %s", t.text)
+		}
+		return &literalNode{value: numberValue(n)}, nil
+	case tokString:
+		return &literalNode{value: stringValue(t.text)}, nil
+	case tokLParen:
+		x, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, errors.New("缺少右括号")
+		}
+		return x, nil
+	case tokSheet:
+		name := p.next()
+		if name.kind != tokName {
+			return nil, fmt.Errorf("工作表%s后缺少单元格引用", t.text)
+		}
+		return p.parseReference(t.text, name.text)
+	case tokName:
+		if p.peek().kind == tokLParen {
+			p.next()
+			return p.parseCall(strings.ToUpper(t.text))
+		}
+		switch strings.ToUpper(t.text) {
+		case "TRUE":
+			return &literalNode{value: boolValue(true)}, nil
+		case "FALSE":
+			return &literalNode{value: boolValue(false)}, nil
+		}
+		return p.parseReference("", t.text)
+	case tokEOF:
+		return nil, errors.New("公式不完整")
+	default:
+		return nil, fmt.Errorf("意外的符号: %s", t.text)
+	}
+}
+
+func (p *formulaParser) parseReference(sheet, ref string) (formulaNode, error) {
+	col, row, ok := parseCellRef(ref)
+	if !ok {
+		// 未知名称在计算时返回#NAME?，与Excel一致
+		return &literalNode{value: errorValue(formulaErrName)}, nil
+	}
+	if p.peek().kind != tokColon {
+		return &refNode{sheet: sheet, col: col, row: row}, nil
+	}
+	p.next()
+	end := p.next()
+	if end.kind != tokName {
+		return nil, errors.New("区域引用不完整")
+	}
+	area, ok := parseCellArea(ref + ":" + end.text)
+	if !ok {
+		return nil, fmt.Errorf("无效的区域引用: %s:%s", ref, end.text)
+	}
+	return &rangeNode{sheet: sheet, area: area}, nil
+}
+
+func (p *formulaParser) parseCall(name string) (formulaNode, error) {
+	call := &callNode{name: name}
+	if p.peek().kind == tokRParen {
+		p.next()
+		return call, nil
+	}
+	for {
+		arg, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		switch p.next().kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return call, nil
+		default:
+			return nil, fmt.Errorf("函数%s缺少右括号", name)
+		}
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// 计算
+
+// workbookEvaluator 计算整个工作簿的公式，每个公式单元格只计算一次
+type workbookEvaluator struct {
+	sheets  map[string]*model.Sheet // 键为小写的工作表名称
+	results map[string]formulaValue
+	pending map[string]bool // 正在计算的单元格，用于发现循环引用
+}
+
+// evaluateWorkbook 计算所有公式并写入Result，非公式单元格的Result被清空
+func evaluateWorkbook(workbook *model.Workbook) {
+	e := &workbookEvaluator{
+		sheets:  make(map[string]*model.Sheet, len(workbook.Sheets)),
+		results: make(map[string]formulaValue),
+		pending: make(map[string]bool),
+	}
+	for i := range workbook.Sheets {
+		e.sheets[strings.ToLower(workbook.Sheets[i].Name)] = &workbook.Sheets[i]
+	}
+
+	for i := range workbook.Sheets {
+		sheet := &workbook.Sheets[i]
+		for ref, cell := range sheet.Cells {
+			if cell.Formula == "" {
+				cell.Result = nil
+				continue
+			}
+			col, row, _ := parseCellRef(ref)
+			cell.Result = e.cellValue(sheet, col, row).toResult()
+		}
+	}
+}
+
+func (e *workbookEvaluator) cellValue(sheet *model.Sheet, col, row int) formulaValue {
+	ref := cellName(col, row)
+	cell := sheet.Cells[ref]
+	if cell == nil {
+		return formulaValue{}
+	}
+	if cell.Formula == "" {
+		return cellInputValue(cell.Value)
+	}
+
+	key := strings.ToLower(sheet.Name) + "!" + ref
+	if value, ok := e.results[key]; ok {
+		return value
+	}
+	if e.pending[key] {
+		return errorValue(formulaErrRef)
+	}
+
+	e.pending[key] = true
+	var value formulaValue
+	node, err := parseFormula(cell.Formula)
+	if err != nil {
+		value = errorValue(formulaErrName)
+	} else {
+		value = e.eval(node, sheet)
+		// 引用空单元格的结果按0处理
+		if value.kind == valueEmpty {
+			value = numberValue(0)
+		}
+	}
+	delete(e.pending, key)
+	e.results[key] = value
+	return value
+}
+
+func (e *workbookEvaluator) resolveSheet(name string, current *model.Sheet) *model.Sheet {
+	if name == "" {
+		return current
+	}
+	return e.sheets[strings.ToLower(name)]
+}
+
+// rangeValues 返回区域内非空单元格的值，按行、列顺序排列
+func (e *workbookEvaluator) rangeValues(node *rangeNode, current *model.Sheet) ([]formulaValue, *formulaValue) {
+	sheet := e.resolveSheet(node.sheet, current)
+	if sheet == nil {
+		v := errorValue(formulaErrRef)
+		return nil, &v
+	}
+
+	type position struct{ col, row int }
+	var positions []position
+	for ref := range sheet.Cells {
+		col, row, ok := parseCellRef(ref)
+		if ok && node.area.contains(col, row) {
+			positions = append(positions, position{col, row})
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].row != positions[j].row {
+			return positions[i].row < positions[j].row
+		}
+		return positions[i].col < positions[j].col
+	})
+
+	values := make([]formulaValue, 0, len(positions))
+	for _, pos := range positions {
+		if v := e.cellValue(sheet, pos.col, pos.row); v.kind != valueEmpty {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (e *workbookEvaluator) eval(node formulaNode, sheet *model.Sheet) formulaValue {
+	switch n := node.(type) {
+	case *literalNode:
+		return n.value
+	case *refNode:
+		target := e.resolveSheet(n.sheet, sheet)
+		if target == nil {
+			return errorValue(formulaErrRef)
+		}
+		return e.cellValue(target, n.col, n.row)
+	case *rangeNode:
+		// 区域只能作为函数参数使用
+		return errorValue(formulaErrValue)
+	case *unaryNode:
+		x := e.eval(n.x, sheet)
+		num, errVal := x.toNumber()
+		if errVal != nil {
+			return *errVal
+		}
+		switch n.op {
+		case "-":
+			return numberValue(-num)
+		case "%":
+			return numberValue(num / 100)
+		default:
+			return numberValue(num)
+		}
+	case *binaryNode:
+		return e.evalBinary(n, sheet)
+	case *callNode:
+		return e.evalCall(n, sheet)
+	default:
+		return errorValue(formulaErrValue)
+	}
+}
+
+func (e *workbookEvaluator) evalBinary(n *binaryNode, sheet *model.Sheet) formulaValue {
+	l := e.eval(n.l, sheet)
+	if l.kind == valueError {
+		return l
+	}
+	r := e.eval(n.r, sheet)
+	if r.kind == valueError {
+		return r
+	}
+
+	switch n.op {
+	case "&":
+		return stringValue(l.toText() + r.toText())
+	case "=", "<>", "<", ">", "<=", ">=":
+		c := compareValues(l, r)
+		switch n.op {
+		case "=":
+			return boolValue(c == 0)
+		case "<>":
+			return boolValue(c != 0)
+		case "<":
+			return boolValue(c < 0)
+		case ">":
+			return boolValue(c > 0)
+		case "<=":
+			return boolValue(c <= 0)
+		default:
+			return boolValue(c >= 0)
+		}
+	}
+
+	a, errVal := l.toNumber()
+	if errVal != nil {
+		return *errVal
+	}
+	b, errVal := r.toNumber()
+	if errVal != nil {
+		return *errVal
+	}
+	switch n.op {
+	case "+":
+		return numberValue(a + b)
+	case "-":
+		return numberValue(a - b)
+	case "*":
+		return numberValue(a * b)
+	case "/":
+		if b == 0 {
+			return errorValue(formulaErrDiv0)
+		}
+		return numberValue(a / b)
+	case "^":
+		return numberValue(math.Pow(a, b))
+	default:
+		return errorValue(formulaErrValue)
+	}
+}
+
+// compareValues 比较规则与Excel一致：数字 < 文本 < 布尔值，文本比较不区分大小写，空单元格按对方类型取0或空字符串
+func compareValues(l, r formulaValue) int {
+	if l.kind == valueEmpty {
+		l = emptyAs(r.kind)
+	}
+	if r.kind == valueEmpty {
+		r = emptyAs(l.kind)
+	}
+	if l.kind != r.kind {
+		if kindOrder(l.kind) < kindOrder(r.kind) {
+			return -1
+		}
+		return 1
+	}
+	switch l.kind {
+	case valueNumber:
+		switch {
+		case l.num < r.num:
+			return -1
+		case l.num > r.num:
+			return 1
+		}
+		return 0
+	case valueBool:
+		switch {
+		case l.b == r.b:
+			return 0
+		case !l.b:
+			return -1
+		}
+		return 1
+	default:
+		return strings.Compare(strings.ToLower(l.str), strings.ToLower(r.str))
+	}
+}
+
+func emptyAs(kind valueKind) formulaValue {
+	switch kind {
+	case valueString:
+		return stringValue("")
+	case valueBool:
+		return boolValue(false)
+	default:
+		return numberValue(0)
+	}
+}
+
+func kindOrder(kind valueKind) int {
+	switch kind {
+	case valueNumber:
+		return 0
+	case valueString:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// collectNumbers 汇总函数的参数：区域中只统计数字，直接传入的参数会转换为数字
+func (e *workbookEvaluator) collectNumbers(args []formulaNode, sheet *model.Sheet) ([]float64, *formulaValue) {
+	var numbers []float64
+	for _, arg := range args {
+		if rn, ok := arg.(*rangeNode); ok {
+			values, errVal := e.rangeValues(rn, sheet)
+			if errVal != nil {
+				return nil, errVal
+			}
+			for _, v := range values {
+				switch v.kind {
+				case valueError:
+					return nil, &v
+				case valueNumber:
+					numbers = append(numbers, v.num)
+				}
+			}
+			continue
+		}
+
+		v := e.eval(arg, sheet)
+		if v.kind == valueEmpty {
+			continue
+		}
+		n, errVal := v.toNumber()
+		if errVal != nil {
+			return nil, errVal
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// collectValues 展开参数中的区域，返回所有非空值
+func (e *workbookEvaluator) collectValues(args []formulaNode, sheet *model.Sheet) ([]formulaValue, *formulaValue) {
+	var values []formulaValue
+	for _, arg := range args {
+		if rn, ok := arg.(*rangeNode); ok {
+			rangeVals, errVal := e.rangeValues(rn, sheet)
+			if errVal != nil {
+				return nil, errVal
+			}
+			values = append(values, rangeVals...)
+			continue
+		}
+		if v := e.eval(arg, sheet); v.kind != valueEmpty {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (e *workbookEvaluator) evalCall(n *callNode, sheet *model.Sheet) formulaValue {
+	argCount := func(min, max int) bool {
+		return len(n.args) >= min && (max < 0 || len(n.args) <= max)
+	}
+	numberArg := func(i int) (float64, *formulaValue) {
+		return e.eval(n.args[i], sheet).toNumber()
+	}
+
+	switch n.name {
+	case "SUM", "AVERAGE", "MIN", "MAX", "COUNT":
+		if !argCount(1, -1) {
+			return errorValue(formulaErrValue)
+		}
+		numbers, errVal := e.collectNumbers(n.args, sheet)
+		if errVal != nil {
+			return *errVal
+		}
+		return aggregate(n.name, numbers)
+	case "COUNTA":
+		values, errVal := e.collectValues(n.args, sheet)
+		if errVal != nil {
+			return *errVal
+		}
+		return numberValue(float64(len(values)))
+	case "IF":
+		if !argCount(2, 3) {
+			return errorValue(formulaErrValue)
+		}
+		cond, errVal := e.eval(n.args[0], sheet).toBool()
+		if errVal != nil {
+			return *errVal
+		}
+		if cond {
+			return e.eval(n.args[1], sheet)
+		}
+		if len(n.args) == 3 {
+			return e.eval(n.args[2], sheet)
+		}
+		return boolValue(false)
+	case "IFERROR":
+		if !argCount(2, 2) {
+			return errorValue(formulaErrValue)
+		}
+		if v := e.eval(n.args[0], sheet); v.kind != valueError {
+			return v
+		}
+		return e.eval(n.args[1], sheet)
+	case "AND", "OR":
+		if !argCount(1, -1) {
+			return errorValue(formulaErrValue)
+		}
+		values, errVal := e.collectValues(n.args, sheet)
+		if errVal != nil {
+			return *errVal
+		}
+		result := n.name == "AND"
+		for _, v := range values {
+			b, errVal := v.toBool()
+			if errVal != nil {
+				return *errVal
+			}
+			if n.name == "AND" {
+				result = result && b
+			} else {
+				result = result || b
+			}
+		}
+		return boolValue(result)
+	case "NOT":
+		if !argCount(1, 1) {
+			return errorValue(formulaErrValue)
+		}
+		b, errVal := e.eval(n.args[0], sheet).toBool()
+		if errVal != nil {
+			return *errVal
+		}
+		return boolValue(!b)
+	case "ROUND":
+		if !argCount(1, 2) {
+			return errorValue(formulaErrValue)
+		}
+		x, errVal := numberArg(0)
+		if errVal != nil {
+			return *errVal
+		}
+		digits := 0.0
+		if len(n.args) == 2 {
+			if digits, errVal = numberArg(1); errVal != nil {
+				return *errVal
+			}
+		}
+		scale := math.Pow(10, math.Trunc(digits))
+		// 远离零的方向舍入，与Excel一致
+		return numberValue(math.Round(roundSignificant(x*scale)) / scale)
+	case "ABS", "INT", "SQRT":
+		if !argCount(1, 1) {
+			return errorValue(formulaErrValue)
+		}
+		x, errVal := numberArg(0)
+		if errVal != nil {
+			return *errVal
+		}
+		switch n.name {
+		case "ABS":
+			return numberValue(math.Abs(x))
+		case "INT":
+			return numberValue(math.Floor(x))
+		default:
+			if x < 0 {
+				return errorValue(formulaErrNum)
+			}
+			return numberValue(math.Sqrt(x))
+		}
+	case "MOD":
+		if !argCount(2, 2) {
+			return errorValue(formulaErrValue)
+		}
+		a, errVal := numberArg(0)
+		if errVal != nil {
+			return *errVal
+		}
+		b, errVal := numberArg(1)
+		if errVal != nil {
+			return *errVal
+		}
+		if b == 0 {
+			return errorValue(formulaErrDiv0)
+		}
+		// 结果与除数同号
+		return numberValue(a - b*math.Floor(a/b))
+	case "CONCAT", "CONCATENATE":
+		values, errVal := e.collectValues(n.args, sheet)
+		if errVal != nil {
+			return *errVal
+		}
+		var sb strings.Builder
+		for _, v := range values {
+			if v.kind == valueError {
+				return v
+			}
+			sb.WriteString(v.toText())
+		}
+		return stringValue(sb.String())
+	case "LEN", "UPPER", "LOWER", "TRIM":
+		if !argCount(1, 1) {
+			return errorValue(formulaErrValue)
+		}
+		v := e.eval(n.args[0], sheet)
+		if v.kind == valueError {
+			return v
+		}
+		s := v.toText()
+		switch n.name {
+		case "LEN":
+			return numberValue(float64(len([]rune(s))))
+		case "UPPER":
+			return stringValue(strings.ToUpper(s))
+		case "LOWER":
+			return stringValue(strings.ToLower(s))
+		default:
+			return stringValue(strings.Join(strings.Fields(s), " "))
+		}
+	case "LEFT", "RIGHT":
+		if !argCount(1, 2) {
+			return errorValue(formulaErrValue)
+		}
+		v := e.eval(n.args[0], sheet)
+		if v.kind == valueError {
+			return v
+		}
+		count := 1.0
+		if len(n.args) == 2 {
+			var errVal *formulaValue
+			if count, errVal = numberArg(1); errVal != nil {
+				return *errVal
+			}
+		}
+		if count < 0 {
+			return errorValue(formulaErrValue)
+		}
+		runes := []rune(v.toText())
+		size := int(count)
+		if size > len(runes) {
+			size = len(runes)
+		}
+		if n.name == "LEFT" {
+			return stringValue(string(runes[:size]))
+		}
+		return stringValue(string(runes[len(runes)-size:]))
+	case "SUMIF", "COUNTIF":
+		return e.evalConditional(n, sheet)
+	default:
+		return errorValue(formulaErrName)
+	}
+}
+
+func aggregate(name string, numbers []float64) formulaValue {
+	switch name {
+	case "COUNT":
+		return numberValue(float64(len(numbers)))
+	case "SUM", "AVERAGE":
+		sum := 0.0
+		for _, n := range numbers {
+			sum += n
+		}
+		if name == "SUM" {
+			return numberValue(sum)
+		}
+		if len(numbers) == 0 {
+			return errorValue(formulaErrDiv0)
+		}
+		return numberValue(sum / float64(len(numbers)))
+	default:
+		if len(numbers) == 0 {
+			return numberValue(0)
+		}
+		result := numbers[0]
+		for _, n := range numbers[1:] {
+			if (name == "MIN" && n < result) || (name == "MAX" && n > result) {
+				result = n
+			}
+		}
+		return numberValue(result)
+	}
+}
+
+// evalConditional 计算SUMIF(range, criteria, [sum_range])和COUNTIF(range, criteria)，
+// 条件支持">10"、"<>完成"等比较运算，不支持通配符
+func (e *workbookEvaluator) evalConditional(n *callNode, sheet *model.Sheet) formulaValue {
+	maxArgs := 2
+	if n.name == "SUMIF" {
+		maxArgs = 3
+	}
+	if len(n.args) < 2 || len(n.args) > maxArgs {
+		return errorValue(formulaErrValue)
+	}
+	criteriaRange, ok := n.args[0].(*rangeNode)
+	if !ok {
+		return errorValue(formulaErrValue)
+	}
+	target := e.resolveSheet(criteriaRange.sheet, sheet)
+	if target == nil {
+		return errorValue(formulaErrRef)
+	}
+	criteria := e.eval(n.args[1], sheet)
+	if criteria.kind == valueError {
+		return criteria
+	}
+
+	// 求和区域与条件区域左上角对齐、大小相同
+	sumSheet, sumArea := target, criteriaRange.area
+	if len(n.args) == 3 {
+		sumRange, ok := n.args[2].(*rangeNode)
+		if !ok {
+			return errorValue(formulaErrValue)
+		}
+		if sumSheet = e.resolveSheet(sumRange.sheet, sheet); sumSheet == nil {
+			return errorValue(formulaErrRef)
+		}
+		sumArea = sumRange.area
+	}
+
+	area := criteriaRange.area
+	count, sum := 0, 0.0
+	for ref := range target.Cells {
+		col, row, ok := parseCellRef(ref)
+		if !ok || !area.contains(col, row) {
+			continue
+		}
+		if !matchCriteria(e.cellValue(target, col, row), criteria) {
+			continue
+		}
+		count++
+		if n.name == "SUMIF" {
+			v := e.cellValue(sumSheet, sumArea.col1+col-area.col1, sumArea.row1+row-area.row1)
+			if v.kind == valueError {
+				return v
+			}
+			if v.kind == valueNumber {
+				sum += v.num
+			}
+		}
+	}
+
+	// 空单元格不在Cells中，条件为空值时需要补上区域内的空单元格
+	if criteria.kind == valueEmpty || (criteria.kind == valueString && criteria.str == "") {
+		size := (area.col2 - area.col1 + 1) * (area.row2 - area.row1 + 1)
+		if n.name == "COUNTIF" {
+			count = size - e.countFilled(target, area)
+		}
+	}
+
+	if n.name == "COUNTIF" {
+		return numberValue(float64(count))
+	}
+	return numberValue(sum)
+}
+
+func (e *workbookEvaluator) countFilled(sheet *model.Sheet, area cellArea) int {
+	filled := 0
+	for ref := range sheet.Cells {
+		col, row, ok := parseCellRef(ref)
+		if ok && area.contains(col, row) && e.cellValue(sheet, col, row).kind != valueEmpty {
+			filled++
+		}
+	}
+	return filled
+}
+
+func matchCriteria(value, criteria formulaValue) bool {
+	op := "="
+	operand := criteria
+	if criteria.kind == valueString {
+		text := criteria.str
+		for _, candidate := range []string{"<>", ">=", "<=", "=", ">", "<"} {
+			if strings.HasPrefix(text, candidate) {
+				op = candidate
+				text = text[len(candidate):]
+				break
+			}
+		}
+		if n, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			operand = numberValue(n)
+		} else {
+			operand = stringValue(text)
+		}
+	}
+
+	if value.kind == valueEmpty {
+		return false
+	}
+	// 数字条件只匹配数字，文本条件只匹配文本，<>除外
+	if value.kind != operand.kind && op != "<>" {
+		return false
+	}
+	c := compareValues(value, operand)
+	switch op {
+	case "<>":
+		return c != 0
+	case ">=":
+		return c >= 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case "<":
+		return c < 0
+	default:
+		return c == 0
+	}
+}
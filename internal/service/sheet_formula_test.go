@@ -0,0 +1,223 @@
+package service
+
+import (
+	"testing"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// testSheet 按引用创建工作表，以"="开头的字符串为公式
+func testSheet(name string, cells map[string]interface{}) model.Sheet {
+	sheet := model.Sheet{Name: name, Cells: make(map[string]*model.Cell, len(cells))}
+	for ref, v := range cells {
+		if s, ok := v.(string); ok && len(s) > 1 && s[0] == '=' {
+			sheet.Cells[ref] = &model.Cell{Formula: s[1:]}
+			continue
+		}
+		sheet.Cells[ref] = &model.Cell{Value: v}
+	}
+	return sheet
+}
+
+func TestEvaluateWorkbook(t *testing.T) {
+	data := map[string]interface{}{
+		"A1": 1.0, "A2": 2.0, "A3": 3.0, "A4": "text", "A5": true,
+		"B1": 10.0, "B2": 20.0, "B3": 30.0,
+		"C1": "完成", "C2": "进行中", "C3": "完成",
+	}
+
+	tests := []struct {
+		name    string
+		formula string
+		want    interface{}
+	}{
+		{"四则运算优先级", "1+2*3-4/2", 5.0},
+		{"括号", "(1+2)*3", 9.0},
+		{"乘方和负号", "-2^2", 4.0},
+		{"百分号", "50%*B1", 5.0},
+		{"浮点误差", "0.1+0.2", 0.3},
+		{"字符串连接", `"a"&A1&TRUE`, "a1TRUE"},
+		{"比较", "A2>A1", true},
+		{"字符串比较不区分大小写", `"ABC"="abc"`, true},
+		{"除以零", "A1/0", formulaErrDiv0},
+		{"文本参与运算", "A4+1", formulaErrValue},
+		{"未知函数", "FOO(1)", formulaErrName},
+		{"未知名称", "foo+1", formulaErrName},
+		{"引用空单元格", "Z99", 0.0},
+		{"引用空单元格参与运算", "Z99+1", 1.0},
+
+		{"SUM区域", "SUM(A1:A5)", 6.0},
+		{"SUM多个区域和参数", "SUM(A1:A3,B1:B3,4)", 70.0},
+		{"SUM反向区域", "SUM(A3:A1)", 6.0},
+		{"SUM整列区域中的空单元格", "SUM(A1:A100)", 6.0},
+		{"AVERAGE只统计数字", "AVERAGE(A1:A5)", 2.0},
+		{"AVERAGE空区域", "AVERAGE(D1:D5)", formulaErrDiv0},
+		{"MIN和MAX", "MAX(A1:B3)-MIN(A1:B3)", 29.0},
+		{"MIN空区域", "MIN(D1:D5)", 0.0},
+		{"COUNT只统计数字", "COUNT(A1:A5)", 3.0},
+		{"COUNTA统计非空单元格", "COUNTA(A1:A5)", 5.0},
+		{"区域不能直接参与运算", "A1:A3+1", formulaErrValue},
+		{"SUMIF比较条件", `SUMIF(B1:B3,">15")`, 50.0},
+		{"SUMIF按条件区域对求和区域求和", `SUMIF(C1:C3,"完成",B1:B3)`, 40.0},
+		{"COUNTIF文本条件", `COUNTIF(C1:C3,"完成")`, 2.0},
+		{"COUNTIF不等于条件", `COUNTIF(C1:C3,"<>完成")`, 1.0},
+		{"COUNTIF空条件统计空单元格", `COUNTIF(D1:D4,"")`, 4.0},
+		{"SUMIF条件区域不是区域", `SUMIF(B1,">0")`, formulaErrValue},
+
+		{"IF", `IF(A1>0,"正","负")`, "正"},
+		{"IF省略第三个参数", "IF(A1>5,1)", false},
+		{"IFERROR", "IFERROR(1/0,-1)", -1.0},
+		{"AND和OR", "AND(A1>0,OR(A2>5,A5))", true},
+		{"ROUND", "ROUND(2.345,2)", 2.35},
+		{"MOD除以零", "MOD(5,0)", formulaErrDiv0},
+		{"SQRT负数", "SQRT(-1)", formulaErrNum},
+		{"文本函数", `UPPER(LEFT("hello",2))&LEN("你好")`, "HE2"},
+		{"函数参数个数错误", "IF(1)", formulaErrValue},
+
+		{"跨工作表引用", "Data!A1*2", 84.0},
+		{"带空格的工作表名称", "'预算 表'!A1+1", 8.0},
+		{"跨工作表区域", "SUM(Data!A1:A2)", 50.0},
+		{"不存在的工作表", "Missing!A1", formulaErrRef},
+		{"语法错误", "SUM(1,", formulaErrName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := testSheet("Sheet1", data)
+			sheet.Cells["Z1"] = &model.Cell{Formula: tt.formula}
+			workbook := &model.Workbook{Sheets: []model.Sheet{
+				sheet,
+				testSheet("Data", map[string]interface{}{"A1": 42.0, "A2": 8.0}),
+				testSheet("预算 表", map[string]interface{}{"A1": 7.0}),
+			}}
+
+			evaluateWorkbook(workbook)
+			if got := workbook.Sheets[0].Cells["Z1"].Result; got != tt.want {
+				t.Errorf("=%s got %#v, want %#v", tt.formula, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateWorkbookCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells map[string]interface{}
+		want  map[string]interface{}
+	}{
+		{
+			name:  "引用自身",
+			cells: map[string]interface{}{"A1": "=A1+1"},
+			want:  map[string]interface{}{"A1": formulaErrRef},
+		},
+		{
+			name:  "两个单元格互相引用",
+			cells: map[string]interface{}{"A1": "=B1", "B1": "=A1"},
+			want:  map[string]interface{}{"A1": formulaErrRef, "B1": formulaErrRef},
+		},
+		{
+			name:  "间接循环引用",
+			cells: map[string]interface{}{"A1": "=B1+1", "B1": "=C1+1", "C1": "=A1+1"},
+			want:  map[string]interface{}{"A1": formulaErrRef, "B1": formulaErrRef, "C1": formulaErrRef},
+		},
+		{
+			name:  "区域包含公式所在的单元格",
+			cells: map[string]interface{}{"A1": 1.0, "A2": 2.0, "A3": "=SUM(A1:A3)"},
+			want:  map[string]interface{}{"A3": formulaErrRef},
+		},
+		{
+			name:  "SUMIF的求和区域包含公式所在的单元格",
+			cells: map[string]interface{}{"A1": 1.0, "A2": 2.0, "B1": 1.0, "B2": "=SUMIF(A1:A2,\">0\",B1:B2)"},
+			want:  map[string]interface{}{"B2": formulaErrRef},
+		},
+		{
+			name:  "IFERROR可以捕获循环引用",
+			cells: map[string]interface{}{"A1": "=IFERROR(A1,0)"},
+			want:  map[string]interface{}{"A1": 0.0},
+		},
+		{
+			name:  "链式引用不是循环",
+			cells: map[string]interface{}{"A1": 1.0, "A2": "=A1+1", "A3": "=A2+1", "A4": "=SUM(A1:A3)"},
+			want:  map[string]interface{}{"A2": 2.0, "A3": 3.0, "A4": 6.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workbook := &model.Workbook{Sheets: []model.Sheet{testSheet("Sheet1", tt.cells)}}
+			evaluateWorkbook(workbook)
+			for ref, want := range tt.want {
+				if got := workbook.Sheets[0].Cells[ref].Result; got != want {
+					t.Errorf("%s got %#v, want %#v", ref, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateWorkbookClearsInputResults(t *testing.T) {
+	workbook := &model.Workbook{Sheets: []model.Sheet{testSheet("Sheet1", map[string]interface{}{"A1": 1.0})}}
+	workbook.Sheets[0].Cells["A1"].Result = 99.0
+
+	evaluateWorkbook(workbook)
+	if got := workbook.Sheets[0].Cells["A1"].Result; got != nil {
+		t.Errorf("result of input cell = %#v, want nil", got)
+	}
+}
+
+func TestCellReferences(t *testing.T) {
+	names := []struct {
+		col  int
+		name string
+	}{
+		{1, "A"}, {26, "Z"}, {27, "AA"}, {52, "AZ"}, {703, "AAA"}, {sheetMaxColumns, "XFD"},
+	}
+	for _, tt := range names {
+		if got := columnName(tt.col); got != tt.name {
+			t.Errorf("columnName(%d) = %q, want %q", tt.col, got, tt.name)
+		}
+		if got := columnIndex(tt.name); got != tt.col {
+			t.Errorf("columnIndex(%q) = %d, want %d", tt.name, got, tt.col)
+		}
+	}
+
+	refs := []struct {
+		ref      string
+		col, row int
+		ok       bool
+	}{
+		{"A1", 1, 1, true},
+		{"b12", 2, 12, true},
+		{"XFD1048576", sheetMaxColumns, sheetMaxRows, true},
+		{"XFE1", 0, 0, false},
+		{"A1048577", 0, 0, false},
+		{"A0", 0, 0, false},
+		{"A01", 0, 0, false},
+		{"1A", 0, 0, false},
+		{"A", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range refs {
+		col, row, ok := parseCellRef(tt.ref)
+		if ok != tt.ok || (ok && (col != tt.col || row != tt.row)) {
+			t.Errorf("parseCellRef(%q) = %d, %d, %v, want %d, %d, %v", tt.ref, col, row, ok, tt.col, tt.row, tt.ok)
+		}
+	}
+
+	areas := []struct {
+		ref  string
+		want cellArea
+		ok   bool
+	}{
+		{"A1:B2", cellArea{col1: 1, row1: 1, col2: 2, row2: 2}, true},
+		{"B2:A1", cellArea{col1: 1, row1: 1, col2: 2, row2: 2}, true},
+		{"C3", cellArea{col1: 3, row1: 3, col2: 3, row2: 3}, true},
+		{"A1:", cellArea{}, false},
+		{"A1:B2:C3", cellArea{}, false},
+	}
+	for _, tt := range areas {
+		got, ok := parseCellArea(tt.ref)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseCellArea(%q) = %+v, %v, want %+v, %v", tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
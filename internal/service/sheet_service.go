@@ -0,0 +1,504 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	sheetMaxSheets     = 50
+	sheetMaxCells      = 200000
+	sheetMaxTextLength = 32767
+	sheetMaxFormula    = 8192
+	// 单次修改的最大单元格数
+	sheetMaxPatchCells = 10000
+	// 导入文件的最大大小
+	xlsxMaxImportSize = 20 << 20
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type SheetService interface {
+	// Get 返回表格文档的结构化内容，公式结果为最近一次保存时计算的值
+	Get(documentID, userID uint) (*model.Workbook, error)
+	// PatchCells 按区域修改单元格，返回值或计算结果发生变化的单元格
+	PatchCells(documentID, userID uint, req *model.PatchSheetCellsRequest, client *model.ClientInfo) (*model.PatchSheetCellsResponse, error)
+	// ExportXLSX 导出为xlsx文件，返回文件名
+	ExportXLSX(documentID, userID uint, w io.Writer) (string, error)
+	// ImportXLSX 从xlsx文件创建表格文档
+	ImportXLSX(userID uint, filename string, r io.Reader, req *model.ImportXLSXRequest, client *model.ClientInfo) (*model.Document, error)
+}
+
+type sheetService struct {
+	documentRepo    repository.DocumentRepository
+	documentService DocumentService
+	logger          *zap.Logger
+
+	// 同一实例内同一文档的修改依次执行，减少冲突；多个实例之间由文档版本检测并发修改
+	locks *documentLocks
+}
+
+func NewSheetService(documentRepo repository.DocumentRepository, documentService DocumentService, logger *zap.Logger) SheetService {
+	return &sheetService{
+		documentRepo:    documentRepo,
+		documentService: documentService,
+		logger:          logger,
+		locks:           newDocumentLocks(),
+	}
+}
+
+func (s *sheetService) Get(documentID, userID uint) (*model.Workbook, error) {
+	document, err := s.getSheetDocument(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return parseWorkbook(document.Content)
+}
+
+func (s *sheetService) PatchCells(documentID, userID uint, req *model.PatchSheetCellsRequest, client *model.ClientInfo) (*model.PatchSheetCellsResponse, error) {
+	unlock := s.locks.lock(documentID)
+	defer unlock()
+
+	document, err := s.getSheetDocument(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	workbook, err := parseWorkbook(document.Content)
+	if err != nil {
+		return nil, err
+	}
+	before := snapshotCells(workbook)
+
+	total := 0
+	for _, update := range req.Updates {
+		sheet := findSheet(workbook, update.Sheet)
+		if sheet == nil {
+			return nil, fmt.Errorf("工作表不存在：%s", update.Sheet)
+		}
+		area, ok := parseCellArea(update.Range)
+		if !ok {
+			return nil, fmt.Errorf("无效的单元格区域：%s", update.Range)
+		}
+		rows, cols := area.row2-area.row1+1, area.col2-area.col1+1
+		if len(update.Cells) != rows {
+			return nil, fmt.Errorf("区域%s需要%d行数据", update.Range, rows)
+		}
+		total += rows * cols
+		if total > sheetMaxPatchCells {
+			return nil, fmt.Errorf("单次最多修改%d个单元格", sheetMaxPatchCells)
+		}
+
+		for i, row := range update.Cells {
+			if len(row) != cols {
+				return nil, fmt.Errorf("区域%s每行需要%d个单元格", update.Range, cols)
+			}
+			for j, cell := range row {
+				ref := cellName(area.col1+j, area.row1+i)
+				if cell == nil {
+					delete(sheet.Cells, ref)
+					continue
+				}
+				sheet.Cells[ref] = cell
+			}
+		}
+	}
+
+	content, err := encodeWorkbook(workbook)
+	if err != nil {
+		return nil, err
+	}
+	err = s.documentService.Update(documentID, userID, &model.UpdateDocumentRequest{Content: content, Version: &document.Version}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 重新解析保存后的内容，以服务端校验和计算后的结果为准
+	saved, err := parseWorkbook(updated.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PatchSheetCellsResponse{
+		Changed:   diffCells(before, snapshotCells(saved)),
+		UpdatedAt: updated.UpdatedAt,
+	}, nil
+}
+
+func (s *sheetService) ExportXLSX(documentID, userID uint, w io.Writer) (string, error) {
+	document, err := s.getSheetDocument(documentID, userID)
+	if err != nil {
+		return "", err
+	}
+	workbook, err := parseWorkbook(document.Content)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeXLSX(w, workbook); err != nil {
+		return "", err
+	}
+	return document.Title + ".xlsx", nil
+}
+
+func (s *sheetService) ImportXLSX(userID uint, filename string, r io.Reader, req *model.ImportXLSXRequest, client *model.ClientInfo) (*model.Document, error) {
+	if !strings.EqualFold(filepath.Ext(filename), ".xlsx") {
+		return nil, errors.New("只支持导入xlsx格式的文件")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, xlsxMaxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > xlsxMaxImportSize {
+		return nil, fmt.Errorf("文件不能超过%dMB", xlsxMaxImportSize>>20)
+	}
+
+	workbook, err := readXLSX(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	content, err := encodeWorkbook(workbook)
+	if err != nil {
+		return nil, err
+	}
+
+	title := req.Title
+	if title == "" {
+		title = truncateRunes(strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)), 250)
+	}
+
+	document, err := s.documentService.Create(userID, &model.CreateDocumentRequest{
+		Title:    title,
+		Content:  content,
+		Type:     model.DocumentTypeExcel,
+		FolderID: req.FolderID,
+	}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Workbook imported",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.Int("sheets", len(workbook.Sheets)))
+
+	return document, nil
+}
+
+func (s *sheetService) getSheetDocument(documentID, userID uint) (*model.Document, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+	if document.Type != model.DocumentTypeExcel {
+		return nil, errors.New("该文档不是表格")
+	}
+	return document, nil
+}
+
+// legacyWorkbook 早期模板使用的格式，rows为二维字符串数组，以"="开头的为公式
+type legacyWorkbook struct {
+	Sheets []struct {
+		model.Sheet
+		Rows [][]interface{} `json:"rows"`
+	} `json:"sheets"`
+}
+
+// parseWorkbook 解析并校验表格内容，计算所有公式。内容为空时返回只有一个空工作表的工作簿
+func parseWorkbook(content string) (*model.Workbook, error) {
+	if strings.TrimSpace(content) == "" {
+		return &model.Workbook{Sheets: []model.Sheet{{Name: "Sheet1", Cells: map[string]*model.Cell{}}}}, nil
+	}
+
+	var legacy legacyWorkbook
+	if err := json.Unmarshal([]byte(content), &legacy); err != nil {
+		return nil, errors.New("表格内容不是有效的JSON")
+	}
+
+	workbook := &model.Workbook{Sheets: make([]model.Sheet, 0, len(legacy.Sheets))}
+	for _, ls := range legacy.Sheets {
+		sheet := ls.Sheet
+		if len(sheet.Cells) == 0 && len(ls.Rows) > 0 {
+			sheet.Cells = make(map[string]*model.Cell)
+			for i, row := range ls.Rows {
+				for j, value := range row {
+					cell := &model.Cell{Value: value}
+					if text, ok := value.(string); ok && strings.HasPrefix(text, "=") {
+						cell = &model.Cell{Formula: text}
+					}
+					sheet.Cells[cellName(j+1, i+1)] = cell
+				}
+			}
+		}
+		workbook.Sheets = append(workbook.Sheets, sheet)
+	}
+
+	if err := validateWorkbook(workbook); err != nil {
+		return nil, err
+	}
+	evaluateWorkbook(workbook)
+	return workbook, nil
+}
+
+// normalizeSheetContent 校验表格内容并重新序列化，保存表格文档前调用
+func normalizeSheetContent(content string) (string, error) {
+	workbook, err := parseWorkbook(content)
+	if err != nil {
+		return "", err
+	}
+	return encodeWorkbook(workbook)
+}
+
+func encodeWorkbook(workbook *model.Workbook) (string, error) {
+	data, err := json.Marshal(workbook)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// validateWorkbook 校验工作簿结构，同时统一单元格引用的写法并去掉空单元格
+func validateWorkbook(workbook *model.Workbook) error {
+	if len(workbook.Sheets) == 0 {
+		return errors.New("表格至少需要一个工作表")
+	}
+	if len(workbook.Sheets) > sheetMaxSheets {
+		return fmt.Errorf("工作表不能超过%d个", sheetMaxSheets)
+	}
+
+	names := make(map[string]bool)
+	totalCells := 0
+	for i := range workbook.Sheets {
+		sheet := &workbook.Sheets[i]
+		name := strings.TrimSpace(sheet.Name)
+		if name == "" || utf8.RuneCountInString(name) > 31 || strings.ContainsAny(name, `[]:*?/\`) {
+			return fmt.Errorf("无效的工作表名称：%s", sheet.Name)
+		}
+		if names[strings.ToLower(name)] {
+			return fmt.Errorf("工作表名称重复：%s", name)
+		}
+		names[strings.ToLower(name)] = true
+		sheet.Name = name
+
+		cells := make(map[string]*model.Cell, len(sheet.Cells))
+		for ref, cell := range sheet.Cells {
+			col, row, ok := parseCellRef(ref)
+			if !ok {
+				return fmt.Errorf("工作表%s中的单元格引用无效：%s", name, ref)
+			}
+			if cell == nil {
+				continue
+			}
+			normalized := cellName(col, row)
+			if _, exists := cells[normalized]; exists {
+				return fmt.Errorf("工作表%s中的单元格重复：%s", name, normalized)
+			}
+			if err := validateCell(cell); err != nil {
+				return fmt.Errorf("工作表%s单元格%s：%s", name, normalized, err.Error())
+			}
+			if cell.Value == nil && cell.Formula == "" && cell.Style == nil {
+				continue
+			}
+			cells[normalized] = cell
+		}
+		sheet.Cells = cells
+		totalCells += len(cells)
+		if totalCells > sheetMaxCells {
+			return fmt.Errorf("单元格总数不能超过%d个", sheetMaxCells)
+		}
+
+		for column, width := range sheet.ColumnWidths {
+			if columnIndex(column) == 0 || width <= 0 || width > 255 {
+				return fmt.Errorf("工作表%s中的列宽无效：%s", name, column)
+			}
+		}
+		for _, merge := range sheet.Merges {
+			if _, ok := parseCellArea(merge); !ok || !strings.Contains(merge, ":") {
+				return fmt.Errorf("工作表%s中的合并区域无效：%s", name, merge)
+			}
+		}
+		if sheet.FrozenRows < 0 || sheet.FrozenRows > sheetMaxRows || sheet.FrozenCols < 0 || sheet.FrozenCols > sheetMaxColumns {
+			return fmt.Errorf("工作表%s的冻结行列无效", name)
+		}
+	}
+	return nil
+}
+
+func validateCell(cell *model.Cell) error {
+	switch v := cell.Value.(type) {
+	case nil, bool:
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("数值无效")
+		}
+	case string:
+		if utf8.RuneCountInString(v) > sheetMaxTextLength {
+			return fmt.Errorf("文本不能超过%d个字符", sheetMaxTextLength)
+		}
+		if v == "" {
+			cell.Value = nil
+		}
+	default:
+		return errors.New("值只能是文本、数字或布尔值")
+	}
+
+	cell.Formula = strings.TrimPrefix(strings.TrimSpace(cell.Formula), "=")
+	if cell.Formula != "" {
+		if len(cell.Formula) > sheetMaxFormula {
+			return fmt.Errorf("公式不能超过%d个字符", sheetMaxFormula)
+		}
+		if _, err := parseFormula(cell.Formula); err != nil {
+			return fmt.Errorf("公式有误：%s", err.Error())
+		}
+		// 公式单元格的值由计算得出
+		cell.Value = nil
+	}
+	cell.Result = nil
+
+	if style := cell.Style; style != nil {
+		if style.Color != "" && !colorPattern.MatchString(style.Color) {
+			return errors.New("文字颜色格式应为#RRGGBB")
+		}
+		if style.Background != "" && !colorPattern.MatchString(style.Background) {
+			return errors.New("背景色格式应为#RRGGBB")
+		}
+		if style.Align != "" && style.Align != "left" && style.Align != "center" && style.Align != "right" {
+			return errors.New("对齐方式只能是left、center或right")
+		}
+		if style.FontSize < 0 || style.FontSize > 409 {
+			return errors.New("字号无效")
+		}
+		if len(style.NumberFormat) > 100 {
+			return errors.New("数字格式过长")
+		}
+		if *style == (model.CellStyle{}) {
+			cell.Style = nil
+		}
+	}
+	return nil
+}
+
+func findSheet(workbook *model.Workbook, name string) *model.Sheet {
+	for i := range workbook.Sheets {
+		if strings.EqualFold(workbook.Sheets[i].Name, name) {
+			return &workbook.Sheets[i]
+		}
+	}
+	return nil
+}
+
+// snapshotCells 以"工作表!引用"为键保存单元格的JSON，用于比较修改前后的差异
+func snapshotCells(workbook *model.Workbook) map[string]string {
+	cells := make(map[string]string)
+	for _, sheet := range workbook.Sheets {
+		for ref, cell := range sheet.Cells {
+			data, _ := json.Marshal(cell)
+			cells[sheet.Name+"!"+ref] = string(data)
+		}
+	}
+	return cells
+}
+
+func diffCells(before, after map[string]string) []model.SheetChangedCell {
+	changed := make([]model.SheetChangedCell, 0)
+	for key, data := range after {
+		if before[key] == data {
+			continue
+		}
+		var cell model.Cell
+		_ = json.Unmarshal([]byte(data), &cell)
+		changed = append(changed, newChangedCell(key, &cell))
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, newChangedCell(key, nil))
+		}
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		if changed[i].Sheet != changed[j].Sheet {
+			return changed[i].Sheet < changed[j].Sheet
+		}
+		ci, ri, _ := parseCellRef(changed[i].Ref)
+		cj, rj, _ := parseCellRef(changed[j].Ref)
+		if ri != rj {
+			return ri < rj
+		}
+		return ci < cj
+	})
+	return changed
+}
+
+func newChangedCell(key string, cell *model.Cell) model.SheetChangedCell {
+	i := strings.LastIndex(key, "!")
+	return model.SheetChangedCell{Sheet: key[:i], Ref: key[i+1:], Cell: cell}
+}
+
+// workbookText 提取表格中的文本，用于语义搜索
+func workbookText(content string) string {
+	workbook, err := parseWorkbook(content)
+	if err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, sheet := range workbook.Sheets {
+		sb.WriteString(sheet.Name)
+		sb.WriteString("\n")
+
+		refs := make([]string, 0, len(sheet.Cells))
+		for ref := range sheet.Cells {
+			refs = append(refs, ref)
+		}
+		sort.Slice(refs, func(i, j int) bool {
+			ci, ri, _ := parseCellRef(refs[i])
+			cj, rj, _ := parseCellRef(refs[j])
+			if ri != rj {
+				return ri < rj
+			}
+			return ci < cj
+		})
+
+		lastRow := 0
+		for _, ref := range refs {
+			cell := sheet.Cells[ref]
+			value := cell.Value
+			if cell.Formula != "" {
+				value = cell.Result
+			}
+			text := cellInputValue(value).toText()
+			if text == "" {
+				continue
+			}
+			_, row, _ := parseCellRef(ref)
+			if lastRow != 0 {
+				if row != lastRow {
+					sb.WriteString("\n")
+				} else {
+					sb.WriteString("\t")
+				}
+			}
+			lastRow = row
+			sb.WriteString(text)
+		}
+		sb.WriteString("\n\n")
+	}
+	return sb.String()
+}
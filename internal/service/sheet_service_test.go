@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"wz-wenzhan-backend/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// testAttachmentService 文档没有附件
+type testAttachmentService struct {
+	AttachmentService
+}
+
+func (s *testAttachmentService) TotalSize(documentID uint) (int64, error) {
+	return 0, nil
+}
+
+// concurrentDocumentRepo 在第writeAt次读取文档后模拟其他实例保存了文档
+type concurrentDocumentRepo struct {
+	*testDocumentRepo
+	reads   int
+	writeAt int
+}
+
+func (r *concurrentDocumentRepo) GetByIDAndUserID(id, userID uint) (*model.Document, error) {
+	document, err := r.testDocumentRepo.GetByIDAndUserID(id, userID)
+	r.reads++
+	if r.reads == r.writeAt {
+		r.document.Content = `{"sheets":[{"name":"Sheet1","cells":{"B1":{"value":"其他用户"}}}]}`
+		r.document.Version++
+	}
+	return document, err
+}
+
+func TestSheetPatchCellsConflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		writeAt int
+		wantErr error
+	}{
+		{"没有并发修改", 0, nil},
+		{"读取表格后被修改", 1, ErrDocumentConflict},
+		{"保存前被修改", 2, ErrDocumentConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := encodeWorkbook(&model.Workbook{Sheets: []model.Sheet{testSheet("Sheet1", map[string]interface{}{"A1": 1.0})}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo := &testDocumentRepo{document: &model.Document{ID: 1, UserID: 2, Type: model.DocumentTypeExcel, Content: content, Version: 3}}
+			concurrent := &concurrentDocumentRepo{testDocumentRepo: repo, writeAt: tt.writeAt}
+			documents := NewDocumentService(concurrent, nil, nil, &testAttachmentService{}, nil, nil, &testEventBus{}, zap.NewNop())
+			s := NewSheetService(concurrent, documents, zap.NewNop())
+
+			_, err = s.PatchCells(1, 2, &model.PatchSheetCellsRequest{Updates: []model.SheetCellUpdate{
+				{Sheet: "Sheet1", Range: "A1", Cells: [][]*model.Cell{{{Value: 2.0}}}},
+			}}, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PatchCells() error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.saved != nil {
+					t.Error("document saved over concurrent change")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PatchCells() error = %v", err)
+			}
+			if repo.saved == nil || repo.saved.Version != 4 {
+				t.Fatalf("saved = %+v, want version 4", repo.saved)
+			}
+		})
+	}
+}
+
+func TestDocumentServiceUpdateVersion(t *testing.T) {
+	version := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name    string
+		version *int64
+		wantErr error
+	}{
+		{"不检查版本", nil, nil},
+		{"版本一致", version(3), nil},
+		{"版本已过期", version(2), ErrDocumentConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &testDocumentRepo{document: &model.Document{ID: 1, UserID: 2, Title: "标题", Version: 3}}
+			service := NewDocumentService(repo, nil, nil, nil, nil, nil, &testEventBus{}, zap.NewNop())
+
+			err := service.Update(1, 2, &model.UpdateDocumentRequest{Title: "新标题", Version: tt.version}, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.saved != nil {
+					t.Error("document saved with stale version")
+				}
+				return
+			}
+			if repo.saved.Version != 4 {
+				t.Errorf("version = %d, want 4", repo.saved.Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,588 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// 站点地址只允许小写字母、数字和连字符
+var siteSlugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// 页面地址由标题生成，最多保留的字符数
+const pageSlugMaxRunes = 80
+
+var errSiteNotFound = errors.New("站点不存在")
+
+type SiteService interface {
+	// Create 将文件夹发布为公开站点，每个文件夹只能发布一个站点
+	Create(userID uint, req *model.CreateSiteRequest) (*model.SiteResponse, error)
+	List(userID uint) ([]model.SiteResponse, error)
+	Update(id, userID uint, req *model.UpdateSiteRequest) (*model.SiteResponse, error)
+	Delete(id, userID uint) error
+	// ListPages 获取站点当前公开的文档及其地址
+	ListPages(id, userID uint) ([]model.SitePageResponse, error)
+	// GetSite 按地址获取公开站点，包含导航和全部公开文档
+	GetSite(slug string) (*model.SiteView, error)
+	GetPage(slug, pageSlug string) (*model.SitePageView, error)
+	// Feed 获取站点最近更新的文档及摘要，用于订阅源
+	Feed(slug string) (*model.SiteView, []model.SiteFeedEntry, error)
+	// HandleEvent 文档发布或移入站点时生成页面地址，文件夹删除后取消发布其站点
+	HandleEvent(event *model.Event) error
+}
+
+type siteService struct {
+	siteRepo     repository.SiteRepository
+	folderRepo   repository.FolderRepository
+	documentRepo repository.DocumentRepository
+	userRepo     repository.UserRepository
+	locks        *documentLocks // 按站点ID加锁，避免并发生成重复的页面地址
+	logger       *zap.Logger
+}
+
+func NewSiteService(siteRepo repository.SiteRepository, folderRepo repository.FolderRepository, documentRepo repository.DocumentRepository, userRepo repository.UserRepository, logger *zap.Logger) SiteService {
+	return &siteService{
+		siteRepo:     siteRepo,
+		folderRepo:   folderRepo,
+		documentRepo: documentRepo,
+		userRepo:     userRepo,
+		locks:        newDocumentLocks(),
+		logger:       logger,
+	}
+}
+
+// siteContent 站点中公开的文件夹和文档
+type siteContent struct {
+	site      *model.PublicSite
+	author    string
+	children  map[uint][]model.Folder // 按上级文件夹ID分组，同级按名称排序
+	documents []model.Document
+	slugs     map[uint]string // 文档ID到页面地址
+}
+
+func (s *siteService) Create(userID uint, req *model.CreateSiteRequest) (*model.SiteResponse, error) {
+	folder, err := s.folderRepo.GetByIDAndUserID(req.FolderID, userID)
+	if err != nil {
+		return nil, errors.New("文件夹不存在或无权访问")
+	}
+	if _, err := s.siteRepo.GetByFolderID(folder.ID); err == nil {
+		return nil, errors.New("该文件夹已发布为站点")
+	}
+
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if slug == "" {
+		if slug, err = s.generateSiteSlug(folder.Name); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkSiteSlug(slug, 0); err != nil {
+		return nil, err
+	}
+
+	site := &model.PublicSite{
+		UserID:      userID,
+		FolderID:    folder.ID,
+		Slug:        slug,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if site.Title == "" {
+		site.Title = folder.Name
+	}
+	if err := s.siteRepo.Create(site); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Site published",
+		zap.Uint("user_id", userID),
+		zap.Uint("site_id", site.ID),
+		zap.Uint("folder_id", folder.ID),
+		zap.String("slug", site.Slug))
+
+	if _, err := s.loadContent(site, true); err != nil {
+		s.logger.Error("Failed to create site pages", zap.Uint("site_id", site.ID), zap.Error(err))
+	}
+
+	response := s.toResponse(site, folder.Name)
+	return &response, nil
+}
+
+func (s *siteService) List(userID uint) ([]model.SiteResponse, error) {
+	sites, err := s.siteRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := s.folderRepo.GetUserFolders(userID)
+	if err != nil {
+		return nil, err
+	}
+	folderNames := make(map[uint]string, len(folders))
+	for _, folder := range folders {
+		folderNames[folder.ID] = folder.Name
+	}
+
+	responses := make([]model.SiteResponse, 0, len(sites))
+	for i := range sites {
+		responses = append(responses, s.toResponse(&sites[i], folderNames[sites[i].FolderID]))
+	}
+	return responses, nil
+}
+
+func (s *siteService) Update(id, userID uint, req *model.UpdateSiteRequest) (*model.SiteResponse, error) {
+	site, err := s.siteRepo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, errSiteNotFound
+	}
+
+	if slug := strings.ToLower(strings.TrimSpace(req.Slug)); slug != "" && slug != site.Slug {
+		if err := s.checkSiteSlug(slug, site.ID); err != nil {
+			return nil, err
+		}
+		site.Slug = slug
+	}
+	if title := strings.TrimSpace(req.Title); title != "" {
+		site.Title = title
+	}
+	if req.Description != nil {
+		site.Description = strings.TrimSpace(*req.Description)
+	}
+	if err := s.siteRepo.Update(site); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Site updated",
+		zap.Uint("user_id", userID),
+		zap.Uint("site_id", site.ID))
+
+	folderName := ""
+	if folder, err := s.folderRepo.GetByIDAndUserID(site.FolderID, userID); err == nil {
+		folderName = folder.Name
+	}
+	response := s.toResponse(site, folderName)
+	return &response, nil
+}
+
+func (s *siteService) Delete(id, userID uint) error {
+	site, err := s.siteRepo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return errSiteNotFound
+	}
+
+	if err := s.siteRepo.Delete(site.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Site unpublished",
+		zap.Uint("user_id", userID),
+		zap.Uint("site_id", site.ID))
+
+	return nil
+}
+
+func (s *siteService) ListPages(id, userID uint) ([]model.SitePageResponse, error) {
+	site, err := s.siteRepo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, errSiteNotFound
+	}
+
+	content, err := s.loadContent(site, true)
+	if err != nil {
+		return nil, err
+	}
+	return content.pages(), nil
+}
+
+func (s *siteService) GetSite(slug string) (*model.SiteView, error) {
+	content, err := s.load(slug)
+	if err != nil {
+		return nil, err
+	}
+	return content.view(0), nil
+}
+
+func (s *siteService) GetPage(slug, pageSlug string) (*model.SitePageView, error) {
+	content, err := s.load(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, summary := range content.documents {
+		if content.slugs[summary.ID] != pageSlug {
+			continue
+		}
+		document, err := s.documentRepo.GetByID(summary.ID)
+		if err != nil {
+			return nil, errors.New("页面不存在")
+		}
+
+		html, toc := documentHTML(document)
+		return &model.SitePageView{
+			SiteView: *content.view(document.ID),
+			Page:     content.page(document),
+			Content:  html,
+			TOC:      toc,
+		}, nil
+	}
+	return nil, errors.New("页面不存在")
+}
+
+func (s *siteService) Feed(slug string) (*model.SiteView, []model.SiteFeedEntry, error) {
+	content, err := s.load(slug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	view := content.view(0)
+	recent := view.Pages
+	if len(recent) > model.SiteFeedLimit {
+		recent = recent[:model.SiteFeedLimit]
+	}
+	ids := make([]uint, 0, len(recent))
+	for _, page := range recent {
+		ids = append(ids, page.DocumentID)
+	}
+	documents, err := s.documentRepo.GetByIDs(content.site.UserID, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]*model.Document, len(documents))
+	for i := range documents {
+		byID[documents[i].ID] = &documents[i]
+	}
+
+	entries := make([]model.SiteFeedEntry, 0, len(recent))
+	for _, page := range recent {
+		document, ok := byID[page.DocumentID]
+		if !ok {
+			continue
+		}
+		entries = append(entries, model.SiteFeedEntry{
+			SitePageResponse: page,
+			Summary:          truncateRunes(strings.Join(strings.Fields(documentText(document)), " "), 200),
+			CreatedAt:        document.CreatedAt,
+		})
+	}
+	return view, entries, nil
+}
+
+func (s *siteService) HandleEvent(event *model.Event) error {
+	switch event.Type {
+	case model.EventDocumentPublished, model.EventDocumentRestored:
+		return s.syncDocumentSites(event.Document.ID)
+	case model.EventDocumentMoved:
+		if event.Document.Status != model.DocumentStatusPublished {
+			return nil
+		}
+		return s.syncDocumentSites(event.Document.ID)
+	case model.EventFolderMoved:
+		folder, err := s.folderRepo.GetByID(event.Folder.ID)
+		if err != nil {
+			return nil
+		}
+		return s.syncSites(folder.UserID)
+	case model.EventFolderDeleted:
+		return s.unpublishFolder(event.Folder.ID)
+	}
+	return nil
+}
+
+// unpublishFolder 取消发布已删除文件夹的站点
+func (s *siteService) unpublishFolder(folderID uint) error {
+	site, err := s.siteRepo.GetByFolderID(folderID)
+	if err != nil {
+		return nil
+	}
+	if err := s.siteRepo.Delete(site.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Site unpublished with deleted folder",
+		zap.Uint("site_id", site.ID),
+		zap.Uint("folder_id", folderID))
+
+	return nil
+}
+
+// syncDocumentSites 为文档所有者的站点中新出现的文档生成页面地址
+func (s *siteService) syncDocumentSites(documentID uint) error {
+	document, err := s.documentRepo.GetByID(documentID)
+	if err != nil {
+		return nil
+	}
+	return s.syncSites(document.UserID)
+}
+
+func (s *siteService) syncSites(userID uint) error {
+	sites, err := s.siteRepo.ListByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for i := range sites {
+		if _, err := s.loadContent(&sites[i], true); err != nil && !errors.Is(err, errSiteNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// load 按地址加载公开站点，所有者已禁用时站点不可访问
+func (s *siteService) load(slug string) (*siteContent, error) {
+	site, err := s.siteRepo.GetBySlug(slug)
+	if err != nil {
+		return nil, errSiteNotFound
+	}
+	return s.loadContent(site, false)
+}
+
+// loadContent 加载站点中公开的文件夹和文档。generate为true时为新出现的文档生成页面地址，
+// 公开访问时只读取已生成的地址，文档在发布或移入站点时生成地址
+func (s *siteService) loadContent(site *model.PublicSite, generate bool) (*siteContent, error) {
+	owner, err := s.userRepo.GetByID(site.UserID)
+	if err != nil || owner.Status == model.UserStatusDisabled {
+		return nil, errSiteNotFound
+	}
+	content := &siteContent{
+		site:     site,
+		author:   owner.Nickname,
+		children: make(map[uint][]model.Folder),
+	}
+	if content.author == "" {
+		content.author = owner.Username
+	}
+
+	folders, err := s.folderRepo.GetUserFolders(site.UserID)
+	if err != nil {
+		return nil, err
+	}
+	rootExists := false
+	for _, folder := range folders {
+		if folder.ID == site.FolderID {
+			rootExists = true
+		}
+		if folder.ParentID != nil {
+			content.children[*folder.ParentID] = append(content.children[*folder.ParentID], folder)
+		}
+	}
+	if !rootExists {
+		return nil, errSiteNotFound
+	}
+	for _, children := range content.children {
+		sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	}
+
+	// 站点包含根文件夹及其全部子文件夹
+	folderIDs := []uint{site.FolderID}
+	for i := 0; i < len(folderIDs); i++ {
+		for _, child := range content.children[folderIDs[i]] {
+			folderIDs = append(folderIDs, child.ID)
+		}
+	}
+	documents, err := s.documentRepo.ListPublishedByFolderIDs(site.UserID, folderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var slugs map[uint]string
+	if generate {
+		slugs, err = s.ensurePages(site, documents)
+	} else {
+		slugs, err = s.pageSlugs(site.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	content.slugs = slugs
+	// 未能生成地址的文档不出现在站点中
+	for _, document := range documents {
+		if _, ok := slugs[document.ID]; ok {
+			content.documents = append(content.documents, document)
+		}
+	}
+	return content, nil
+}
+
+// ensurePages 为首次出现在站点中的文档生成页面地址。已生成的地址一直保留，文档取消发布后再次发布时沿用原地址
+func (s *siteService) ensurePages(site *model.PublicSite, documents []model.Document) (map[uint]string, error) {
+	unlock := s.locks.lock(site.ID)
+	defer unlock()
+
+	slugs, err := s.pageSlugs(site.ID)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		used[slug] = true
+	}
+
+	for _, document := range documents {
+		if _, ok := slugs[document.ID]; ok {
+			continue
+		}
+		base := slugify(document.Title, true, pageSlugMaxRunes)
+		if base == "" {
+			base = fmt.Sprintf("page-%d", document.ID)
+		}
+		slug := base
+		for i := 2; used[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		page := &model.PublicPage{SiteID: site.ID, DocumentID: document.ID, Slug: slug}
+		if err := s.siteRepo.CreatePage(page); err != nil {
+			s.logger.Error("Failed to create site page",
+				zap.Uint("site_id", site.ID),
+				zap.Uint("document_id", document.ID),
+				zap.Error(err))
+			continue
+		}
+		slugs[document.ID] = slug
+		used[slug] = true
+	}
+	return slugs, nil
+}
+
+// pageSlugs 获取站点已生成的页面地址，文档ID到页面地址
+func (s *siteService) pageSlugs(siteID uint) (map[uint]string, error) {
+	pages, err := s.siteRepo.ListPages(siteID)
+	if err != nil {
+		return nil, err
+	}
+	slugs := make(map[uint]string, len(pages))
+	for _, page := range pages {
+		slugs[page.DocumentID] = page.Slug
+	}
+	return slugs, nil
+}
+
+// generateSiteSlug 按文件夹名称生成站点地址，名称中没有可用字符或地址已被使用时添加随机后缀
+func (s *siteService) generateSiteSlug(name string) (string, error) {
+	base := slugify(name, false, 80)
+	if base != "" {
+		if _, err := s.siteRepo.GetBySlug(base); err != nil {
+			return base, nil
+		}
+	} else {
+		base = "site"
+	}
+
+	suffix, err := generateRandomString(8)
+	if err != nil {
+		return "", err
+	}
+	return base + "-" + suffix, nil
+}
+
+// checkSiteSlug 校验站点地址格式并检查是否已被其他站点使用
+func (s *siteService) checkSiteSlug(slug string, siteID uint) error {
+	if len(slug) > 100 || !siteSlugPattern.MatchString(slug) {
+		return errors.New("站点地址只能包含小写字母、数字和连字符")
+	}
+	if existing, err := s.siteRepo.GetBySlug(slug); err == nil && existing.ID != siteID {
+		return errors.New("站点地址已被使用")
+	}
+	return nil
+}
+
+func (s *siteService) toResponse(site *model.PublicSite, folderName string) model.SiteResponse {
+	return model.SiteResponse{
+		ID:          site.ID,
+		FolderID:    site.FolderID,
+		FolderName:  folderName,
+		Slug:        site.Slug,
+		Title:       site.Title,
+		Description: site.Description,
+		URL:         sitePath(site.Slug),
+		CreatedAt:   site.CreatedAt,
+		UpdatedAt:   site.UpdatedAt,
+	}
+}
+
+func (c *siteContent) page(document *model.Document) model.SitePageResponse {
+	slug := c.slugs[document.ID]
+	return model.SitePageResponse{
+		DocumentID: document.ID,
+		Title:      document.Title,
+		Slug:       slug,
+		URL:        sitePath(c.site.Slug) + "/pages/" + url.PathEscape(slug),
+		UpdatedAt:  document.UpdatedAt,
+	}
+}
+
+// pages 返回全部公开文档，按更新时间倒序
+func (c *siteContent) pages() []model.SitePageResponse {
+	pages := make([]model.SitePageResponse, 0, len(c.documents))
+	for i := range c.documents {
+		pages = append(pages, c.page(&c.documents[i]))
+	}
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].UpdatedAt.After(pages[j].UpdatedAt) })
+	return pages
+}
+
+// view 生成页面数据，currentID为当前文档，在导航中高亮
+func (c *siteContent) view(currentID uint) *model.SiteView {
+	byFolder := make(map[uint][]model.Document)
+	for _, document := range c.documents {
+		if document.FolderID != nil {
+			byFolder[*document.FolderID] = append(byFolder[*document.FolderID], document)
+		}
+	}
+	return &model.SiteView{
+		Site:   c.site,
+		URL:    sitePath(c.site.Slug),
+		Nav:    c.nav(c.site.FolderID, byFolder, currentID),
+		Pages:  c.pages(),
+		Author: c.author,
+	}
+}
+
+// nav 生成文件夹的导航，子文件夹在前、文档在后，没有公开文档的文件夹不显示
+func (c *siteContent) nav(folderID uint, byFolder map[uint][]model.Document, currentID uint) []model.SiteNavNode {
+	var nodes []model.SiteNavNode
+	for _, child := range c.children[folderID] {
+		children := c.nav(child.ID, byFolder, currentID)
+		if len(children) == 0 {
+			continue
+		}
+		nodes = append(nodes, model.SiteNavNode{Title: child.Name, Children: children})
+	}
+	for i := range byFolder[folderID] {
+		document := &byFolder[folderID][i]
+		nodes = append(nodes, model.SiteNavNode{
+			Title:   document.Title,
+			URL:     c.page(document).URL,
+			Current: document.ID == currentID,
+		})
+	}
+	return nodes
+}
+
+func sitePath(slug string) string {
+	return "/sites/" + url.PathEscape(slug)
+}
+
+// slugify 将名称转换为地址，连续的非字母数字字符替换为一个连字符。unicode为true时保留中文等非ASCII字母
+func slugify(name string, unicodeLetters bool, maxRunes int) string {
+	var runes []rune
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			runes = append(runes, r)
+		case unicodeLetters && r >= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			runes = append(runes, r)
+		case len(runes) > 0 && runes[len(runes)-1] != '-':
+			runes = append(runes, '-')
+		}
+		if len(runes) >= maxRunes {
+			break
+		}
+	}
+	return strings.Trim(string(runes), "-")
+}
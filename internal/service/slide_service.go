@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	slideMaxSlides      = 500
+	slideMaxTextLength  = 1000
+	slideMaxParagraphs  = 100
+	slideMaxParagraph   = 5000
+	slideMaxLevel       = 4
+	slideMaxNotesLength = 20000
+	slideIDLength       = 12
+	// 导入文件的最大大小
+	pptxMaxImportSize = 50 << 20
+)
+
+var slideLayouts = map[model.SlideLayout]bool{
+	model.SlideLayoutTitle:     true,
+	model.SlideLayoutSection:   true,
+	model.SlideLayoutContent:   true,
+	model.SlideLayoutTwoColumn: true,
+	model.SlideLayoutImage:     true,
+	model.SlideLayoutBlank:     true,
+}
+
+type SlideService interface {
+	Get(documentID, userID uint) (*model.Presentation, error)
+	// AddSlide 在指定位置插入幻灯片
+	AddSlide(documentID, userID uint, req *model.AddSlideRequest, client *model.ClientInfo) (*model.PresentationResponse, error)
+	// UpdateSlide 替换幻灯片的版式和内容，备注为空时保留原备注
+	UpdateSlide(documentID, userID uint, slideID string, slide *model.Slide, client *model.ClientInfo) (*model.PresentationResponse, error)
+	UpdateNotes(documentID, userID uint, slideID, notes string, client *model.ClientInfo) (*model.PresentationResponse, error)
+	DeleteSlide(documentID, userID uint, slideID string, client *model.ClientInfo) (*model.PresentationResponse, error)
+	// DuplicateSlide 复制幻灯片并插入到原幻灯片之后
+	DuplicateSlide(documentID, userID uint, slideID string, client *model.ClientInfo) (*model.PresentationResponse, error)
+	// Reorder 按给定的ID顺序重新排列幻灯片
+	Reorder(documentID, userID uint, slideIDs []string, client *model.ClientInfo) (*model.PresentationResponse, error)
+	// ExportPPTX 导出为pptx文件，返回文件名
+	ExportPPTX(documentID, userID uint, w io.Writer) (string, error)
+	// ImportPPTX 从pptx文件创建演示文稿
+	ImportPPTX(userID uint, filename string, r io.Reader, req *model.ImportPPTXRequest, client *model.ClientInfo) (*model.Document, error)
+}
+
+type slideService struct {
+	documentRepo    repository.DocumentRepository
+	documentService DocumentService
+	logger          *zap.Logger
+
+	// 同一文档的修改依次执行
+	locks *documentLocks
+}
+
+func NewSlideService(documentRepo repository.DocumentRepository, documentService DocumentService, logger *zap.Logger) SlideService {
+	return &slideService{
+		documentRepo:    documentRepo,
+		documentService: documentService,
+		logger:          logger,
+		locks:           newDocumentLocks(),
+	}
+}
+
+func (s *slideService) Get(documentID, userID uint) (*model.Presentation, error) {
+	document, err := s.getPresentationDocument(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return parsePresentation(document.Content)
+}
+
+func (s *slideService) AddSlide(documentID, userID uint, req *model.AddSlideRequest, client *model.ClientInfo) (*model.PresentationResponse, error) {
+	return s.modify(documentID, userID, client, func(presentation *model.Presentation) (string, error) {
+		if len(presentation.Slides) >= slideMaxSlides {
+			return "", fmt.Errorf("幻灯片不能超过%d张", slideMaxSlides)
+		}
+		slide := req.Slide
+		slide.ID = newSlideID()
+		if err := validateSlide(&slide); err != nil {
+			return "", err
+		}
+
+		index := len(presentation.Slides)
+		if req.Index != nil && *req.Index < index {
+			index = *req.Index
+		}
+		presentation.Slides = insertSlide(presentation.Slides, index, &slide)
+		return slide.ID, nil
+	})
+}
+
+func (s *slideService) UpdateSlide(documentID, userID uint, slideID string, slide *model.Slide, client *model.ClientInfo) (*model.PresentationResponse, error) {
+	return s.modify(documentID, userID, client, func(presentation *model.Presentation) (string, error) {
+		index := findSlide(presentation, slideID)
+		if index < 0 {
+			return "", errors.New("幻灯片不存在")
+		}
+		updated := *slide
+		updated.ID = slideID
+		if updated.Notes == "" {
+			updated.Notes = presentation.Slides[index].Notes
+		}
+		if err := validateSlide(&updated); err != nil {
+			return "", err
+		}
+		presentation.Slides[index] = &updated
+		return slideID, nil
+	})
+}
+
+func (s *slideService) UpdateNotes(documentID, userID uint, slideID, notes string, client *model.ClientInfo) (*model.PresentationResponse, error) {
+	return s.modify(documentID, userID, client, func(presentation *model.Presentation) (string, error) {
+		index := findSlide(presentation, slideID)
+		if index < 0 {
+			return "", errors.New("幻灯片不存在")
+		}
+		if utf8.RuneCountInString(notes) > slideMaxNotesLength {
+			return "", fmt.Errorf("备注不能超过%d个字符", slideMaxNotesLength)
+		}
+		presentation.Slides[index].Notes = notes
+		return slideID, nil
+	})
+}
+
+func (s *slideService) DeleteSlide(documentID, userID uint, slideID string, client *model.ClientInfo) (*model.PresentationResponse, error) {
+	return s.modify(documentID, userID, client, func(presentation *model.Presentation) (string, error) {
+		index := findSlide(presentation, slideID)
+		if index < 0 {
+			return "", errors.New("幻灯片不存在")
+		}
+		if len(presentation.Slides) == 1 {
+			return "", errors.New("演示文稿至少需要保留一张幻灯片")
+		}
+		presentation.Slides = append(presentation.Slides[:index], presentation.Slides[index+1:]...)
+		return "", nil
+	})
+}
+
+func (s *slideService) DuplicateSlide(documentID, userID uint, slideID string, client *model.ClientInfo) (*model.PresentationResponse, error) {
+	return s.modify(documentID, userID, client, func(presentation *model.Presentation) (string, error) {
+		index := findSlide(presentation, slideID)
+		if index < 0 {
+			return "", errors.New("幻灯片不存在")
+		}
+		if len(presentation.Slides) >= slideMaxSlides {
+			return "", fmt.Errorf("幻灯片不能超过%d张", slideMaxSlides)
+		}
+
+		source := presentation.Slides[index]
+		duplicate := *source
+		duplicate.ID = newSlideID()
+		duplicate.Body = append([]model.SlideParagraph(nil), source.Body...)
+		duplicate.Body2 = append([]model.SlideParagraph(nil), source.Body2...)
+		if source.Image != nil {
+			image := *source.Image
+			duplicate.Image = &image
+		}
+		presentation.Slides = insertSlide(presentation.Slides, index+1, &duplicate)
+		return duplicate.ID, nil
+	})
+}
+
+func (s *slideService) Reorder(documentID, userID uint, slideIDs []string, client *model.ClientInfo) (*model.PresentationResponse, error) {
+	return s.modify(documentID, userID, client, func(presentation *model.Presentation) (string, error) {
+		if len(slideIDs) != len(presentation.Slides) {
+			return "", errors.New("排序需要包含全部幻灯片")
+		}
+		byID := make(map[string]*model.Slide, len(presentation.Slides))
+		for _, slide := range presentation.Slides {
+			byID[slide.ID] = slide
+		}
+
+		slides := make([]*model.Slide, 0, len(slideIDs))
+		for _, id := range slideIDs {
+			slide, ok := byID[id]
+			if !ok {
+				return "", fmt.Errorf("幻灯片不存在或重复：%s", id)
+			}
+			delete(byID, id)
+			slides = append(slides, slide)
+		}
+		presentation.Slides = slides
+		return "", nil
+	})
+}
+
+// modify 加锁读取演示文稿，执行修改后通过文档服务保存，返回保存后的内容
+func (s *slideService) modify(documentID, userID uint, client *model.ClientInfo, apply func(*model.Presentation) (string, error)) (*model.PresentationResponse, error) {
+	unlock := s.locks.lock(documentID)
+	defer unlock()
+
+	document, err := s.getPresentationDocument(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	presentation, err := parsePresentation(document.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	slideID, err := apply(presentation)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := encodePresentation(presentation)
+	if err != nil {
+		return nil, err
+	}
+	err = s.documentService.Update(documentID, userID, &model.UpdateDocumentRequest{Content: content, Version: &document.Version}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := parsePresentation(updated.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PresentationResponse{
+		Presentation: saved,
+		SlideID:      slideID,
+		UpdatedAt:    updated.UpdatedAt,
+	}, nil
+}
+
+func (s *slideService) ExportPPTX(documentID, userID uint, w io.Writer) (string, error) {
+	document, err := s.getPresentationDocument(documentID, userID)
+	if err != nil {
+		return "", err
+	}
+	presentation, err := parsePresentation(document.Content)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writePPTX(w, document.Title, presentation); err != nil {
+		return "", err
+	}
+	return document.Title + ".pptx", nil
+}
+
+func (s *slideService) ImportPPTX(userID uint, filename string, r io.Reader, req *model.ImportPPTXRequest, client *model.ClientInfo) (*model.Document, error) {
+	if !strings.EqualFold(filepath.Ext(filename), ".pptx") {
+		return nil, errors.New("只支持导入pptx格式的文件")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, pptxMaxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > pptxMaxImportSize {
+		return nil, fmt.Errorf("文件不能超过%dMB", pptxMaxImportSize>>20)
+	}
+
+	presentation, err := readPPTX(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	content, err := encodePresentation(presentation)
+	if err != nil {
+		return nil, err
+	}
+
+	title := req.Title
+	if title == "" {
+		title = truncateRunes(strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)), 250)
+	}
+
+	document, err := s.documentService.Create(userID, &model.CreateDocumentRequest{
+		Title:    title,
+		Content:  content,
+		Type:     model.DocumentTypePPT,
+		FolderID: req.FolderID,
+	}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Presentation imported",
+		zap.Uint("user_id", userID),
+		zap.Uint("document_id", document.ID),
+		zap.Int("slides", len(presentation.Slides)))
+
+	return document, nil
+}
+
+func (s *slideService) getPresentationDocument(documentID, userID uint) (*model.Document, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(documentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+	if document.Type != model.DocumentTypePPT {
+		return nil, errors.New("该文档不是演示文稿")
+	}
+	return document, nil
+}
+
+// parsePresentation 解析并校验演示文稿内容。内容为空时返回只有一张标题页的演示文稿
+func parsePresentation(content string) (*model.Presentation, error) {
+	if strings.TrimSpace(content) == "" {
+		return &model.Presentation{
+			Aspect: model.SlideAspectWide,
+			Slides: []*model.Slide{{ID: newSlideID(), Layout: model.SlideLayoutTitle}},
+		}, nil
+	}
+
+	var presentation model.Presentation
+	if err := json.Unmarshal([]byte(content), &presentation); err != nil {
+		return nil, errors.New("演示文稿内容不是有效的JSON")
+	}
+	if err := validatePresentation(&presentation); err != nil {
+		return nil, err
+	}
+	return &presentation, nil
+}
+
+// normalizePresentationContent 校验演示文稿内容并重新序列化，保存演示文稿前调用
+func normalizePresentationContent(content string) (string, error) {
+	presentation, err := parsePresentation(content)
+	if err != nil {
+		return "", err
+	}
+	return encodePresentation(presentation)
+}
+
+func encodePresentation(presentation *model.Presentation) (string, error) {
+	data, err := json.Marshal(presentation)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func newSlideID() string {
+	id, _ := generateRandomString(slideIDLength)
+	return id
+}
+
+// validatePresentation 检查幻灯片数量和内容。缺少ID的幻灯片按位置生成ID，ID重复时生成随机ID
+func validatePresentation(presentation *model.Presentation) error {
+	switch presentation.Aspect {
+	case "":
+		presentation.Aspect = model.SlideAspectWide
+	case model.SlideAspectWide, model.SlideAspectStandard:
+	default:
+		return fmt.Errorf("不支持的幻灯片比例：%s", presentation.Aspect)
+	}
+
+	slides := make([]*model.Slide, 0, len(presentation.Slides))
+	for _, slide := range presentation.Slides {
+		if slide != nil {
+			slides = append(slides, slide)
+		}
+	}
+	if len(slides) == 0 {
+		return errors.New("演示文稿至少需要一张幻灯片")
+	}
+	if len(slides) > slideMaxSlides {
+		return fmt.Errorf("幻灯片不能超过%d张", slideMaxSlides)
+	}
+
+	seen := make(map[string]bool, len(slides))
+	for i, slide := range slides {
+		if slide.ID == "" {
+			slide.ID = fmt.Sprintf("s%d", i+1)
+		}
+		if len(slide.ID) > 32 || seen[slide.ID] {
+			slide.ID = newSlideID()
+		}
+		seen[slide.ID] = true
+		if err := validateSlide(slide); err != nil {
+			return fmt.Errorf("第%d张幻灯片：%w", i+1, err)
+		}
+	}
+	presentation.Slides = slides
+	return nil
+}
+
+// validateSlide 检查版式和各字段的长度，清除当前版式不使用的字段
+func validateSlide(slide *model.Slide) error {
+	if slide.Layout == "" {
+		slide.Layout = model.SlideLayoutContent
+	}
+	if !slideLayouts[slide.Layout] {
+		return fmt.Errorf("不支持的幻灯片版式：%s", slide.Layout)
+	}
+	if utf8.RuneCountInString(slide.Title) > slideMaxTextLength || utf8.RuneCountInString(slide.Subtitle) > slideMaxTextLength {
+		return fmt.Errorf("标题不能超过%d个字符", slideMaxTextLength)
+	}
+	if utf8.RuneCountInString(slide.Notes) > slideMaxNotesLength {
+		return fmt.Errorf("备注不能超过%d个字符", slideMaxNotesLength)
+	}
+	if slide.Background != "" && !colorPattern.MatchString(slide.Background) {
+		return errors.New("背景色需要是#RRGGBB格式")
+	}
+	for _, body := range [][]model.SlideParagraph{slide.Body, slide.Body2} {
+		if len(body) > slideMaxParagraphs {
+			return fmt.Errorf("正文不能超过%d段", slideMaxParagraphs)
+		}
+		for i := range body {
+			if utf8.RuneCountInString(body[i].Text) > slideMaxParagraph {
+				return fmt.Errorf("正文段落不能超过%d个字符", slideMaxParagraph)
+			}
+			if body[i].Level < 0 || body[i].Level > slideMaxLevel {
+				return fmt.Errorf("段落级别需要在0到%d之间", slideMaxLevel)
+			}
+		}
+	}
+
+	switch slide.Layout {
+	case model.SlideLayoutTitle, model.SlideLayoutSection:
+		slide.Body, slide.Body2, slide.Image = nil, nil, nil
+	case model.SlideLayoutContent:
+		slide.Subtitle, slide.Body2, slide.Image = "", nil, nil
+	case model.SlideLayoutTwoColumn:
+		slide.Subtitle, slide.Image = "", nil
+	case model.SlideLayoutImage:
+		if slide.Image == nil || len(slide.Image.URL) > 2048 || !isSafeURL(slide.Image.URL, false) {
+			return errors.New("图片地址只支持http(s)链接或站内路径")
+		}
+		slide.Image.URL = strings.TrimSpace(slide.Image.URL)
+		slide.Image.Alt = truncateRunes(slide.Image.Alt, 500)
+		slide.Subtitle, slide.Body, slide.Body2 = "", nil, nil
+	case model.SlideLayoutBlank:
+		slide.Title, slide.Subtitle, slide.Body, slide.Body2, slide.Image = "", "", nil, nil, nil
+	}
+	return nil
+}
+
+func findSlide(presentation *model.Presentation, slideID string) int {
+	for i, slide := range presentation.Slides {
+		if slide.ID == slideID {
+			return i
+		}
+	}
+	return -1
+}
+
+func insertSlide(slides []*model.Slide, index int, slide *model.Slide) []*model.Slide {
+	slides = append(slides, nil)
+	copy(slides[index+1:], slides[index:])
+	slides[index] = slide
+	return slides
+}
+
+// presentationText 提取幻灯片的标题、正文和备注，用于搜索索引
+func presentationText(content string) string {
+	presentation, err := parsePresentation(content)
+	if err != nil {
+		return content
+	}
+
+	var b strings.Builder
+	for _, slide := range presentation.Slides {
+		lines := []string{slide.Title, slide.Subtitle}
+		for _, body := range [][]model.SlideParagraph{slide.Body, slide.Body2} {
+			for _, paragraph := range body {
+				lines = append(lines, paragraph.Text)
+			}
+		}
+		if slide.Image != nil {
+			lines = append(lines, slide.Image.Alt)
+		}
+		lines = append(lines, slide.Notes)
+
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
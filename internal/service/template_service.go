@@ -0,0 +1,332 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const maxTemplateVariableLength = 1000
+
+var (
+	templatePlaceholderPattern  = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	templateVariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+type TemplateService interface {
+	// Create 将用户自己的文档保存为模板
+	Create(userID uint, req *model.CreateTemplateRequest) (*model.TemplateDetailResponse, error)
+	GetByID(id, userID uint) (*model.TemplateDetailResponse, error)
+	Update(id, userID uint, req *model.UpdateTemplateRequest) (*model.TemplateDetailResponse, error)
+	Delete(id, userID uint) error
+	List(userID uint, req *model.TemplateListRequest) ([]model.TemplateResponse, int64, error)
+	// Render 填充模板的占位符，未提供值的变量使用默认值，必填变量缺失时返回错误
+	Render(id, userID uint, values map[string]string) (*model.RenderedTemplate, error)
+	// RecordUsage 从模板创建文档成功后累加使用次数
+	RecordUsage(id uint)
+}
+
+type templateService struct {
+	templateRepo repository.TemplateRepository
+	documentRepo repository.DocumentRepository
+	userRepo     repository.UserRepository
+	logger       *zap.Logger
+}
+
+func NewTemplateService(templateRepo repository.TemplateRepository, documentRepo repository.DocumentRepository, userRepo repository.UserRepository, logger *zap.Logger) TemplateService {
+	return &templateService{
+		templateRepo: templateRepo,
+		documentRepo: documentRepo,
+		userRepo:     userRepo,
+		logger:       logger,
+	}
+}
+
+func (s *templateService) Create(userID uint, req *model.CreateTemplateRequest) (*model.TemplateDetailResponse, error) {
+	document, err := s.documentRepo.GetByIDAndUserID(req.DocumentID, userID)
+	if err != nil {
+		return nil, errors.New("文档不存在或无权访问")
+	}
+
+	if err := validateTemplateVariables(req.Variables); err != nil {
+		return nil, err
+	}
+	variables, err := json.Marshal(req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = model.TemplateScopePersonal
+	}
+	title := req.Title
+	if title == "" {
+		title = document.Title
+	}
+
+	template := &model.DocumentTemplate{
+		UserID:           userID,
+		Scope:            scope,
+		Name:             req.Name,
+		Description:      req.Description,
+		Category:         req.Category,
+		Type:             document.Type,
+		Title:            title,
+		Content:          document.Content,
+		Tags:             document.Tags,
+		Variables:        string(variables),
+		SourceDocumentID: &document.ID,
+	}
+	if err := s.templateRepo.Create(template); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Template created",
+		zap.Uint("user_id", userID),
+		zap.Uint("template_id", template.ID),
+		zap.Uint("document_id", document.ID),
+		zap.String("scope", string(scope)))
+
+	return toTemplateDetailResponse(template, userID), nil
+}
+
+func (s *templateService) GetByID(id, userID uint) (*model.TemplateDetailResponse, error) {
+	template, err := s.templateRepo.GetAvailable(id, userID)
+	if err != nil {
+		return nil, errors.New("模板不存在")
+	}
+	return toTemplateDetailResponse(template, userID), nil
+}
+
+func (s *templateService) Update(id, userID uint, req *model.UpdateTemplateRequest) (*model.TemplateDetailResponse, error) {
+	template, err := s.getEditable(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		template.Name = req.Name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.Category != nil {
+		template.Category = *req.Category
+	}
+	if req.Scope != "" {
+		template.Scope = req.Scope
+	}
+	if req.Title != "" {
+		template.Title = req.Title
+	}
+	if req.Variables != nil {
+		if err := validateTemplateVariables(*req.Variables); err != nil {
+			return nil, err
+		}
+		variables, err := json.Marshal(*req.Variables)
+		if err != nil {
+			return nil, err
+		}
+		template.Variables = string(variables)
+	}
+
+	if err := s.templateRepo.Update(template); err != nil {
+		return nil, err
+	}
+
+	return toTemplateDetailResponse(template, userID), nil
+}
+
+func (s *templateService) Delete(id, userID uint) error {
+	if _, err := s.getEditable(id, userID); err != nil {
+		return err
+	}
+	if err := s.templateRepo.Delete(id, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Template deleted", zap.Uint("user_id", userID), zap.Uint("template_id", id))
+	return nil
+}
+
+func (s *templateService) List(userID uint, req *model.TemplateListRequest) ([]model.TemplateResponse, int64, error) {
+	templates, total, err := s.templateRepo.List(userID, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]model.TemplateResponse, 0, len(templates))
+	for i := range templates {
+		responses = append(responses, toTemplateResponse(&templates[i], userID))
+	}
+	return responses, total, nil
+}
+
+func (s *templateService) Render(id, userID uint, values map[string]string) (*model.RenderedTemplate, error) {
+	template, err := s.templateRepo.GetAvailable(id, userID)
+	if err != nil {
+		return nil, errors.New("模板不存在")
+	}
+
+	for name, value := range values {
+		if utf8.RuneCountInString(value) > maxTemplateVariableLength {
+			return nil, fmt.Errorf("模板变量%s的值过长", name)
+		}
+	}
+
+	resolved := s.builtinValues(userID)
+	for name := range resolved {
+		if value, ok := values[name]; ok {
+			resolved[name] = value
+		}
+	}
+	for _, variable := range template.VariableList() {
+		value := values[variable.Name]
+		if value == "" {
+			value = variable.Default
+		}
+		if value == "" && variable.Required {
+			label := variable.Label
+			if label == "" {
+				label = variable.Name
+			}
+			return nil, fmt.Errorf("请填写模板变量：%s", label)
+		}
+		resolved[variable.Name] = value
+	}
+
+	// 表格、演示文稿、思维导图和块结构的Word文档内容为JSON，替换值需要转义以免破坏结构
+	escape := func(value string) string { return value }
+	isJSON := template.Type == model.DocumentTypeExcel || template.Type == model.DocumentTypePPT || template.Type == model.DocumentTypeMindMap ||
+		template.Type == model.DocumentTypeWord && strings.HasPrefix(strings.TrimSpace(template.Content), "{")
+	if isJSON {
+		escape = func(value string) string {
+			encoded, _ := json.Marshal(value)
+			return string(encoded[1 : len(encoded)-1])
+		}
+	}
+
+	title := truncateRunes(fillTemplatePlaceholders(template.Title, resolved, nil), 250)
+	if strings.TrimSpace(title) == "" {
+		title = template.Name
+	}
+
+	return &model.RenderedTemplate{
+		Type:    template.Type,
+		Title:   title,
+		Content: fillTemplatePlaceholders(template.Content, resolved, escape),
+		Tags:    template.Tags,
+	}, nil
+}
+
+func (s *templateService) RecordUsage(id uint) {
+	if err := s.templateRepo.IncrementUsage(id); err != nil {
+		s.logger.Error("Failed to record template usage", zap.Uint("template_id", id), zap.Error(err))
+	}
+}
+
+// getEditable 系统模板和其他用户的模板不可修改
+func (s *templateService) getEditable(id, userID uint) (*model.DocumentTemplate, error) {
+	template, err := s.templateRepo.GetAvailable(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("模板不存在")
+		}
+		return nil, err
+	}
+	if template.Scope == model.TemplateScopeSystem || template.UserID != userID {
+		return nil, errors.New("无权修改该模板")
+	}
+	return template, nil
+}
+
+// builtinValues 内置占位符的值
+func (s *templateService) builtinValues(userID uint) map[string]string {
+	now := time.Now()
+	_, week := now.ISOWeek()
+
+	userName := ""
+	if user, err := s.userRepo.GetByID(userID); err == nil {
+		userName = user.Nickname
+		if userName == "" {
+			userName = user.Username
+		}
+	}
+
+	return map[string]string{
+		"date":  now.Format("2006-01-02"),
+		"time":  now.Format("15:04"),
+		"year":  strconv.Itoa(now.Year()),
+		"month": strconv.Itoa(int(now.Month())),
+		"week":  strconv.Itoa(week),
+		"user":  userName,
+	}
+}
+
+// fillTemplatePlaceholders 替换{{name}}占位符，未定义的占位符原样保留
+func fillTemplatePlaceholders(text string, values map[string]string, escape func(string) string) string {
+	return templatePlaceholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := templatePlaceholderPattern.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			return match
+		}
+		if escape != nil {
+			return escape(value)
+		}
+		return value
+	})
+}
+
+func validateTemplateVariables(variables []model.TemplateVariable) error {
+	seen := make(map[string]bool, len(variables))
+	for _, builtin := range model.BuiltinTemplateVariables {
+		seen[builtin.Name] = true
+	}
+	for _, variable := range variables {
+		if !templateVariableNamePattern.MatchString(variable.Name) {
+			return fmt.Errorf("无效的变量名：%s，只能包含字母、数字和下划线", variable.Name)
+		}
+		if seen[variable.Name] {
+			return fmt.Errorf("变量名重复或与内置变量冲突：%s", variable.Name)
+		}
+		seen[variable.Name] = true
+	}
+	return nil
+}
+
+func toTemplateResponse(template *model.DocumentTemplate, userID uint) model.TemplateResponse {
+	return model.TemplateResponse{
+		ID:          template.ID,
+		Scope:       template.Scope,
+		Name:        template.Name,
+		Description: template.Description,
+		Category:    template.Category,
+		Type:        template.Type,
+		Title:       template.Title,
+		Tags:        template.Tags,
+		Variables:   template.VariableList(),
+		UsageCount:  template.UsageCount,
+		Editable:    template.Scope != model.TemplateScopeSystem && template.UserID == userID,
+		CreatedAt:   template.CreatedAt,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
+
+func toTemplateDetailResponse(template *model.DocumentTemplate, userID uint) *model.TemplateDetailResponse {
+	return &model.TemplateDetailResponse{
+		TemplateResponse: toTemplateResponse(template, userID),
+		Content:          template.Content,
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"image"
+	"mime/multipart"
+	"regexp"
 	"time"
 	"wz-wenzhan-backend/internal/model"
 	"wz-wenzhan-backend/internal/repository"
@@ -21,17 +25,38 @@ type UserService interface {
 	GetProfile(userID uint) (*model.UserProfile, error)
 	UpdateProfile(userID uint, req *model.UpdateProfileRequest) error
 	ChangePassword(userID uint, oldPassword, newPassword string) error
+	ValidateSession(userID uint, tokenVersion int) (*model.User, error)
+	UploadAvatar(userID uint, fileHeader *multipart.FileHeader) (*model.AvatarResponse, error)
+	// GetIdenticon 按默认头像标识生成头像，不查询用户，任意合法标识都返回图片
+	GetIdenticon(key string, size int) ([]byte, error)
+}
+
+const (
+	maxAvatarFileSize = int64(5 << 20) // 5MB
+	minAvatarSide     = 32
+	maxAvatarPixels   = 40000000 // 防止解压炸弹
+)
+
+// 默认头像标识为用户名的SHA-256
+var identiconKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+var allowedAvatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
 }
 
 type userService struct {
-	userRepo repository.UserRepository
-	logger   *zap.Logger
+	userRepo    repository.UserRepository
+	fileService FileService
+	logger      *zap.Logger
 }
 
-func NewUserService(userRepo repository.UserRepository, logger *zap.Logger) UserService {
+func NewUserService(userRepo repository.UserRepository, fileService FileService, logger *zap.Logger) UserService {
 	return &userService{
-		userRepo: userRepo,
-		logger:   logger,
+		userRepo:    userRepo,
+		fileService: fileService,
+		logger:      logger,
 	}
 }
 
@@ -66,7 +91,8 @@ func (s *userService) Register(req *model.RegisterRequest) (*model.User, error)
 		Email:    req.Email,
 		Password: string(hashedPassword),
 		Nickname: req.Nickname,
-		Status:   1,
+		Status:   model.UserStatusActive,
+		Role:     model.UserRoleUser,
 	}
 
 	if user.Nickname == "" {
@@ -99,12 +125,12 @@ func (s *userService) Login(req *model.LoginRequest) (string, *model.UserProfile
 	}
 
 	// 检查用户状态
-	if user.Status != 1 {
+	if user.Status != model.UserStatusActive {
 		return "", nil, errors.New("用户已被禁用")
 	}
 
 	// 生成JWT token
-	token, err := utils.GenerateJWT(user.ID)
+	token, err := utils.GenerateJWT(user.ID, user.TokenVersion)
 	if err != nil {
 		return "", nil, err
 	}
@@ -119,9 +145,10 @@ func (s *userService) Login(req *model.LoginRequest) (string, *model.UserProfile
 		ID:       user.ID,
 		Username: user.Username,
 		Email:    user.Email,
-		Avatar:   user.Avatar,
+		Avatar:   avatarOrDefault(user),
 		Nickname: user.Nickname,
 		Status:   user.Status,
+		Role:     user.Role,
 	}
 
 	s.logger.Info("User logged in", zap.String("username", user.Username))
@@ -138,9 +165,10 @@ func (s *userService) GetProfile(userID uint) (*model.UserProfile, error) {
 		ID:       user.ID,
 		Username: user.Username,
 		Email:    user.Email,
-		Avatar:   user.Avatar,
+		Avatar:   avatarOrDefault(user),
 		Nickname: user.Nickname,
 		Status:   user.Status,
+		Role:     user.Role,
 	}
 
 	return profile, nil
@@ -195,3 +223,108 @@ func (s *userService) ChangePassword(userID uint, oldPassword, newPassword strin
 	s.logger.Info("User password changed", zap.Uint("user_id", userID))
 	return nil
 }
+
+func (s *userService) ValidateSession(userID uint, tokenVersion int) (*model.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("用户不存在")
+		}
+		return nil, err
+	}
+
+	if user.Status != model.UserStatusActive {
+		return nil, errors.New("用户已被禁用")
+	}
+	if user.TokenVersion != tokenVersion {
+		return nil, errors.New("登录已失效，请重新登录")
+	}
+
+	return user, nil
+}
+
+func (s *userService) UploadAvatar(userID uint, fileHeader *multipart.FileHeader) (*model.AvatarResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验文件大小和类型
+	if fileHeader.Size > maxAvatarFileSize {
+		return nil, errors.New("头像文件大小超过限制，最大支持5MB")
+	}
+	contentType := fileHeader.Header.Get("Content-Type")
+	if !allowedAvatarTypes[contentType] {
+		return nil, fmt.Errorf("不支持的头像格式: %s", contentType)
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// 先读取图片尺寸，避免解码超大图片
+	config, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return nil, errors.New("无法识别的图片文件")
+	}
+	if config.Width < minAvatarSide || config.Height < minAvatarSide {
+		return nil, fmt.Errorf("图片尺寸过小，宽高至少为%d像素", minAvatarSide)
+	}
+	if config.Width*config.Height > maxAvatarPixels {
+		return nil, errors.New("图片尺寸过大")
+	}
+
+	if _, err := src.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, errors.New("无法识别的图片文件")
+	}
+
+	// 居中裁剪为正方形并生成各标准尺寸
+	square := utils.CropSquare(img)
+	version := time.Now().Unix()
+	response := &model.AvatarResponse{Sizes: make(map[string]string)}
+	for name, size := range model.AvatarSizes {
+		data, err := utils.EncodePNG(utils.ResizeImage(square, size, size))
+		if err != nil {
+			return nil, err
+		}
+
+		url, err := s.fileService.SaveUserFile(userID, "avatar", fmt.Sprintf("avatar_%d.png", size), data)
+		if err != nil {
+			return nil, err
+		}
+		// 文件名固定，追加版本号避免浏览器缓存旧头像
+		response.Sizes[name] = fmt.Sprintf("%s?v=%d", url, version)
+	}
+	response.Avatar = response.Sizes["large"]
+
+	user.Avatar = response.Avatar
+	err = s.userRepo.Update(user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User avatar uploaded", zap.Uint("user_id", userID))
+	return response, nil
+}
+
+func (s *userService) GetIdenticon(key string, size int) ([]byte, error) {
+	if !identiconKeyPattern.MatchString(key) {
+		return nil, errors.New("无效的头像标识")
+	}
+
+	return utils.EncodePNG(utils.GenerateIdenticon(key, size))
+}
+
+// avatarOrDefault 未设置头像的用户返回默认头像地址
+func avatarOrDefault(user *model.User) string {
+	if user.Avatar != "" {
+		return user.Avatar
+	}
+	return model.DefaultAvatarURL(user.Username)
+}
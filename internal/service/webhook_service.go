@@ -0,0 +1,601 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"wz-wenzhan-backend/internal/model"
+	"wz-wenzhan-backend/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxWebhooksPerUser = 20
+	// 单次投递的超时时间
+	webhookTimeout = 10 * time.Second
+	// 最多投递次数（含首次），重试间隔从webhookRetryBaseDelay开始逐次翻倍
+	webhookMaxAttempts     = 6
+	webhookRetryBaseDelay  = 30 * time.Second
+	webhookRetryMaxDelay   = time.Hour
+	webhookDueBatchSize    = 100
+	webhookDueConcurrency  = 10
+	webhookResponseMaxSize = 2048
+	// 投递记录保留时间，仅清理已结束的投递
+	webhookDeliveryRetention = 30 * 24 * time.Hour
+)
+
+var errWebhookPrivateAddress = errors.New("不允许向内网地址投递")
+
+type WebhookService interface {
+	Create(userID uint, req *model.CreateWebhookRequest) (*model.WebhookSecretResponse, error)
+	List(userID uint) ([]model.WebhookResponse, error)
+	GetByID(id, userID uint) (*model.WebhookResponse, error)
+	Update(id, userID uint, req *model.UpdateWebhookRequest) (*model.WebhookResponse, error)
+	Delete(id, userID uint) error
+	// RotateSecret 重新生成签名密钥，旧密钥立即失效
+	RotateSecret(id, userID uint) (*model.WebhookSecretResponse, error)
+	ListDeliveries(id, userID uint, req *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error)
+	// TestDelivery 同步发送一次ping事件，失败不重试
+	TestDelivery(id, userID uint) (*model.WebhookDelivery, error)
+	// HandleEvent 事件订阅者，为订阅了该事件的Webhook生成投递并异步发送
+	HandleEvent(event *model.Event) error
+	// StartScheduler 定时重试到期的投递并清理过期的投递记录
+	StartScheduler(interval time.Duration)
+}
+
+type webhookService struct {
+	webhookRepo         repository.WebhookRepository
+	allowPrivateNetwork bool
+	client              *http.Client
+	logger              *zap.Logger
+}
+
+// NewWebhookService allowPrivateNetwork为false时拒绝投递到回环、内网和链路本地地址
+func NewWebhookService(webhookRepo repository.WebhookRepository, allowPrivateNetwork bool, logger *zap.Logger) WebhookService {
+	s := &webhookService{
+		webhookRepo:         webhookRepo,
+		allowPrivateNetwork: allowPrivateNetwork,
+		logger:              logger,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// 在建立连接时按解析后的IP校验，防止通过DNS指向内网绕过地址检查
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if s.allowPrivateNetwork {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isPrivateIP(ip) {
+				return errWebhookPrivateAddress
+			}
+			return nil
+		},
+	}
+
+	s.client = &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        20,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// 不跟随重定向，重定向响应视为投递失败
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return s
+}
+
+func (s *webhookService) Create(userID uint, req *model.CreateWebhookRequest) (*model.WebhookSecretResponse, error) {
+	count, err := s.webhookRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, fmt.Errorf("每个用户最多创建%d个Webhook", maxWebhooksPerUser)
+	}
+
+	if err := s.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &model.Webhook{
+		UserID:   userID,
+		Name:     req.Name,
+		URL:      req.URL,
+		Secret:   secret,
+		Events:   events,
+		IsActive: true,
+	}
+
+	err = s.webhookRepo.Create(webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Webhook created",
+		zap.Uint("user_id", userID),
+		zap.Uint("webhook_id", webhook.ID),
+		zap.String("events", webhook.Events))
+
+	return &model.WebhookSecretResponse{
+		WebhookResponse: toWebhookResponse(webhook),
+		Secret:          secret,
+	}, nil
+}
+
+func (s *webhookService) List(userID uint) ([]model.WebhookResponse, error) {
+	webhooks, err := s.webhookRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		responses = append(responses, toWebhookResponse(&webhooks[i]))
+	}
+
+	return responses, nil
+}
+
+func (s *webhookService) GetByID(id, userID uint) (*model.WebhookResponse, error) {
+	webhook, err := s.getWebhook(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toWebhookResponse(webhook)
+	return &response, nil
+}
+
+func (s *webhookService) Update(id, userID uint, req *model.UpdateWebhookRequest) (*model.WebhookResponse, error) {
+	webhook, err := s.getWebhook(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		webhook.Name = req.Name
+	}
+	if req.URL != "" {
+		if err := s.validateURL(req.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = req.URL
+	}
+	if len(req.Events) > 0 {
+		events, err := normalizeWebhookEvents(req.Events)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Events = events
+	}
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+
+	err = s.webhookRepo.Update(webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Webhook updated",
+		zap.Uint("user_id", userID),
+		zap.Uint("webhook_id", id))
+
+	response := toWebhookResponse(webhook)
+	return &response, nil
+}
+
+func (s *webhookService) Delete(id, userID uint) error {
+	err := s.webhookRepo.Delete(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("Webhook不存在")
+		}
+		return err
+	}
+
+	s.logger.Info("Webhook deleted",
+		zap.Uint("user_id", userID),
+		zap.Uint("webhook_id", id))
+
+	return nil
+}
+
+func (s *webhookService) RotateSecret(id, userID uint) (*model.WebhookSecretResponse, error) {
+	webhook, err := s.getWebhook(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+
+	err = s.webhookRepo.Update(webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Webhook secret rotated",
+		zap.Uint("user_id", userID),
+		zap.Uint("webhook_id", id))
+
+	return &model.WebhookSecretResponse{
+		WebhookResponse: toWebhookResponse(webhook),
+		Secret:          secret,
+	}, nil
+}
+
+func (s *webhookService) ListDeliveries(id, userID uint, req *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error) {
+	if _, err := s.getWebhook(id, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.webhookRepo.ListDeliveries(id, req)
+}
+
+func (s *webhookService) TestDelivery(id, userID uint) (*model.WebhookDelivery, error) {
+	webhook, err := s.getWebhook(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.enqueue(webhook, model.WebhookEventPing, map[string]interface{}{
+		"webhook_id": webhook.ID,
+		"message":    "这是一条测试消息",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.process(delivery)
+
+	return s.webhookRepo.GetDelivery(delivery.ID)
+}
+
+func (s *webhookService) HandleEvent(event *model.Event) error {
+	webhookEvent, data := webhookEventFor(event)
+	if webhookEvent == "" {
+		return nil
+	}
+
+	webhooks, err := s.webhookRepo.ListActiveByUserID(event.ActorID)
+	if err != nil {
+		return err
+	}
+
+	for i := range webhooks {
+		if !webhooks[i].Subscribes(webhookEvent) {
+			continue
+		}
+		// 单个Webhook写入失败时继续处理其他Webhook，返回错误会导致事件重试，已写入的Webhook重复投递
+		delivery, err := s.enqueue(&webhooks[i], webhookEvent, data)
+		if err != nil {
+			s.logger.Error("Failed to enqueue webhook delivery",
+				zap.Uint("webhook_id", webhooks[i].ID),
+				zap.String("event", string(webhookEvent)),
+				zap.Error(err))
+			continue
+		}
+		go s.process(delivery)
+	}
+
+	return nil
+}
+
+func (s *webhookService) StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastCleanup time.Time
+		for {
+			s.deliverDue()
+			if time.Since(lastCleanup) >= time.Hour {
+				deleted, err := s.webhookRepo.DeleteDeliveriesBefore(time.Now().Add(-webhookDeliveryRetention))
+				if err != nil {
+					s.logger.Error("Failed to clean up webhook deliveries", zap.Error(err))
+				} else if deleted > 0 {
+					s.logger.Info("Webhook deliveries cleaned up", zap.Int64("count", deleted))
+				}
+				lastCleanup = time.Now()
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *webhookService) deliverDue() {
+	deliveries, err := s.webhookRepo.ListDueDeliveries(time.Now(), webhookDueBatchSize)
+	if err != nil {
+		s.logger.Error("Failed to list due webhook deliveries", zap.Error(err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, webhookDueConcurrency)
+	for i := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery *model.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.process(delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+// enqueue 生成投递记录，请求体在生成时固定，重试时发送相同内容
+func (s *webhookService) enqueue(webhook *model.Webhook, event model.WebhookEvent, data interface{}) (*model.WebhookDelivery, error) {
+	now := time.Now()
+	payload, err := json.Marshal(&model.WebhookPayload{
+		Event:     event,
+		UserID:    webhook.UserID,
+		Timestamp: now,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &model.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: now,
+	}
+	if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// process 发送一次投递并记录结果，失败时按指数退避安排重试
+func (s *webhookService) process(delivery *model.WebhookDelivery) {
+	now := time.Now()
+	claimed, err := s.webhookRepo.ClaimDelivery(delivery.ID, now, now.Add(webhookTimeout+time.Minute))
+	if err != nil {
+		s.logger.Error("Failed to claim webhook delivery", zap.Uint("delivery_id", delivery.ID), zap.Error(err))
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	webhook, err := s.webhookRepo.GetByID(delivery.WebhookID)
+	if err != nil || !webhook.IsActive {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.Error = "Webhook已删除或已停用"
+		s.saveDelivery(delivery)
+		return
+	}
+
+	delivery.Attempts++
+	start := time.Now()
+	statusCode, body, sendErr := s.send(webhook, delivery)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.ResponseStatus = statusCode
+	delivery.ResponseBody = body
+	delivery.Error = ""
+
+	if sendErr == nil && statusCode >= 200 && statusCode < 300 {
+		deliveredAt := time.Now()
+		delivery.Status = model.WebhookDeliverySuccess
+		delivery.DeliveredAt = &deliveredAt
+		s.saveDelivery(delivery)
+		if err := s.webhookRepo.TouchLastDelivery(webhook.ID, deliveredAt); err != nil {
+			s.logger.Error("Failed to update webhook last delivery", zap.Uint("webhook_id", webhook.ID), zap.Error(err))
+		}
+		return
+	}
+
+	if sendErr != nil {
+		delivery.Error = truncateRunes(sendErr.Error(), 900)
+	} else {
+		delivery.Error = fmt.Sprintf("接收方返回状态码 %d", statusCode)
+	}
+
+	if delivery.Event == model.WebhookEventPing || delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+	} else {
+		delivery.Status = model.WebhookDeliveryRetrying
+		delivery.NextAttemptAt = time.Now().Add(webhookRetryDelay(delivery.Attempts))
+	}
+	s.saveDelivery(delivery)
+
+	s.logger.Warn("Webhook delivery failed",
+		zap.Uint("webhook_id", webhook.ID),
+		zap.Uint("delivery_id", delivery.ID),
+		zap.Int("attempts", delivery.Attempts),
+		zap.String("status", string(delivery.Status)),
+		zap.String("error", delivery.Error))
+}
+
+func (s *webhookService) send(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wz-wenzhan-webhook/1.0")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxSize))
+	return resp.StatusCode, string(body), nil
+}
+
+func (s *webhookService) saveDelivery(delivery *model.WebhookDelivery) {
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		s.logger.Error("Failed to save webhook delivery", zap.Uint("delivery_id", delivery.ID), zap.Error(err))
+	}
+}
+
+func (s *webhookService) getWebhook(id, userID uint) (*model.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Webhook不存在")
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// validateURL 校验回调地址，域名指向的IP在投递时再校验
+func (s *webhookService) validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return errors.New("无效的回调地址")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.New("回调地址仅支持http和https")
+	}
+	if s.allowPrivateNetwork {
+		return nil
+	}
+
+	host := parsed.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return errWebhookPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+		return errWebhookPrivateAddress
+	}
+	return nil
+}
+
+// signWebhookPayload 计算签名：HMAC-SHA256(secret, timestamp + "." + body)，
+// 接收方应同时校验时间戳，拒绝过旧的请求以防重放
+func signWebhookPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay 第n次失败后的等待时间：30s、1m、2m、4m……最长1小时
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookRetryMaxDelay {
+			return webhookRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+func normalizeWebhookEvents(events []model.WebhookEvent) (string, error) {
+	seen := make(map[model.WebhookEvent]bool)
+	values := make([]string, 0, len(events))
+	for _, event := range events {
+		if !model.IsValidWebhookEvent(event) {
+			return "", errors.New("无效的事件类型: " + string(event))
+		}
+		if !seen[event] {
+			seen[event] = true
+			values = append(values, string(event))
+		}
+	}
+	return strings.Join(values, ","), nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret, err := generateRandomString(48)
+	if err != nil {
+		return "", err
+	}
+	return model.WebhookSecretPrefix + secret, nil
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast()
+}
+
+func toWebhookResponse(webhook *model.Webhook) model.WebhookResponse {
+	return model.WebhookResponse{
+		ID:             webhook.ID,
+		Name:           webhook.Name,
+		URL:            webhook.URL,
+		Events:         webhook.EventList(),
+		IsActive:       webhook.IsActive,
+		LastDeliveryAt: webhook.LastDeliveryAt,
+		CreatedAt:      webhook.CreatedAt,
+		UpdatedAt:      webhook.UpdatedAt,
+	}
+}
+
+// webhookEventFor 将领域事件映射为Webhook事件和投递的数据，不对外投递的事件返回空
+func webhookEventFor(event *model.Event) (model.WebhookEvent, interface{}) {
+	switch event.Type {
+	case model.EventDocumentCreated, model.EventDocumentCopied:
+		return model.WebhookEventDocumentCreated, event.Document
+	case model.EventDocumentUpdated, model.EventDocumentMoved:
+		return model.WebhookEventDocumentUpdated, event.Document
+	case model.EventDocumentPublished:
+		return model.WebhookEventDocumentPublished, event.Document
+	case model.EventDocumentDeleted:
+		return model.WebhookEventDocumentDeleted, event.Document
+	case model.EventDocumentShared:
+		return model.WebhookEventShareCreated, event.Share
+	case model.EventFolderCreated:
+		return model.WebhookEventFolderCreated, event.Folder
+	case model.EventFolderUpdated:
+		return model.WebhookEventFolderUpdated, event.Folder
+	case model.EventFolderMoved:
+		return model.WebhookEventFolderMoved, event.Folder
+	case model.EventFolderDeleted:
+		return model.WebhookEventFolderDeleted, event.Folder
+	}
+	return "", nil
+}
@@ -10,17 +10,25 @@ import (
 type WorkspaceService interface {
 	GetDashboard(userID uint) (*model.DashboardData, error)
 	GetStats(userID uint) (*model.WorkspaceStats, error)
+	// GetRecentlyOpened 获取最近打开的文档，与按更新时间排序的最近文档不同，只查看过的文档也会返回
+	GetRecentlyOpened(userID uint, limit int) ([]model.AccessedDocumentResponse, error)
+	// GetFrequentDocuments 获取常用文档，按打开次数和最近打开时间综合排序
+	GetFrequentDocuments(userID uint, limit int) ([]model.AccessedDocumentResponse, error)
 }
 
 type workspaceService struct {
-	workspaceRepo repository.WorkspaceRepository
-	logger        *zap.Logger
+	workspaceRepo   repository.WorkspaceRepository
+	favoriteService FavoriteService
+	accessService   AccessService
+	logger          *zap.Logger
 }
 
-func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, logger *zap.Logger) WorkspaceService {
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, favoriteService FavoriteService, accessService AccessService, logger *zap.Logger) WorkspaceService {
 	return &workspaceService{
-		workspaceRepo: workspaceRepo,
-		logger:        logger,
+		workspaceRepo:   workspaceRepo,
+		favoriteService: favoriteService,
+		accessService:   accessService,
+		logger:          logger,
 	}
 }
 
@@ -30,6 +38,23 @@ func (s *workspaceService) GetDashboard(userID uint) (*model.DashboardData, erro
 		return nil, err
 	}
 
+	data.Pinned, err = s.favoriteService.ListPins(userID)
+	if err != nil {
+		return nil, err
+	}
+	data.Favorites, err = s.favoriteService.RecentFavorites(userID, model.DashboardFavoriteLimit)
+	if err != nil {
+		return nil, err
+	}
+	data.RecentlyOpened, err = s.accessService.Recent(userID, model.DashboardAccessLimit)
+	if err != nil {
+		return nil, err
+	}
+	data.FrequentDocuments, err = s.accessService.Frequent(userID, model.DashboardAccessLimit)
+	if err != nil {
+		return nil, err
+	}
+
 	s.logger.Debug("Dashboard data retrieved", zap.Uint("user_id", userID))
 	return data, nil
 }
@@ -43,3 +68,11 @@ func (s *workspaceService) GetStats(userID uint) (*model.WorkspaceStats, error)
 	s.logger.Debug("Workspace stats retrieved", zap.Uint("user_id", userID))
 	return stats, nil
 }
+
+func (s *workspaceService) GetRecentlyOpened(userID uint, limit int) ([]model.AccessedDocumentResponse, error) {
+	return s.accessService.Recent(userID, limit)
+}
+
+func (s *workspaceService) GetFrequentDocuments(userID uint, limit int) ([]model.AccessedDocumentResponse, error) {
+	return s.accessService.Frequent(userID, limit)
+}
@@ -0,0 +1,710 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// 只实现工作簿结构需要的SpreadsheetML子集：单元格值、公式及其缓存结果、常用样式、列宽、合并单元格和冻结窗格
+
+const (
+	xlsxNSMain          = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxNSRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxNSPackageRels   = "http://schemas.openxmlformats.org/package/2006/relationships"
+	// 解压后单个文件的最大大小，防止压缩炸弹
+	xlsxMaxPartSize = 100 << 20
+)
+
+// xlsxBuiltinNumFmts 常用的内置数字格式
+var xlsxBuiltinNumFmts = map[int]string{
+	1:  "0",
+	2:  "0.00",
+	3:  "#,##0",
+	4:  "#,##0.00",
+	9:  "0%",
+	10: "0.00%",
+	11: "0.00E+00",
+	14: "yyyy-mm-dd",
+	20: "hh:mm",
+	22: "yyyy-mm-dd hh:mm",
+	49: "@",
+}
+
+// writeXLSX 将工作簿写为xlsx文件，公式同时写入计算结果，打开时无需重新计算即可显示
+func writeXLSX(w io.Writer, workbook *model.Workbook) error {
+	zw := zip.NewWriter(w)
+	styles := newXLSXStyleTable()
+
+	sheetXML := make([][]byte, len(workbook.Sheets))
+	for i := range workbook.Sheets {
+		sheetXML[i] = buildSheetXML(&workbook.Sheets[i], styles)
+	}
+
+	var contentTypes, workbookXML, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	workbookXML.WriteString(xml.Header)
+	workbookXML.WriteString(`<workbook xmlns="` + xlsxNSMain + `" xmlns:r="` + xlsxNSRelationships + `"><sheets>`)
+
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="` + xlsxNSPackageRels + `">`)
+
+	for i, sheet := range workbook.Sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbookXML, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.Name), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="`+xlsxNSRelationships+`/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="`+xlsxNSRelationships+`/styles" Target="styles.xml"/>`, len(workbook.Sheets)+1)
+
+	contentTypes.WriteString(`</Types>`)
+	// 打开文件时重新计算，避免与Excel的计算结果不一致
+	workbookXML.WriteString(`</sheets><calcPr fullCalcOnLoad="1"/></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	rootRels := xml.Header + `<Relationships xmlns="` + xlsxNSPackageRels + `">` +
+		`<Relationship Id="rId1" Type="` + xlsxNSRelationships + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(contentTypes.String())},
+		{"_rels/.rels", []byte(rootRels)},
+		{"xl/workbook.xml", []byte(workbookXML.String())},
+		{"xl/_rels/workbook.xml.rels", []byte(workbookRels.String())},
+		{"xl/styles.xml", styles.xml()},
+	}
+	for i, data := range sheetXML {
+		parts = append(parts, struct {
+			name string
+			data []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), data})
+	}
+
+	for _, part := range parts {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(part.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func buildSheetXML(sheet *model.Sheet, styles *xlsxStyleTable) []byte {
+	type positioned struct {
+		ref      string
+		col, row int
+		cell     *model.Cell
+	}
+	cells := make([]positioned, 0, len(sheet.Cells))
+	for ref, cell := range sheet.Cells {
+		col, row, ok := parseCellRef(ref)
+		if ok {
+			cells = append(cells, positioned{ref, col, row, cell})
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].row != cells[j].row {
+			return cells[i].row < cells[j].row
+		}
+		return cells[i].col < cells[j].col
+	})
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="` + xlsxNSMain + `" xmlns:r="` + xlsxNSRelationships + `">`)
+
+	if sheet.FrozenRows > 0 || sheet.FrozenCols > 0 {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane`)
+		if sheet.FrozenCols > 0 {
+			fmt.Fprintf(&b, ` xSplit="%d"`, sheet.FrozenCols)
+		}
+		if sheet.FrozenRows > 0 {
+			fmt.Fprintf(&b, ` ySplit="%d"`, sheet.FrozenRows)
+		}
+		fmt.Fprintf(&b, ` topLeftCell="%s" state="frozen"/></sheetView></sheetViews>`, cellName(sheet.FrozenCols+1, sheet.FrozenRows+1))
+	}
+
+	if len(sheet.ColumnWidths) > 0 {
+		columns := make([]int, 0, len(sheet.ColumnWidths))
+		widths := make(map[int]float64, len(sheet.ColumnWidths))
+		for name, width := range sheet.ColumnWidths {
+			col := columnIndex(name)
+			columns = append(columns, col)
+			widths[col] = width
+		}
+		sort.Ints(columns)
+		b.WriteString(`<cols>`)
+		for _, col := range columns {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, col, col, formatNumber(widths[col]))
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	currentRow := 0
+	for _, pc := range cells {
+		if pc.row != currentRow {
+			if currentRow != 0 {
+				b.WriteString(`</row>`)
+			}
+			fmt.Fprintf(&b, `<row r="%d">`, pc.row)
+			currentRow = pc.row
+		}
+		writeXLSXCell(&b, pc.ref, pc.cell, styles.index(pc.cell.Style))
+	}
+	if currentRow != 0 {
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+
+	if len(sheet.Merges) > 0 {
+		fmt.Fprintf(&b, `<mergeCells count="%d">`, len(sheet.Merges))
+		for _, merge := range sheet.Merges {
+			fmt.Fprintf(&b, `<mergeCell ref="%s"/>`, xmlEscape(strings.ToUpper(merge)))
+		}
+		b.WriteString(`</mergeCells>`)
+	}
+
+	b.WriteString(`</worksheet>`)
+	return b.Bytes()
+}
+
+func writeXLSXCell(b *bytes.Buffer, ref string, cell *model.Cell, style int) {
+	fmt.Fprintf(b, `<c r="%s"`, ref)
+	if style > 0 {
+		fmt.Fprintf(b, ` s="%d"`, style)
+	}
+
+	if cell.Formula != "" {
+		var cellType, cached string
+		switch v := cell.Result.(type) {
+		case float64:
+			cached = formatNumber(v)
+		case bool:
+			cellType, cached = "b", boolDigit(v)
+		case string:
+			cellType, cached = "str", v
+			if strings.HasPrefix(v, "#") {
+				cellType = "e"
+			}
+		}
+		if cellType != "" {
+			fmt.Fprintf(b, ` t="%s"`, cellType)
+		}
+		fmt.Fprintf(b, `><f>%s</f><v>%s</v></c>`, xmlEscape(cell.Formula), xmlEscape(cached))
+		return
+	}
+
+	switch v := cell.Value.(type) {
+	case float64:
+		fmt.Fprintf(b, `><v>%s</v></c>`, formatNumber(v))
+	case bool:
+		fmt.Fprintf(b, ` t="b"><v>%s</v></c>`, boolDigit(v))
+	case string:
+		fmt.Fprintf(b, ` t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(v))
+	default:
+		b.WriteString(`/>`)
+	}
+}
+
+func boolDigit(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxStyleTable 导出时对样式去重，生成styles.xml中的字体、填充、数字格式和单元格格式
+type xlsxStyleTable struct {
+	fonts   []string
+	fills   []string
+	numFmts []string
+	xfs     []string
+	indexes map[string]int
+}
+
+func newXLSXStyleTable() *xlsxStyleTable {
+	return &xlsxStyleTable{
+		fonts: []string{`<font><sz val="11"/><name val="Calibri"/></font>`},
+		// 前两个填充是规范要求的保留项
+		fills:   []string{`<fill><patternFill patternType="none"/></fill>`, `<fill><patternFill patternType="gray125"/></fill>`},
+		xfs:     []string{`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`},
+		indexes: make(map[string]int),
+	}
+}
+
+// index 返回样式在cellXfs中的序号，无样式时为0
+func (t *xlsxStyleTable) index(style *model.CellStyle) int {
+	if style == nil {
+		return 0
+	}
+
+	var font strings.Builder
+	font.WriteString(`<font>`)
+	if style.Bold {
+		font.WriteString(`<b/>`)
+	}
+	if style.Italic {
+		font.WriteString(`<i/>`)
+	}
+	if style.Underline {
+		font.WriteString(`<u/>`)
+	}
+	size := style.FontSize
+	if size == 0 {
+		size = 11
+	}
+	fmt.Fprintf(&font, `<sz val="%s"/>`, formatNumber(size))
+	if style.Color != "" {
+		fmt.Fprintf(&font, `<color rgb="FF%s"/>`, strings.ToUpper(style.Color[1:]))
+	}
+	font.WriteString(`<name val="Calibri"/></font>`)
+	fontID := appendUnique(&t.fonts, font.String())
+
+	fillID := 0
+	if style.Background != "" {
+		fill := fmt.Sprintf(`<fill><patternFill patternType="solid"><fgColor rgb="FF%s"/><bgColor indexed="64"/></patternFill></fill>`, strings.ToUpper(style.Background[1:]))
+		fillID = appendUnique(&t.fills, fill)
+	}
+
+	numFmtID := 0
+	if style.NumberFormat != "" {
+		numFmtID = -1
+		for id, code := range xlsxBuiltinNumFmts {
+			if code == style.NumberFormat {
+				numFmtID = id
+				break
+			}
+		}
+		if numFmtID < 0 {
+			// 自定义格式的编号从164开始
+			numFmtID = 164 + appendUnique(&t.numFmts, style.NumberFormat)
+		}
+	}
+
+	xf := fmt.Sprintf(`<xf numFmtId="%d" fontId="%d" fillId="%d" borderId="0" xfId="0"`, numFmtID, fontID, fillID)
+	if numFmtID > 0 {
+		xf += ` applyNumberFormat="1"`
+	}
+	if fontID > 0 {
+		xf += ` applyFont="1"`
+	}
+	if fillID > 0 {
+		xf += ` applyFill="1"`
+	}
+	if style.Align != "" {
+		xf += fmt.Sprintf(` applyAlignment="1"><alignment horizontal="%s"/></xf>`, style.Align)
+	} else {
+		xf += `/>`
+	}
+
+	if index, ok := t.indexes[xf]; ok {
+		return index
+	}
+	t.xfs = append(t.xfs, xf)
+	t.indexes[xf] = len(t.xfs) - 1
+	return len(t.xfs) - 1
+}
+
+func appendUnique(list *[]string, item string) int {
+	for i, existing := range *list {
+		if existing == item {
+			return i
+		}
+	}
+	*list = append(*list, item)
+	return len(*list) - 1
+}
+
+func (t *xlsxStyleTable) xml() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<styleSheet xmlns="` + xlsxNSMain + `">`)
+	if len(t.numFmts) > 0 {
+		fmt.Fprintf(&b, `<numFmts count="%d">`, len(t.numFmts))
+		for i, code := range t.numFmts {
+			fmt.Fprintf(&b, `<numFmt numFmtId="%d" formatCode="%s"/>`, 164+i, xmlEscape(code))
+		}
+		b.WriteString(`</numFmts>`)
+	}
+	fmt.Fprintf(&b, `<fonts count="%d">%s</fonts>`, len(t.fonts), strings.Join(t.fonts, ""))
+	fmt.Fprintf(&b, `<fills count="%d">%s</fills>`, len(t.fills), strings.Join(t.fills, ""))
+	b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+	fmt.Fprintf(&b, `<cellXfs count="%d">%s</cellXfs>`, len(t.xfs), strings.Join(t.xfs, ""))
+	b.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	b.WriteString(`</styleSheet>`)
+	return b.Bytes()
+}
+
+// 读取xlsx
+
+type xlsxWorkbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationshipsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStringsXML struct {
+	Items []struct {
+		T string `xml:"t"`
+		R []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxColorXML struct {
+	RGB string `xml:"rgb,attr"`
+}
+
+type xlsxStylesXML struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	Fonts []struct {
+		B    *struct{} `xml:"b"`
+		I    *struct{} `xml:"i"`
+		U    *struct{} `xml:"u"`
+		Size *struct {
+			Val float64 `xml:"val,attr"`
+		} `xml:"sz"`
+		Color *xlsxColorXML `xml:"color"`
+	} `xml:"fonts>font"`
+	Fills []struct {
+		Pattern struct {
+			Type    string        `xml:"patternType,attr"`
+			FgColor *xlsxColorXML `xml:"fgColor"`
+		} `xml:"patternFill"`
+	} `xml:"fills>fill"`
+	CellXfs []struct {
+		NumFmtID  int `xml:"numFmtId,attr"`
+		FontID    int `xml:"fontId,attr"`
+		FillID    int `xml:"fillId,attr"`
+		Alignment *struct {
+			Horizontal string `xml:"horizontal,attr"`
+		} `xml:"alignment"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheetXML struct {
+	Panes []struct {
+		XSplit float64 `xml:"xSplit,attr"`
+		YSplit float64 `xml:"ySplit,attr"`
+		State  string  `xml:"state,attr"`
+	} `xml:"sheetViews>sheetView>pane"`
+	Cols []struct {
+		Min   int     `xml:"min,attr"`
+		Max   int     `xml:"max,attr"`
+		Width float64 `xml:"width,attr"`
+	} `xml:"cols>col"`
+	Rows []struct {
+		Cells []struct {
+			Ref     string `xml:"r,attr"`
+			Type    string `xml:"t,attr"`
+			Style   int    `xml:"s,attr"`
+			Formula *struct {
+				Text string `xml:",chardata"`
+				Type string `xml:"t,attr"`
+			} `xml:"f"`
+			Value  *string `xml:"v"`
+			Inline *struct {
+				T string `xml:"t"`
+				R []struct {
+					T string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	Merges []struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"mergeCells>mergeCell"`
+}
+
+// readXLSX 解析xlsx文件。共享公式只保留主单元格的公式，其余单元格使用缓存的计算结果
+func readXLSX(r io.ReaderAt, size int64) (*model.Workbook, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("无法读取文件，请确认是有效的xlsx文件")
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var workbookXML xlsxWorkbookXML
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbookXML, true); err != nil {
+		return nil, err
+	}
+	if len(workbookXML.Sheets) == 0 {
+		return nil, errors.New("文件中没有工作表")
+	}
+	if len(workbookXML.Sheets) > sheetMaxSheets {
+		return nil, fmt.Errorf("工作表不能超过%d个", sheetMaxSheets)
+	}
+
+	var rels xlsxRelationshipsXML
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels, true); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	var sharedXML xlsxSharedStringsXML
+	if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &sharedXML, false); err != nil {
+		return nil, err
+	}
+	shared := make([]string, len(sharedXML.Items))
+	for i, item := range sharedXML.Items {
+		shared[i] = item.T
+		for _, run := range item.R {
+			shared[i] += run.T
+		}
+	}
+
+	var stylesXML xlsxStylesXML
+	if err := decodeXLSXPart(files, "xl/styles.xml", &stylesXML, false); err != nil {
+		return nil, err
+	}
+	styles := convertXLSXStyles(&stylesXML)
+
+	workbook := &model.Workbook{}
+	for _, ws := range workbookXML.Sheets {
+		var sheetXML xlsxSheetXML
+		if err := decodeXLSXPart(files, targets[ws.RID], &sheetXML, true); err != nil {
+			return nil, err
+		}
+		sheet, err := convertXLSXSheet(ws.Name, &sheetXML, shared, styles)
+		if err != nil {
+			return nil, err
+		}
+		workbook.Sheets = append(workbook.Sheets, *sheet)
+	}
+
+	if err := validateWorkbook(workbook); err != nil {
+		return nil, err
+	}
+	return workbook, nil
+}
+
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}, required bool) error {
+	f, ok := files[name]
+	if !ok {
+		if required {
+			return fmt.Errorf("文件缺少%s，请确认是有效的xlsx文件", name)
+		}
+		return nil
+	}
+	if f.UncompressedSize64 > xlsxMaxPartSize {
+		return errors.New("文件内容过大")
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, xlsxMaxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("无法解析%s: %w", name, err)
+	}
+	return nil
+}
+
+func convertXLSXStyles(stylesXML *xlsxStylesXML) []*model.CellStyle {
+	numFmts := make(map[int]string, len(xlsxBuiltinNumFmts)+len(stylesXML.NumFmts))
+	for id, code := range xlsxBuiltinNumFmts {
+		numFmts[id] = code
+	}
+	for _, nf := range stylesXML.NumFmts {
+		numFmts[nf.ID] = nf.Code
+	}
+
+	styles := make([]*model.CellStyle, len(stylesXML.CellXfs))
+	for i, xf := range stylesXML.CellXfs {
+		style := &model.CellStyle{NumberFormat: numFmts[xf.NumFmtID]}
+		if xf.FontID > 0 && xf.FontID < len(stylesXML.Fonts) {
+			font := stylesXML.Fonts[xf.FontID]
+			style.Bold = font.B != nil
+			style.Italic = font.I != nil
+			style.Underline = font.U != nil
+			if font.Size != nil && font.Size.Val != 11 && font.Size.Val > 0 && font.Size.Val <= 409 {
+				style.FontSize = font.Size.Val
+			}
+			if font.Color != nil {
+				style.Color = argbToHex(font.Color.RGB)
+			}
+		}
+		if xf.FillID > 1 && xf.FillID < len(stylesXML.Fills) {
+			fill := stylesXML.Fills[xf.FillID].Pattern
+			if fill.Type == "solid" && fill.FgColor != nil {
+				style.Background = argbToHex(fill.FgColor.RGB)
+			}
+		}
+		if xf.Alignment != nil {
+			switch xf.Alignment.Horizontal {
+			case "left", "center", "right":
+				style.Align = xf.Alignment.Horizontal
+			}
+		}
+		if len(style.NumberFormat) > 100 {
+			style.NumberFormat = ""
+		}
+		if *style != (model.CellStyle{}) {
+			styles[i] = style
+		}
+	}
+	return styles
+}
+
+// argbToHex 将xlsx中的AARRGGBB颜色转换为#RRGGBB，主题色等其他形式忽略
+func argbToHex(argb string) string {
+	if len(argb) == 8 {
+		argb = argb[2:]
+	}
+	if len(argb) != 6 {
+		return ""
+	}
+	color := "#" + strings.ToUpper(argb)
+	if !colorPattern.MatchString(color) {
+		return ""
+	}
+	return color
+}
+
+func convertXLSXSheet(name string, sheetXML *xlsxSheetXML, shared []string, styles []*model.CellStyle) (*model.Sheet, error) {
+	sheet := &model.Sheet{Name: name, Cells: make(map[string]*model.Cell)}
+
+	for _, pane := range sheetXML.Panes {
+		if pane.State == "frozen" || pane.State == "frozenSplit" {
+			sheet.FrozenCols = int(pane.XSplit)
+			sheet.FrozenRows = int(pane.YSplit)
+		}
+	}
+
+	for _, col := range sheetXML.Cols {
+		if col.Width <= 0 || col.Width > 255 || col.Min < 1 {
+			continue
+		}
+		// 整表统一列宽时max通常为16384，只保留前256列
+		max := col.Max
+		if max > 256 {
+			max = 256
+		}
+		for c := col.Min; c <= max; c++ {
+			if sheet.ColumnWidths == nil {
+				sheet.ColumnWidths = make(map[string]float64)
+			}
+			sheet.ColumnWidths[columnName(c)] = col.Width
+		}
+	}
+
+	for _, row := range sheetXML.Rows {
+		for _, c := range row.Cells {
+			if _, _, ok := parseCellRef(c.Ref); !ok {
+				return nil, fmt.Errorf("工作表%s中的单元格引用无效：%s", name, c.Ref)
+			}
+			cell := &model.Cell{}
+			if c.Style > 0 && c.Style < len(styles) {
+				cell.Style = styles[c.Style]
+			}
+
+			raw := ""
+			if c.Value != nil {
+				raw = *c.Value
+			}
+			switch c.Type {
+			case "s":
+				index, err := strconv.Atoi(raw)
+				if err != nil || index < 0 || index >= len(shared) {
+					return nil, fmt.Errorf("工作表%s单元格%s的共享字符串无效", name, c.Ref)
+				}
+				cell.Value = shared[index]
+			case "inlineStr":
+				if c.Inline != nil {
+					text := c.Inline.T
+					for _, run := range c.Inline.R {
+						text += run.T
+					}
+					cell.Value = text
+				}
+			case "b":
+				cell.Value = raw == "1"
+			case "str", "e":
+				if raw != "" {
+					cell.Value = raw
+				}
+			default:
+				if raw != "" {
+					n, err := strconv.ParseFloat(raw, 64)
+					if err != nil {
+						return nil, fmt.Errorf("工作表%s单元格%s的数值无效", name, c.Ref)
+					}
+					cell.Value = n
+				}
+			}
+
+			if c.Formula != nil && strings.TrimSpace(c.Formula.Text) != "" {
+				if _, err := parseFormula(c.Formula.Text); err == nil {
+					cell.Formula = c.Formula.Text
+					cell.Value = nil
+				}
+			}
+
+			if cell.Value == nil && cell.Formula == "" && cell.Style == nil {
+				continue
+			}
+			col, rowIndex, _ := parseCellRef(c.Ref)
+			sheet.Cells[cellName(col, rowIndex)] = cell
+		}
+	}
+
+	for _, merge := range sheetXML.Merges {
+		if _, ok := parseCellArea(merge.Ref); ok && strings.Contains(merge.Ref, ":") {
+			sheet.Merges = append(sheet.Merges, merge.Ref)
+		}
+	}
+	return sheet, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"wz-wenzhan-backend/internal/model"
+)
+
+// testZip 按文件名和内容创建zip文件
+func testZip(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const (
+	testXLSXWorkbook = `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" r:id="rId1"/></sheets></workbook>`
+	testXLSXRels     = `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// testXLSXParts 返回只有一个工作表的最小xlsx文件内容
+func testXLSXParts(sheetData string) map[string]string {
+	return map[string]string{
+		"xl/workbook.xml":            testXLSXWorkbook,
+		"xl/_rels/workbook.xml.rels": testXLSXRels,
+		"xl/sharedStrings.xml":       `<sst><si><t>hello</t></si><si><r><t>富</t></r><r><t>文本</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row>` + sheetData + `</row></sheetData></worksheet>`,
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheetData string
+		want      map[string]*model.Cell
+	}{
+		{"数字", `<c r="A1"><v>1.5</v></c>`, map[string]*model.Cell{"A1": {Value: 1.5}}},
+		{"共享字符串", `<c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c>`, map[string]*model.Cell{"A1": {Value: "hello"}, "B1": {Value: "富文本"}}},
+		{"内联字符串", `<c r="A1" t="inlineStr"><is><t>inline</t></is></c>`, map[string]*model.Cell{"A1": {Value: "inline"}}},
+		{"布尔值", `<c r="A1" t="b"><v>1</v></c>`, map[string]*model.Cell{"A1": {Value: true}}},
+		{"公式忽略缓存的结果", `<c r="A1"><f>1+2</f><v>3</v></c>`, map[string]*model.Cell{"A1": {Formula: "1+2"}}},
+		{"无法解析的公式保留缓存的结果", `<c r="A1"><f>1+</f><v>3</v></c>`, map[string]*model.Cell{"A1": {Value: 3.0}}},
+		{"小写的单元格引用", `<c r="b2"><v>2</v></c>`, map[string]*model.Cell{"B2": {Value: 2.0}}},
+		{"忽略空单元格", `<c r="A1"/>`, map[string]*model.Cell{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testZip(t, testXLSXParts(tt.sheetData))
+			workbook, err := readXLSX(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("readXLSX() error = %v", err)
+			}
+			cells := workbook.Sheets[0].Cells
+			if len(cells) != len(tt.want) {
+				t.Fatalf("got %d cells, want %d", len(cells), len(tt.want))
+			}
+			for ref, want := range tt.want {
+				got, ok := cells[ref]
+				if !ok {
+					t.Fatalf("cell %s missing", ref)
+				}
+				if got.Value != want.Value || got.Formula != want.Formula || got.Result != want.Result {
+					t.Errorf("cell %s = %+v, want %+v", ref, *got, *want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadXLSXMalformed(t *testing.T) {
+	withPart := func(name, content string) map[string]string {
+		parts := testXLSXParts(`<c r="A1"><v>1</v></c>`)
+		if content == "" {
+			delete(parts, name)
+		} else {
+			parts[name] = content
+		}
+		return parts
+	}
+	tooManySheets := `<workbook><sheets>` + strings.Repeat(`<sheet name="s"/>`, sheetMaxSheets+1) + `</sheets></workbook>`
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"不是zip文件", []byte("not a zip file"), "无法读取文件"},
+		{"空文件", nil, "无法读取文件"},
+		{"缺少workbook.xml", testZip(t, withPart("xl/workbook.xml", "")), "文件缺少xl/workbook.xml"},
+		{"缺少关系文件", testZip(t, withPart("xl/_rels/workbook.xml.rels", "")), "文件缺少xl/_rels/workbook.xml.rels"},
+		{"缺少工作表文件", testZip(t, withPart("xl/worksheets/sheet1.xml", "")), "文件缺少"},
+		{"workbook.xml不是xml", testZip(t, withPart("xl/workbook.xml", "<workbook><sheets>")), "无法解析xl/workbook.xml"},
+		{"没有工作表", testZip(t, withPart("xl/workbook.xml", "<workbook/>")), "文件中没有工作表"},
+		{"工作表过多", testZip(t, withPart("xl/workbook.xml", tooManySheets)), fmt.Sprintf("工作表不能超过%d个", sheetMaxSheets)},
+		{"单元格引用无效", testZip(t, testXLSXParts(`<c r="A0"><v>1</v></c>`)), "单元格引用无效"},
+		{"单元格引用超出范围", testZip(t, testXLSXParts(`<c r="XFE1"><v>1</v></c>`)), "单元格引用无效"},
+		{"共享字符串索引越界", testZip(t, testXLSXParts(`<c r="A1" t="s"><v>2</v></c>`)), "共享字符串无效"},
+		{"共享字符串索引为负数", testZip(t, testXLSXParts(`<c r="A1" t="s"><v>-1</v></c>`)), "共享字符串无效"},
+		{"数值无效", testZip(t, testXLSXParts(`<c r="A1"><v>abc</v></c>`)), "数值无效"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readXLSX(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readXLSX() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadXLSXTruncated(t *testing.T) {
+	data := testZip(t, testXLSXParts(`<c r="A1"><v>1</v></c>`))
+	for _, n := range []int{len(data) / 4, len(data) / 2, len(data) - 1} {
+		if _, err := readXLSX(bytes.NewReader(data[:n]), int64(n)); err == nil {
+			t.Errorf("readXLSX() of %d/%d bytes succeeded, want error", n, len(data))
+		}
+	}
+}
+
+func TestReadXLSXOversizedPart(t *testing.T) {
+	// 伪造声明的解压后大小，不必真正写入超大内容
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range testXLSXParts(`<c r="A1"><v>1</v></c>`) {
+		header := &zip.FileHeader{Name: name, Method: zip.Store}
+		header.CompressedSize64 = uint64(len(content))
+		header.UncompressedSize64 = uint64(len(content))
+		if name == "xl/worksheets/sheet1.xml" {
+			header.UncompressedSize64 = xlsxMaxPartSize + 1
+		}
+		w, err := zw.CreateRaw(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	_, err := readXLSX(bytes.NewReader(data), int64(len(data)))
+	if err == nil || !strings.Contains(err.Error(), "文件内容过大") {
+		t.Errorf("readXLSX() error = %v, want 文件内容过大", err)
+	}
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	workbook := &model.Workbook{Sheets: []model.Sheet{
+		testSheet("数据", map[string]interface{}{
+			"A1": "名称", "B1": 1.5, "C1": true, "D1": "=B1*2",
+		}),
+		testSheet("汇总", map[string]interface{}{"A1": "=SUM(数据!B1:B2)"}),
+	}}
+	workbook.Sheets[0].Cells["A1"].Style = &model.CellStyle{Bold: true}
+	workbook.Sheets[0].Merges = []string{"A2:B2"}
+	evaluateWorkbook(workbook)
+
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, workbook); err != nil {
+		t.Fatalf("writeXLSX() error = %v", err)
+	}
+	got, err := readXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("readXLSX() error = %v", err)
+	}
+
+	if len(got.Sheets) != 2 || got.Sheets[0].Name != "数据" || got.Sheets[1].Name != "汇总" {
+		t.Fatalf("sheets = %+v", got.Sheets)
+	}
+	cells := got.Sheets[0].Cells
+	if v := cells["A1"].Value; v != "名称" {
+		t.Errorf("A1 = %#v, want 名称", v)
+	}
+	if s := cells["A1"].Style; s == nil || !s.Bold {
+		t.Errorf("A1 style = %+v, want bold", s)
+	}
+	if v := cells["B1"].Value; v != 1.5 {
+		t.Errorf("B1 = %#v, want 1.5", v)
+	}
+	if v := cells["C1"].Value; v != true {
+		t.Errorf("C1 = %#v, want true", v)
+	}
+	if f := cells["D1"].Formula; f != "B1*2" {
+		t.Errorf("D1 formula = %q, want B1*2", f)
+	}
+	if f := got.Sheets[1].Cells["A1"].Formula; f != "SUM(数据!B1:B2)" {
+		t.Errorf("汇总!A1 formula = %q", f)
+	}
+	if merges := got.Sheets[0].Merges; len(merges) != 1 || merges[0] != "A2:B2" {
+		t.Errorf("merges = %v, want [A2:B2]", merges)
+	}
+}
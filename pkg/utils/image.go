@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+)
+
+// CropSquare 以中心为基准将图片裁剪为正方形
+func CropSquare(img image.Image) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == height {
+		return img
+	}
+
+	side := width
+	if height < side {
+		side = height
+	}
+	x0 := bounds.Min.X + (width-side)/2
+	y0 := bounds.Min.Y + (height-side)/2
+	rect := image.Rect(0, 0, side, side)
+
+	dst := image.NewRGBA(rect)
+	draw.Draw(dst, rect, img, image.Point{X: x0, Y: y0}, draw.Src)
+	return dst
+}
+
+// ResizeImage 将图片缩放到指定尺寸，缩小时对源像素区域取平均值
+func ResizeImage(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := bounds.Min.Y + (y+1)*srcH/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := bounds.Min.X + (x+1)*srcW/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+// EncodePNG 将图片编码为PNG
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateIdenticon 根据种子字符串生成5x5对称的默认头像
+func GenerateIdenticon(seed string, size int) *image.RGBA {
+	const grid = 5
+	hash := md5.Sum([]byte(seed))
+
+	foreground := color.RGBA{
+		R: hash[0]/2 + 64,
+		G: hash[1]/2 + 64,
+		B: hash[2]/2 + 64,
+		A: 255,
+	}
+	background := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	padding := size / 10
+	cell := (size - 2*padding) / grid
+	offset := (size - cell*grid) / 2
+
+	// 只计算左侧三列，右侧两列镜像
+	for row := 0; row < grid; row++ {
+		for col := 0; col < (grid+1)/2; col++ {
+			index := row*3 + col
+			if (hash[3+index/2]>>(uint(index%2)*4))&1 != 0 {
+				continue
+			}
+			for _, c := range []int{col, grid - 1 - col} {
+				rect := image.Rect(
+					offset+c*cell, offset+row*cell,
+					offset+(c+1)*cell, offset+(row+1)*cell,
+				)
+				draw.Draw(img, rect, &image.Uniform{C: foreground}, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	return img
+}
@@ -10,13 +10,15 @@ import (
 var jwtSecret = []byte("wz-wenzhan-backend-secret")
 
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID       uint `json:"user_id"`
+	TokenVersion int  `json:"token_version"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID uint) (string, error) {
+func GenerateJWT(userID uint, tokenVersion int) (string, error) {
 	claims := Claims{
-		UserID: userID,
+		UserID:       userID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
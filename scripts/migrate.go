@@ -21,6 +21,33 @@ func main() {
 		&model.Document{},
 		&model.Activity{},
 		&model.RecycleItem{},
+		&model.APIToken{},
+		&model.AuditLog{},
+		&model.DataExport{},
+		&model.AccountDeletion{},
+		&model.Comment{},
+		&model.Notification{},
+		&model.NotificationPreference{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.OutboxEvent{},
+		&model.DocumentTemplate{},
+		&model.AIDraft{},
+		&model.DocumentVersion{},
+		&model.AISuggestion{},
+		&model.AIUsage{},
+		&model.DocumentEmbedding{},
+		&model.MindMapNodeIndex{},
+		&model.DocumentLink{},
+		&model.Attachment{},
+		&model.DocumentReview{},
+		&model.DocumentReviewer{},
+		&model.PublicSite{},
+		&model.PublicPage{},
+		&model.Favorite{},
+		&model.Pin{},
+		&model.DocumentAccess{},
+		&model.ShareRecipient{},
 	)
 
 	if err != nil {
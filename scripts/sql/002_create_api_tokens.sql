@@ -0,0 +1,20 @@
+-- 个人访问令牌表
+CREATE TABLE IF NOT EXISTS `api_tokens` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `name` varchar(100) NOT NULL COMMENT '令牌名称',
+  `prefix` varchar(16) NOT NULL COMMENT '令牌前缀，用于识别',
+  `token_hash` varchar(64) NOT NULL COMMENT '令牌SHA-256摘要',
+  `scopes` varchar(500) NOT NULL COMMENT '权限范围，逗号分隔，如documents:read',
+  `expires_at` datetime NOT NULL COMMENT '过期时间',
+  `last_used_at` datetime DEFAULT NULL COMMENT '最后使用时间',
+  `last_used_ip` varchar(45) DEFAULT '' COMMENT '最后使用IP',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_token_hash` (`token_hash`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_deleted_at` (`deleted_at`),
+  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='个人访问令牌表';
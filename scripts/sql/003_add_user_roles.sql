@@ -0,0 +1,8 @@
+-- 用户角色与令牌版本
+ALTER TABLE `users`
+  ADD COLUMN `role` varchar(20) NOT NULL DEFAULT 'user' COMMENT '角色：user-普通用户，admin-管理员，auditor-审计员' AFTER `status`,
+  ADD COLUMN `token_version` int NOT NULL DEFAULT '0' COMMENT '令牌版本，禁用账号或重置密码时递增' AFTER `role`,
+  ADD KEY `idx_role` (`role`);
+
+-- 初始管理员
+UPDATE `users` SET `role` = 'admin' WHERE `username` = 'admin';
@@ -0,0 +1,21 @@
+-- 审计日志表（只追加，每条记录的hash包含上一条记录的hash）
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `actor_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '操作人ID，匿名操作为0',
+  `actor_role` varchar(20) DEFAULT '' COMMENT '操作人角色',
+  `action` varchar(50) NOT NULL COMMENT '审计动作',
+  `resource_type` varchar(30) DEFAULT '' COMMENT '对象类型',
+  `resource_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '对象ID',
+  `ip_address` varchar(45) DEFAULT '' COMMENT 'IP地址',
+  `user_agent` varchar(500) DEFAULT '' COMMENT '用户代理',
+  `before` text COMMENT '变更前的值(JSON)',
+  `after` text COMMENT '变更后的值(JSON)',
+  `prev_hash` varchar(64) DEFAULT '' COMMENT '上一条记录的hash',
+  `hash` varchar(64) NOT NULL COMMENT '本条记录的hash',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_hash` (`hash`),
+  KEY `idx_actor_id` (`actor_id`),
+  KEY `idx_action` (`action`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='审计日志表';
@@ -0,0 +1,34 @@
+-- 个人数据导出任务表
+CREATE TABLE IF NOT EXISTS `data_exports` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `status` varchar(20) NOT NULL COMMENT '状态：pending/processing/completed/failed/expired',
+  `file_path` varchar(500) DEFAULT '' COMMENT '导出文件路径',
+  `file_size` bigint DEFAULT '0' COMMENT '文件大小(字节)',
+  `error` varchar(500) DEFAULT '' COMMENT '失败原因',
+  `completed_at` datetime DEFAULT NULL COMMENT '完成时间',
+  `expires_at` datetime DEFAULT NULL COMMENT '文件过期时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='个人数据导出任务表';
+
+-- 账号注销申请表
+CREATE TABLE IF NOT EXISTS `account_deletions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `status` varchar(20) NOT NULL COMMENT '状态：pending/cancelled/completed',
+  `content_action` varchar(20) NOT NULL COMMENT '内容处理方式：purge-删除，transfer-转移',
+  `transfer_to_user_id` bigint unsigned DEFAULT NULL COMMENT '接收内容的用户ID',
+  `reason` varchar(500) DEFAULT '' COMMENT '注销原因',
+  `scheduled_at` datetime NOT NULL COMMENT '计划执行时间（冷静期结束）',
+  `completed_at` datetime DEFAULT NULL COMMENT '执行时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_scheduled_at` (`scheduled_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='账号注销申请表';
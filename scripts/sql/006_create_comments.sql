@@ -0,0 +1,28 @@
+-- 文档评论表
+CREATE TABLE IF NOT EXISTS `comments` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `user_id` bigint unsigned NOT NULL COMMENT '评论人ID',
+  `parent_id` bigint unsigned DEFAULT NULL COMMENT '讨论串首条评论ID，为空表示首条评论',
+  `content` text NOT NULL COMMENT '评论内容',
+  `anchor_type` varchar(10) DEFAULT '' COMMENT '锚点类型：range-文本范围，block-内容块，空-整篇文档',
+  `anchor_start` int DEFAULT '0' COMMENT '文本起始偏移',
+  `anchor_end` int DEFAULT '0' COMMENT '文本结束偏移',
+  `anchor_block_id` varchar(64) DEFAULT '' COMMENT '内容块ID',
+  `quote` varchar(1000) DEFAULT '' COMMENT '评论时选中的文本',
+  `mentions` varchar(500) DEFAULT '' COMMENT '被@的用户ID，逗号分隔',
+  `resolved` tinyint(1) DEFAULT '0' COMMENT '是否已解决',
+  `resolved_by` bigint unsigned DEFAULT NULL COMMENT '解决人ID',
+  `resolved_at` datetime DEFAULT NULL COMMENT '解决时间',
+  `edited_at` datetime DEFAULT NULL COMMENT '最后编辑时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_document_id` (`document_id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_parent_id` (`parent_id`),
+  KEY `idx_deleted_at` (`deleted_at`),
+  FOREIGN KEY (`document_id`) REFERENCES `documents` (`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档评论表';
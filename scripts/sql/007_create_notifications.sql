@@ -0,0 +1,30 @@
+-- 站内通知表
+CREATE TABLE IF NOT EXISTS `notifications` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '接收人ID',
+  `type` varchar(30) NOT NULL COMMENT '通知类型',
+  `title` varchar(255) NOT NULL COMMENT '标题',
+  `content` varchar(1000) DEFAULT '' COMMENT '内容摘要',
+  `actor_id` bigint unsigned DEFAULT NULL COMMENT '触发通知的用户ID，系统通知为空',
+  `resource_type` varchar(20) DEFAULT '' COMMENT '关联资源类型',
+  `resource_id` bigint unsigned DEFAULT '0' COMMENT '关联资源ID',
+  `link` varchar(500) DEFAULT '' COMMENT '跳转链接',
+  `read_at` datetime DEFAULT NULL COMMENT '阅读时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_read` (`user_id`, `read_at`),
+  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='站内通知表';
+
+-- 通知偏好表
+CREATE TABLE IF NOT EXISTS `notification_preferences` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `type` varchar(30) NOT NULL COMMENT '通知类型',
+  `enabled` tinyint(1) DEFAULT '1' COMMENT '是否接收',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_type` (`user_id`, `type`),
+  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='通知偏好表';
@@ -0,0 +1,40 @@
+-- Webhook表
+CREATE TABLE IF NOT EXISTS `webhooks` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `name` varchar(100) NOT NULL COMMENT '名称',
+  `url` varchar(500) NOT NULL COMMENT '回调地址',
+  `secret` varchar(80) NOT NULL COMMENT 'HMAC签名密钥',
+  `events` varchar(500) NOT NULL COMMENT '订阅的事件，逗号分隔',
+  `is_active` tinyint(1) DEFAULT '1' COMMENT '是否启用',
+  `last_delivery_at` datetime DEFAULT NULL COMMENT '最后成功投递时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_deleted_at` (`deleted_at`),
+  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook表';
+
+-- Webhook投递记录表
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `webhook_id` bigint unsigned NOT NULL COMMENT 'Webhook ID',
+  `event` varchar(50) NOT NULL COMMENT '事件类型',
+  `payload` mediumtext COMMENT '请求体',
+  `status` varchar(20) NOT NULL COMMENT '状态：pending-等待投递，retrying-等待重试，success-成功，failed-失败',
+  `attempts` int DEFAULT '0' COMMENT '已投递次数',
+  `next_attempt_at` datetime NOT NULL COMMENT '下次投递时间',
+  `response_status` int DEFAULT '0' COMMENT '接收方响应状态码',
+  `response_body` text COMMENT '接收方响应内容（截断）',
+  `error` varchar(1000) DEFAULT '' COMMENT '失败原因',
+  `duration_ms` bigint DEFAULT '0' COMMENT '耗时(毫秒)',
+  `delivered_at` datetime DEFAULT NULL COMMENT '投递成功时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_id` (`webhook_id`),
+  KEY `idx_status_next` (`status`, `next_attempt_at`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook投递记录表';
@@ -0,0 +1,16 @@
+-- 事件发件箱表
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `type` varchar(50) NOT NULL COMMENT '事件类型',
+  `payload` mediumtext NOT NULL COMMENT '事件内容(JSON)',
+  `handled` varchar(500) DEFAULT '' COMMENT '已处理成功的订阅者，逗号分隔',
+  `status` varchar(20) NOT NULL COMMENT '状态：pending-待处理，done-已完成，failed-失败',
+  `attempts` int DEFAULT '0' COMMENT '已处理次数',
+  `last_error` varchar(1000) DEFAULT '' COMMENT '最近一次失败原因',
+  `next_attempt_at` datetime NOT NULL COMMENT '下次处理时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_status_next` (`status`, `next_attempt_at`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='事件发件箱表';
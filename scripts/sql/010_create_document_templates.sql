@@ -0,0 +1,42 @@
+-- 文档模板表
+CREATE TABLE IF NOT EXISTS `document_templates` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned DEFAULT '0' COMMENT '创建者，系统模板为0',
+  `scope` varchar(20) NOT NULL COMMENT '范围：system-系统，personal-个人，team-团队',
+  `name` varchar(100) NOT NULL COMMENT '模板名称',
+  `description` varchar(500) DEFAULT '' COMMENT '模板说明',
+  `category` varchar(50) DEFAULT '' COMMENT '分类',
+  `type` varchar(20) NOT NULL COMMENT '文档类型',
+  `title` varchar(255) NOT NULL COMMENT '新文档标题，可包含占位符',
+  `content` longtext COMMENT '模板内容，可包含占位符',
+  `tags` varchar(500) DEFAULT '' COMMENT '新文档标签',
+  `variables` text COMMENT '声明的占位符(JSON)',
+  `source_document_id` bigint unsigned DEFAULT NULL COMMENT '保存模板时的来源文档',
+  `usage_count` int DEFAULT '0' COMMENT '使用次数',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_scope` (`scope`),
+  KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档模板表';
+
+-- 系统模板
+INSERT INTO `document_templates` (`user_id`, `scope`, `name`, `description`, `category`, `type`, `title`, `content`, `tags`, `variables`) VALUES
+(0, 'system', '会议纪要', '记录会议议题、讨论要点、决议和待办事项', '会议', 'word', '{{topic}}会议纪要 {{date}}',
+'# {{topic}}会议纪要\n\n**时间**: {{date}} {{time}}\n**主持人**: {{host}}\n**记录人**: {{user}}\n**参与人员**: \n\n## 议题\n\n1. \n\n## 讨论要点\n\n- \n\n## 决议\n\n- \n\n## 待办事项\n\n| 事项 | 负责人 | 截止日期 |\n| --- | --- | --- |\n|  |  |  |\n',
+'会议,纪要',
+'[{"name":"topic","label":"会议主题","default":"","required":true},{"name":"host","label":"主持人","default":"","required":false}]'),
+(0, 'system', '周报', '按本周完成、进行中、下周计划和风险整理工作周报', '工作汇报', 'word', '{{user}}的周报 {{year}}年第{{week}}周',
+'# 周报 {{year}}年第{{week}}周\n\n**姓名**: {{user}}\n**部门**: {{department}}\n**日期**: {{date}}\n\n## 本周完成\n\n- \n\n## 进行中\n\n- \n\n## 下周计划\n\n- \n\n## 风险与需要的支持\n\n- \n',
+'周报,汇报',
+'[{"name":"department","label":"部门","default":"","required":false}]'),
+(0, 'system', 'OKR', '以思维导图拆解目标和关键结果', '目标管理', 'mindmap', '{{period}} OKR',
+'{"root":{"text":"{{period}} OKR","children":[{"text":"O1：{{objective}}","children":[{"text":"KR1：","children":[]},{"text":"KR2：","children":[]},{"text":"KR3：","children":[]}]},{"text":"O2：","children":[{"text":"KR1：","children":[]},{"text":"KR2：","children":[]}]}]}}',
+'OKR,目标',
+'[{"name":"period","label":"周期","default":"","required":true},{"name":"objective","label":"首要目标","default":"","required":false}]'),
+(0, 'system', '预算表', '按项目登记预算与实际支出并计算差额', '财务', 'excel', '{{project}}预算表',
+'{"sheets":[{"name":"预算","rows":[["{{project}}预算表","","","","",""],["项目","类别","预算金额","实际金额","差额","备注"],["","","","","=C3-D3",""],["","","","","=C4-D4",""],["","","","","=C5-D5",""],["合计","","=SUM(C3:C5)","=SUM(D3:D5)","=C6-D6",""]]}]}',
+'预算,财务',
+'[{"name":"project","label":"项目名称","default":"","required":true}]');
@@ -0,0 +1,22 @@
+-- AI起草记录表
+CREATE TABLE IF NOT EXISTS `ai_drafts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `document_id` bigint unsigned DEFAULT NULL COMMENT '生成成功后保存的文档',
+  `prompt` text NOT NULL COMMENT '提示词',
+  `outline` text COMMENT '大纲(JSON)',
+  `source_document_ids` varchar(255) DEFAULT '' COMMENT '参考文档，逗号分隔',
+  `provider` varchar(50) DEFAULT '' COMMENT '模型服务商',
+  `model` varchar(100) DEFAULT '' COMMENT '模型',
+  `status` varchar(20) NOT NULL COMMENT '状态：generating-生成中，completed-已完成，failed-失败，cancelled-已取消',
+  `error` varchar(1000) DEFAULT '' COMMENT '失败原因',
+  `prompt_tokens` int DEFAULT '0' COMMENT '输入token数',
+  `completion_tokens` int DEFAULT '0' COMMENT '输出token数',
+  `duration_ms` bigint DEFAULT '0' COMMENT '生成耗时(毫秒)',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_document_id` (`document_id`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI起草记录表';
@@ -0,0 +1,55 @@
+-- 文档版本表
+CREATE TABLE IF NOT EXISTS `document_versions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `version` int NOT NULL COMMENT '版本号，同一文档内递增',
+  `user_id` bigint unsigned NOT NULL COMMENT '产生该版本的用户',
+  `title` varchar(255) NOT NULL COMMENT '标题',
+  `content` longtext COMMENT '内容',
+  `size` bigint DEFAULT '0' COMMENT '内容大小',
+  `source` varchar(20) NOT NULL COMMENT '来源：snapshot-自动保存，ai_assist-应用AI建议，restore-恢复历史版本',
+  `note` varchar(255) DEFAULT '' COMMENT '说明',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_document_version` (`document_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档版本表';
+
+-- AI建议表
+CREATE TABLE IF NOT EXISTS `ai_suggestions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `feature` varchar(20) NOT NULL COMMENT '操作：summarize-摘要，rewrite-改写，translate-翻译，continue-续写，outline-大纲',
+  `range_start` int DEFAULT '0' COMMENT '选中范围起始(字符)',
+  `range_end` int DEFAULT '0' COMMENT '选中范围结束(字符)',
+  `instruction` varchar(500) DEFAULT '' COMMENT '补充要求',
+  `target_language` varchar(50) DEFAULT '' COMMENT '翻译目标语言',
+  `output` longtext COMMENT '生成结果',
+  `base_hash` varchar(64) DEFAULT '' COMMENT '生成时文档内容的SHA-256',
+  `status` varchar(20) NOT NULL COMMENT '状态：pending-待处理，applied-已应用，discarded-已放弃',
+  `version_id` bigint unsigned DEFAULT NULL COMMENT '应用后生成的版本',
+  `model` varchar(100) DEFAULT '' COMMENT '模型',
+  `prompt_tokens` int DEFAULT '0' COMMENT '输入token数',
+  `completion_tokens` int DEFAULT '0' COMMENT '输出token数',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_document_id` (`document_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI建议表';
+
+-- AI用量表
+CREATE TABLE IF NOT EXISTS `ai_usages` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `feature` varchar(20) NOT NULL COMMENT 'AI功能',
+  `provider` varchar(50) DEFAULT '' COMMENT '模型服务商',
+  `model` varchar(100) DEFAULT '' COMMENT '模型',
+  `status` varchar(20) NOT NULL COMMENT '结果：success-成功，failed-失败',
+  `prompt_tokens` int DEFAULT '0' COMMENT '输入token数',
+  `completion_tokens` int DEFAULT '0' COMMENT '输出token数',
+  `duration_ms` bigint DEFAULT '0' COMMENT '耗时(毫秒)',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_created` (`user_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='AI用量表';
@@ -0,0 +1,16 @@
+-- 文档向量表
+CREATE TABLE IF NOT EXISTS `document_embeddings` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `user_id` bigint unsigned NOT NULL COMMENT '文档所有者',
+  `chunk_index` int NOT NULL COMMENT '分块序号',
+  `content` text COMMENT '分块原文',
+  `content_hash` varchar(64) NOT NULL COMMENT '整篇文档标题和内容的SHA-256',
+  `model` varchar(100) NOT NULL COMMENT '生成向量的模型',
+  `dimensions` int NOT NULL COMMENT '向量维度',
+  `vector` mediumblob NOT NULL COMMENT '向量，float32小端序',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_document_id` (`document_id`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档向量表';
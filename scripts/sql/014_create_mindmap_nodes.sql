@@ -0,0 +1,16 @@
+-- 思维导图节点索引表
+CREATE TABLE IF NOT EXISTS `mindmap_nodes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `document_id` bigint unsigned NOT NULL COMMENT '思维导图文档ID',
+  `user_id` bigint unsigned NOT NULL COMMENT '文档所有者',
+  `node_id` varchar(32) NOT NULL COMMENT '节点ID',
+  `parent_id` varchar(32) DEFAULT NULL COMMENT '父节点ID，中心主题为空',
+  `depth` int NOT NULL COMMENT '层级，中心主题为0',
+  `text` varchar(1000) DEFAULT NULL COMMENT '节点文本',
+  `note` text COMMENT '节点备注',
+  `path` varchar(2000) DEFAULT NULL COMMENT '祖先节点文本，以" > "连接',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_document_id` (`document_id`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='思维导图节点索引表';
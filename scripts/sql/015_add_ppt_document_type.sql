@@ -0,0 +1,3 @@
+-- 新增演示文稿类型
+ALTER TABLE `documents`
+  MODIFY COLUMN `type` varchar(20) NOT NULL COMMENT '文档类型：word,excel,ppt,mindmap,note,ai_draft,imported';
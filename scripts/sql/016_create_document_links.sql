@@ -0,0 +1,15 @@
+-- 文档链接表
+CREATE TABLE IF NOT EXISTS `document_links` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '文档所有者',
+  `source_id` bigint unsigned NOT NULL COMMENT '包含链接的文档ID',
+  `target_id` bigint unsigned DEFAULT NULL COMMENT '链接指向的文档ID，按标题找不到文档时为空',
+  `target_title` varchar(255) DEFAULT NULL COMMENT '按标题链接时的标题',
+  `link_type` varchar(10) NOT NULL COMMENT '链接写法：id-文档ID，title-文档标题',
+  `context` varchar(500) DEFAULT NULL COMMENT '链接前后的文本',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_source_id` (`source_id`),
+  KEY `idx_target_id` (`target_id`),
+  KEY `idx_user_title` (`user_id`, `target_title`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档链接表';
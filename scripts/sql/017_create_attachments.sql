@@ -0,0 +1,14 @@
+-- 文档附件表
+CREATE TABLE IF NOT EXISTS `attachments` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '文档所有者',
+  `document_id` bigint unsigned NOT NULL COMMENT '所属文档ID',
+  `file_name` varchar(255) NOT NULL COMMENT '上传时的文件名',
+  `file_path` varchar(500) NOT NULL COMMENT '相对上传目录的文件路径',
+  `mime_type` varchar(100) DEFAULT NULL COMMENT '文件类型',
+  `size` bigint NOT NULL DEFAULT 0 COMMENT '文件大小(字节)，计入文档大小',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_document_id` (`document_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档附件表';
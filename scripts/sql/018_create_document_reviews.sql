@@ -0,0 +1,35 @@
+-- 新增审核中、审核通过状态和定时发布时间
+ALTER TABLE `documents`
+  MODIFY COLUMN `status` tinyint DEFAULT '1' COMMENT '状态：1-草稿，2-已发布，3-已归档，4-审核中，5-审核通过',
+  ADD COLUMN `publish_at` datetime DEFAULT NULL COMMENT '定时发布时间，仅审核通过的文档有效',
+  ADD KEY `idx_publish_at` (`publish_at`);
+
+-- 文档审核表
+CREATE TABLE IF NOT EXISTS `document_reviews` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `submitter_id` bigint unsigned NOT NULL COMMENT '提交人ID',
+  `status` varchar(20) NOT NULL COMMENT '审核状态：pending,approved,rejected,withdrawn',
+  `message` varchar(500) DEFAULT NULL COMMENT '提交说明',
+  `completed_at` datetime DEFAULT NULL COMMENT '审核结束时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_document_id` (`document_id`),
+  KEY `idx_submitter_id` (`submitter_id`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档审核表';
+
+-- 文档审核人表
+CREATE TABLE IF NOT EXISTS `document_reviewers` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `review_id` bigint unsigned NOT NULL COMMENT '审核ID',
+  `reviewer_id` bigint unsigned NOT NULL COMMENT '审核人ID',
+  `decision` varchar(20) NOT NULL COMMENT '审核意见：pending,approved,rejected',
+  `comment` varchar(1000) DEFAULT NULL COMMENT '审核意见说明',
+  `decided_at` datetime DEFAULT NULL COMMENT '审核时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_review_id` (`review_id`),
+  KEY `idx_reviewer_decision` (`reviewer_id`, `decision`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档审核人表';
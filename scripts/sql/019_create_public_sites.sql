@@ -0,0 +1,27 @@
+-- 公开站点表
+CREATE TABLE IF NOT EXISTS `public_sites` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '站点所有者',
+  `folder_id` bigint unsigned NOT NULL COMMENT '发布的文件夹ID',
+  `slug` varchar(100) NOT NULL COMMENT '站点地址',
+  `title` varchar(200) NOT NULL COMMENT '站点标题',
+  `description` varchar(500) DEFAULT NULL COMMENT '站点简介',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_folder_id` (`folder_id`),
+  UNIQUE KEY `idx_slug` (`slug`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='公开站点表';
+
+-- 公开站点页面地址表
+CREATE TABLE IF NOT EXISTS `public_pages` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `site_id` bigint unsigned NOT NULL COMMENT '站点ID',
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `slug` varchar(200) NOT NULL COMMENT '页面地址，生成后不随标题变化',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_site_document` (`site_id`, `document_id`),
+  UNIQUE KEY `idx_site_slug` (`site_id`, `slug`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='公开站点页面地址表';
@@ -0,0 +1,22 @@
+-- 收藏表
+CREATE TABLE IF NOT EXISTS `favorites` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `resource_type` varchar(20) NOT NULL COMMENT '资源类型：document, folder',
+  `resource_id` bigint unsigned NOT NULL COMMENT '文档或文件夹ID',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_resource` (`user_id`, `resource_type`, `resource_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='收藏表';
+
+-- 置顶表
+CREATE TABLE IF NOT EXISTS `pins` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `resource_type` varchar(20) NOT NULL COMMENT '资源类型：document, folder',
+  `resource_id` bigint unsigned NOT NULL COMMENT '文档或文件夹ID',
+  `position` int NOT NULL DEFAULT '0' COMMENT '置顶顺序，从小到大',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_resource` (`user_id`, `resource_type`, `resource_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='置顶表';
@@ -0,0 +1,13 @@
+-- 文档打开记录表
+CREATE TABLE IF NOT EXISTS `document_accesses` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `open_count` int NOT NULL DEFAULT '0' COMMENT '累计打开次数',
+  `last_opened_at` datetime NOT NULL COMMENT '最近打开时间',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_document` (`user_id`, `document_id`),
+  KEY `idx_user_last_opened` (`user_id`, `last_opened_at`),
+  KEY `idx_document_id` (`document_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档打开记录表';
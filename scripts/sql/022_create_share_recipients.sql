@@ -0,0 +1,11 @@
+-- 文档分享对象表
+CREATE TABLE IF NOT EXISTS `share_recipients` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `document_id` bigint unsigned NOT NULL COMMENT '文档ID',
+  `share_token` varchar(32) NOT NULL COMMENT '分享时的分享token，重新分享后失效',
+  `user_id` bigint unsigned NOT NULL COMMENT '分享给的用户ID',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_document_token` (`document_id`, `share_token`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文档分享对象表';
@@ -0,0 +1,6 @@
+-- 常用文档得分，写入打开记录时按半衰期衰减后累加
+ALTER TABLE `document_accesses`
+  ADD COLUMN `score` double NOT NULL DEFAULT '0' COMMENT '按半衰期衰减到最近打开时间的打开次数' AFTER `open_count`;
+
+-- 已有记录以累计打开次数作为初始得分
+UPDATE `document_accesses` SET `score` = `open_count`;
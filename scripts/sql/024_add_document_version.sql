@@ -0,0 +1,3 @@
+-- 文档内容版本，每次更新加1，保存时检查版本以检测并发修改
+ALTER TABLE `documents`
+  ADD COLUMN `version` bigint NOT NULL DEFAULT '0' COMMENT '内容版本' AFTER `publish_at`;
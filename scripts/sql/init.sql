@@ -9,6 +9,29 @@ USE `wz_wenzhan`;
 
 -- 执行建表脚本
 SOURCE ./001_create_tables.sql;
+SOURCE ./002_create_api_tokens.sql;
+SOURCE ./003_add_user_roles.sql;
+SOURCE ./004_create_audit_logs.sql;
+SOURCE ./005_create_account_tables.sql;
+SOURCE ./006_create_comments.sql;
+SOURCE ./007_create_notifications.sql;
+SOURCE ./008_create_webhooks.sql;
+SOURCE ./009_create_outbox_events.sql;
+SOURCE ./010_create_document_templates.sql;
+SOURCE ./011_create_ai_drafts.sql;
+SOURCE ./012_create_ai_assist_tables.sql;
+SOURCE ./013_create_document_embeddings.sql;
+SOURCE ./014_create_mindmap_nodes.sql;
+SOURCE ./015_add_ppt_document_type.sql;
+SOURCE ./016_create_document_links.sql;
+SOURCE ./017_create_attachments.sql;
+SOURCE ./018_create_document_reviews.sql;
+SOURCE ./019_create_public_sites.sql;
+SOURCE ./020_create_favorites_and_pins.sql;
+SOURCE ./021_create_document_accesses.sql;
+SOURCE ./022_create_share_recipients.sql;
+SOURCE ./023_add_document_access_score.sql;
+SOURCE ./024_add_document_version.sql;
 
 -- 创建上传目录索引
 INSERT INTO `folders` (`name`, `user_id`, `parent_id`) VALUES